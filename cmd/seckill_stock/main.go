@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/data"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/pkg/logger"
+)
+
+// This command repairs the Redis side of seckill vouchers that have not ended yet:
+// seckill:stock:vid:{id} is reset to the remaining stock in tb_seckill_voucher and
+// order:vid:{id} is rebuilt from users holding active orders. Run it for vouchers
+// created before stock preloading existed, or after the Redis keys were lost, while
+// no seckill traffic is flowing.
+//
+// Usage:
+//
+//	go run cmd/seckill_stock/main.go -config configs/app.yaml
+func main() {
+	defaultPath := os.Getenv("HMDP_CONFIG")
+	if defaultPath == "" {
+		defaultPath = "configs/app.yaml"
+	}
+	cfgPath := flag.String("config", defaultPath, "config file path")
+	timeout := flag.Duration("timeout", 5*time.Minute, "overall timeout")
+	flag.Parse()
+
+	cfg := config.MustLoad(*cfgPath)
+	log, err := logger.New(cfg.Logging.Level, "cli")
+	if err != nil {
+		panic(err)
+	}
+	defer log.Sync()
+
+	db, err := data.NewMySQL(cfg.MySQL, log)
+	if err != nil {
+		log.Fatal("mysql init failed", zap.Error(err))
+	}
+	rdb := data.NewRedis(cfg.Redis)
+	defer rdb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if err := data.Ping(ctx, rdb); err != nil {
+		log.Fatal("redis ping failed", zap.Error(err))
+	}
+
+	voucherSvc := service.NewVoucherService(db, service.NewSeckillVoucherService(db), rdb, log)
+	count, err := voucherSvc.SyncSeckillStock(ctx)
+	if err != nil {
+		log.Fatal("sync seckill stock failed", zap.Error(err))
+	}
+	log.Info("sync seckill stock done", zap.Int("vouchers", count))
+}
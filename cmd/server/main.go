@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/segmentio/kafka-go"
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"github.com/prometheus/client_golang/prometheus"
@@ -32,8 +33,12 @@ func main() {
 	if cfgPath == "" {
 		cfgPath = "configs/app.yaml"
 	}
-	// 加载配置
-	cfg := config.MustLoad(cfgPath)
+	// 加载配置并监听文件变更，部分配置支持热更新
+	cfgWatcher, err := config.Watch(cfgPath)
+	if err != nil {
+		panic(err)
+	}
+	cfg := cfgWatcher.Current()
 	serviceName := cfg.Observability.ServiceName
 	if serviceName == "" {
 		serviceName = "hmdp-backend"
@@ -42,7 +47,7 @@ func main() {
 	if environment == "" {
 		environment = "local"
 	}
-	log, err := logger.New(cfg.Logging.Level, environment)
+	log, logLevel, err := logger.NewWithLevel(cfg.Logging.Level, environment)
 	if err != nil {
 		panic(err)
 	}
@@ -124,6 +129,15 @@ func main() {
 	// 缓存补偿消费者
 	cacheInvalidateReader := data.NewKafkaReader(cfg.Kafka, cfg.Kafka.CacheInvalidateTopic, cfg.Kafka.GroupID+"-shop-cache")
 	cacheInvalidateDLQReader := data.NewKafkaReader(cfg.Kafka, cfg.Kafka.CacheInvalidateDLQTopic, cfg.Kafka.GroupID+"-shop-cache-dlq")
+	// 笔记发布事件的生产者与消费者，未配置 topic 时同步推送
+	var feedWriter *kafka.Writer
+	var feedReader *kafka.Reader
+	if cfg.Kafka.FeedTopic != "" {
+		feedWriter = data.NewKafkaWriter(cfg.Kafka, cfg.Kafka.FeedTopic)
+		feedReader = data.NewKafkaReader(cfg.Kafka, cfg.Kafka.FeedTopic, cfg.Kafka.GroupID+"-feed")
+		// feedReader 由 BlogService.Shutdown 关闭
+		defer feedWriter.Close()
+	}
 	defer kafkaWriter.Close()
 	defer kafkaRetryWriter.Close()
 	defer kafkaDLQWriter.Close()
@@ -141,6 +155,7 @@ func main() {
 		zap.String("dlqTopic", cfg.Kafka.DLQTopic),
 		zap.String("cacheInvalidateTopic", cfg.Kafka.CacheInvalidateTopic),
 		zap.String("cacheInvalidateDLQTopic", cfg.Kafka.CacheInvalidateDLQTopic),
+		zap.String("feedTopic", cfg.Kafka.FeedTopic),
 		zap.String("groupID", cfg.Kafka.GroupID),
 		zap.String("retryGroupID", cfg.Kafka.GroupID+"-retry"),
 	)
@@ -158,6 +173,11 @@ func main() {
 	if cfg.Observability.Metrics.Enabled {
 		metricsRegistry = observability.NewMetricsRegistry()
 		seckillMetrics = observability.NewSeckillMetrics(metricsRegistry, serviceName)
+		// MySQL 与 Redis 调用指标
+		if err := db.Use(observability.NewDBMetrics(metricsRegistry, serviceName)); err != nil {
+			log.Warn("gorm metrics plugin init failed", zap.Error(err))
+		}
+		redisClient.AddHook(observability.NewRedisMetrics(metricsRegistry, serviceName))
 	}
 	services := service.NewRegistry(
 		db,
@@ -172,12 +192,27 @@ func main() {
 		kafkaDLQReader,
 		cacheInvalidateReader,
 		cacheInvalidateDLQReader,
+		feedWriter,
+		feedReader,
 		smtpCfg,
 		cfg.App.ShopCache,
+		cfg.App.ShopGeo,
+		cfg.App.Points,
+		cfg.App.Order,
+		cfg.App.Auth,
+		cfg.App.Sensitive,
+		cfg.App.Feed,
+		data.NewElasticsearch(cfg.Elasticsearch),
 		seckillMetrics,
 		log,
 	)
 
+	// 缓存预热：在 HTTP 服务启动前完成，避免冷启动时请求直接打到数据库
+	warmup := service.NewWarmupService(services.Shop, services.ShopType, cfg.App.Warmup, log)
+	if err := warmup.Run(context.Background()); err != nil {
+		log.Fatal("cache warmup failed", zap.Error(err))
+	}
+
 	// 初始化 Gin 引擎
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
@@ -211,7 +246,36 @@ func main() {
 	engine.GET("/healthz", healthHandler.Healthz)
 	engine.GET("/readyz", healthHandler.Readyz)
 
-	router.RegisterRoutes(engine, services, uploadDir, redisClient)
+	router.RegisterRoutes(engine, services, uploadDir, redisClient, cfg.App.Auth, func() config.RateLimitConfig {
+		return cfgWatcher.Current().App.RateLimit
+	})
+
+	// 配置热更新：日志级别、秒杀限流、商铺缓存策略与 TTL、订单积压阈值与库存自动修正即时生效，其余配置修改后需重启
+	cfgWatcher.OnError(func(err error) {
+		log.Warn("reload config failed, keeping previous config", zap.Error(err))
+	})
+	cfgWatcher.Subscribe(func(old, cur *config.Config) {
+		if old.Logging.Level != cur.Logging.Level {
+			logLevel.SetLevel(logger.ParseLevel(cur.Logging.Level))
+			log.Info("log level changed", zap.String("level", logLevel.String()))
+		}
+		if old.App.RateLimit != cur.App.RateLimit {
+			log.Info("seckill rate limit changed",
+				zap.Duration("window", cur.App.RateLimit.SeckillWindow),
+				zap.Int("perUser", cur.App.RateLimit.SeckillPerUser),
+				zap.Int("perVoucher", cur.App.RateLimit.SeckillPerVoucher),
+			)
+		}
+		if old.App.ShopCache != cur.App.ShopCache {
+			services.Shop.ApplyCacheConfig(cur.App.ShopCache)
+		}
+		if old.App.Order != cur.App.Order {
+			services.VoucherOrder.ApplyOrderConfig(cur.App.Order)
+		}
+		if changed := config.RestartRequired(old, cur); len(changed) > 0 {
+			log.Warn("config changes require a restart to take effect", zap.Strings("sections", changed))
+		}
+	})
 
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 	server := &http.Server{
@@ -232,10 +296,32 @@ func main() {
 	<-quit
 	log.Info("shutting down server...")
 
+	// 先让就绪检查失败，等待负载均衡观察到失败并摘除实例后再关闭监听
+	healthHandler.SetDraining()
+	if cfg.Server.DrainDelay > 0 {
+		log.Info("draining before shutdown", zap.Duration("delay", cfg.Server.DrainDelay))
+		time.Sleep(cfg.Server.DrainDelay)
+	}
 	ctxShutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := server.Shutdown(ctxShutdown); err != nil {
 		log.Fatal("server shutdown failed", zap.Error(err))
 	}
+	// 等待订单消费者处理完已拉取的消息，之后再关闭 Kafka 读写端
+	if err := services.VoucherOrder.Shutdown(ctxShutdown); err != nil {
+		log.Warn("order consumers shutdown timed out", zap.Error(err))
+	}
+	if err := services.Outbox.Shutdown(ctxShutdown); err != nil {
+		log.Warn("outbox relay shutdown timed out", zap.Error(err))
+	}
+	if err := services.GroupBuy.Shutdown(ctxShutdown); err != nil {
+		log.Warn("group buy expiry shutdown timed out", zap.Error(err))
+	}
+	if err := services.Blog.Shutdown(ctxShutdown); err != nil {
+		log.Warn("blog feed consumer shutdown failed", zap.Error(err))
+	}
+	if err := services.Email.Shutdown(ctxShutdown); err != nil {
+		log.Warn("email dispatcher shutdown timed out", zap.Error(err))
+	}
 	log.Info("server exited")
 }
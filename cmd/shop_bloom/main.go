@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/data"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
+	"hmdp-backend/pkg/logger"
+)
+
+// This command rebuilds the shop bloom filter (bloom:shop) from tb_shop.
+// It is the offline counterpart of the periodic rebuild in ShopService; run it after
+// bulk imports or when the filter key was lost.
+//
+// Usage:
+//
+//	go run cmd/shop_bloom/main.go -config configs/app.yaml
+func main() {
+	defaultPath := os.Getenv("HMDP_CONFIG")
+	if defaultPath == "" {
+		defaultPath = "configs/app.yaml"
+	}
+	cfgPath := flag.String("config", defaultPath, "config file path")
+	timeout := flag.Duration("timeout", 5*time.Minute, "overall timeout")
+	flag.Parse()
+
+	cfg := config.MustLoad(*cfgPath)
+	log, err := logger.New(cfg.Logging.Level, "cli")
+	if err != nil {
+		panic(err)
+	}
+	defer log.Sync()
+
+	db, err := data.NewMySQL(cfg.MySQL, log)
+	if err != nil {
+		log.Fatal("mysql init failed", zap.Error(err))
+	}
+	rdb := data.NewRedis(cfg.Redis)
+	defer rdb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if err := data.Ping(ctx, rdb); err != nil {
+		log.Fatal("redis ping failed", zap.Error(err))
+	}
+
+	// 不传 Kafka 读写端，避免启动缓存补偿消费者
+	shopSvc := service.NewShopService(db, rdb, nil, nil, nil, nil, utils.SMTPConfig{}, cfg.App.ShopCache, cfg.App.ShopGeo, nil, log)
+	count, err := shopSvc.ReloadBloom(ctx)
+	if err != nil {
+		log.Fatal("reload shop bloom failed", zap.Error(err))
+	}
+	log.Info("reload shop bloom done", zap.Int("shops", count))
+}
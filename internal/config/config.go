@@ -1,10 +1,7 @@
 package config
 
 import (
-	"fmt"
 	"time"
-
-	"github.com/spf13/viper"
 )
 
 // Config defines the application configuration structure
@@ -17,11 +14,13 @@ type Config struct {
 	App     AppConfig     `mapstructure:"app"`
 	Logging LoggingConfig `mapstructure:"logging"`
 	Observability ObservabilityConfig `mapstructure:"observability"`
+	Elasticsearch ElasticsearchConfig `mapstructure:"elasticsearch"`
 }
 
 // ServerConfig defines HTTP server options
 type ServerConfig struct {
-	Port int `mapstructure:"port"`
+	Port       int           `mapstructure:"port"`
+	DrainDelay time.Duration `mapstructure:"drainDelay"` // 退出时 /readyz 先返回失败，等待该时长让负载均衡摘除实例后再关闭监听
 }
 
 // MySQLConfig configures the relational database connection
@@ -47,6 +46,7 @@ type KafkaConfig struct {
 	DLQTopic   string `mapstructure:"dlqTopic"`
 	CacheInvalidateTopic    string `mapstructure:"cacheInvalidateTopic"`
 	CacheInvalidateDLQTopic string `mapstructure:"cacheInvalidateDLQTopic"`
+	FeedTopic               string `mapstructure:"feedTopic"` // blog published events; empty keeps synchronous fan-out
 	GroupID string   `mapstructure:"groupId"`
 }
 
@@ -63,13 +63,97 @@ type SMTPConfig struct {
 type AppConfig struct {
 	ImageUploadDir string `mapstructure:"imageUploadDir"`
 	ShopCache      ShopCacheConfig `mapstructure:"shopCache"`
+	ShopGeo        ShopGeoConfig   `mapstructure:"shopGeo"`
+	Points         PointsConfig    `mapstructure:"points"`
+	Order          OrderConfig     `mapstructure:"order"`
+	RateLimit      RateLimitConfig `mapstructure:"rateLimit"`
+	Auth           AuthConfig      `mapstructure:"auth"`
+	Sensitive      SensitiveConfig `mapstructure:"sensitive"`
+	Feed           FeedConfig      `mapstructure:"feed"`
+	Warmup         WarmupConfig    `mapstructure:"warmup"`
 }
 
 // ShopCacheConfig configures local cache and cache delete behavior for shops.
 type ShopCacheConfig struct {
+	Strategy           string        `mapstructure:"strategy"`      // mutex（默认，热点商铺走逻辑过期）或 logical（全部走逻辑过期）
+	LocalDisabled      bool          `mapstructure:"localDisabled"` // 关闭进程内缓存，只使用 Redis
 	LocalTTL           time.Duration `mapstructure:"localTTL"`
+	LocalMaxSizeMB     int           `mapstructure:"localMaxSizeMB"` // 进程内缓存容量上限（MB），0 表示不限
 	DeleteRetryCount   int           `mapstructure:"deleteRetryCount"`
 	DeleteRetryDelay   time.Duration `mapstructure:"deleteRetryDelay"`
+	RedisTTL           time.Duration `mapstructure:"redisTTL"` // Redis 商铺缓存有效期，默认 30 分钟
+}
+
+// ShopGeoConfig bounds the radius of nearby shop searches.
+type ShopGeoConfig struct {
+	DefaultRadius float64 `mapstructure:"defaultRadius"` // 未指定半径时的搜索半径（米）
+	MaxRadius     float64 `mapstructure:"maxRadius"`     // 允许的最大搜索半径（米），超出时按上限搜索
+}
+
+// PointsConfig configures paying orders with points.
+type PointsConfig struct {
+	PointsPerYuan     int64 `mapstructure:"pointsPerYuan"`     // 多少积分抵扣 1 元
+	MaxDeductPercent  int64 `mapstructure:"maxDeductPercent"`  // 单笔订单积分最多抵扣的金额比例（百分比）
+	MaxPointsPerOrder int64 `mapstructure:"maxPointsPerOrder"` // 单笔订单最多使用的积分，0 表示不限制
+}
+
+// OrderConfig configures the voucher order lifecycle.
+type OrderConfig struct {
+	Queue      string        `mapstructure:"queue"`      // kafka（默认）、outbox（事务发件箱中继到 Kafka）或 stream（Redis Streams 消费者组）
+	PayTimeout time.Duration `mapstructure:"payTimeout"` // 下单后未支付自动取消的时间，默认 15 分钟
+	Workers    int           `mapstructure:"workers"`    // Kafka 订单消费并发数，同一张券的消息按序处理，默认 1（串行）
+	// 秒杀库存对账：周期比对 Redis 库存与数据库，默认 5 分钟；AutoFix 时自动修正连续两次对账一致的偏差
+	ReconcileInterval time.Duration `mapstructure:"reconcileInterval"`
+	ReconcileAutoFix  bool          `mapstructure:"reconcileAutoFix"`
+	// 拼团：开团后在 GroupBuyWindow 内未成团则失败并自动退款，默认 24 小时
+	GroupBuyWindow time.Duration `mapstructure:"groupBuyWindow"`
+	// 待落库订单积压达到 MaxQueueDepth 时秒杀直接返回系统繁忙，0 表示不限制；可通过管理端接口动态调整
+	MaxQueueDepth int64 `mapstructure:"maxQueueDepth"`
+}
+
+// RateLimitConfig throttles the seckill endpoint before requests reach the Lua script.
+type RateLimitConfig struct {
+	SeckillWindow     time.Duration `mapstructure:"seckillWindow"`     // 滑动窗口长度
+	SeckillPerUser    int           `mapstructure:"seckillPerUser"`    // 每个用户在窗口内最多的秒杀请求数，0 表示不限制
+	SeckillPerVoucher int           `mapstructure:"seckillPerVoucher"` // 每张券在窗口内最多的秒杀请求数，0 表示不限制
+}
+
+// AuthConfig selects how login sessions are issued and validated.
+type AuthConfig struct {
+	Mode      string        `mapstructure:"mode"`      // redis（默认，Redis Hash 会话）或 jwt（无状态令牌）
+	JWTSecret string        `mapstructure:"jwtSecret"` // HS256 签名密钥，配置后中间件可校验 JWT
+	JWTTTL    time.Duration `mapstructure:"jwtTTL"`    // JWT 有效期
+	JWTIssuer string        `mapstructure:"jwtIssuer"`
+	TOTPKey   string        `mapstructure:"totpKey"`   // 加密存储 TOTP 密钥的 AES 密钥，未配置时不可开启二次验证
+	WeChat    WeChatConfig  `mapstructure:"wechat"`
+}
+
+// WeChatConfig configures WeChat mini-program login.
+type WeChatConfig struct {
+	AppID     string `mapstructure:"appId"`
+	AppSecret string `mapstructure:"appSecret"`
+}
+
+// SensitiveConfig configures the sensitive word filter for user content.
+type SensitiveConfig struct {
+	Mode           string        `mapstructure:"mode"`           // reject（默认，拒绝提交）或 mask（替换为 *）
+	Words          []string      `mapstructure:"words"`          // 内置词表，与 tb_sensitive_word 合并
+	ReloadInterval time.Duration `mapstructure:"reloadInterval"` // 从数据库重新加载词表的周期，0 表示只在启动时加载
+}
+
+// FeedConfig bounds the size and lifetime of follower feed inboxes.
+type FeedConfig struct {
+	InboxMax        int           `mapstructure:"inboxMax"`        // 每个收件箱保留的最近笔记数
+	InboxTTL        time.Duration `mapstructure:"inboxTTL"`        // 用户持续未读取关注流时收件箱的过期时间
+	CleanupInterval time.Duration `mapstructure:"cleanupInterval"` // 扫描未设置过期时间的历史收件箱的周期，0 表示不扫描
+}
+
+// WarmupConfig controls cache pre-population before the HTTP server starts.
+type WarmupConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	HotShops int           `mapstructure:"hotShops"` // 按销量预热逻辑过期缓存的商铺数量
+	Timeout  time.Duration `mapstructure:"timeout"`  // 整个预热阶段的超时时间
+	FailFast bool          `mapstructure:"failFast"` // 任一步骤失败时终止启动，默认仅记录告警
 }
 
 // LoggingConfig controls structured logging output.
@@ -77,6 +161,17 @@ type LoggingConfig struct {
 	Level string `mapstructure:"level"`
 }
 
+// ElasticsearchConfig enables full-text blog and shop search; MySQL LIKE is used when disabled.
+type ElasticsearchConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Addr      string        `mapstructure:"addr"`      // 如 http://127.0.0.1:9200
+	Index     string        `mapstructure:"index"`     // 笔记索引名
+	ShopIndex string        `mapstructure:"shopIndex"` // 商铺索引名
+	Username  string        `mapstructure:"username"`
+	Password  string        `mapstructure:"password"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
 // ObservabilityConfig controls health checks, metrics, and tracing.
 type ObservabilityConfig struct {
 	ServiceName string `mapstructure:"serviceName"`
@@ -105,18 +200,9 @@ type ObservabilityLoggingConfig struct {
 	RequestIDHeader string `mapstructure:"requestIdHeader"`
 }
 
-// Load loads configuration from a YAML file path.
+// Load loads configuration from a YAML file path; HMDP_* environment variables override file values.
 func Load(path string) (*Config, error) {
-	vp := viper.New()
-	vp.SetConfigFile(path)
-	if err := vp.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("read config: %w", err)
-	}
-	var cfg Config
-	if err := vp.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("unmarshal config: %w", err)
-	}
-	return &cfg, nil
+	return read(newViper(path))
 }
 
 // MustLoad wraps Load and panics on failure.
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix prefixes every environment variable that overrides a config key.
+const EnvPrefix = "HMDP"
+
+// bindEnv lets HMDP_* environment variables override the config file. Each key maps to
+// the prefix plus its path in upper snake case, e.g. mysql.dsn -> HMDP_MYSQL_DSN and
+// app.auth.jwtSecret -> HMDP_APP_AUTH_JWT_SECRET. Keys are bound explicitly so that
+// settings absent from the file can still be supplied through the environment.
+func bindEnv(vp *viper.Viper) {
+	vp.SetEnvPrefix(EnvPrefix)
+	// 文件中已有的 key 同时接受 viper 默认的命名（HMDP_APP_AUTH_JWTSECRET），显式绑定的名称优先
+	vp.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	vp.AutomaticEnv()
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		_ = vp.BindEnv(key, EnvName(key))
+	}
+}
+
+// EnvName returns the environment variable that overrides the given config key.
+func EnvName(key string) string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = upperSnake(p)
+	}
+	return EnvPrefix + "_" + strings.Join(parts, "_")
+}
+
+// configKeys lists the dotted mapstructure paths of all leaf fields in t.
+func configKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		// time.Duration 等非结构体类型按叶子处理
+		if f.Type.Kind() == reflect.Struct {
+			keys = append(keys, configKeys(f.Type, key)...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// upperSnake converts camelCase to UPPER_SNAKE_CASE, keeping acronyms together:
+// jwtTTL -> JWT_TTL, localMaxSizeMB -> LOCAL_MAX_SIZE_MB, otlpGrpcEndpoint -> OTLP_GRPC_ENDPOINT.
+func upperSnake(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
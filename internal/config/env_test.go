@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestEnvName 校验配置 key 到环境变量名的映射，驼峰与缩写按单词拆分
+func TestEnvName(t *testing.T) {
+	cases := map[string]string{
+		"mysql.dsn":                              "HMDP_MYSQL_DSN",
+		"redis.password":                         "HMDP_REDIS_PASSWORD",
+		"smtp.pass":                              "HMDP_SMTP_PASS",
+		"app.auth.jwtSecret":                     "HMDP_APP_AUTH_JWT_SECRET",
+		"app.auth.jwtTTL":                        "HMDP_APP_AUTH_JWT_TTL",
+		"app.shopCache.localMaxSizeMB":           "HMDP_APP_SHOP_CACHE_LOCAL_MAX_SIZE_MB",
+		"kafka.cacheInvalidateDLQTopic":          "HMDP_KAFKA_CACHE_INVALIDATE_DLQ_TOPIC",
+		"observability.tracing.otlpGrpcEndpoint": "HMDP_OBSERVABILITY_TRACING_OTLP_GRPC_ENDPOINT",
+	}
+	for key, want := range cases {
+		if got := EnvName(key); got != want {
+			t.Fatalf("EnvName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// TestLoadEnvOverride 校验环境变量覆盖文件中的值，文件中缺失的 key 也能通过环境变量设置
+func TestLoadEnvOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	data := []byte("mysql:\n  dsn: \"file-dsn\"\nkafka:\n  brokers:\n    - \"file:9092\"\napp:\n  order:\n    payTimeout: 15m\n")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv("HMDP_MYSQL_DSN", "env-dsn")
+	t.Setenv("HMDP_REDIS_PASSWORD", "secret")
+	t.Setenv("HMDP_APP_AUTH_JWT_SECRET", "jwt")
+	t.Setenv("HMDP_KAFKA_BROKERS", "a:9092,b:9092")
+	t.Setenv("HMDP_APP_ORDER_PAY_TIMEOUT", "5m")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if cfg.MySQL.DSN != "env-dsn" {
+		t.Fatalf("mysql.dsn = %q, want env-dsn", cfg.MySQL.DSN)
+	}
+	if cfg.Redis.Password != "secret" || cfg.App.Auth.JWTSecret != "jwt" {
+		t.Fatalf("redis.password = %q, app.auth.jwtSecret = %q", cfg.Redis.Password, cfg.App.Auth.JWTSecret)
+	}
+	if want := []string{"a:9092", "b:9092"}; !reflect.DeepEqual(cfg.Kafka.Brokers, want) {
+		t.Fatalf("kafka.brokers = %v, want %v", cfg.Kafka.Brokers, want)
+	}
+	if cfg.App.Order.PayTimeout != 5*time.Minute {
+		t.Fatalf("app.order.payTimeout = %v, want 5m", cfg.App.Order.PayTimeout)
+	}
+}
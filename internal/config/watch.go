@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Watcher keeps the latest configuration and reloads it whenever the file changes.
+// Only the settings that subscribers apply take effect at runtime; see RestartRequired.
+type Watcher struct {
+	vp      *viper.Viper
+	current atomic.Pointer[Config]
+
+	mu      sync.Mutex // 串行化重载与订阅回调
+	subs    []func(old, cur *Config)
+	onError func(error)
+}
+
+// Watch loads the configuration file and starts watching it for changes.
+func Watch(path string) (*Watcher, error) {
+	vp := newViper(path)
+	cfg, err := read(vp)
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{vp: vp}
+	w.current.Store(cfg)
+	vp.OnConfigChange(func(fsnotify.Event) { w.reload() })
+	vp.WatchConfig()
+	return w, nil
+}
+
+// Current returns the latest successfully loaded configuration; callers must not modify it.
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Subscribe registers fn to be called after each successful reload with the previous
+// and the new configuration. Callbacks run sequentially on the watcher goroutine.
+func (w *Watcher) Subscribe(fn func(old, cur *Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subs = append(w.subs, fn)
+}
+
+// OnError registers fn to be called when a reload fails; the previous configuration stays in effect.
+func (w *Watcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = fn
+}
+
+func (w *Watcher) reload() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// 编辑器保存文件时可能先清空再写入，读到不完整内容时保留旧配置，等待下一次变更事件
+	cfg, err := decode(w.vp)
+	if err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+		return
+	}
+	old := w.current.Load()
+	if reflect.DeepEqual(old, cfg) {
+		return
+	}
+	w.current.Store(cfg)
+	for _, fn := range w.subs {
+		fn(old, cfg)
+	}
+}
+
+// RestartRequired lists the changed sections that are only read at startup.
+func RestartRequired(old, cur *Config) []string {
+	sections := []struct {
+		name     string
+		old, cur any
+	}{
+		{"server", old.Server, cur.Server},
+		{"mysql", old.MySQL, cur.MySQL},
+		{"redis", old.Redis, cur.Redis},
+		{"kafka", old.Kafka, cur.Kafka},
+		{"smtp", old.SMTP, cur.SMTP},
+		{"elasticsearch", old.Elasticsearch, cur.Elasticsearch},
+		{"observability", old.Observability, cur.Observability},
+		{"app.imageUploadDir", old.App.ImageUploadDir, cur.App.ImageUploadDir},
+		{"app.shopCache.localDisabled", old.App.ShopCache.LocalDisabled, cur.App.ShopCache.LocalDisabled},
+		{"app.shopCache.localTTL", old.App.ShopCache.LocalTTL, cur.App.ShopCache.LocalTTL},
+		{"app.shopCache.localMaxSizeMB", old.App.ShopCache.LocalMaxSizeMB, cur.App.ShopCache.LocalMaxSizeMB},
+		{"app.shopGeo", old.App.ShopGeo, cur.App.ShopGeo},
+		{"app.points", old.App.Points, cur.App.Points},
+		{"app.order.queue", old.App.Order.Queue, cur.App.Order.Queue},
+		{"app.order.payTimeout", old.App.Order.PayTimeout, cur.App.Order.PayTimeout},
+		{"app.order.workers", old.App.Order.Workers, cur.App.Order.Workers},
+		{"app.order.reconcileInterval", old.App.Order.ReconcileInterval, cur.App.Order.ReconcileInterval},
+		{"app.order.groupBuyWindow", old.App.Order.GroupBuyWindow, cur.App.Order.GroupBuyWindow},
+		{"app.auth", old.App.Auth, cur.App.Auth},
+		{"app.sensitive", old.App.Sensitive, cur.App.Sensitive},
+		{"app.feed", old.App.Feed, cur.App.Feed},
+		{"app.warmup", old.App.Warmup, cur.App.Warmup},
+	}
+	var changed []string
+	for _, s := range sections {
+		if !reflect.DeepEqual(s.old, s.cur) {
+			changed = append(changed, s.name)
+		}
+	}
+	return changed
+}
+
+func newViper(path string) *viper.Viper {
+	vp := viper.New()
+	vp.SetConfigFile(path)
+	bindEnv(vp)
+	return vp
+}
+
+// read reads the file and decodes it into a Config.
+func read(vp *viper.Viper) (*Config, error) {
+	if err := vp.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	return decode(vp)
+}
+
+// decode decodes the values already read by vp.
+func decode(vp *viper.Viper) (*Config, error) {
+	var cfg Config
+	if err := vp.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestWatcherReload 校验配置文件修改后 Current 返回新配置，订阅者收到新旧配置，需重启的配置项被列出
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	write := func(level string, port int) {
+		t.Helper()
+		data := []byte("server:\n  port: " + strconv.Itoa(port) + "\nlogging:\n  level: " + level + "\n")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	write("info", 8081)
+
+	w, err := Watch(path)
+	if err != nil {
+		t.Fatalf("Watch error: %v", err)
+	}
+	if got := w.Current().Logging.Level; got != "info" {
+		t.Fatalf("initial level = %q, want info", got)
+	}
+	changes := make(chan [2]*Config, 4)
+	w.Subscribe(func(old, cur *Config) { changes <- [2]*Config{old, cur} })
+
+	write("debug", 9090)
+	select {
+	case c := <-changes:
+		if c[0].Logging.Level != "info" || c[1].Logging.Level != "debug" {
+			t.Fatalf("change = %q -> %q, want info -> debug", c[0].Logging.Level, c[1].Logging.Level)
+		}
+		if got := RestartRequired(c[0], c[1]); !reflect.DeepEqual(got, []string{"server"}) {
+			t.Fatalf("RestartRequired = %v, want [server]", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change notification after rewriting config")
+	}
+	if got := w.Current().Logging.Level; got != "debug" {
+		t.Fatalf("current level = %q, want debug", got)
+	}
+}
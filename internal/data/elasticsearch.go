@@ -0,0 +1,90 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"hmdp-backend/internal/config"
+)
+
+// Elasticsearch 基于 REST API 的轻量客户端，只覆盖笔记与商铺搜索用到的文档读写与查询
+type Elasticsearch struct {
+	addr      string
+	index     string
+	shopIndex string
+	username  string
+	password  string
+	client    *http.Client
+}
+
+// NewElasticsearch 构建 Elasticsearch 客户端，未启用时返回 nil
+func NewElasticsearch(cfg config.ElasticsearchConfig) *Elasticsearch {
+	if !cfg.Enabled || cfg.Addr == "" {
+		return nil
+	}
+	index := cfg.Index
+	if index == "" {
+		index = "hmdp-blog"
+	}
+	shopIndex := cfg.ShopIndex
+	if shopIndex == "" {
+		shopIndex = "hmdp-shop"
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	return &Elasticsearch{
+		addr:      strings.TrimRight(cfg.Addr, "/"),
+		index:     index,
+		shopIndex: shopIndex,
+		username:  cfg.Username,
+		password:  cfg.Password,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Index 返回笔记文档所在的索引名
+func (e *Elasticsearch) Index() string { return e.index }
+
+// ShopIndex 返回商铺文档所在的索引名
+func (e *Elasticsearch) ShopIndex() string { return e.shopIndex }
+
+// Do 发送请求，body 与 out 均为 JSON；非 2xx 响应返回错误
+func (e *Elasticsearch) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, e.addr+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.username != "" {
+		req.SetBasicAuth(e.username, e.password)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("elasticsearch %s %s: %d %s", method, path, resp.StatusCode, msg)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,9 @@
+package dto
+
+// BlogUpdateForm 编辑笔记表单，未传的字段（nil）保持原值不变
+type BlogUpdateForm struct {
+	Title   *string  `json:"title"`
+	Content *string  `json:"content"`
+	Images  *string  `json:"images"`
+	Tags    []string `json:"tags"`
+}
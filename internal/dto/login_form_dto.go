@@ -1,8 +1,45 @@
 package dto
 
-// LoginForm 登录表单
+// 验证码登录渠道
+const (
+	LoginChannelPhone = "phone"
+	LoginChannelEmail = "email"
+)
+
+// LoginForm 登录表单，Channel 为空时按手机号登录
 type LoginForm struct {
+	Channel  string `json:"channel"`
 	Phone    string `json:"phone"`
+	Email    string `json:"email"`
 	Code     string `json:"code"`
 	Password string `json:"password"`
 }
+
+// PasswordForm 设置/修改密码表单，首次设置密码时 OldPassword 可为空
+type PasswordForm struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+// ChangePhoneForm 更换手机号表单，OldCode 与 NewCode 分别为原手机号与新手机号收到的验证码
+type ChangePhoneForm struct {
+	OldCode  string `json:"oldCode"`
+	NewPhone string `json:"newPhone"`
+	NewCode  string `json:"newCode"`
+}
+
+// OAuthLoginForm 第三方登录表单，code 为客户端从平台获取的授权码
+type OAuthLoginForm struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorLoginForm 二次验证登录表单，ticket 为第一步登录返回的票据
+type TwoFactorLoginForm struct {
+	Ticket string `json:"ticket"`
+	Code   string `json:"code"`
+}
+
+// TwoFactorCodeForm 开启或关闭二次验证时提交的动态码（关闭时也可使用恢复码）
+type TwoFactorCodeForm struct {
+	Code string `json:"code"`
+}
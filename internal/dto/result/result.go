@@ -27,3 +27,7 @@ func Fail(msg string) Result {
 	return Result{Success: false, ErrorMsg: msg}
 }
 
+// FailWithData 返回携带附加数据（如错误原因码）的失败响应
+func FailWithData(msg string, data interface{}) Result {
+	return Result{Success: false, ErrorMsg: msg, Data: data}
+}
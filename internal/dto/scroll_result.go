@@ -0,0 +1,10 @@
+package dto
+
+// ScrollResult 基于 ZSet 分数的滚动分页结果：下一页请求时带上 minTime 与 offset，
+// offset 为本页中与 minTime 同分数的记录数，用于跳过已返回的数据
+type ScrollResult struct {
+	List    interface{} `json:"list"`
+	MinTime int64       `json:"minTime"`
+	Offset  int64       `json:"offset"`
+	HasMore bool        `json:"hasMore"`
+}
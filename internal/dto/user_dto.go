@@ -5,4 +5,5 @@ type UserDTO struct {
 	ID       int64  `json:"id"`
 	NickName string `json:"nickName"`
 	Icon     string `json:"icon"`
+	Role     string `json:"role,omitempty"`
 }
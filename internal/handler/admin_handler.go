@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
+)
+
+// AdminHandler 处理管理员接口
+type AdminHandler struct {
+	userService  *service.UserService
+	shopService  *service.ShopService
+	viewService  *service.ShopViewService
+	orderService *service.VoucherOrderService
+}
+
+func NewAdminHandler(userSvc *service.UserService, shopSvc *service.ShopService, viewSvc *service.ShopViewService, orderSvc *service.VoucherOrderService) *AdminHandler {
+	return &AdminHandler{userService: userSvc, shopService: shopSvc, viewService: viewSvc, orderService: orderSvc}
+}
+
+// BanUser 封禁用户
+func (h *AdminHandler) BanUser(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	if err := h.userService.Ban(ctx.Request.Context(), id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// UnbanUser 解除封禁
+func (h *AdminHandler) UnbanUser(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	if err := h.userService.Unban(ctx.Request.Context(), id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// RevokeSessions 强制下线：吊销用户在所有设备上的登录状态
+func (h *AdminHandler) RevokeSessions(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	revoked, err := h.userService.RevokeSessions(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(gin.H{"revoked": revoked}))
+}
+
+// QueryBannedUsers 分页查询被封禁的用户
+func (h *AdminHandler) QueryBannedUsers(ctx *gin.Context) {
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	users, err := h.userService.ListBanned(ctx.Request.Context(), page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(users))
+}
+
+// ReloadShopGeo 全量重建商铺 GEO 索引
+func (h *AdminHandler) ReloadShopGeo(ctx *gin.Context) {
+	count, err := h.shopService.ReloadGeo(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(gin.H{"shops": count}))
+}
+
+// QueryPendingShops 分页查询待审核的商家入驻申请
+func (h *AdminHandler) QueryPendingShops(ctx *gin.Context) {
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	shops, total, err := h.shopService.ListPending(ctx.Request.Context(), page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithPage(shops, total))
+}
+
+// ReviewShop 审核入驻申请，请求体为 {approve, note}，驳回时 note 必填
+func (h *AdminHandler) ReviewShop(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	var req struct {
+		Approve bool   `json:"approve"`
+		Note    string `json:"note"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	if err := h.shopService.Review(ctx.Request.Context(), id, req.Approve, req.Note); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// ImportShops 从上传的 CSV（表单字段 file）批量导入商铺，dryRun=true 时只校验不写入
+func (h *AdminHandler) ImportShops(ctx *gin.Context) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("missing file"))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	defer file.Close()
+	report, err := h.shopService.Import(ctx.Request.Context(), file, ctx.Query("dryRun") == "true")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(report))
+}
+
+// QueryShopViews 查询商铺最近 days 天（默认 7 天）的每日浏览量与独立访客数
+func (h *AdminHandler) QueryShopViews(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	days := 7
+	if v := ctx.Query("days"); v != "" {
+		if days, err = strconv.Atoi(v); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid days"))
+			return
+		}
+	}
+	trend, err := h.viewService.Trend(ctx.Request.Context(), id, days)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(trend))
+}
+
+// QueryOrders 按券、商铺、用户、状态与下单日期（from/to，格式 2006-01-02，含首尾两天）分页查询订单
+func (h *AdminHandler) QueryOrders(ctx *gin.Context) {
+	filter, err := parseOrderFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	orders, total, err := h.orderService.ListOrders(ctx.Request.Context(), filter, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithPage(orders, total))
+}
+
+// ExportOrders 以 CSV 附件流式导出符合条件的订单，筛选参数与 QueryOrders 相同
+func (h *AdminHandler) ExportOrders(ctx *gin.Context) {
+	filter, err := parseOrderFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	filename := "voucher-orders-" + time.Now().Format("20060102150405") + ".csv"
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Status(http.StatusOK)
+	// 响应头已发送，导出中途失败只能中断响应
+	if err := h.orderService.ExportOrders(ctx.Request.Context(), filter, ctx.Writer); err != nil {
+		_ = ctx.Error(err)
+		ctx.Abort()
+	}
+}
+
+// QueryStockDrifts 查询最近一次秒杀库存对账发现的偏差
+func (h *AdminHandler) QueryStockDrifts(ctx *gin.Context) {
+	drifts, err := h.orderService.StockDrifts(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(drifts))
+}
+
+// ReconcileStock 立即执行一次秒杀库存对账并返回发现的偏差
+func (h *AdminHandler) ReconcileStock(ctx *gin.Context) {
+	drifts, err := h.orderService.ReconcileStock(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(drifts))
+}
+
+// QueryOrderQueue 查询待落库订单积压与当前生效的积压阈值
+func (h *AdminHandler) QueryOrderQueue(ctx *gin.Context) {
+	status, err := h.orderService.QueueStatus(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(status))
+}
+
+// UpdateOrderQueueLimit 动态调整订单积压阈值，请求体为 {maxQueueDepth}，0 表示不限制
+func (h *AdminHandler) UpdateOrderQueueLimit(ctx *gin.Context) {
+	var req struct {
+		MaxQueueDepth *int64 `json:"maxQueueDepth"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.MaxQueueDepth == nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	status, err := h.orderService.SetMaxQueueDepth(ctx.Request.Context(), *req.MaxQueueDepth)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(status))
+}
+
+// ResetOrderQueueLimit 清除动态积压阈值，恢复使用配置值
+func (h *AdminHandler) ResetOrderQueueLimit(ctx *gin.Context) {
+	status, err := h.orderService.ResetMaxQueueDepth(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(status))
+}
+
+// parseOrderFilter 解析订单筛选参数；to 为包含当天的结束日期
+func parseOrderFilter(ctx *gin.Context) (service.OrderFilter, error) {
+	var filter service.OrderFilter
+	ids := []struct {
+		name string
+		dst  *int64
+	}{
+		{"voucherId", &filter.VoucherID},
+		{"shopId", &filter.ShopID},
+		{"userId", &filter.UserID},
+	}
+	for _, p := range ids {
+		if v := ctx.Query(p.name); v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return filter, errors.New("invalid " + p.name)
+			}
+			*p.dst = id
+		}
+	}
+	if v := ctx.Query("status"); v != "" {
+		status, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid status")
+		}
+		filter.Status = status
+	}
+	if v := ctx.Query("from"); v != "" {
+		from, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return filter, errors.New("invalid from")
+		}
+		filter.From = from
+	}
+	if v := ctx.Query("to"); v != "" {
+		to, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return filter, errors.New("invalid to")
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, errors.New("from 不能晚于 to")
+	}
+	return filter, nil
+}
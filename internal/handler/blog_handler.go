@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"context"
+	"hmdp-backend/internal/dto"
 	"hmdp-backend/internal/dto/result"
 	"hmdp-backend/internal/middleware"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -14,12 +17,17 @@ import (
 )
 
 type BlogHandler struct {
-	blogService *service.BlogService
-	userService *service.UserService
+	blogService   *service.BlogService
+	userService   *service.UserService
+	tagService    *service.TagService
+	searchSvc     *service.BlogSearchService
+	searchHistory *service.SearchService
+	favoriteSvc   *service.FavoriteService
+	uploadDir     string
 }
 
-func NewBlogHandler(blogSvc *service.BlogService, userSvc *service.UserService) *BlogHandler {
-	return &BlogHandler{blogService: blogSvc, userService: userSvc}
+func NewBlogHandler(blogSvc *service.BlogService, userSvc *service.UserService, tagSvc *service.TagService, searchSvc *service.BlogSearchService, searchHistory *service.SearchService, favoriteSvc *service.FavoriteService, uploadDir string) *BlogHandler {
+	return &BlogHandler{blogService: blogSvc, userService: userSvc, tagService: tagSvc, searchSvc: searchSvc, searchHistory: searchHistory, favoriteSvc: favoriteSvc, uploadDir: uploadDir}
 }
 
 // SaveBlog 保存博客
@@ -45,6 +53,60 @@ func (h *BlogHandler) SaveBlog(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, result.OkWithData(blog.ID))
 }
 
+// UpdateBlog 作者编辑笔记，编辑后不再使用的图片一并删除
+func (h *BlogHandler) UpdateBlog(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var form dto.BlogUpdateForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	old, err := h.blogService.Update(ctx.Request.Context(), loginUser.ID, id, form)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	// 未传 images 时图片不变，无需清理
+	if form.Images != nil {
+		keep := make(map[string]struct{})
+		for _, img := range strings.Split(*form.Images, ",") {
+			keep[strings.TrimSpace(img)] = struct{}{}
+		}
+		removeBlogImages(h.uploadDir, old.UserID, old.Images, keep)
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// DeleteBlog 作者删除笔记及其图片
+func (h *BlogHandler) DeleteBlog(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	blog, err := h.blogService.Delete(ctx.Request.Context(), loginUser.ID, id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	removeBlogImages(h.uploadDir, blog.UserID, blog.Images, nil)
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
 // LikeBlog 点赞博客
 func (h *BlogHandler) LikeBlog(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
@@ -80,17 +142,81 @@ func (h *BlogHandler) QueryMyBlog(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
 	}
-	for i := range blogs {
-		isLike, err := h.blogService.IsLiked(ctx.Request.Context(), blogs[i].ID, loginUser.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
-			return
-		}
-		blogs[i].IsLike = &isLike
+	if err := h.fillIsLike(ctx.Request.Context(), blogs, loginUser.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
 	}
 	ctx.JSON(http.StatusOK, result.OkWithData(blogs))
 }
 
+// PinBlog 置顶自己的笔记
+func (h *BlogHandler) PinBlog(ctx *gin.Context) {
+	h.togglePin(ctx, true)
+}
+
+// UnpinBlog 取消置顶
+func (h *BlogHandler) UnpinBlog(ctx *gin.Context) {
+	h.togglePin(ctx, false)
+}
+
+func (h *BlogHandler) togglePin(ctx *gin.Context, pin bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	if pin {
+		err = h.blogService.Pin(ctx.Request.Context(), loginUser.ID, id)
+	} else {
+		err = h.blogService.Unpin(ctx.Request.Context(), loginUser.ID, id)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// QueryMyDrafts 分页查询当前用户的草稿
+func (h *BlogHandler) QueryMyDrafts(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	blogs, err := h.blogService.QueryDrafts(ctx.Request.Context(), loginUser.ID, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(blogs))
+}
+
+// PublishBlog 发布草稿，发布后推送到粉丝收件箱
+func (h *BlogHandler) PublishBlog(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	if err := h.blogService.Publish(ctx.Request.Context(), loginUser.ID, id); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
 func (h *BlogHandler) QueryHotBlog(ctx *gin.Context) {
 	page := utils.ParsePage(ctx.Query("current"), 1)
 	blogs, err := h.blogService.QueryHot(ctx.Request.Context(), page, utils.MAX_PAGE_SIZE)
@@ -98,26 +224,17 @@ func (h *BlogHandler) QueryHotBlog(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
 	}
+	if err := h.fillAuthors(ctx.Request.Context(), blogs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
 	loginUser, _ := middleware.GetLoginUser(ctx)
-	for i := range blogs {
-		user, err := h.userService.FindByID(ctx.Request.Context(), blogs[i].UserID)
-		if err != nil {
+	// 判断用户是否点赞
+	if loginUser != nil {
+		if err := h.fillIsLike(ctx.Request.Context(), blogs, loginUser.ID); err != nil {
 			ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 			return
 		}
-		if user != nil {
-			blogs[i].Name = user.NickName
-			blogs[i].Icon = user.Icon
-		}
-		// 判断用户是否点赞
-		if loginUser != nil {
-			isLike, err := h.blogService.IsLiked(ctx.Request.Context(), blogs[i].ID, loginUser.ID)
-			if err != nil {
-				ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
-				return
-			}
-			blogs[i].IsLike = &isLike
-		}
 	}
 	ctx.JSON(http.StatusOK, result.OkWithData(blogs))
 }
@@ -129,16 +246,64 @@ func (h *BlogHandler) QueryBlogByID(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
 		return
 	}
+	h.respondBlogDetail(ctx, id)
+}
+
+// ShareBlog 生成笔记的分享短链 token 并累加分享次数
+func (h *BlogHandler) ShareBlog(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	token, shares, err := h.blogService.Share(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(gin.H{"token": token, "shares": shares}))
+}
+
+// QueryBlogByShare 通过分享 token 打开笔记详情
+func (h *BlogHandler) QueryBlogByShare(ctx *gin.Context) {
+	id, err := h.blogService.ResolveShare(ctx.Request.Context(), ctx.Param("token"))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, result.Fail(err.Error()))
+		return
+	}
+	h.respondBlogDetail(ctx, id)
+}
+
+// respondBlogDetail 输出笔记详情：记录浏览量并填充话题、作者、分享数及当前用户的点赞与收藏状态
+func (h *BlogHandler) respondBlogDetail(ctx *gin.Context, id int64) {
 	loginUser, _ := middleware.GetLoginUser(ctx)
 	blog, err := h.blogService.GetByID(ctx.Request.Context(), id)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
 	}
-	if blog == nil {
+	// 草稿与被屏蔽的笔记仅作者本人可见
+	if blog == nil || (blog.Status != model.BlogStatusPublished && (loginUser == nil || loginUser.ID != blog.UserID)) {
 		ctx.JSON(http.StatusNotFound, result.Fail("blog not found"))
 		return
 	}
+	if err := h.blogService.RecordView(ctx.Request.Context(), blog); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	if blog.Tags, err = h.tagService.Tags(ctx.Request.Context(), blog.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	if blog.Shares, err = h.blogService.ShareCount(ctx.Request.Context(), blog.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
 	user, err := h.userService.FindByID(ctx.Request.Context(), blog.UserID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
@@ -155,6 +320,12 @@ func (h *BlogHandler) QueryBlogByID(ctx *gin.Context) {
 			return
 		}
 		blog.IsLike = &isLike
+		isFavorite, err := h.favoriteSvc.IsFavorited(ctx.Request.Context(), loginUser.ID, blog.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+			return
+		}
+		blog.IsFavorite = &isFavorite
 	}
 	ctx.JSON(http.StatusOK, result.OkWithData(blog))
 }
@@ -199,9 +370,7 @@ func (h *BlogHandler) QueryBlogOfUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
 	}
-	// 填充作者信息一次
-	author, err := h.userService.FindByID(ctx.Request.Context(), userID)
-	if err != nil {
+	if err := h.fillAuthors(ctx.Request.Context(), blogs); err != nil {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
 	}
@@ -209,66 +378,189 @@ func (h *BlogHandler) QueryBlogOfUser(ctx *gin.Context) {
 	// 若当前有登录用户，标记是否点赞
 	loginUser, _ := middleware.GetLoginUser(ctx)
 
-	for i := range blogs {
-		if author != nil {
-			blogs[i].Name = author.NickName
-			blogs[i].Icon = author.Icon
-		}
-		if loginUser != nil {
-			isLike, err := h.blogService.IsLiked(ctx.Request.Context(), blogs[i].ID, loginUser.ID)
-			if err != nil {
-				ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
-				return
-			}
-			blogs[i].IsLike = &isLike
+	if loginUser != nil {
+		if err := h.fillIsLike(ctx.Request.Context(), blogs, loginUser.ID); err != nil {
+			ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+			return
 		}
 	}
 	ctx.JSON(http.StatusOK, result.OkWithData(blogs))
 }
 
-// QueryFollowFeed 获取关注的笔记流（滚动分页：lastId=上次最小时间戳，offset=同分数偏移）
+// QueryFollowFeed 获取关注的笔记流（滚动分页：minTime=上次最小时间戳，offset=同分数偏移），返回 ScrollResult
 func (h *BlogHandler) QueryFollowFeed(ctx *gin.Context) {
 	loginUser, ok := middleware.GetLoginUser(ctx)
 	if !ok || loginUser == nil {
 		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
 		return
 	}
-	lastIDStr := ctx.DefaultQuery("lastId", "0")
-	offsetStr := ctx.DefaultQuery("offset", "0")
-	lastID, _ := strconv.ParseInt(lastIDStr, 10, 64)
-	offset, _ := strconv.ParseInt(offsetStr, 10, 64)
+	// 兼容旧客户端使用的 lastId 参数
+	minTimeStr := ctx.Query("minTime")
+	if minTimeStr == "" {
+		minTimeStr = ctx.DefaultQuery("lastId", "0")
+	}
+	minTime, _ := strconv.ParseInt(minTimeStr, 10, 64)
+	offset, _ := strconv.ParseInt(ctx.DefaultQuery("offset", "0"), 10, 64)
 	if offset < 0 {
 		offset = 0
 	}
 
-	blogs, nextLast, nextOffset, err := h.blogService.QueryFeed(ctx.Request.Context(), loginUser.ID, lastID, offset, 10)
+	blogs, scroll, err := h.blogService.QueryFeed(ctx.Request.Context(), loginUser.ID, minTime, offset, 10)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
 	}
 
 	// 填充作者信息与 isLike
-	for i := range blogs {
-		user, err := h.userService.FindByID(ctx.Request.Context(), blogs[i].UserID)
-		if err != nil {
+	if err := h.fillAuthors(ctx.Request.Context(), blogs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	if err := h.fillIsLike(ctx.Request.Context(), blogs, loginUser.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+
+	if blogs == nil {
+		blogs = []model.Blog{}
+	}
+	scroll.List = blogs
+	ctx.JSON(http.StatusOK, result.OkWithData(scroll))
+}
+
+// QueryBlogByTag 分页查询某话题下的笔记
+func (h *BlogHandler) QueryBlogByTag(ctx *gin.Context) {
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	blogs, err := h.tagService.QueryBlogs(ctx.Request.Context(), ctx.Param("name"), page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	if err := h.fillAuthors(ctx.Request.Context(), blogs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	if loginUser, _ := middleware.GetLoginUser(ctx); loginUser != nil {
+		if err := h.fillIsLike(ctx.Request.Context(), blogs, loginUser.ID); err != nil {
+			ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(blogs))
+}
+
+// SearchBlog 按关键字全文搜索笔记，命中片段放在 highlight 字段
+func (h *BlogHandler) SearchBlog(ctx *gin.Context) {
+	keyword := strings.TrimSpace(ctx.Query("keyword"))
+	if keyword == "" {
+		ctx.JSON(http.StatusBadRequest, result.Fail("keyword is required"))
+		return
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	blogs, total, err := h.searchSvc.Search(ctx.Request.Context(), keyword, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	if err := h.fillAuthors(ctx.Request.Context(), blogs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	if loginUser, _ := middleware.GetLoginUser(ctx); loginUser != nil {
+		if err := h.fillIsLike(ctx.Request.Context(), blogs, loginUser.ID); err != nil {
 			ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 			return
 		}
-		if user != nil {
-			blogs[i].Name = user.NickName
-			blogs[i].Icon = user.Icon
+		// 首页搜索时记录搜索历史，翻页不重复记录
+		if page == 1 {
+			_ = h.searchHistory.Record(ctx.Request.Context(), loginUser.ID, service.SearchScopeBlog, keyword)
 		}
-		isLike, err := h.blogService.IsLiked(ctx.Request.Context(), blogs[i].ID, loginUser.ID)
-		if err != nil {
+	}
+	ctx.JSON(http.StatusOK, result.OkWithPage(blogs, total))
+}
+
+// QueryTrendingTopics 查询最近 24 小时的热门话题
+func (h *BlogHandler) QueryTrendingTopics(ctx *gin.Context) {
+	topics, err := h.tagService.Trending(ctx.Request.Context(), 10)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(topics))
+}
+
+// QueryNearbyBlog 查询附近的笔记，按距离排序（x/y 为经纬度，radius 单位米）
+func (h *BlogHandler) QueryNearbyBlog(ctx *gin.Context) {
+	x, err := strconv.ParseFloat(ctx.Query("x"), 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid x"))
+		return
+	}
+	y, err := strconv.ParseFloat(ctx.Query("y"), 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid y"))
+		return
+	}
+	radius := 5000.0
+	if radiusStr := ctx.Query("radius"); radiusStr != "" {
+		radius, err = strconv.ParseFloat(radiusStr, 64)
+		if err != nil || radius <= 0 || radius > 20000 {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid radius"))
+			return
+		}
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	blogs, err := h.blogService.QueryNearby(ctx.Request.Context(), x, y, radius, page, utils.DEFAULT_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	if err := h.fillAuthors(ctx.Request.Context(), blogs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	loginUser, _ := middleware.GetLoginUser(ctx)
+	if loginUser != nil {
+		if err := h.fillIsLike(ctx.Request.Context(), blogs, loginUser.ID); err != nil {
 			ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 			return
 		}
-		blogs[i].IsLike = &isLike
 	}
+	ctx.JSON(http.StatusOK, result.OkWithData(blogs))
+}
 
-	ctx.JSON(http.StatusOK, result.OkWithData(map[string]interface{}{
-		"blogs":  blogs,
-		"lastId": nextLast,
-		"offset": nextOffset,
-	}))
+// fillAuthors 批量填充笔记作者的昵称与头像
+func (h *BlogHandler) fillAuthors(ctx context.Context, blogs []model.Blog) error {
+	ids := make([]int64, 0, len(blogs))
+	for i := range blogs {
+		ids = append(ids, blogs[i].UserID)
+	}
+	authors, err := h.userService.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range blogs {
+		if author, ok := authors[blogs[i].UserID]; ok {
+			blogs[i].Name = author.NickName
+			blogs[i].Icon = author.Icon
+		}
+	}
+	return nil
+}
+
+// fillIsLike 批量标记当前用户是否点赞过这些笔记
+func (h *BlogHandler) fillIsLike(ctx context.Context, blogs []model.Blog, userID int64) error {
+	ids := make([]int64, 0, len(blogs))
+	for i := range blogs {
+		ids = append(ids, blogs[i].ID)
+	}
+	liked, err := h.blogService.IsLikedBatch(ctx, ids, userID)
+	if err != nil {
+		return err
+	}
+	for i := range blogs {
+		isLike := liked[blogs[i].ID]
+		blogs[i].IsLike = &isLike
+	}
+	return nil
 }
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/service"
+)
+
+// CampaignHandler 处理秒杀活动相关接口
+type CampaignHandler struct {
+	campaignSvc *service.CampaignService
+}
+
+func NewCampaignHandler(svc *service.CampaignService) *CampaignHandler {
+	return &CampaignHandler{campaignSvc: svc}
+}
+
+// AddCampaign 创建活动并关联秒杀券
+func (h *CampaignHandler) AddCampaign(ctx *gin.Context) {
+	var form service.CampaignForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	campaign, err := h.campaignSvc.Create(ctx.Request.Context(), form)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(campaign.ID))
+}
+
+// QueryActiveCampaigns 查询进行中的活动及其秒杀券，用于首页秒杀专区
+func (h *CampaignHandler) QueryActiveCampaigns(ctx *gin.Context) {
+	list, err := h.campaignSvc.ListActive(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(list))
+}
+
+// QueryCampaignStats 查询活动维度的库存与销量统计
+func (h *CampaignHandler) QueryCampaignStats(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	stats, err := h.campaignSvc.Stats(ctx.Request.Context(), id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(stats))
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
+)
+
+// CommentHandler 处理笔记评论
+type CommentHandler struct {
+	commentService *service.CommentService
+}
+
+func NewCommentHandler(commentSvc *service.CommentService) *CommentHandler {
+	return &CommentHandler{commentService: commentSvc}
+}
+
+// SaveComment 发表评论，请求体为 {blogId, parentId, answerId, content}
+func (h *CommentHandler) SaveComment(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var comment model.BlogComment
+	if err := ctx.ShouldBindJSON(&comment); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	// 编号、作者与创建时间由服务端决定，忽略客户端传入的值
+	comment.ID = 0
+	comment.UserID = loginUser.ID
+	comment.CreateTime = time.Time{}
+	if err := h.commentService.Create(ctx.Request.Context(), &comment); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(comment.ID))
+}
+
+// QueryComments 分页查询笔记的评论
+func (h *CommentHandler) QueryComments(ctx *gin.Context) {
+	blogID, err := strconv.ParseInt(ctx.Query("blogId"), 10, 64)
+	if err != nil || blogID <= 0 {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid blog id"))
+		return
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	comments, err := h.commentService.List(ctx.Request.Context(), blogID, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(comments))
+}
+
+// DeleteComment 删除评论
+func (h *CommentHandler) DeleteComment(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	if err := h.commentService.Delete(ctx.Request.Context(), loginUser.ID, id); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
+)
+
+// FavoriteHandler 处理笔记收藏与收藏夹
+type FavoriteHandler struct {
+	favoriteService *service.FavoriteService
+}
+
+func NewFavoriteHandler(favoriteSvc *service.FavoriteService) *FavoriteHandler {
+	return &FavoriteHandler{favoriteService: favoriteSvc}
+}
+
+// AddFavorite 收藏笔记，请求体为 {blogId, collectionId}，collectionId 省略时放入默认收藏夹
+func (h *FavoriteHandler) AddFavorite(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var req struct {
+		BlogID       int64 `json:"blogId"`
+		CollectionID int64 `json:"collectionId"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.BlogID <= 0 {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	if err := h.favoriteService.Add(ctx.Request.Context(), loginUser.ID, req.BlogID, req.CollectionID); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// RemoveFavorite 取消收藏
+func (h *FavoriteHandler) RemoveFavorite(ctx *gin.Context) {
+	blogID, err := strconv.ParseInt(ctx.Param("blogId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid blog id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	if err := h.favoriteService.Remove(ctx.Request.Context(), loginUser.ID, blogID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// QueryFavorites 分页查询我的收藏，collectionId 省略时查询全部收藏夹，0 为默认收藏夹
+func (h *FavoriteHandler) QueryFavorites(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	collectionID := int64(-1)
+	if raw := ctx.Query("collectionId"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id < 0 {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid collection id"))
+			return
+		}
+		collectionID = id
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	blogs, err := h.favoriteService.List(ctx.Request.Context(), loginUser.ID, collectionID, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(blogs))
+}
+
+// QueryCollections 查询我的收藏夹
+func (h *FavoriteHandler) QueryCollections(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	collections, err := h.favoriteService.ListCollections(ctx.Request.Context(), loginUser.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(collections))
+}
+
+// SaveCollection 新建收藏夹，请求体为 {name}
+func (h *FavoriteHandler) SaveCollection(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	collection, err := h.favoriteService.CreateCollection(ctx.Request.Context(), loginUser.ID, req.Name)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(collection))
+}
+
+// DeleteCollection 删除收藏夹，其中的收藏一并取消
+func (h *FavoriteHandler) DeleteCollection(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	if err := h.favoriteService.DeleteCollection(ctx.Request.Context(), loginUser.ID, id); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
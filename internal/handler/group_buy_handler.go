@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/service"
+)
+
+// GroupBuyHandler 处理拼团的开团、参团与查询
+type GroupBuyHandler struct {
+	groupBuySvc *service.GroupBuyService
+}
+
+func NewGroupBuyHandler(groupBuySvc *service.GroupBuyService) *GroupBuyHandler {
+	return &GroupBuyHandler{groupBuySvc: groupBuySvc}
+}
+
+// CreateGroup 开团并支付团长订单，请求体为 {voucherId, points, payType}
+func (h *GroupBuyHandler) CreateGroup(ctx *gin.Context) {
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var req struct {
+		VoucherID int64 `json:"voucherId"`
+		service.PayRequest
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.VoucherID <= 0 {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	res, err := h.groupBuySvc.Create(ctx.Request.Context(), user.ID, req.VoucherID, req.PayRequest)
+	if err != nil {
+		writeVoucherRuleError(ctx, err, http.StatusBadRequest)
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(res))
+}
+
+// JoinGroup 参团并支付，请求体可选 {points, payType}
+func (h *GroupBuyHandler) JoinGroup(ctx *gin.Context) {
+	groupID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid group id"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var req service.PayRequest
+	// 请求体可为空，表示不使用积分
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+			return
+		}
+	}
+	res, err := h.groupBuySvc.Join(ctx.Request.Context(), user.ID, groupID, req)
+	if err != nil {
+		writeVoucherRuleError(ctx, err, http.StatusBadRequest)
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(res))
+}
+
+// QueryGroup 查询拼团详情及已参团用户
+func (h *GroupBuyHandler) QueryGroup(ctx *gin.Context) {
+	groupID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid group id"))
+		return
+	}
+	group, err := h.groupBuySvc.Get(ctx.Request.Context(), groupID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(group))
+}
+
+// QueryFormingGroups 查询券下仍可参加的拼团
+func (h *GroupBuyHandler) QueryFormingGroups(ctx *gin.Context) {
+	voucherID, err := strconv.ParseInt(ctx.Param("voucherId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid voucher id"))
+		return
+	}
+	groups, err := h.groupBuySvc.ListForming(ctx.Request.Context(), voucherID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(groups))
+}
@@ -3,12 +3,14 @@ package handler
 import (
 	"context"
 	"errors"
-	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 
 	"hmdp-backend/internal/data"
@@ -21,14 +23,31 @@ type HealthHandler struct {
 	kafkaBrokers []string
 	log          *zap.Logger
 	checkTimeout time.Duration
+	draining     atomic.Bool // 进入优雅关闭后置为 true，就绪检查直接失败
 }
 // sqlDB 定义了数据库连接需要实现的接口
 type sqlDB interface {
 	PingContext(ctx context.Context) error
 }
 
+// 依赖检查结果
+const (
+	checkStatusUp   = "up"
+	checkStatusDown = "down"
+)
+
+// dependencyCheck 单个依赖的检查结果
+type dependencyCheck struct {
+	Status    string `json:"status"`          // up | down
+	LatencyMs int64  `json:"latencyMs"`       // 检查耗时（毫秒）
+	Error     string `json:"error,omitempty"` // 失败原因
+}
+
 // NewHealthHandler 创建一个新的 HealthHandler 实例
 func NewHealthHandler(db sqlDB, redisClient *redis.Client, kafkaBrokers []string, log *zap.Logger) *HealthHandler {
+	if log == nil {
+		log = zap.NewNop()
+	}
 	return &HealthHandler{
 		db:           db,
 		redis:        redisClient,
@@ -38,50 +57,85 @@ func NewHealthHandler(db sqlDB, redisClient *redis.Client, kafkaBrokers []string
 	}
 }
 
-// Healthz 返回服务健康状态
+// Healthz 返回服务健康状态（存活检查），只要进程能处理请求即返回 200，不检查外部依赖
 func (h *HealthHandler) Healthz(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
-// Readyz 返回服务就绪状态（服务是否可以对外接收流量）
+// SetDraining 标记服务进入优雅关闭，之后的就绪检查返回 503，使负载均衡在连接关闭前摘除流量
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Readyz 返回服务就绪状态（服务是否可以对外接收流量）；并发检查 MySQL、Redis 与 Kafka，
+// 任一依赖不可用时返回 503，响应体中列出每个依赖的状态与耗时
 func (h *HealthHandler) Readyz(c *gin.Context) {
+	if h.draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.checkTimeout)
 	defer cancel()
 
-	checks := map[string]string{}
-	if err := h.db.PingContext(ctx); err != nil {
-		checks["mysql"] = err.Error()
-	}
-	if err := data.Ping(ctx, h.redis); err != nil {
-		checks["redis"] = err.Error()
+	probes := map[string]func(context.Context) error{
+		"mysql": h.db.PingContext,
+		"redis": func(ctx context.Context) error { return data.Ping(ctx, h.redis) },
+		"kafka": func(ctx context.Context) error { return checkKafka(ctx, h.kafkaBrokers) },
 	}
-	if err := checkKafka(ctx, h.kafkaBrokers); err != nil {
-		checks["kafka"] = err.Error()
+	checks := make(map[string]dependencyCheck, len(probes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, probe := range probes {
+		wg.Add(1)
+		go func(name string, probe func(context.Context) error) {
+			defer wg.Done()
+			start := time.Now()
+			err := probe(ctx)
+			check := dependencyCheck{Status: checkStatusUp, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				check.Status = checkStatusDown
+				check.Error = err.Error()
+			}
+			mu.Lock()
+			checks[name] = check
+			mu.Unlock()
+		}(name, probe)
 	}
+	wg.Wait()
 
-	if len(checks) > 0 {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status": "fail",
-			"checks": checks,
-		})
-		return
+	status, code := "ok", http.StatusOK
+	for name, check := range checks {
+		if check.Status == checkStatusDown {
+			status, code = "fail", http.StatusServiceUnavailable
+			h.log.Warn("readiness check failed", zap.String("dependency", name), zap.String("error", check.Error))
+		}
 	}
-
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+	})
 }
-// checkKafka 检查与 Kafka 的连接
+// checkKafka 检查 Kafka 是否可用：连接任一 broker 并拉取集群元数据，确认对端是可用的 Kafka 节点
 func checkKafka(ctx context.Context, brokers []string) error {
 	if len(brokers) == 0 {
 		return errors.New("no kafka brokers configured")
 	}
 	// 创建 建立网络连接对象
-	dialer := net.Dialer{Timeout: time.Second}
+	dialer := &kafka.Dialer{Timeout: time.Second}
 	var lastErr error
 	for _, broker := range brokers {
 		// 尝试连接每个 broker
 		conn, err := dialer.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		_, err = conn.Brokers()
+		_ = conn.Close()
 		if err == nil {
-			_ = conn.Close()
 			return nil
 		}
 		lastErr = err
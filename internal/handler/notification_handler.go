@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
+)
+
+// NotificationHandler 处理站内通知相关接口
+type NotificationHandler struct {
+	notificationSvc *service.NotificationService
+	settingSvc      *service.NotificationSettingService
+}
+
+func NewNotificationHandler(svc *service.NotificationService, settingSvc *service.NotificationSettingService) *NotificationHandler {
+	return &NotificationHandler{notificationSvc: svc, settingSvc: settingSvc}
+}
+
+// QueryNotifications 分页查询当前用户的站内通知
+func (h *NotificationHandler) QueryNotifications(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	list, err := h.notificationSvc.List(ctx.Request.Context(), loginUser.ID, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(list))
+}
+
+// QuerySettings 查询当前用户各通知类型的渠道开关
+func (h *NotificationHandler) QuerySettings(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	prefs, err := h.settingSvc.Get(ctx.Request.Context(), loginUser.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(prefs))
+}
+
+// UpdateSettings 更新当前用户的通知偏好，请求体为 {type: {inApp, push, email}}
+func (h *NotificationHandler) UpdateSettings(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var prefs map[string]service.ChannelPreference
+	if err := ctx.ShouldBindJSON(&prefs); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	if err := h.settingSvc.Update(ctx.Request.Context(), loginUser.ID, prefs); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
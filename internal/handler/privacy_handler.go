@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/service"
+)
+
+// PrivacyHandler 处理用户隐私设置
+type PrivacyHandler struct {
+	privacySvc *service.PrivacyService
+}
+
+func NewPrivacyHandler(privacySvc *service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{privacySvc: privacySvc}
+}
+
+// QueryPrivacy 查询当前用户的隐私设置
+func (h *PrivacyHandler) QueryPrivacy(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	privacy, err := h.privacySvc.Get(ctx.Request.Context(), loginUser.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(privacy))
+}
+
+// UpdatePrivacy 更新当前用户的隐私设置，请求体为 {hideBlogsFromFeed, hideFollows}
+func (h *PrivacyHandler) UpdatePrivacy(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var privacy model.UserPrivacy
+	if err := ctx.ShouldBindJSON(&privacy); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	if err := h.privacySvc.Update(ctx.Request.Context(), loginUser.ID, privacy); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
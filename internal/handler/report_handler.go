@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
+)
+
+// ReportHandler 处理内容举报与管理员审核
+type ReportHandler struct {
+	reportService *service.ReportService
+}
+
+func NewReportHandler(reportSvc *service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService: reportSvc}
+}
+
+// SaveReport 举报笔记或评论，请求体为 {targetType, targetId, reason}
+func (h *ReportHandler) SaveReport(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var report model.Report
+	if err := ctx.ShouldBindJSON(&report); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	report.UserID = loginUser.ID
+	if err := h.reportService.Create(ctx.Request.Context(), &report); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(report.ID))
+}
+
+// QueryReports 管理员分页查询举报队列，默认只看待处理的举报
+func (h *ReportHandler) QueryReports(ctx *gin.Context) {
+	status := model.ReportStatusPending
+	if raw := ctx.Query("status"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid status"))
+			return
+		}
+		status = v
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	reports, err := h.reportService.List(ctx.Request.Context(), status, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(reports))
+}
+
+// ReviewReport 管理员审核举报，请求体为 {action}，取值 hide 或 dismiss
+func (h *ReportHandler) ReviewReport(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var req struct {
+		Action string `json:"action"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	if err := h.reportService.Review(ctx.Request.Context(), loginUser.ID, id, req.Action); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
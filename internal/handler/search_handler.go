@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/service"
+)
+
+// SearchHandler 处理搜索历史与搜索联想接口
+type SearchHandler struct {
+	searchSvc *service.SearchService
+}
+
+func NewSearchHandler(svc *service.SearchService) *SearchHandler {
+	return &SearchHandler{searchSvc: svc}
+}
+
+// QueryHistory 查询当前用户的搜索历史，type 取 shop 或 blog
+func (h *SearchHandler) QueryHistory(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	history, err := h.searchSvc.History(ctx.Request.Context(), loginUser.ID, searchScope(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(history))
+}
+
+// ClearHistory 清空当前用户的搜索历史
+func (h *SearchHandler) ClearHistory(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	if err := h.searchSvc.Clear(ctx.Request.Context(), loginUser.ID, searchScope(ctx)); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// Suggest 搜索联想，登录用户优先返回个人历史
+func (h *SearchHandler) Suggest(ctx *gin.Context) {
+	var userID int64
+	if loginUser, ok := middleware.GetLoginUser(ctx); ok && loginUser != nil {
+		userID = loginUser.ID
+	}
+	list, err := h.searchSvc.Suggest(ctx.Request.Context(), userID, searchScope(ctx), ctx.Query("prefix"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(list))
+}
+
+// searchScope 读取 type 参数，默认为店铺搜索
+func searchScope(ctx *gin.Context) string {
+	return ctx.DefaultQuery("type", service.SearchScopeShop)
+}
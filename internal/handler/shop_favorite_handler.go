@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
+)
+
+// ShopFavoriteHandler 处理商铺收藏
+type ShopFavoriteHandler struct {
+	favoriteService *service.ShopFavoriteService
+}
+
+func NewShopFavoriteHandler(favoriteSvc *service.ShopFavoriteService) *ShopFavoriteHandler {
+	return &ShopFavoriteHandler{favoriteService: favoriteSvc}
+}
+
+// ToggleFavorite 收藏或取消收藏商铺，返回切换后的状态
+func (h *ShopFavoriteHandler) ToggleFavorite(ctx *gin.Context) {
+	shopID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid shop id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	favorited, err := h.favoriteService.Toggle(ctx.Request.Context(), loginUser.ID, shopID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(favorited))
+}
+
+// IsFavorite 查询当前用户是否收藏了商铺
+func (h *ShopFavoriteHandler) IsFavorite(ctx *gin.Context) {
+	shopID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid shop id"))
+		return
+	}
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	favorited, err := h.favoriteService.IsFavorited(ctx.Request.Context(), loginUser.ID, shopID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(favorited))
+}
+
+// QueryMyFavorites 分页查询我收藏的商铺，传入 x、y 时附带距离
+func (h *ShopFavoriteHandler) QueryMyFavorites(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var x, y float64
+	if xStr, yStr := ctx.Query("x"), ctx.Query("y"); xStr != "" && yStr != "" {
+		var err error
+		if x, err = strconv.ParseFloat(xStr, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid x"))
+			return
+		}
+		if y, err = strconv.ParseFloat(yStr, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid y"))
+			return
+		}
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	shops, err := h.favoriteService.List(ctx.Request.Context(), loginUser.ID, page, utils.MAX_PAGE_SIZE, x, y)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(shops))
+}
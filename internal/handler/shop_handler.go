@@ -2,8 +2,10 @@ package handler
 
 import (
 	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
@@ -13,11 +15,16 @@ import (
 )
 
 type ShopHandler struct {
-	service *service.ShopService
+	service    *service.ShopService
+	searchSvc  *service.SearchService
+	historySvc *service.ShopHistoryService
+	shopSearch *service.ShopSearchService
+	viewSvc    *service.ShopViewService
+	uploadDir  string
 }
 
-func NewShopHandler(svc *service.ShopService) *ShopHandler {
-	return &ShopHandler{service: svc}
+func NewShopHandler(svc *service.ShopService, searchSvc *service.SearchService, historySvc *service.ShopHistoryService, shopSearch *service.ShopSearchService, viewSvc *service.ShopViewService, uploadDir string) *ShopHandler {
+	return &ShopHandler{service: svc, searchSvc: searchSvc, historySvc: historySvc, shopSearch: shopSearch, viewSvc: viewSvc, uploadDir: uploadDir}
 }
 
 // QueryShopByID 根据ID查询店铺
@@ -32,15 +39,68 @@ func (h *ShopHandler) QueryShopByID(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
 	}
+	// 登录用户记录浏览历史，失败不影响详情返回
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if ok && loginUser != nil && shop != nil {
+		_ = h.historySvc.Record(ctx.Request.Context(), loginUser.ID, shop.ID)
+	}
+	// 统计详情页 PV/UV，未登录访客按客户端 IP 去重
+	if shop != nil {
+		visitor := "ip:" + ctx.ClientIP()
+		if ok && loginUser != nil {
+			visitor = "u:" + strconv.FormatInt(loginUser.ID, 10)
+		}
+		_ = h.viewSvc.Record(ctx.Request.Context(), shop.ID, visitor)
+	}
 	ctx.JSON(http.StatusOK, result.OkWithData(shop))
 }
 
+// QueryShopBatch 批量查询店铺，ids 为逗号分隔的店铺ID，按传入顺序返回
+func (h *ShopHandler) QueryShopBatch(ctx *gin.Context) {
+	var ids []int64
+	for _, part := range strings.Split(ctx.Query("ids"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id <= 0 {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid ids"))
+			return
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		ctx.JSON(http.StatusBadRequest, result.Fail("ids is required"))
+		return
+	}
+	if len(ids) > service.ShopBatchMax {
+		ctx.JSON(http.StatusBadRequest, result.Fail("too many ids"))
+		return
+	}
+	shops, err := h.service.GetByIDs(ctx.Request.Context(), ids)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(shops))
+}
+
+// SaveShop 新增店铺：管理员直接上架，商家提交入驻申请等待审核
 func (h *ShopHandler) SaveShop(ctx *gin.Context) {
 	var shop model.Shop
 	if err := ctx.ShouldBindJSON(&shop); err != nil {
 		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
 		return
 	}
+	if loginUser, ok := middleware.GetLoginUser(ctx); ok && loginUser != nil && loginUser.Role == model.RoleMerchant {
+		if err := h.service.Submit(ctx.Request.Context(), loginUser.ID, &shop); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusOK, result.OkWithData(shop.ID))
+		return
+	}
 	if err := h.service.Create(ctx.Request.Context(), &shop); err != nil {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
@@ -55,6 +115,9 @@ func (h *ShopHandler) UpdateShop(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
 		return
 	}
+	if !h.checkMerchantOwner(ctx, shop.ID) {
+		return
+	}
 	if err := h.service.Update(ctx.Request.Context(), &shop); err != nil {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
@@ -62,6 +125,298 @@ func (h *ShopHandler) UpdateShop(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, result.Ok())
 }
 
+// DeleteShop 删除店铺，路由仅对管理员开放
+func (h *ShopHandler) DeleteShop(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid shop id"))
+		return
+	}
+	if err := h.service.Delete(ctx.Request.Context(), id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// UploadShopImage 上传商铺图片（表单字段 file）并追加到图库末尾，返回最新图片列表
+func (h *ShopHandler) UploadShopImage(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid shop id"))
+		return
+	}
+	if !h.checkMerchantOwner(ctx, id) {
+		return
+	}
+	fileName, ok := saveUploadedImage(ctx, h.uploadDir, "shops")
+	if !ok {
+		return
+	}
+	images, err := h.service.AddImage(ctx.Request.Context(), id, fileName)
+	if err != nil {
+		_ = removeUploadedFile(h.uploadDir, fileName)
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(images))
+}
+
+// ReorderShopImages 调整商铺图片顺序，请求体为 {images: [...]}
+func (h *ShopHandler) ReorderShopImages(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid shop id"))
+		return
+	}
+	if !h.checkMerchantOwner(ctx, id) {
+		return
+	}
+	var req struct {
+		Images []string `json:"images"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	images, err := h.service.ReorderImages(ctx.Request.Context(), id, req.Images)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(images))
+}
+
+// DeleteShopImage 从图库删除图片（查询参数 name）并删除文件
+func (h *ShopHandler) DeleteShopImage(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid shop id"))
+		return
+	}
+	if !h.checkMerchantOwner(ctx, id) {
+		return
+	}
+	name := ctx.Query("name")
+	if name == "" {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid filename"))
+		return
+	}
+	images, err := h.service.RemoveImage(ctx.Request.Context(), id, name)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	// 图库已更新，文件删除失败只留下孤立文件
+	_ = removeUploadedFile(h.uploadDir, name)
+	ctx.JSON(http.StatusOK, result.OkWithData(images))
+}
+
+// SearchShop 按关键字、类型搜索店铺，传入经纬度时按距离排序
+func (h *ShopHandler) SearchShop(ctx *gin.Context) {
+	q := service.ShopSearchQuery{
+		Keyword: ctx.Query("keyword"),
+		Page:    utils.ParsePage(ctx.Query("current"), 1),
+		Size:    utils.MAX_PAGE_SIZE,
+	}
+	if typeIDStr := ctx.Query("typeId"); typeIDStr != "" {
+		typeID, err := strconv.ParseInt(typeIDStr, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid typeId"))
+			return
+		}
+		q.TypeID = typeID
+	}
+	if xStr, yStr := ctx.Query("x"), ctx.Query("y"); xStr != "" && yStr != "" {
+		x, err := strconv.ParseFloat(xStr, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid x"))
+			return
+		}
+		y, err := strconv.ParseFloat(yStr, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid y"))
+			return
+		}
+		q.X, q.Y = x, y
+	}
+	shops, total, err := h.shopSearch.Search(ctx.Request.Context(), q)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	// 登录用户首页搜索时记录搜索历史，翻页不重复记录
+	if loginUser, ok := middleware.GetLoginUser(ctx); ok && loginUser != nil && q.Page == 1 && q.Keyword != "" {
+		_ = h.searchSvc.Record(ctx.Request.Context(), loginUser.ID, service.SearchScopeShop, q.Keyword)
+	}
+	ctx.JSON(http.StatusOK, result.OkWithPage(shops, total))
+}
+
+// QueryMyShops 商家查询名下的店铺及入驻申请的审核状态
+func (h *ShopHandler) QueryMyShops(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	shops, err := h.service.ListOwned(ctx.Request.Context(), loginUser.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(shops))
+}
+
+// ResubmitShop 商家修改被驳回的入驻申请后重新提交审核
+func (h *ShopHandler) ResubmitShop(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid shop id"))
+		return
+	}
+	var shop model.Shop
+	if err := ctx.ShouldBindJSON(&shop); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	shop.ID = id
+	if err := h.service.Resubmit(ctx.Request.Context(), loginUser.ID, &shop); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// checkMerchantOwner 商家只能管理自己名下的店铺，管理员不受限制；校验失败时已写入响应
+func (h *ShopHandler) checkMerchantOwner(ctx *gin.Context, shopID int64) bool {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil || loginUser.Role != model.RoleMerchant {
+		return true
+	}
+	if err := h.service.CheckOwner(ctx.Request.Context(), shopID, loginUser.ID); err != nil {
+		ctx.JSON(http.StatusForbidden, result.Fail(err.Error()))
+		return false
+	}
+	return true
+}
+
+// ListShops 组合筛选店铺：类型、关键字、人均价格区间、最低评分、排序与位置，返回分页结果
+func (h *ShopHandler) ListShops(ctx *gin.Context) {
+	f := service.ShopFilter{
+		Keyword: ctx.Query("keyword"),
+		SortBy:  ctx.Query("sortBy"),
+		Unit:    ctx.Query("unit"),
+		Page:    utils.ParsePage(ctx.Query("current"), 1),
+		Size:    utils.DEFAULT_PAGE_SIZE,
+	}
+	if err := service.ValidateShopSortBy(f.SortBy); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	if err := service.ValidateGeoUnit(f.Unit); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	var err error
+	if v := ctx.Query("typeId"); v != "" {
+		if f.TypeID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid typeId"))
+			return
+		}
+	}
+	if v := ctx.Query("minPrice"); v != "" {
+		if f.MinPrice, err = strconv.ParseInt(v, 10, 64); err != nil || f.MinPrice < 0 {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid minPrice"))
+			return
+		}
+	}
+	if v := ctx.Query("maxPrice"); v != "" {
+		if f.MaxPrice, err = strconv.ParseInt(v, 10, 64); err != nil || f.MaxPrice < 0 {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid maxPrice"))
+			return
+		}
+	}
+	if f.MinPrice > 0 && f.MaxPrice > 0 && f.MinPrice > f.MaxPrice {
+		ctx.JSON(http.StatusBadRequest, result.Fail("minPrice 不能大于 maxPrice"))
+		return
+	}
+	if v := ctx.Query("minScore"); v != "" {
+		if f.MinScore, err = strconv.Atoi(v); err != nil || f.MinScore < 0 {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid minScore"))
+			return
+		}
+	}
+	if xStr, yStr := ctx.Query("x"), ctx.Query("y"); xStr != "" && yStr != "" {
+		if f.X, err = strconv.ParseFloat(xStr, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid x"))
+			return
+		}
+		if f.Y, err = strconv.ParseFloat(yStr, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid y"))
+			return
+		}
+		if v := ctx.Query("radius"); v != "" {
+			if f.Radius, err = strconv.ParseFloat(v, 64); err != nil || f.Radius < 0 {
+				ctx.JSON(http.StatusBadRequest, result.Fail("invalid radius"))
+				return
+			}
+		}
+	} else if f.SortBy == service.ShopSortDistance {
+		ctx.JSON(http.StatusBadRequest, result.Fail("按距离排序需要提供 x、y"))
+		return
+	}
+	shops, total, err := h.service.List(ctx.Request.Context(), f)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithPage(shops, total))
+}
+
+// QueryShopClusters 按地图视野（minX、minY、maxX、maxY）与缩放级别 zoom 返回聚合后的店铺标记，typeId 可选
+func (h *ShopHandler) QueryShopClusters(ctx *gin.Context) {
+	var bounds service.ShopBounds
+	for _, p := range []struct {
+		name string
+		dst  *float64
+	}{
+		{"minX", &bounds.MinX},
+		{"minY", &bounds.MinY},
+		{"maxX", &bounds.MaxX},
+		{"maxY", &bounds.MaxY},
+	} {
+		v, err := strconv.ParseFloat(ctx.Query(p.name), 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid "+p.name))
+			return
+		}
+		*p.dst = v
+	}
+	zoom, err := strconv.Atoi(ctx.Query("zoom"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid zoom"))
+		return
+	}
+	var typeID int64
+	if typeIDStr := ctx.Query("typeId"); typeIDStr != "" {
+		if typeID, err = strconv.ParseInt(typeIDStr, 10, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid typeId"))
+			return
+		}
+	}
+	clusters, err := h.service.ClusterShops(ctx.Request.Context(), typeID, bounds, zoom)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(clusters))
+}
+
 // QueryShopByType 根据类型分页查询店铺
 func (h *ShopHandler) QueryShopByType(ctx *gin.Context) {
 	typeIDStr := ctx.Query("typeId")
@@ -75,9 +430,16 @@ func (h *ShopHandler) QueryShopByType(ctx *gin.Context) {
 		return
 	}
 	page := utils.ParsePage(ctx.Query("current"), 1)
+	// openNow=true 时只返回当前营业中的店铺
+	openNow := ctx.Query("openNow") == "true"
+	// sortBy 支持 score、comments、avgPrice、distance，传入经纬度时默认按距离排序
+	sortBy := ctx.Query("sortBy")
+	if err := service.ValidateShopSortBy(sortBy); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
 
 	xStr, yStr := ctx.Query("x"), ctx.Query("y")
-	// 如果传入经纬度，则按距离排序
 	if xStr != "" && yStr != "" {
 		x, err := strconv.ParseFloat(xStr, 64)
 		if err != nil {
@@ -89,17 +451,46 @@ func (h *ShopHandler) QueryShopByType(ctx *gin.Context) {
 			ctx.JSON(http.StatusBadRequest, result.Fail("invalid y"))
 			return
 		}
-		shops, err := h.service.QueryByTypeWithLocation(ctx.Request.Context(), typeID, page, utils.DEFAULT_PAGE_SIZE, x, y)
+		// radius 为搜索半径，unit 为半径与返回距离的单位（m、km、mi、ft，默认 m）
+		var radius float64
+		if radiusStr := ctx.Query("radius"); radiusStr != "" {
+			if radius, err = strconv.ParseFloat(radiusStr, 64); err != nil || radius < 0 {
+				ctx.JSON(http.StatusBadRequest, result.Fail("invalid radius"))
+				return
+			}
+		}
+		unit := ctx.Query("unit")
+		if err := service.ValidateGeoUnit(unit); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+			return
+		}
+		// 按距离排序走 Redis GEO
+		if sortBy == "" || sortBy == service.ShopSortDistance {
+			shops, err := h.service.QueryByTypeWithLocation(ctx.Request.Context(), typeID, page, utils.DEFAULT_PAGE_SIZE, x, y, radius, unit, openNow)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+				return
+			}
+			ctx.JSON(http.StatusOK, result.OkWithData(shops))
+			return
+		}
+		// 其余排序方式仍按数据库分页，只附带距离
+		shops, err := h.service.QueryByType(ctx.Request.Context(), typeID, page, utils.DEFAULT_PAGE_SIZE, sortBy, openNow)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 			return
 		}
+		service.AttachShopDistance(shops, x, y, unit)
 		ctx.JSON(http.StatusOK, result.OkWithData(shops))
 		return
 	}
+	if sortBy == service.ShopSortDistance {
+		ctx.JSON(http.StatusBadRequest, result.Fail("按距离排序需要提供 x、y"))
+		return
+	}
 
 	// 未提供经纬度则按原逻辑分页查询
-	shops, err := h.service.QueryByType(ctx.Request.Context(), typeID, page, utils.DEFAULT_PAGE_SIZE)
+	shops, err := h.service.QueryByType(ctx.Request.Context(), typeID, page, utils.DEFAULT_PAGE_SIZE, sortBy, openNow)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
@@ -115,5 +506,39 @@ func (h *ShopHandler) QueryShopByName(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
 		return
 	}
+	// 登录用户首页搜索时记录搜索历史，翻页不重复记录
+	if loginUser, ok := middleware.GetLoginUser(ctx); ok && loginUser != nil && page == 1 {
+		_ = h.searchSvc.Record(ctx.Request.Context(), loginUser.ID, service.SearchScopeShop, name)
+	}
 	ctx.JSON(http.StatusOK, result.OkWithData(shops))
 }
+
+// QueryShopHistory 查询当前用户最近浏览的店铺
+func (h *ShopHandler) QueryShopHistory(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	list, err := h.historySvc.List(ctx.Request.Context(), loginUser.ID, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(list))
+}
+
+// ClearShopHistory 清空当前用户的浏览记录
+func (h *ShopHandler) ClearShopHistory(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	if err := h.historySvc.Clear(ctx.Request.Context(), loginUser.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
+)
+
+// ShopReviewHandler 处理商铺评价
+type ShopReviewHandler struct {
+	reviewService *service.ShopReviewService
+}
+
+func NewShopReviewHandler(reviewSvc *service.ShopReviewService) *ShopReviewHandler {
+	return &ShopReviewHandler{reviewService: reviewSvc}
+}
+
+// SaveReview 发表评价，请求体为 {shopId, score, content, images}
+func (h *ShopReviewHandler) SaveReview(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var review model.ShopReview
+	if err := ctx.ShouldBindJSON(&review); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	// 编号、作者与创建/更新时间由服务端决定，忽略客户端传入的值
+	review.ID = 0
+	review.UserID = loginUser.ID
+	review.CreateTime = time.Time{}
+	review.UpdateTime = time.Time{}
+	if err := h.reviewService.Create(ctx.Request.Context(), &review); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(review.ID))
+}
+
+// UpdateReview 修改自己的评价，请求体为 {score, content, images}
+func (h *ShopReviewHandler) UpdateReview(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	var review model.ShopReview
+	if err := ctx.ShouldBindJSON(&review); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	review.ID = id
+	if err := h.reviewService.Update(ctx.Request.Context(), loginUser.ID, &review); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// QueryReviews 分页查询商铺的评价
+func (h *ShopReviewHandler) QueryReviews(ctx *gin.Context) {
+	shopID, err := strconv.ParseInt(ctx.Query("shopId"), 10, 64)
+	if err != nil || shopID <= 0 {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid shop id"))
+		return
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	reviews, err := h.reviewService.List(ctx.Request.Context(), shopID, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(reviews))
+}
+
+// DeleteReview 删除自己的评价
+func (h *ShopReviewHandler) DeleteReview(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid id"))
+		return
+	}
+	if err := h.reviewService.Delete(ctx.Request.Context(), loginUser.ID, id); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
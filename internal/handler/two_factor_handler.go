@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto"
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/service"
+)
+
+// TwoFactorHandler 处理二次验证的开启与关闭
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+}
+
+func NewTwoFactorHandler(svc *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: svc}
+}
+
+// Status 查询当前用户是否已开启二次验证
+func (h *TwoFactorHandler) Status(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	enabled, err := h.twoFactorService.Enabled(ctx.Request.Context(), loginUser.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(gin.H{"enabled": enabled}))
+}
+
+// Setup 生成 TOTP 密钥与 otpauth 链接
+func (h *TwoFactorHandler) Setup(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	setup, err := h.twoFactorService.Setup(ctx.Request.Context(), loginUser.ID, loginUser.NickName)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(setup))
+}
+
+// Enable 校验动态码后开启二次验证，返回恢复码
+func (h *TwoFactorHandler) Enable(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var form dto.TwoFactorCodeForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	codes, err := h.twoFactorService.Enable(ctx.Request.Context(), loginUser.ID, form.Code)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(gin.H{"recoveryCodes": codes}))
+}
+
+// Disable 校验动态码或恢复码后关闭二次验证
+func (h *TwoFactorHandler) Disable(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var form dto.TwoFactorCodeForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	if err := h.twoFactorService.Disable(ctx.Request.Context(), loginUser.ID, form.Code); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
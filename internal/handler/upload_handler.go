@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"hash/fnv"
 	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -21,23 +24,37 @@ func NewUploadHandler(uploadDir string) *UploadHandler {
 	return &UploadHandler{uploadDir: uploadDir}
 }
 
+// UploadImage 上传笔记图片；登录用户的图片保存在 blogs/{userId} 下，编辑或删除笔记时只清理作者本人上传的图片
 func (h *UploadHandler) UploadImage(ctx *gin.Context) {
+	category := "blogs"
+	if user, ok := middleware.GetLoginUser(ctx); ok && user != nil {
+		category = blogImageDir(user.ID)
+	}
+	fileName, ok := saveUploadedImage(ctx, h.uploadDir, category)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(fileName))
+}
+
+// saveUploadedImage 保存表单中的 file 到上传目录的 category 子目录，返回相对路径；失败时已写入响应
+func saveUploadedImage(ctx *gin.Context, uploadDir, category string) (string, bool) {
 	file, err := ctx.FormFile("file")
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, result.Fail("missing file"))
-		return
+		return "", false
 	}
-	fileName := h.createNewFileName(file.Filename)
-	target := filepath.Join(h.uploadDir, strings.TrimPrefix(fileName, "/"))
+	fileName := newUploadFileName(category, file.Filename)
+	target := filepath.Join(uploadDir, strings.TrimPrefix(fileName, "/"))
 	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
 		ctx.JSON(http.StatusInternalServerError, result.Fail("failed to create dir"))
-		return
+		return "", false
 	}
 	if err := ctx.SaveUploadedFile(file, target); err != nil {
 		ctx.JSON(http.StatusInternalServerError, result.Fail("文件上传失败"))
-		return
+		return "", false
 	}
-	ctx.JSON(http.StatusOK, result.OkWithData(fileName))
+	return fileName, true
 }
 
 func (h *UploadHandler) DeleteBlogImage(ctx *gin.Context) {
@@ -46,24 +63,62 @@ func (h *UploadHandler) DeleteBlogImage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, result.Fail("invalid filename"))
 		return
 	}
-	target := filepath.Join(h.uploadDir, strings.TrimPrefix(name, "/"))
+	if err := removeUploadedFile(h.uploadDir, name); err != nil {
+		if errors.Is(err, errInvalidUploadName) {
+			ctx.JSON(http.StatusBadRequest, result.Fail("错误的文件名称"))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, result.Fail("删除失败"))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+var errInvalidUploadName = errors.New("invalid upload file name")
+
+// removeUploadedFile 删除上传目录下的文件，文件不存在视为成功，拒绝目录与越出上传目录的路径
+func removeUploadedFile(uploadDir, name string) error {
+	rel := filepath.Clean(strings.TrimPrefix(name, "/"))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return errInvalidUploadName
+	}
+	target := filepath.Join(uploadDir, rel)
 	info, err := os.Stat(target)
 	if err != nil {
-		ctx.JSON(http.StatusOK, result.Ok())
-		return
+		return nil
 	}
 	if info.IsDir() {
-		ctx.JSON(http.StatusBadRequest, result.Fail("错误的文件名称"))
-		return
+		return errInvalidUploadName
 	}
-	if err := os.Remove(target); err != nil {
-		ctx.JSON(http.StatusInternalServerError, result.Fail("删除失败"))
-		return
+	return os.Remove(target)
+}
+
+// blogImageDir 返回用户笔记图片的上传子目录
+func blogImageDir(userID int64) string {
+	return "blogs/" + strconv.FormatInt(userID, 10)
+}
+
+// removeBlogImages 删除笔记图片（逗号分隔），keep 中仍在使用的图片保留；
+// 图片路径来自客户端，只删除作者本人上传目录下的文件，避免借笔记删除他人或商铺的图片
+func removeBlogImages(uploadDir string, authorID int64, images string, keep map[string]struct{}) {
+	ownDir := "/" + blogImageDir(authorID) + "/"
+	for _, img := range strings.Split(images, ",") {
+		img = strings.TrimSpace(img)
+		if img == "" {
+			continue
+		}
+		if _, ok := keep[img]; ok {
+			continue
+		}
+		if !strings.HasPrefix(path.Clean("/"+img), ownDir) {
+			continue
+		}
+		_ = removeUploadedFile(uploadDir, img)
 	}
-	ctx.JSON(http.StatusOK, result.Ok())
 }
 
-func (h *UploadHandler) createNewFileName(original string) string {
+// newUploadFileName 生成 /{category}/{d1}/{d2}/{uuid}.{suffix} 形式的文件名，按哈希打散目录
+func newUploadFileName(category, original string) string {
 	suffix := ""
 	if idx := strings.LastIndex(original, "."); idx >= 0 {
 		suffix = original[idx+1:]
@@ -74,7 +129,7 @@ func (h *UploadHandler) createNewFileName(original string) string {
 	hash := hasher.Sum32()
 	d1 := int(hash & 0xF)
 	d2 := int((hash >> 4) & 0xF)
-	rel := filepath.ToSlash(filepath.Join("blogs", strconv.Itoa(d1), strconv.Itoa(d2), name))
+	rel := filepath.ToSlash(filepath.Join(category, strconv.Itoa(d1), strconv.Itoa(d2), name))
 	if suffix != "" {
 		rel = rel + "." + suffix
 	}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"hmdp-backend/internal/dto"
 	"hmdp-backend/internal/dto/result"
 	"hmdp-backend/internal/middleware"
@@ -11,23 +13,94 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
 )
 
 type UserHandler struct {
-	userService *service.UserService
+	userService     *service.UserService
+	pointsService   *service.PointsService
+	oauthService    *service.OAuthService
+	accountService  *service.AccountService
+	captchaService  *service.CaptchaService
+	loginLogService *service.LoginLogService
 }
 
-func NewUserHandler(userSvc *service.UserService) *UserHandler {
-	return &UserHandler{userService: userSvc}
+func NewUserHandler(
+	userSvc *service.UserService,
+	pointsSvc *service.PointsService,
+	oauthSvc *service.OAuthService,
+	accountSvc *service.AccountService,
+	captchaSvc *service.CaptchaService,
+	loginLogSvc *service.LoginLogService,
+) *UserHandler {
+	return &UserHandler{
+		userService:     userSvc,
+		pointsService:   pointsSvc,
+		oauthService:    oauthSvc,
+		accountService:  accountSvc,
+		captchaService:  captchaSvc,
+		loginLogService: loginLogSvc,
+	}
+}
+
+// respondLogin 输出登录结果：开启二次验证的账号返回 401 与票据，客户端需继续调用 /user/login/2fa
+func respondLogin(ctx *gin.Context, token string, err error, failStatus int) {
+	var tfErr *service.TwoFactorRequiredError
+	if errors.As(err, &tfErr) {
+		ctx.JSON(http.StatusUnauthorized, result.FailWithData(tfErr.Error(), gin.H{"twoFactorRequired": true, "ticket": tfErr.Ticket}))
+		return
+	}
+	if err != nil {
+		ctx.JSON(failStatus, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(token))
 }
 
-// SendCode 根据手机号发送验证码
+// loginContext 携带客户端 IP 与设备信息，用于记录登录日志
+func loginContext(ctx *gin.Context) context.Context {
+	return service.WithLoginClient(ctx.Request.Context(), service.LoginClient{
+		IP:     ctx.ClientIP(),
+		Device: ctx.GetHeader("User-Agent"),
+	})
+}
+
+// Captcha 获取图形验证码，发送短信/邮件验证码前需先通过校验
+func (h *UserHandler) Captcha(ctx *gin.Context) {
+	captcha, err := h.captchaService.Generate()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(captcha))
+}
+
+// SendCode 根据手机号或邮箱发送验证码
 func (h *UserHandler) SendCode(ctx *gin.Context) {
-	// 获取URL参数
-	phone := ctx.DefaultQuery("phone", "")
+	// 获取URL参数，传 email 时走邮箱渠道
+	channel, target := dto.LoginChannelPhone, ctx.DefaultQuery("phone", "")
+	if email := ctx.Query("email"); email != "" {
+		channel, target = dto.LoginChannelEmail, email
+	}
+	// 图形验证码校验，拦截脚本刷短信
+	if !h.captchaService.Verify(ctx.Query("captchaId"), ctx.Query("captcha")) {
+		ctx.JSON(http.StatusBadRequest, result.Fail("图形验证码错误或已过期"))
+		return
+	}
 	// 1.调用service发送验证码并保存到redis
-	if err := h.userService.SendCode(ctx.Request.Context(), phone); err != nil {
+	if err := h.userService.SendCode(ctx.Request.Context(), channel, target, ctx.ClientIP()); err != nil {
+		var limitErr *service.SendCodeLimitError
+		if errors.As(err, &limitErr) {
+			// 返回剩余秒数，前端据此展示倒计时
+			ctx.JSON(http.StatusTooManyRequests, result.FailWithData(limitErr.Error(), gin.H{
+				"scope":      limitErr.Scope,
+				"window":     limitErr.Window,
+				"retryAfter": limitErr.RetryAfter,
+			}))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
 	}
 
 	ctx.JSON(http.StatusOK, result.Ok())
@@ -40,17 +113,106 @@ func (h *UserHandler) Login(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
 		return
 	}
-	token, err := h.userService.Login(ctx.Request.Context(), form)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+	token, err := h.userService.Login(loginContext(ctx), form)
+	respondLogin(ctx, token, err, http.StatusInternalServerError)
+}
+
+// Register 手机号注册并设置密码
+func (h *UserHandler) Register(ctx *gin.Context) {
+	var form dto.LoginForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
 		return
 	}
-	ctx.JSON(http.StatusOK, result.OkWithData(token))
+	token, err := h.userService.Register(loginContext(ctx), form)
+	respondLogin(ctx, token, err, http.StatusBadRequest)
 }
 
-// Logout 退出登录
+// LoginWithPassword 密码登录
+func (h *UserHandler) LoginWithPassword(ctx *gin.Context) {
+	var form dto.LoginForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	token, err := h.userService.LoginWithPassword(loginContext(ctx), form)
+	respondLogin(ctx, token, err, http.StatusUnauthorized)
+}
+
+// LoginWithTwoFactor 二次验证登录：提交票据与动态码（或恢复码）
+func (h *UserHandler) LoginWithTwoFactor(ctx *gin.Context) {
+	var form dto.TwoFactorLoginForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	token, err := h.userService.LoginWithTwoFactor(loginContext(ctx), form.Ticket, form.Code)
+	respondLogin(ctx, token, err, http.StatusUnauthorized)
+}
+
+// LoginWithOAuth 第三方登录，provider 取值如 wechat
+func (h *UserHandler) LoginWithOAuth(ctx *gin.Context) {
+	var form dto.OAuthLoginForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	token, err := h.oauthService.Login(loginContext(ctx), ctx.Param("provider"), form.Code)
+	respondLogin(ctx, token, err, http.StatusBadRequest)
+}
+
+// SetPassword 设置或修改当前用户的密码
+func (h *UserHandler) SetPassword(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var form dto.PasswordForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	if err := h.userService.SetPassword(ctx.Request.Context(), loginUser.ID, form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// ChangePhone 更换绑定手机号，需先通过 /user/code 分别向原手机号与新手机号发送验证码；成功后全部设备需重新登录
+func (h *UserHandler) ChangePhone(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var form dto.ChangePhoneForm
+	if err := ctx.ShouldBindJSON(&form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	if err := h.userService.ChangePhone(ctx.Request.Context(), loginUser.ID, form); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// Logout 退出登录，all=true 时退出全部设备
 func (h *UserHandler) Logout(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, result.Fail("功能未完成"))
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	token, _ := middleware.GetLoginToken(ctx)
+	all := ctx.Query("all") == "true"
+	if err := h.userService.Logout(ctx.Request.Context(), loginUser.ID, token, all); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
 }
 
 // Me 获取用户个人信息
@@ -85,6 +247,17 @@ func (h *UserHandler) Info(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, result.OkWithData(info))
 }
 
+// SearchUsers 按昵称搜索用户，供关注、@ 提及等场景选择用户
+func (h *UserHandler) SearchUsers(ctx *gin.Context) {
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	users, err := h.userService.SearchByNickName(ctx.Request.Context(), ctx.Query("name"), page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(users))
+}
+
 // GetUserByID 通过 /user/:id 获取用户信息（用于用户主页）
 func (h *UserHandler) GetUserByID(ctx *gin.Context) {
 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
@@ -132,3 +305,65 @@ func (h *UserHandler) SignCount(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, result.OkWithData(count))
 }
+
+// Points 查询当前用户积分余额
+func (h *UserHandler) Points(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	balance, err := h.pointsService.Balance(ctx.Request.Context(), loginUser.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(balance))
+}
+
+// DeleteAccount 注销当前账号
+func (h *UserHandler) DeleteAccount(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	if err := h.accountService.Delete(ctx.Request.Context(), loginUser.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// ExportAccount 以 JSON 附件导出当前用户的笔记、订单与关注关系
+func (h *UserHandler) ExportAccount(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	archive, err := h.accountService.Export(ctx.Request.Context(), loginUser.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	filename := "hmdp-account-" + strconv.FormatInt(loginUser.ID, 10) + ".json"
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.JSON(http.StatusOK, archive)
+}
+
+// LoginHistory 查询当前用户最近的登录记录
+func (h *UserHandler) LoginHistory(ctx *gin.Context) {
+	loginUser, ok := middleware.GetLoginUser(ctx)
+	if !ok || loginUser == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	size, _ := strconv.Atoi(ctx.DefaultQuery("size", "20"))
+	logs, err := h.loginLogService.List(ctx.Request.Context(), loginUser.ID, size)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(logs))
+}
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"context"
 	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/middleware"
 	"net/http"
 	"strconv"
 
@@ -12,11 +14,12 @@ import (
 )
 
 type VoucherHandler struct {
-	service *service.VoucherService
+	service  *service.VoucherService
+	reminder *service.SeckillReminderService
 }
 
-func NewVoucherHandler(svc *service.VoucherService) *VoucherHandler {
-	return &VoucherHandler{service: svc}
+func NewVoucherHandler(svc *service.VoucherService, reminder *service.SeckillReminderService) *VoucherHandler {
+	return &VoucherHandler{service: svc, reminder: reminder}
 }
 
 func (h *VoucherHandler) AddVoucher(ctx *gin.Context) {
@@ -26,7 +29,7 @@ func (h *VoucherHandler) AddVoucher(ctx *gin.Context) {
 		return
 	}
 	if err := h.service.Create(ctx.Request.Context(), &voucher); err != nil {
-		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		writeVoucherRuleError(ctx, err, http.StatusInternalServerError)
 		return
 	}
 	ctx.JSON(http.StatusOK, result.OkWithData(voucher.ID))
@@ -40,7 +43,7 @@ func (h *VoucherHandler) AddSeckillVoucher(ctx *gin.Context) {
 		return
 	}
 	if err := h.service.AddSeckillVoucher(ctx.Request.Context(), &voucher); err != nil {
-		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		writeVoucherRuleError(ctx, err, http.StatusInternalServerError)
 		return
 	}
 	ctx.JSON(http.StatusOK, result.OkWithData(voucher.ID))
@@ -60,3 +63,81 @@ func (h *VoucherHandler) QueryVoucherOfShop(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, result.OkWithData(vouchers))
 }
+
+// QueryRemainingStock 查询秒杀券的实时剩余库存，供前端在售罄时置灰抢购按钮
+func (h *VoucherHandler) QueryRemainingStock(ctx *gin.Context) {
+	voucherID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid voucher id"))
+		return
+	}
+	stock, err := h.service.RemainingStock(ctx.Request.Context(), voucherID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(stock))
+}
+
+// QuerySeckillTiming 返回服务器时间与秒杀开始、结束时间，供前端渲染倒计时
+func (h *VoucherHandler) QuerySeckillTiming(ctx *gin.Context) {
+	voucherID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid voucher id"))
+		return
+	}
+	timing, err := h.service.SeckillTiming(ctx.Request.Context(), voucherID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(timing))
+}
+
+// SubscribeReminder 订阅秒杀开抢提醒
+func (h *VoucherHandler) SubscribeReminder(ctx *gin.Context) {
+	h.handleReminder(ctx, h.reminder.Subscribe)
+}
+
+// UnsubscribeReminder 取消秒杀开抢提醒
+func (h *VoucherHandler) UnsubscribeReminder(ctx *gin.Context) {
+	h.handleReminder(ctx, h.reminder.Unsubscribe)
+}
+
+// QueryReminder 查询当前用户是否已订阅秒杀开抢提醒
+func (h *VoucherHandler) QueryReminder(ctx *gin.Context) {
+	voucherID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid voucher id"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	subscribed, err := h.reminder.Subscribed(ctx.Request.Context(), user.ID, voucherID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(gin.H{"subscribed": subscribed}))
+}
+
+func (h *VoucherHandler) handleReminder(ctx *gin.Context, action func(context.Context, int64, int64) error) {
+	voucherID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid voucher id"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	if err := action(ctx.Request.Context(), user.ID, voucherID); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
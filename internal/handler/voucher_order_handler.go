@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"hmdp-backend/internal/dto/result"
 	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/model"
 	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
 	"net/http"
 	"strconv"
 
@@ -12,10 +16,14 @@ import (
 
 type VoucherOrderHandler struct {
 	voucherOrderSvc *service.VoucherOrderService
+	voucherSvc      *service.VoucherService
+	paymentSvc      *service.PaymentService
+	transferSvc     *service.OrderTransferService
+	redeemSvc       *service.RedemptionService
 }
 
-func NewVoucherOrderHandler(svc *service.VoucherOrderService) *VoucherOrderHandler {
-	return &VoucherOrderHandler{voucherOrderSvc: svc}
+func NewVoucherOrderHandler(svc *service.VoucherOrderService, voucherSvc *service.VoucherService, paymentSvc *service.PaymentService, transferSvc *service.OrderTransferService, redeemSvc *service.RedemptionService) *VoucherOrderHandler {
+	return &VoucherOrderHandler{voucherOrderSvc: svc, voucherSvc: voucherSvc, paymentSvc: paymentSvc, transferSvc: transferSvc, redeemSvc: redeemSvc}
 }
 
 // SeckillVoucher 处理秒杀优惠券
@@ -34,12 +42,310 @@ func (h *VoucherOrderHandler) SeckillVoucher(ctx *gin.Context) {
 		return
 	}
 
+	// 按进程内缓存的时间窗口提前拦截未开始的抢购，不访问 Redis
+	if err := h.voucherSvc.CheckSeckillWindow(ctx.Request.Context(), voucherID); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+
 	// 调用业务层执行秒杀下单：校验时间/库存、扣减库存、生成订单
 	orderID, svcErr := h.voucherOrderSvc.Seckill(ctx.Request.Context(), voucherID, user.ID)
+	if errors.Is(svcErr, service.ErrOrderQueueBusy) {
+		ctx.JSON(http.StatusServiceUnavailable, result.Fail(svcErr.Error()))
+		return
+	}
 	if svcErr != nil {
-		ctx.JSON(http.StatusBadRequest, result.Fail(svcErr.Error()))
+		writeVoucherRuleError(ctx, svcErr, http.StatusBadRequest)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, result.OkWithData(orderID))
 }
+
+// PayOrder 支付订单，可使用积分抵扣部分金额
+func (h *VoucherOrderHandler) PayOrder(ctx *gin.Context) {
+	orderID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid order id"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var req service.PayRequest
+	// 请求体可为空，表示不使用积分
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+			return
+		}
+	}
+	res, err := h.paymentSvc.Pay(ctx.Request.Context(), user.ID, orderID, req)
+	if err != nil {
+		writeVoucherRuleError(ctx, err, http.StatusBadRequest)
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(res))
+}
+
+// RefundOrder 申请退款，退还支付时使用的积分并释放秒杀库存，请求体可选 {reason}
+func (h *VoucherOrderHandler) RefundOrder(ctx *gin.Context) {
+	orderID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid order id"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	// 请求体可为空，表示不填写退款原因
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+			return
+		}
+	}
+	if err := h.paymentSvc.Refund(ctx.Request.Context(), user.ID, orderID, req.Reason); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// QueryMyOrders 分页查询当前用户的订单，可按 status 过滤
+func (h *VoucherOrderHandler) QueryMyOrders(ctx *gin.Context) {
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	status := 0
+	if v := ctx.Query("status"); v != "" {
+		var err error
+		if status, err = strconv.Atoi(v); err != nil || status < 0 {
+			ctx.JSON(http.StatusBadRequest, result.Fail("invalid status"))
+			return
+		}
+	}
+	page := utils.ParsePage(ctx.Query("current"), 1)
+	orders, total, err := h.voucherOrderSvc.ListByUser(ctx.Request.Context(), user.ID, status, page, utils.MAX_PAGE_SIZE)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithPage(orders, total))
+}
+
+// QueryOrder 查询订单详情，仅订单所属用户可见
+func (h *VoucherOrderHandler) QueryOrder(ctx *gin.Context) {
+	orderID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid order id"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	order, err := h.voucherOrderSvc.GetByUser(ctx.Request.Context(), user.ID, orderID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(order))
+}
+
+// QueryRedeemQRCode 返回订单核销码的二维码图片（PNG），可通过 size 指定边长像素
+func (h *VoucherOrderHandler) QueryRedeemQRCode(ctx *gin.Context) {
+	orderID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid order id"))
+		return
+	}
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", "0"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid size"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	png, err := h.redeemSvc.RedeemQRCode(ctx.Request.Context(), user.ID, orderID, size)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	// 二维码包含核销凭证，禁止中间缓存
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "image/png", png)
+}
+
+// GiftOrder 将未使用的订单转赠给其他用户（按手机号或用户ID），等待对方接收
+func (h *VoucherOrderHandler) GiftOrder(ctx *gin.Context) {
+	orderID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid order id"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var target service.GiftTarget
+	if err := ctx.ShouldBindJSON(&target); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	transfer, err := h.transferSvc.Gift(ctx.Request.Context(), user.ID, orderID, target)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(transfer.ID))
+}
+
+// GiftOrderByCode 为未使用的订单生成一次性领取码，转出方分享领取码后由好友凭码领取
+func (h *VoucherOrderHandler) GiftOrderByCode(ctx *gin.Context) {
+	orderID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid order id"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	transfer, err := h.transferSvc.GiftByCode(ctx.Request.Context(), user.ID, orderID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(gin.H{
+		"transferId": transfer.ID,
+		"claimCode":  transfer.ClaimCode,
+		"expireTime": transfer.ExpireTime,
+	}))
+}
+
+// ClaimGift 凭领取码领取转赠，请求体为 {code}
+func (h *VoucherOrderHandler) ClaimGift(ctx *gin.Context) {
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	transfer, err := h.transferSvc.Claim(ctx.Request.Context(), user.ID, req.Code)
+	if err != nil {
+		var limitErr *service.ClaimLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(http.StatusTooManyRequests, result.FailWithData(limitErr.Error(), gin.H{"retryAfter": limitErr.RetryAfter}))
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(transfer.OrderID))
+}
+
+// QueryPendingGifts 查询待当前用户接收的转赠
+func (h *VoucherOrderHandler) QueryPendingGifts(ctx *gin.Context) {
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	transfers, err := h.transferSvc.ListIncoming(ctx.Request.Context(), user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(transfers))
+}
+
+// AcceptGift 接收转赠
+func (h *VoucherOrderHandler) AcceptGift(ctx *gin.Context) {
+	h.handleGift(ctx, h.transferSvc.Accept)
+}
+
+// RejectGift 拒绝转赠
+func (h *VoucherOrderHandler) RejectGift(ctx *gin.Context) {
+	h.handleGift(ctx, h.transferSvc.Reject)
+}
+
+// WithdrawGift 撤回转赠
+func (h *VoucherOrderHandler) WithdrawGift(ctx *gin.Context) {
+	h.handleGift(ctx, h.transferSvc.Withdraw)
+}
+
+// handleGift 解析转赠ID与登录用户后执行转赠状态操作
+func (h *VoucherOrderHandler) handleGift(ctx *gin.Context, action func(context.Context, int64, int64) error) {
+	transferID, err := strconv.ParseInt(ctx.Param("transferId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid transfer id"))
+		return
+	}
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	if err := action(ctx.Request.Context(), user.ID, transferID); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail(err.Error()))
+		return
+	}
+	ctx.JSON(http.StatusOK, result.Ok())
+}
+
+// RedeemOrder 商家到店核销：校验核销码并将订单置为已核销；商家只能核销自己名下门店，管理员不受限制
+func (h *VoucherOrderHandler) RedeemOrder(ctx *gin.Context) {
+	user, ok := middleware.GetLoginUser(ctx)
+	if !ok || user == nil {
+		ctx.JSON(http.StatusUnauthorized, result.Fail("未登录"))
+		return
+	}
+	var req service.RedeemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, result.Fail("invalid payload"))
+		return
+	}
+	var merchantID int64
+	if user.Role == model.RoleMerchant {
+		merchantID = user.ID
+	}
+	order, err := h.redeemSvc.Redeem(ctx.Request.Context(), merchantID, req)
+	if err != nil {
+		writeVoucherRuleError(ctx, err, http.StatusBadRequest)
+		return
+	}
+	ctx.JSON(http.StatusOK, result.OkWithData(order))
+}
+
+// writeVoucherRuleError 输出业务失败响应；规则校验失败时返回 400 并附带原因码便于前端区分提示，
+// 其余错误使用 fallback 状态码
+func writeVoucherRuleError(ctx *gin.Context, err error, fallback int) {
+	var ruleErr *service.VoucherRuleError
+	if errors.As(err, &ruleErr) {
+		ctx.JSON(http.StatusBadRequest, result.FailWithData(ruleErr.Message, gin.H{"reason": ruleErr.Reason}))
+		return
+	}
+	ctx.JSON(fallback, result.Fail(err.Error()))
+}
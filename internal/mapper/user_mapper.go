@@ -21,5 +21,6 @@ func ToUserDTO(u *model.User) *dto.UserDTO {
 		ID:       u.ID,
 		Icon:     u.Icon,
 		NickName: u.NickName,
+		Role:     u.Role,
 	}
 }
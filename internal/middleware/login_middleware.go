@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,10 +15,15 @@ import (
 	"hmdp-backend/internal/utils"
 )
 
-const loginUserContextKey = "loginUser"
+const (
+	loginUserContextKey  = "loginUser"
+	loginTokenContextKey = "loginToken"
+	// SessionTTLHeader 响应头：当前登录状态剩余有效期（秒），客户端据此判断何时需要重新登录
+	SessionTTLHeader = "X-Session-TTL"
+)
 
-// LoginMiddleware 校验登录
-func LoginMiddleware(rdb *redis.Client) gin.HandlerFunc {
+// LoginMiddleware 校验登录，同时支持 Redis 会话 token 与 JWT（配置 jwtSecret 时启用）
+func LoginMiddleware(rdb *redis.Client, jwtSecret string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// 需要登录
 		needAuth := !isAnonymousPath(ctx.Request.URL.Path)
@@ -31,6 +37,31 @@ func LoginMiddleware(rdb *redis.Client) gin.HandlerFunc {
 			ctx.Next()
 			return
 		}
+		// JWT 无状态校验，不依赖 Redis 会话
+		if jwtSecret != "" && utils.LooksLikeJWT(token) {
+			claims, err := utils.ParseJWT(jwtSecret, token)
+			if err == nil && isRevoked(ctx, rdb, claims) {
+				err = errTokenRevoked
+			}
+			if err != nil {
+				if needAuth {
+					ctx.AbortWithStatusJSON(http.StatusUnauthorized, result.Fail("登录状态已失效"))
+				} else {
+					ctx.Next()
+				}
+				return
+			}
+			if isBanned(ctx, rdb, claims.UserID) {
+				ctx.AbortWithStatusJSON(http.StatusForbidden, result.Fail("账号已被封禁"))
+				return
+			}
+			ctx.Set(loginUserContextKey, &dto.UserDTO{ID: claims.UserID, NickName: claims.NickName, Icon: claims.Icon, Role: claims.Role})
+			ctx.Set(loginTokenContextKey, token)
+			// JWT 无法续期，返回距离过期的剩余时间
+			ctx.Header(SessionTTLHeader, strconv.FormatInt(int64(time.Until(claims.ExpiresAt.Time).Seconds()), 10))
+			ctx.Next()
+			return
+		}
 		key := utils.LOGIN_USER_KEY + token
 		// 从redis中获取用户信息
 		data, err := rdb.HGetAll(ctx.Request.Context(), key).Result()
@@ -51,18 +82,51 @@ func LoginMiddleware(rdb *redis.Client) gin.HandlerFunc {
 			return
 		}
 		id, _ := strconv.ParseInt(data["id"], 10, 64)
+		if isBanned(ctx, rdb, id) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, result.Fail("账号已被封禁"))
+			return
+		}
 		user := &dto.UserDTO{
 			ID:       id,
 			NickName: data["nickName"],
 			Icon:     data["icon"],
+			Role:     data["role"],
 		}
 		ctx.Set(loginUserContextKey, user)
-		// 刷新token有效期
-		rdb.Expire(ctx, key, time.Duration(utils.LOGIN_USER_TTL)*time.Second)
+		ctx.Set(loginTokenContextKey, token)
+		// 滑动续期：每次请求刷新token有效期，用户的 token 索引随之续期
+		ttl := time.Duration(utils.LOGIN_USER_TTL) * time.Second
+		if _, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Expire(ctx, key, ttl)
+			pipe.Expire(ctx, utils.LOGIN_TOKENS_KEY+data["id"], ttl)
+			return nil
+		}); err == nil {
+			ctx.Header(SessionTTLHeader, strconv.Itoa(utils.LOGIN_USER_TTL))
+		}
 		ctx.Next()
 	}
 }
 
+var errTokenRevoked = errors.New("token revoked")
+
+// isRevoked 检查 JWT 是否签发于强制下线之前（毫秒精度），Redis 异常时放行
+func isRevoked(ctx *gin.Context, rdb *redis.Client, claims *utils.JWTClaims) bool {
+	if rdb == nil || claims.IssuedAt == nil {
+		return false
+	}
+	revokedAt, err := rdb.Get(ctx.Request.Context(), utils.LOGIN_REVOKE_KEY+strconv.FormatInt(claims.UserID, 10)).Int64()
+	return err == nil && claims.IssuedAtMilli() < revokedAt
+}
+
+// isBanned 检查用户是否在封禁名单中，Redis 异常时放行
+func isBanned(ctx *gin.Context, rdb *redis.Client, userID int64) bool {
+	if rdb == nil {
+		return false
+	}
+	banned, err := rdb.SIsMember(ctx.Request.Context(), utils.USER_BANNED_KEY, userID).Result()
+	return err == nil && banned
+}
+
 // GetLoginUser 从 Gin Context 中读取登录用户信息
 func GetLoginUser(ctx *gin.Context) (*dto.UserDTO, bool) {
 	v, exists := ctx.Get(loginUserContextKey)
@@ -73,6 +137,16 @@ func GetLoginUser(ctx *gin.Context) (*dto.UserDTO, bool) {
 	return user, ok
 }
 
+// GetLoginToken 从 Gin Context 中读取当前请求使用的登录 token
+func GetLoginToken(ctx *gin.Context) (string, bool) {
+	v, exists := ctx.Get(loginTokenContextKey)
+	if !exists {
+		return "", false
+	}
+	token, ok := v.(string)
+	return token, ok
+}
+
 // isAnonymousPath 这些路径放行 不需要登录即可访问
 func isAnonymousPath(path string) bool {
 	switch path {
@@ -80,13 +154,14 @@ func isAnonymousPath(path string) bool {
 		return true
 	default:
 	}
-	for _, prefix := range []string{"/shop", "/voucher", "/shop-type", "/upload"} {
+	for _, prefix := range []string{"/shop", "/voucher", "/shop-type", "/upload", "/user/login/oauth", "/blog/tag", "/blog/share"} {
 		if path == prefix || strings.HasPrefix(path, prefix+"/") {
 			return true
 		}
 	}
 	switch path {
-	case "/blog/hot", "/user/code", "/user/login":
+	case "/blog/hot", "/blog/nearby", "/blog/search", "/blog/topics/trending", "/campaign/active", "/search/suggest", "/user/captcha", "/user/code", "/user/login",
+		"/user/login/password", "/user/login/2fa", "/user/register":
 		return true
 	default:
 		return false
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/utils"
+)
+
+// SeckillRateLimit 秒杀接口限流：按用户与按券分别做滑动窗口计数，超限返回 429；
+// 需挂载在 LoginMiddleware 之后，限流器自身出错时放行，避免 Redis 抖动导致秒杀不可用。
+// 每个请求通过 limits 读取当前阈值，配置热更新后立即生效
+func SeckillRateLimit(rdb *redis.Client, limits func() config.RateLimitConfig) gin.HandlerFunc {
+	limiter := utils.NewSlidingWindowLimiter(rdb)
+	return func(ctx *gin.Context) {
+		cfg := limits()
+		window := cfg.SeckillWindow
+		if window <= 0 {
+			window = time.Second
+		}
+		var keys []string
+		var thresholds []int
+		if user, ok := GetLoginUser(ctx); ok && user != nil && cfg.SeckillPerUser > 0 {
+			keys = append(keys, utils.SECKILL_LIMIT_USER+strconv.FormatInt(user.ID, 10))
+			thresholds = append(thresholds, cfg.SeckillPerUser)
+		}
+		if cfg.SeckillPerVoucher > 0 {
+			keys = append(keys, utils.SECKILL_LIMIT_VOUCH+ctx.Param("id"))
+			thresholds = append(thresholds, cfg.SeckillPerVoucher)
+		}
+		for i, key := range keys {
+			allowed, err := limiter.Allow(ctx.Request.Context(), key, thresholds[i], window)
+			if err != nil {
+				break
+			}
+			if !allowed {
+				ctx.AbortWithStatusJSON(http.StatusTooManyRequests, result.Fail("请求过于频繁，请稍后再试"))
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/dto/result"
+	"hmdp-backend/internal/model"
+)
+
+// RequireRoles 仅允许指定角色的登录用户访问，需挂载在 LoginMiddleware 之后
+func RequireRoles(roles ...string) gin.HandlerFunc {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, r := range roles {
+		allowed[r] = struct{}{}
+	}
+	return func(ctx *gin.Context) {
+		user, ok := GetLoginUser(ctx)
+		if !ok || user == nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, result.Fail("未登录"))
+			return
+		}
+		if _, ok := allowed[user.Role]; !ok {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, result.Fail("无权限"))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// AdminMiddleware 管理端接口：仅管理员与商家可访问
+func AdminMiddleware() gin.HandlerFunc {
+	return RequireRoles(model.RoleAdmin, model.RoleMerchant)
+}
@@ -12,11 +12,31 @@ type Blog struct {
 	Content    string    `gorm:"column:content" json:"content"`
 	Liked      int       `gorm:"column:liked" json:"liked"`
 	Comments   int       `gorm:"column:comments" json:"comments"`
+	Views      int64     `gorm:"column:views" json:"views"`
+	Status     int       `gorm:"column:status;default:1" json:"status"`
+	X          float64   `gorm:"column:x" json:"x"` // 经度，未指定时取关联店铺坐标
+	Y          float64   `gorm:"column:y" json:"y"` // 纬度
 	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
 	UpdateTime time.Time `gorm:"column:update_time;autoUpdateTime" json:"updateTime"`
 	Icon       string    `gorm:"-" json:"icon,omitempty"`
 	Name       string    `gorm:"-" json:"name,omitempty"`
 	IsLike     *bool     `gorm:"-" json:"isLike,omitempty"`
+	IsFavorite *bool     `gorm:"-" json:"isFavorite,omitempty"`
+	Pinned     bool      `gorm:"-" json:"pinned,omitempty"`
+	Distance   *float64  `gorm:"-" json:"distance,omitempty"`
+	Tags       []string  `gorm:"-" json:"tags,omitempty"`
+	Shares     int64     `gorm:"-" json:"shares"`
+	Highlight  Highlight `gorm:"-" json:"highlight,omitempty"`
 }
 
+// 笔记状态
+const (
+	BlogStatusPublished = 1
+	BlogStatusDraft     = 2
+	BlogStatusHidden    = 3 // 被管理员屏蔽
+)
+
 func (Blog) TableName() string { return "tb_blog" }
+
+// Highlight 全文搜索命中片段，key 为字段名
+type Highlight map[string][]string
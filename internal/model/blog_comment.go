@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// BlogComment mirrors tb_blog_comment.
+type BlogComment struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	BlogID     int64     `gorm:"column:blog_id" json:"blogId"`
+	UserID     int64     `gorm:"column:user_id" json:"userId"`
+	ParentID   int64     `gorm:"column:parent_id" json:"parentId"` // 一级评论为 0，回复时为所属一级评论ID
+	AnswerID   int64     `gorm:"column:answer_id" json:"answerId"` // 被回复的用户ID
+	Content    string    `gorm:"column:content" json:"content"`
+	Status     int       `gorm:"column:status;default:1" json:"-"`
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+	Icon       string    `gorm:"-" json:"icon,omitempty"`
+	Name       string    `gorm:"-" json:"name,omitempty"`
+}
+
+// 评论状态
+const (
+	CommentStatusNormal = 1
+	CommentStatusHidden = 2
+)
+
+func (BlogComment) TableName() string { return "tb_blog_comment" }
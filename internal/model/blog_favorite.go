@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// BlogCollection mirrors tb_blog_collection.
+type BlogCollection struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID     int64     `gorm:"column:user_id;index" json:"userId"`
+	Name       string    `gorm:"column:name" json:"name"`
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+}
+
+func (BlogCollection) TableName() string { return "tb_blog_collection" }
+
+// BlogFavorite mirrors tb_blog_favorite.
+type BlogFavorite struct {
+	ID           int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID       int64     `gorm:"column:user_id;uniqueIndex:uk_user_blog" json:"userId"`
+	BlogID       int64     `gorm:"column:blog_id;uniqueIndex:uk_user_blog" json:"blogId"`
+	CollectionID int64     `gorm:"column:collection_id" json:"collectionId"` // 0 表示默认收藏夹
+	CreateTime   time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+}
+
+func (BlogFavorite) TableName() string { return "tb_blog_favorite" }
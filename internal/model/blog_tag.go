@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// BlogTag mirrors tb_blog_tag.
+type BlogTag struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name       string    `gorm:"column:name" json:"name"` // 唯一索引
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+}
+
+func (BlogTag) TableName() string { return "tb_blog_tag" }
+
+// BlogTagRel mirrors tb_blog_tag_rel.
+type BlogTagRel struct {
+	BlogID int64 `gorm:"column:blog_id;primaryKey" json:"blogId"`
+	TagID  int64 `gorm:"column:tag_id;primaryKey" json:"tagId"`
+}
+
+func (BlogTagRel) TableName() string { return "tb_blog_tag_rel" }
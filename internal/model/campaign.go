@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Campaign mirrors tb_campaign.
+type Campaign struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Name       string    `gorm:"column:name" json:"name"`
+	Banner     string    `gorm:"column:banner" json:"banner"`
+	BeginTime  time.Time `gorm:"column:begin_time" json:"beginTime"`
+	EndTime    time.Time `gorm:"column:end_time" json:"endTime"`
+	Status     int       `gorm:"column:status" json:"status"` // 1上架 2下架
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+	UpdateTime time.Time `gorm:"column:update_time;autoUpdateTime" json:"updateTime"`
+}
+
+func (Campaign) TableName() string { return "tb_campaign" }
+
+// CampaignVoucher mirrors tb_campaign_voucher.
+type CampaignVoucher struct {
+	ID         int64 `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	CampaignID int64 `gorm:"column:campaign_id" json:"campaignId"`
+	VoucherID  int64 `gorm:"column:voucher_id" json:"voucherId"`
+	Sort       int   `gorm:"column:sort" json:"sort"`
+}
+
+func (CampaignVoucher) TableName() string { return "tb_campaign_voucher" }
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// 拼团状态：1拼团中 2已成团 3已失败（超时未成团，已支付的订单自动退款）
+const (
+	GroupBuyStatusForming = 1
+	GroupBuyStatusSuccess = 2
+	GroupBuyStatusFailed  = 3
+)
+
+// GroupBuy mirrors tb_group_buy：参团订单通过 tb_voucher_order.group_id 关联.
+type GroupBuy struct {
+	ID         int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	VoucherID  int64      `gorm:"column:voucher_id;index" json:"voucherId"`
+	LeaderID   int64      `gorm:"column:leader_id" json:"leaderId"`
+	Size       int        `gorm:"column:size" json:"size"`     // 成团人数
+	Joined     int        `gorm:"column:joined" json:"joined"` // 已支付的参团人数
+	Status     int        `gorm:"column:status" json:"status"`
+	ExpireTime time.Time  `gorm:"column:expire_time" json:"expireTime"` // 截止时间，届时未成团则拼团失败
+	FinishTime *time.Time `gorm:"column:finish_time" json:"finishTime"` // 成团或失败的时间
+	CreateTime time.Time  `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+	UpdateTime time.Time  `gorm:"column:update_time;autoUpdateTime" json:"updateTime"`
+}
+
+func (GroupBuy) TableName() string { return "tb_group_buy" }
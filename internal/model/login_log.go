@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// LoginLog mirrors tb_login_log.
+type LoginLog struct {
+	ID        int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID    int64     `gorm:"column:user_id" json:"userId"`
+	IP        string    `gorm:"column:ip" json:"ip"`
+	Device    string    `gorm:"column:device" json:"device"`
+	LoginTime time.Time `gorm:"column:login_time" json:"loginTime"`
+}
+
+func (LoginLog) TableName() string { return "tb_login_log" }
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// NotificationSetting mirrors tb_notification_setting.
+type NotificationSetting struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	UserID     int64     `gorm:"column:user_id" json:"-"` // 与 notify_type 组成唯一索引
+	Type       string    `gorm:"column:notify_type" json:"type"`
+	InApp      bool      `gorm:"column:in_app" json:"inApp"`
+	Push       bool      `gorm:"column:push" json:"push"`
+	Email      bool      `gorm:"column:email" json:"email"`
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"-"`
+	UpdateTime time.Time `gorm:"column:update_time;autoUpdateTime" json:"-"`
+}
+
+func (NotificationSetting) TableName() string { return "tb_notification_setting" }
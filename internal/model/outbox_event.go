@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+const (
+	OutboxStatusPending = 0 // 待投递
+	OutboxStatusSent    = 1 // 已投递
+	OutboxStatusFailed  = 2 // 超过最大投递次数，需人工处理
+)
+
+// OutboxEvent mirrors tb_outbox：与业务数据同事务写入的待投递 Kafka 消息.
+type OutboxEvent struct {
+	ID            int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Topic         string     `gorm:"column:topic" json:"topic"`
+	MsgKey        string     `gorm:"column:msg_key" json:"msgKey"`
+	Payload       string     `gorm:"column:payload;type:text" json:"payload"`
+	Status        int        `gorm:"column:status;index:idx_outbox_status" json:"status"`
+	Attempts      int        `gorm:"column:attempts" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index:idx_outbox_status" json:"nextAttemptAt"` // 投递失败后按退避时间推迟下次投递
+	LastError     string     `gorm:"column:last_error" json:"lastError"`
+	CreateTime    time.Time  `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+	SentTime      *time.Time `gorm:"column:sent_time" json:"sentTime"`
+}
+
+func (OutboxEvent) TableName() string { return "tb_outbox" }
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Report mirrors tb_report.
+type Report struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	TargetType int       `gorm:"column:target_type;index:idx_target" json:"targetType"`
+	TargetID   int64     `gorm:"column:target_id;index:idx_target" json:"targetId"`
+	UserID     int64     `gorm:"column:user_id" json:"userId"` // 举报人
+	Reason     string    `gorm:"column:reason" json:"reason"`
+	Status     int       `gorm:"column:status;default:1" json:"status"`
+	HandlerID  int64     `gorm:"column:handler_id" json:"handlerId"` // 处理的管理员
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+	UpdateTime time.Time `gorm:"column:update_time;autoUpdateTime" json:"updateTime"`
+}
+
+// 举报对象类型
+const (
+	ReportTargetBlog    = 1
+	ReportTargetComment = 2
+)
+
+// 举报处理状态
+const (
+	ReportStatusPending   = 1
+	ReportStatusHidden    = 2 // 已屏蔽违规内容
+	ReportStatusDismissed = 3 // 举报不成立
+)
+
+func (Report) TableName() string { return "tb_report" }
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// SensitiveWord mirrors tb_sensitive_word.
+type SensitiveWord struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Word       string    `gorm:"column:word" json:"word"`
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+}
+
+func (SensitiveWord) TableName() string { return "tb_sensitive_word" }
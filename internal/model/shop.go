@@ -6,20 +6,42 @@ import "time"
 type Shop struct {
 	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
 	Name       string    `gorm:"column:name" json:"name"`
-	TypeID     int64     `gorm:"column:type_id" json:"typeId"`
+	TypeID     int64     `gorm:"column:type_id;index:idx_type_score;index:idx_type_comments;index:idx_type_price" json:"typeId"`
 	Images     string    `gorm:"column:images" json:"images"`
+	Gallery    []string  `gorm:"-" json:"gallery,omitempty"` // 由 Images 拆分出的有序图片列表，仅详情返回
 	Area       string    `gorm:"column:area" json:"area"`
 	Address    string    `gorm:"column:address" json:"address"`
 	X          float64   `gorm:"column:x" json:"x"`
 	Y          float64   `gorm:"column:y" json:"y"`
-	AvgPrice   int64     `gorm:"column:avg_price" json:"avgPrice"`
+	AvgPrice   int64     `gorm:"column:avg_price;index:idx_type_price" json:"avgPrice"`
 	Sold       int       `gorm:"column:sold" json:"sold"`
-	Comments   int       `gorm:"column:comments" json:"comments"`
-	Score      int       `gorm:"column:score" json:"score"`
+	Comments   int       `gorm:"column:comments;index:idx_type_comments" json:"comments"`
+	Score      int       `gorm:"column:score;index:idx_type_score" json:"score"`
 	OpenHours  string    `gorm:"column:open_hours" json:"openHours"`
+	Status     int       `gorm:"column:status;default:1;index" json:"status"`
+	OwnerID    int64     `gorm:"column:owner_id;index" json:"ownerId,omitempty"`            // 提交入驻申请的商家用户
+	ReviewNote string    `gorm:"column:review_note" json:"reviewNote,omitempty"`            // 审核驳回原因
+	Schedule   Schedule  `gorm:"column:schedule;serializer:json" json:"schedule,omitempty"` // 结构化营业时间，为空时按 OpenHours 解析
 	CreateTime time.Time `gorm:"column:create_time" json:"createTime"`
 	UpdateTime time.Time `gorm:"column:update_time" json:"updateTime"`
 	Distance   *float64  `gorm:"-" json:"distance,omitempty"`
 }
 
+// 商铺审核状态，只有已通过的商铺对顾客可见
+const (
+	ShopStatusApproved = 1
+	ShopStatusPending  = 2
+	ShopStatusRejected = 3
+)
+
 func (Shop) TableName() string { return "tb_shop" }
+
+// OpenPeriod 一段营业时间，Close 不晚于 Open 表示跨零点营业（如 18:00-02:00）
+type OpenPeriod struct {
+	Days  []int  `json:"days,omitempty"` // 适用星期（1=周一，7=周日），空表示每天
+	Open  string `json:"open"`           // HH:MM
+	Close string `json:"close"`          // HH:MM
+}
+
+// Schedule 商铺的营业时间表，任一时段命中即视为营业中
+type Schedule []OpenPeriod
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// ShopDailyView mirrors tb_shop_daily_view：商铺每日浏览量（PV）与独立访客数（UV）.
+type ShopDailyView struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"-"`
+	ShopID     int64     `gorm:"column:shop_id;uniqueIndex:uk_shop_day" json:"shopId"`
+	Day        string    `gorm:"column:day;type:date;uniqueIndex:uk_shop_day" json:"day"` // 2006-01-02
+	PV         int64     `gorm:"column:pv" json:"pv"`
+	UV         int64     `gorm:"column:uv" json:"uv"`
+	UpdateTime time.Time `gorm:"column:update_time;autoUpdateTime" json:"-"`
+}
+
+func (ShopDailyView) TableName() string { return "tb_shop_daily_view" }
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// ShopFavorite mirrors tb_shop_favorite.
+type ShopFavorite struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID     int64     `gorm:"column:user_id;uniqueIndex:uk_user_shop" json:"userId"`
+	ShopID     int64     `gorm:"column:shop_id;uniqueIndex:uk_user_shop" json:"shopId"`
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+}
+
+func (ShopFavorite) TableName() string { return "tb_shop_favorite" }
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// ShopReview mirrors tb_shop_review.
+type ShopReview struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	ShopID     int64     `gorm:"column:shop_id" json:"shopId"`
+	UserID     int64     `gorm:"column:user_id" json:"userId"`
+	Score      int       `gorm:"column:score" json:"score"` // 1~5 分
+	Content    string    `gorm:"column:content" json:"content"`
+	Images     string    `gorm:"column:images" json:"images"` // 多张图片以逗号分隔
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+	UpdateTime time.Time `gorm:"column:update_time;autoUpdateTime" json:"updateTime"`
+	Icon       string    `gorm:"-" json:"icon,omitempty"`
+	Name       string    `gorm:"-" json:"name,omitempty"`
+}
+
+func (ShopReview) TableName() string { return "tb_shop_review" }
@@ -1,16 +1,37 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // User mirrors tb_user.
 type User struct {
-	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
-	Phone      string    `gorm:"column:phone" json:"phone"`
-	Password   string    `gorm:"column:password" json:"password"`
-	NickName   string    `gorm:"column:nick_name" json:"nickName"`
-	Icon       string    `gorm:"column:icon" json:"icon"`
-	CreateTime time.Time `gorm:"column:create_time" json:"createTime"`
-	UpdateTime time.Time `gorm:"column:update_time" json:"updateTime"`
+	ID         int64          `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	Phone      string         `gorm:"column:phone" json:"phone"`
+	Email      string         `gorm:"column:email" json:"email"`
+	Password   string         `gorm:"column:password" json:"-"` // bcrypt 哈希，不对外输出
+	NickName   string         `gorm:"column:nick_name;index:idx_nick_name" json:"nickName"`
+	Icon       string         `gorm:"column:icon" json:"icon"`
+	Role       string         `gorm:"column:role;default:user" json:"role"`
+	Status     int            `gorm:"column:status;default:1" json:"status"` // 1正常 2封禁
+	CreateTime time.Time      `gorm:"column:create_time" json:"createTime"`
+	UpdateTime time.Time      `gorm:"column:update_time" json:"updateTime"`
+	DeleteTime gorm.DeletedAt `gorm:"column:delete_time;index" json:"-"` // 注销时软删除
 }
 
+// 用户角色
+const (
+	RoleUser     = "user"
+	RoleMerchant = "merchant"
+	RoleAdmin    = "admin"
+)
+
+// 用户状态
+const (
+	UserStatusNormal = 1
+	UserStatusBanned = 2
+)
+
 func (User) TableName() string { return "tb_user" }
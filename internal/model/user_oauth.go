@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// UserOAuth mirrors tb_user_oauth.
+type UserOAuth struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID     int64     `gorm:"column:user_id" json:"userId"`
+	Provider   string    `gorm:"column:provider" json:"provider"` // 与 open_id 组成唯一索引
+	OpenID     string    `gorm:"column:open_id" json:"openId"`
+	UnionID    string    `gorm:"column:union_id" json:"unionId"`
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+}
+
+func (UserOAuth) TableName() string { return "tb_user_oauth" }
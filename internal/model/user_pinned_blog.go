@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// UserPinnedBlog mirrors tb_user_pinned_blog.
+type UserPinnedBlog struct {
+	UserID     int64     `gorm:"column:user_id;primaryKey" json:"userId"`
+	BlogID     int64     `gorm:"column:blog_id;index" json:"blogId"`
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+}
+
+func (UserPinnedBlog) TableName() string { return "tb_user_pinned_blog" }
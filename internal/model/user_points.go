@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// UserPoints mirrors tb_user_points.
+type UserPoints struct {
+	UserID     int64     `gorm:"column:user_id;primaryKey" json:"userId"`
+	Balance    int64     `gorm:"column:balance" json:"balance"`
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+	UpdateTime time.Time `gorm:"column:update_time;autoUpdateTime" json:"updateTime"`
+}
+
+func (UserPoints) TableName() string { return "tb_user_points" }
+
+// PointsLog mirrors tb_points_log.
+type PointsLog struct {
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	UserID     int64     `gorm:"column:user_id" json:"userId"`
+	OrderID    int64     `gorm:"column:order_id" json:"orderId"`
+	Change     int64     `gorm:"column:change_amount" json:"change"`
+	Reason     string    `gorm:"column:reason" json:"reason"`
+	CreateTime time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+}
+
+func (PointsLog) TableName() string { return "tb_points_log" }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// UserPrivacy mirrors tb_user_privacy.
+type UserPrivacy struct {
+	UserID            int64     `gorm:"column:user_id;primaryKey" json:"-"`
+	HideBlogsFromFeed bool      `gorm:"column:hide_blogs_from_feed" json:"hideBlogsFromFeed"` // 发布的笔记不推送到粉丝收件箱
+	HideFollows       bool      `gorm:"column:hide_follows" json:"hideFollows"`               // 不对他人展示关注列表
+	CreateTime        time.Time `gorm:"column:create_time;autoCreateTime" json:"-"`
+	UpdateTime        time.Time `gorm:"column:update_time;autoUpdateTime" json:"-"`
+}
+
+func (UserPrivacy) TableName() string { return "tb_user_privacy" }
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// UserTwoFactor mirrors tb_user_two_factor.
+type UserTwoFactor struct {
+	UserID        int64     `gorm:"column:user_id;primaryKey" json:"userId"`
+	Secret        string    `gorm:"column:secret" json:"-"`         // AES-GCM 加密后的 TOTP 密钥
+	RecoveryCodes string    `gorm:"column:recovery_codes" json:"-"` // 恢复码 SHA-256 摘要，逗号分隔
+	Enabled       bool      `gorm:"column:enabled" json:"enabled"`
+	CreateTime    time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+	UpdateTime    time.Time `gorm:"column:update_time;autoUpdateTime" json:"updateTime"`
+}
+
+func (UserTwoFactor) TableName() string { return "tb_user_two_factor" }
@@ -2,6 +2,20 @@ package model
 
 import "time"
 
+// 券状态：1上架 2下架 3已过期
+const (
+	VoucherStatusOnline  = 1
+	VoucherStatusOffline = 2
+	VoucherStatusExpired = 3
+)
+
+// 券类型：1代金券（抵扣 ActualValue）2折扣券（按 DiscountPercent 打折）3兑换券（兑换 FreeItem），0 按代金券处理
+const (
+	VoucherKindCash     = 1
+	VoucherKindDiscount = 2
+	VoucherKindFreeItem = 3
+)
+
 // Voucher mirrors tb_voucher.
 type Voucher struct {
 	ID          int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
@@ -13,6 +27,14 @@ type Voucher struct {
 	ActualValue int64      `gorm:"column:actual_value" json:"actualValue"`
 	Type        int        `gorm:"column:type" json:"type"`
 	Status      int        `gorm:"column:status" json:"status"`
+	MinSpend    int64      `gorm:"column:min_spend" json:"minSpend"`                    // 最低消费金额（分），0 表示不限制
+	Weekdays    string     `gorm:"column:weekdays" json:"weekdays"`                     // 可用星期，如 "1,2,3,4,5"（1=周一，7=周日），空表示不限制
+	ShopIDs     string     `gorm:"column:applicable_shop_ids" json:"applicableShopIds"` // 适用门店ID列表，逗号分隔，空表示仅限 ShopID
+	Kind        int        `gorm:"column:kind" json:"kind"`                             // 券类型
+	DiscountPct int        `gorm:"column:discount_percent" json:"discountPercent"`      // 折扣券的折扣百分比，85 表示八五折
+	MaxDiscount int64      `gorm:"column:max_discount" json:"maxDiscount"`              // 折扣券单次最高优惠金额（分），0 表示不限制
+	FreeItem    string     `gorm:"column:free_item" json:"freeItem"`                    // 兑换券可兑换的商品
+	GroupSize   int        `gorm:"column:group_size" json:"groupSize"`                  // 拼团成团人数，0 表示不支持拼团
 	CreateTime  time.Time  `gorm:"column:create_time;autoCreateTime" json:"createTime"`
 	UpdateTime  time.Time  `gorm:"column:update_time;autoUpdateTime" json:"updateTime"`
 	Stock       *int       `gorm:"-" json:"stock,omitempty"`
@@ -2,6 +2,23 @@ package model
 
 import "time"
 
+// 订单状态：1未支付 2已支付 3已核销 4已取消 5退款中 6已退款
+const (
+	OrderStatusUnpaid    = 1
+	OrderStatusPaid      = 2
+	OrderStatusUsed      = 3
+	OrderStatusCancelled = 4
+	OrderStatusRefunding = 5
+	OrderStatusRefunded  = 6
+)
+
+// 支付方式：1余额 2支付宝 3微信
+const (
+	PayTypeBalance = 1
+	PayTypeAlipay  = 2
+	PayTypeWechat  = 3
+)
+
 // VoucherOrder mirrors tb_voucher_order.
 type VoucherOrder struct {
 	ID         int64      `gorm:"column:id;primaryKey" json:"id"`
@@ -9,11 +26,18 @@ type VoucherOrder struct {
 	VoucherID  int64      `gorm:"column:voucher_id" json:"voucherId"`
 	PayType    int        `gorm:"column:pay_type" json:"payType"`
 	Status     int        `gorm:"column:status" json:"status"`
+	PayAmount  int64      `gorm:"column:pay_amount" json:"payAmount"`   // 实付金额（分），不含积分抵扣
+	PointsUsed int64      `gorm:"column:points_used" json:"pointsUsed"` // 支付时抵扣的积分
+	TradeNo    string     `gorm:"column:trade_no" json:"tradeNo"`       // 支付渠道交易号，全额积分抵扣时为空
 	CreateTime time.Time  `gorm:"column:create_time" json:"createTime"`
 	PayTime    *time.Time `gorm:"column:pay_time" json:"payTime"`
 	UseTime    *time.Time `gorm:"column:use_time" json:"useTime"`
 	RefundTime *time.Time `gorm:"column:refund_time" json:"refundTime"`
 	UpdateTime time.Time  `gorm:"column:update_time" json:"updateTime"`
+	RedeemCode string     `gorm:"column:redeem_code;uniqueIndex;default:null" json:"redeemCode,omitempty"` // 到店核销码，支付成功后生成，未支付时为 NULL
+	UseShopID  int64      `gorm:"column:use_shop_id" json:"useShopId,omitempty"`                           // 核销门店
+	GroupID    int64      `gorm:"column:group_id;index" json:"groupId,omitempty"`                          // 所属拼团，0 表示非拼团订单
+	PayerID    int64      `gorm:"column:payer_id" json:"payerId,omitempty"`                                // 支付用户，订单转赠后不变，退款积分退还给该用户
 }
 
 func (VoucherOrder) TableName() string { return "tb_voucher_order" }
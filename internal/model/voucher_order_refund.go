@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// VoucherOrderRefund mirrors tb_voucher_order_refund：订单退款审计记录.
+type VoucherOrderRefund struct {
+	ID           int64     `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	OrderID      int64     `gorm:"column:order_id;index" json:"orderId"`
+	UserID       int64     `gorm:"column:user_id" json:"userId"`
+	VoucherID    int64     `gorm:"column:voucher_id" json:"voucherId"`
+	Amount       int64     `gorm:"column:amount" json:"amount"`              // 退回的实付金额（分）
+	Points       int64     `gorm:"column:points" json:"points"`              // 退回的积分
+	StockRestore bool      `gorm:"column:stock_restore" json:"stockRestore"` // 是否回补了秒杀库存
+	Reason       string    `gorm:"column:reason" json:"reason"`
+	CreateTime   time.Time `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+}
+
+func (VoucherOrderRefund) TableName() string { return "tb_voucher_order_refund" }
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// 转赠状态：1待接收 2已接收 3已拒绝 4已撤回
+const (
+	TransferStatusPending   = 1
+	TransferStatusAccepted  = 2
+	TransferStatusRejected  = 3
+	TransferStatusWithdrawn = 4
+)
+
+// VoucherOrderTransfer mirrors tb_voucher_order_transfer.
+type VoucherOrderTransfer struct {
+	ID         int64      `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	OrderID    int64      `gorm:"column:order_id" json:"orderId"`
+	VoucherID  int64      `gorm:"column:voucher_id" json:"voucherId"`
+	FromUserID int64      `gorm:"column:from_user_id" json:"fromUserId"`
+	ToUserID   int64      `gorm:"column:to_user_id" json:"toUserId"` // 领取码转赠在领取前为 0
+	Status     int        `gorm:"column:status" json:"status"`
+	ClaimCode  string     `gorm:"column:claim_code;uniqueIndex;default:null" json:"claimCode,omitempty"` // 一次性领取码，持码的任意用户可领取
+	ExpireTime *time.Time `gorm:"column:expire_time" json:"expireTime,omitempty"`                        // 领取码过期时间，过期后转赠失效
+	CreateTime time.Time  `gorm:"column:create_time;autoCreateTime" json:"createTime"`
+	UpdateTime time.Time  `gorm:"column:update_time;autoUpdateTime" json:"updateTime"`
+}
+
+func (VoucherOrderTransfer) TableName() string { return "tb_voucher_order_transfer" }
@@ -0,0 +1,116 @@
+package observability
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+const dbMetricsStartKey = "metrics:start"
+
+// DBMetrics 以 GORM 插件的方式采集 MySQL 语句的执行次数与耗时
+type DBMetrics struct {
+	queryTotal    *prometheus.CounterVec
+	queryDuration *prometheus.HistogramVec
+}
+
+var _ gorm.Plugin = (*DBMetrics)(nil)
+
+// NewDBMetrics 创建数据库指标收集器，通过 db.Use 挂载
+func NewDBMetrics(registry *prometheus.Registry, serviceName string) *DBMetrics {
+	if registry == nil {
+		registry = NewMetricsRegistry()
+	}
+
+	constLabels := prometheus.Labels{}
+	if serviceName != "" {
+		constLabels["service"] = serviceName
+	}
+	// 语句总数，operation 取 create / query / update / delete / row / raw
+	queryTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   "db",
+		Subsystem:   "client",
+		Name:        "queries_total",
+		Help:        "Total database statements by result.",
+		ConstLabels: constLabels,
+	}, []string{"operation", "table", "result"})
+	// 语句耗时分布
+	queryDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   "db",
+		Subsystem:   "client",
+		Name:        "query_duration_seconds",
+		Help:        "Database statement duration in seconds.",
+		Buckets:     prometheus.DefBuckets,
+		ConstLabels: constLabels,
+	}, []string{"operation", "table"})
+
+	registry.MustRegister(queryTotal, queryDuration)
+
+	return &DBMetrics{
+		queryTotal:    queryTotal,
+		queryDuration: queryDuration,
+	}
+}
+
+// Name 实现 gorm.Plugin
+func (m *DBMetrics) Name() string {
+	return "hmdp:metrics"
+}
+
+// Initialize 在各类语句的执行前后注册回调
+func (m *DBMetrics) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	type register func(name string, fn func(*gorm.DB)) error
+	hooks := []struct {
+		operation string
+		before    register
+		after     register
+	}{
+		{"create", cb.Create().Before("*").Register, cb.Create().After("*").Register},
+		{"query", cb.Query().Before("*").Register, cb.Query().After("*").Register},
+		{"update", cb.Update().Before("*").Register, cb.Update().After("*").Register},
+		{"delete", cb.Delete().Before("*").Register, cb.Delete().After("*").Register},
+		{"row", cb.Row().Before("*").Register, cb.Row().After("*").Register},
+		{"raw", cb.Raw().Before("*").Register, cb.Raw().After("*").Register},
+	}
+	for _, h := range hooks {
+		operation := h.operation
+		if err := h.before("metrics:before_"+operation, m.before); err != nil {
+			return err
+		}
+		if err := h.after("metrics:after_"+operation, func(db *gorm.DB) {
+			m.after(db, operation)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DBMetrics) before(db *gorm.DB) {
+	db.InstanceSet(dbMetricsStartKey, time.Now())
+}
+
+func (m *DBMetrics) after(db *gorm.DB, operation string) {
+	v, ok := db.InstanceGet(dbMetricsStartKey)
+	if !ok {
+		return
+	}
+	start, ok := v.(time.Time)
+	if !ok {
+		return
+	}
+	table := db.Statement.Table
+	if table == "" {
+		table = "unknown"
+	}
+	result := "ok"
+	// 查询无结果属于正常业务分支，不计为失败
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		result = "error"
+	}
+	m.queryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+	m.queryTotal.WithLabelValues(operation, table, result).Inc()
+}
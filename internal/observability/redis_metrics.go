@@ -0,0 +1,98 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisMetrics 以 go-redis Hook 的方式采集 Redis 命令的调用次数与耗时
+type RedisMetrics struct {
+	cmdTotal    *prometheus.CounterVec
+	cmdDuration *prometheus.HistogramVec
+}
+
+var _ redis.Hook = (*RedisMetrics)(nil)
+
+// NewRedisMetrics 创建 Redis 指标收集器，通过 client.AddHook 挂载
+func NewRedisMetrics(registry *prometheus.Registry, serviceName string) *RedisMetrics {
+	if registry == nil {
+		registry = NewMetricsRegistry()
+	}
+
+	constLabels := prometheus.Labels{}
+	if serviceName != "" {
+		constLabels["service"] = serviceName
+	}
+	// 命令总数，result 取 ok / nil / error
+	cmdTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   "redis",
+		Subsystem:   "client",
+		Name:        "commands_total",
+		Help:        "Total Redis commands by result.",
+		ConstLabels: constLabels,
+	}, []string{"command", "result"})
+	// 命令耗时分布，管道按整体记录为 pipeline
+	cmdDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   "redis",
+		Subsystem:   "client",
+		Name:        "command_duration_seconds",
+		Help:        "Redis command duration in seconds.",
+		Buckets:     []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		ConstLabels: constLabels,
+	}, []string{"command"})
+
+	registry.MustRegister(cmdTotal, cmdDuration)
+
+	return &RedisMetrics{
+		cmdTotal:    cmdTotal,
+		cmdDuration: cmdDuration,
+	}
+}
+
+// DialHook 不采集建连指标
+func (m *RedisMetrics) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook 记录单条命令
+func (m *RedisMetrics) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		m.cmdDuration.WithLabelValues(cmd.Name()).Observe(time.Since(start).Seconds())
+		m.cmdTotal.WithLabelValues(cmd.Name(), redisResult(cmd.Err())).Inc()
+		return err
+	}
+}
+
+// ProcessPipelineHook 管道中的命令逐条计数，耗时按整个管道记录
+func (m *RedisMetrics) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		m.cmdDuration.WithLabelValues("pipeline").Observe(time.Since(start).Seconds())
+		for _, cmd := range cmds {
+			m.cmdTotal.WithLabelValues(cmd.Name(), redisResult(cmd.Err())).Inc()
+		}
+		return err
+	}
+}
+
+// redisResult 将命令错误归类，redis.Nil 表示 key 不存在，不计为失败
+func redisResult(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, redis.Nil):
+		return "nil"
+	default:
+		return "error"
+	}
+}
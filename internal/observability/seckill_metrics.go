@@ -14,6 +14,7 @@ type SeckillMetrics struct {
 	kafkaConsumeTotal   *prometheus.CounterVec
 	kafkaConsumeLatency *prometheus.HistogramVec // Kafka消费处理耗时分布
 	retryTotal          *prometheus.CounterVec
+	orderQueueDepth     prometheus.Gauge // 待落库订单积压
 }
 
 func NewSeckillMetrics(registry *prometheus.Registry, serviceName string) *SeckillMetrics {
@@ -76,7 +77,15 @@ func NewSeckillMetrics(registry *prometheus.Registry, serviceName string) *Secki
 		ConstLabels: constLabels,
 	}, []string{"phase"})
 
-	registry.MustRegister(seckillTotal, seckillLatency, kafkaPublishTotal, kafkaConsumeTotal, kafkaConsumeLatency, retryTotal)
+	orderQueueDepth := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "seckill",
+		Subsystem:   "order",
+		Name:        "queue_depth",
+		Help:        "Seckill orders accepted but not yet persisted.",
+		ConstLabels: constLabels,
+	})
+
+	registry.MustRegister(seckillTotal, seckillLatency, kafkaPublishTotal, kafkaConsumeTotal, kafkaConsumeLatency, retryTotal, orderQueueDepth)
 
 	return &SeckillMetrics{
 		seckillTotal:        seckillTotal,
@@ -85,6 +94,7 @@ func NewSeckillMetrics(registry *prometheus.Registry, serviceName string) *Secki
 		kafkaConsumeTotal:   kafkaConsumeTotal,
 		kafkaConsumeLatency: kafkaConsumeLatency,
 		retryTotal:          retryTotal,
+		orderQueueDepth:     orderQueueDepth,
 	}
 }
 // ObserveSeckill 记录一次秒杀请求的结果与耗时
@@ -120,3 +130,10 @@ func (m *SeckillMetrics) ObserveRetry(phase string) {
 	}
 	m.retryTotal.WithLabelValues(phase).Inc()
 }
+// SetOrderQueueDepth 记录待落库订单的积压数量
+func (m *SeckillMetrics) SetOrderQueueDepth(depth int64) {
+	if m == nil {
+		return
+	}
+	m.orderQueueDepth.Set(float64(depth))
+}
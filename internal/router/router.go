@@ -4,62 +4,150 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 
+	"hmdp-backend/internal/config"
 	"hmdp-backend/internal/handler"
 	"hmdp-backend/internal/middleware"
+	"hmdp-backend/internal/model"
 	"hmdp-backend/internal/service"
 )
 
 // RegisterRoutes 统一注册所有模块的路由
-func RegisterRoutes(engine *gin.Engine, services *service.Registry, uploadDir string, rdb *redis.Client) {
+func RegisterRoutes(engine *gin.Engine, services *service.Registry, uploadDir string, rdb *redis.Client, authCfg config.AuthConfig, limits func() config.RateLimitConfig) {
 	engine.Use(middleware.CORSMiddleware())
-	engine.Use(middleware.LoginMiddleware(rdb))
+	engine.Use(middleware.LoginMiddleware(rdb, authCfg.JWTSecret))
 
-	shopHandler := handler.NewShopHandler(services.Shop)
+	shopHandler := handler.NewShopHandler(services.Shop, services.Search, services.ShopHistory, services.ShopSearch, services.ShopView, uploadDir)
 	shopTypeHandler := handler.NewShopTypeHandler(services.ShopType)
-	voucherHandler := handler.NewVoucherHandler(services.Voucher)
-	blogHandler := handler.NewBlogHandler(services.Blog, services.User)
+	shopReviewHandler := handler.NewShopReviewHandler(services.ShopReview)
+	shopFavoriteHandler := handler.NewShopFavoriteHandler(services.ShopFavorite)
+	voucherHandler := handler.NewVoucherHandler(services.Voucher, services.SeckillRemind)
+	blogHandler := handler.NewBlogHandler(services.Blog, services.User, services.Tag, services.BlogSearch, services.Search, services.Favorite, uploadDir)
+	commentHandler := handler.NewCommentHandler(services.Comment)
+	reportHandler := handler.NewReportHandler(services.Report)
+	favoriteHandler := handler.NewFavoriteHandler(services.Favorite)
 	uploadHandler := handler.NewUploadHandler(uploadDir)
-	userHandler := handler.NewUserHandler(services.User)
-	voucherOrderHandler := handler.NewVoucherOrderHandler(services.VoucherOrder)
+	userHandler := handler.NewUserHandler(services.User, services.Points, services.OAuth, services.Account, services.Captcha, services.LoginLog)
+	voucherOrderHandler := handler.NewVoucherOrderHandler(services.VoucherOrder, services.Voucher, services.Payment, services.OrderTransfer, services.Redemption)
 	followHandler := handler.NewFollowHandler(services.Follow, services.User)
+	notificationHandler := handler.NewNotificationHandler(services.Notification, services.NotifySetting)
+	searchHandler := handler.NewSearchHandler(services.Search)
+	adminHandler := handler.NewAdminHandler(services.User, services.Shop, services.ShopView, services.VoucherOrder)
+	twoFactorHandler := handler.NewTwoFactorHandler(services.TwoFactor)
+	privacyHandler := handler.NewPrivacyHandler(services.Privacy)
+	campaignHandler := handler.NewCampaignHandler(services.Campaign)
+	groupBuyHandler := handler.NewGroupBuyHandler(services.GroupBuy)
+
+	// 管理端接口仅允许管理员与商家访问
+	adminOnly := middleware.AdminMiddleware()
 
 	shopGroup := engine.Group("/shop")
 	shopGroup.GET("/:id", shopHandler.QueryShopByID)
-	shopGroup.POST("", shopHandler.SaveShop)
-	shopGroup.PUT("", shopHandler.UpdateShop)
+	shopGroup.POST("", adminOnly, shopHandler.SaveShop)
+	shopGroup.PUT("", adminOnly, shopHandler.UpdateShop)
 	shopGroup.GET("/of/type", shopHandler.QueryShopByType)
 	shopGroup.GET("/of/name", shopHandler.QueryShopByName)
+	shopGroup.GET("/search", shopHandler.SearchShop)
+	shopGroup.GET("/list", shopHandler.ListShops)
+	shopGroup.GET("/batch", shopHandler.QueryShopBatch)
+	shopGroup.GET("/cluster", shopHandler.QueryShopClusters)
+	shopGroup.GET("/mine", adminOnly, shopHandler.QueryMyShops)
+	shopGroup.PUT("/:id/resubmit", adminOnly, shopHandler.ResubmitShop)
+	shopGroup.DELETE("/:id", middleware.RequireRoles(model.RoleAdmin), shopHandler.DeleteShop) // 删除商铺仅管理员可操作
+	shopGroup.POST("/:id/images", adminOnly, shopHandler.UploadShopImage)
+	shopGroup.PUT("/:id/images", adminOnly, shopHandler.ReorderShopImages)
+	shopGroup.DELETE("/:id/images", adminOnly, shopHandler.DeleteShopImage)
+	shopGroup.POST("/reviews", shopReviewHandler.SaveReview)
+	shopGroup.GET("/reviews", shopReviewHandler.QueryReviews)
+	shopGroup.PUT("/reviews/:id", shopReviewHandler.UpdateReview)
+	shopGroup.DELETE("/reviews/:id", shopReviewHandler.DeleteReview)
+	shopGroup.POST("/:id/favorite", shopFavoriteHandler.ToggleFavorite)
+	shopGroup.GET("/:id/favorite", shopFavoriteHandler.IsFavorite)
+	shopGroup.GET("/favorites", shopFavoriteHandler.QueryMyFavorites)
+	shopGroup.GET("/history", shopHandler.QueryShopHistory)
+	shopGroup.DELETE("/history", shopHandler.ClearShopHistory)
 
 	engine.GET("/shop-type/list", shopTypeHandler.QueryTypeList)
 
 	voucherGroup := engine.Group("/voucher")
-	voucherGroup.POST("", voucherHandler.AddVoucher)
-	voucherGroup.POST("/seckill", voucherHandler.AddSeckillVoucher)
+	voucherGroup.POST("", adminOnly, voucherHandler.AddVoucher)
+	voucherGroup.POST("/seckill", adminOnly, voucherHandler.AddSeckillVoucher)
 	voucherGroup.GET("/list/:shopId", voucherHandler.QueryVoucherOfShop)
+	voucherGroup.GET("/stock/:id", voucherHandler.QueryRemainingStock)
+	voucherGroup.GET("/seckill/:id/time", voucherHandler.QuerySeckillTiming)
+	voucherGroup.GET("/:id/remind", voucherHandler.QueryReminder)
+	voucherGroup.POST("/:id/remind", voucherHandler.SubscribeReminder)
+	voucherGroup.DELETE("/:id/remind", voucherHandler.UnsubscribeReminder)
+
+	campaignGroup := engine.Group("/campaign")
+	campaignGroup.POST("", adminOnly, campaignHandler.AddCampaign)
+	campaignGroup.GET("/active", campaignHandler.QueryActiveCampaigns)
+	campaignGroup.GET("/:id/stats", campaignHandler.QueryCampaignStats)
 
 	blogGroup := engine.Group("/blog")
 	blogGroup.POST("", blogHandler.SaveBlog)
 	blogGroup.PUT("/like/:id", blogHandler.LikeBlog)
 	blogGroup.GET("/:id", blogHandler.QueryBlogByID)
+	blogGroup.PUT("/:id", blogHandler.UpdateBlog)
+	blogGroup.DELETE("/:id", blogHandler.DeleteBlog)
+	blogGroup.POST("/:id/publish", blogHandler.PublishBlog)
+	blogGroup.POST("/:id/share", blogHandler.ShareBlog)
+	blogGroup.PUT("/:id/pin", blogHandler.PinBlog)
+	blogGroup.DELETE("/:id/pin", blogHandler.UnpinBlog)
+	blogGroup.GET("/share/:token", blogHandler.QueryBlogByShare)
 	blogGroup.GET("/likes/:id", blogHandler.QueryBlogLikes)
 	blogGroup.GET("/of/me", blogHandler.QueryMyBlog)
+	blogGroup.GET("/of/me/drafts", blogHandler.QueryMyDrafts)
 	blogGroup.GET("/of/user", blogHandler.QueryBlogOfUser)
 	blogGroup.GET("/of/follow", blogHandler.QueryFollowFeed)
 	blogGroup.GET("/hot", blogHandler.QueryHotBlog)
+	blogGroup.GET("/nearby", blogHandler.QueryNearbyBlog)
+	blogGroup.GET("/search", blogHandler.SearchBlog)
+	blogGroup.GET("/tag/:name", blogHandler.QueryBlogByTag)
+	blogGroup.GET("/topics/trending", blogHandler.QueryTrendingTopics)
+	blogGroup.POST("/comments", commentHandler.SaveComment)
+	blogGroup.GET("/comments", commentHandler.QueryComments)
+	blogGroup.DELETE("/comments/:id", commentHandler.DeleteComment)
+	blogGroup.POST("/report", reportHandler.SaveReport)
+	blogGroup.POST("/favorites", favoriteHandler.AddFavorite)
+	blogGroup.DELETE("/favorites/:blogId", favoriteHandler.RemoveFavorite)
+	blogGroup.GET("/favorites", favoriteHandler.QueryFavorites)
+	blogGroup.GET("/collections", favoriteHandler.QueryCollections)
+	blogGroup.POST("/collections", favoriteHandler.SaveCollection)
+	blogGroup.DELETE("/collections/:id", favoriteHandler.DeleteCollection)
 
 	uploadGroup := engine.Group("/upload")
 	uploadGroup.POST("/blog", uploadHandler.UploadImage)
 	uploadGroup.GET("/blog/delete", uploadHandler.DeleteBlogImage)
 
 	userGroup := engine.Group("/user")
+	userGroup.GET("/captcha", userHandler.Captcha)
 	userGroup.POST("/code", userHandler.SendCode)
 	userGroup.POST("/login", userHandler.Login)
+	userGroup.POST("/login/password", userHandler.LoginWithPassword)
+	userGroup.POST("/login/oauth/:provider", userHandler.LoginWithOAuth)
+	userGroup.POST("/login/2fa", userHandler.LoginWithTwoFactor)
+	userGroup.POST("/register", userHandler.Register)
+	userGroup.POST("/password", userHandler.SetPassword)
+	userGroup.POST("/phone", userHandler.ChangePhone)
 	userGroup.POST("/logout", userHandler.Logout)
 	userGroup.GET("/me", userHandler.Me)
 	userGroup.GET("/info/:id", userHandler.Info)
+	userGroup.GET("/search", userHandler.SearchUsers)
 	userGroup.GET("/:id", userHandler.GetUserByID)
 	userGroup.POST("/sign", userHandler.Sign)
 	userGroup.GET("/sign/count", userHandler.SignCount)
+	userGroup.GET("/points", userHandler.Points)
+	userGroup.DELETE("/account", userHandler.DeleteAccount)
+	userGroup.GET("/account/export", userHandler.ExportAccount)
+	userGroup.GET("/login-history", userHandler.LoginHistory)
+	userGroup.GET("/2fa", twoFactorHandler.Status)
+	userGroup.POST("/2fa/setup", twoFactorHandler.Setup)
+	userGroup.POST("/2fa/enable", twoFactorHandler.Enable)
+	userGroup.POST("/2fa/disable", twoFactorHandler.Disable)
+	userGroup.GET("/notification-settings", notificationHandler.QuerySettings)
+	userGroup.PUT("/notification-settings", notificationHandler.UpdateSettings)
+	userGroup.GET("/privacy", privacyHandler.QueryPrivacy)
+	userGroup.PUT("/privacy", privacyHandler.UpdatePrivacy)
 
 	followGroup := engine.Group("/follow")
 	followGroup.PUT("/:id/:follow", followHandler.Follow) // follow=true 关注，false 取关
@@ -67,6 +155,52 @@ func RegisterRoutes(engine *gin.Engine, services *service.Registry, uploadDir st
 	followGroup.GET("/common/:id", followHandler.CommonFollow)
 
 	voucherOrderGroup := engine.Group("/voucher-order")
-	voucherOrderGroup.POST("/seckill/:id", voucherOrderHandler.SeckillVoucher)
+	voucherOrderGroup.POST("/seckill/:id", middleware.SeckillRateLimit(rdb, limits), voucherOrderHandler.SeckillVoucher)
+	voucherOrderGroup.GET("/my", voucherOrderHandler.QueryMyOrders)
+	voucherOrderGroup.POST("/redeem", adminOnly, voucherOrderHandler.RedeemOrder)
+	voucherOrderGroup.GET("/:id", voucherOrderHandler.QueryOrder)
+	voucherOrderGroup.GET("/:id/qrcode", voucherOrderHandler.QueryRedeemQRCode)
+	voucherOrderGroup.POST("/:id/pay", voucherOrderHandler.PayOrder)
+	voucherOrderGroup.POST("/:id/refund", voucherOrderHandler.RefundOrder)
+	voucherOrderGroup.POST("/:id/gift", voucherOrderHandler.GiftOrder)
+	voucherOrderGroup.POST("/:id/gift-code", voucherOrderHandler.GiftOrderByCode)
+	voucherOrderGroup.GET("/gift/pending", voucherOrderHandler.QueryPendingGifts)
+	voucherOrderGroup.POST("/gift/claim", voucherOrderHandler.ClaimGift)
+	voucherOrderGroup.POST("/gift/:transferId/accept", voucherOrderHandler.AcceptGift)
+	voucherOrderGroup.POST("/gift/:transferId/reject", voucherOrderHandler.RejectGift)
+	voucherOrderGroup.POST("/gift/:transferId/withdraw", voucherOrderHandler.WithdrawGift)
+
+	groupBuyGroup := engine.Group("/group-buy")
+	groupBuyGroup.POST("", groupBuyHandler.CreateGroup)
+	groupBuyGroup.GET("/voucher/:voucherId", groupBuyHandler.QueryFormingGroups)
+	groupBuyGroup.GET("/:id", groupBuyHandler.QueryGroup)
+	groupBuyGroup.POST("/:id/join", groupBuyHandler.JoinGroup)
+
+	engine.GET("/notification/list", notificationHandler.QueryNotifications)
+
+	adminGroup := engine.Group("/admin", middleware.RequireRoles(model.RoleAdmin))
+	adminGroup.POST("/user/:id/ban", adminHandler.BanUser)
+	adminGroup.POST("/user/:id/unban", adminHandler.UnbanUser)
+	adminGroup.GET("/user/banned", adminHandler.QueryBannedUsers)
+	adminGroup.POST("/user/:id/revoke-sessions", adminHandler.RevokeSessions)
+	adminGroup.GET("/reports", reportHandler.QueryReports)
+	adminGroup.POST("/shop/geo/reload", adminHandler.ReloadShopGeo)
+	adminGroup.GET("/shop/pending", adminHandler.QueryPendingShops)
+	adminGroup.POST("/shop/:id/review", adminHandler.ReviewShop)
+	adminGroup.POST("/shop/import", adminHandler.ImportShops)
+	adminGroup.GET("/shop/:id/views", adminHandler.QueryShopViews)
+	adminGroup.GET("/orders", adminHandler.QueryOrders)
+	adminGroup.GET("/orders/export", adminHandler.ExportOrders)
+	adminGroup.GET("/seckill/stock/drift", adminHandler.QueryStockDrifts)
+	adminGroup.POST("/seckill/stock/reconcile", adminHandler.ReconcileStock)
+	adminGroup.GET("/seckill/queue", adminHandler.QueryOrderQueue)
+	adminGroup.PUT("/seckill/queue/limit", adminHandler.UpdateOrderQueueLimit)
+	adminGroup.DELETE("/seckill/queue/limit", adminHandler.ResetOrderQueueLimit)
+	adminGroup.POST("/reports/:id/review", reportHandler.ReviewReport)
+
+	searchGroup := engine.Group("/search")
+	searchGroup.GET("/history", searchHandler.QueryHistory)
+	searchGroup.DELETE("/history", searchHandler.ClearHistory)
+	searchGroup.GET("/suggest", searchHandler.Suggest)
 
 }
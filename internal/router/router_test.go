@@ -0,0 +1,46 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/service"
+	"hmdp-backend/internal/utils"
+)
+
+// TestDeleteShopAdminOnly 校验删除商铺仅管理员可访问，商家调用返回 403
+func TestDeleteShopAdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "secret"
+	engine := gin.New()
+	RegisterRoutes(engine, &service.Registry{}, t.TempDir(), nil, config.AuthConfig{JWTSecret: secret}, func() config.RateLimitConfig {
+		return config.RateLimitConfig{}
+	})
+
+	cases := []struct {
+		role string
+		want int
+	}{
+		{model.RoleMerchant, http.StatusForbidden},
+		{model.RoleUser, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		token, err := utils.SignJWT(secret, "hmdp", time.Hour, utils.JWTClaims{UserID: 1, Role: tc.role})
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodDelete, "/shop/1", nil)
+		req.Header.Set("authorization", token)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("role %s: want status %d, got %d", tc.role, tc.want, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// AccountArchive 用户数据导出包
+type AccountArchive struct {
+	ExportTime time.Time            `json:"exportTime"`
+	User       *model.User          `json:"user"`
+	Info       *model.UserInfo      `json:"info,omitempty"`
+	Blogs      []model.Blog         `json:"blogs"`
+	Orders     []model.VoucherOrder `json:"orders"`
+	Following  []int64              `json:"following"`
+	Followers  []int64              `json:"followers"`
+}
+
+// AccountService 处理账号注销与个人数据导出
+type AccountService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+	log *zap.Logger
+}
+
+// NewAccountService 创建 AccountService 实例
+func NewAccountService(db *gorm.DB, rdb *redis.Client, log *zap.Logger) *AccountService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &AccountService{db: db, rdb: rdb, log: log}
+}
+
+// Delete 注销账号：软删除用户并清理关注关系、登录态及 Redis 中的个人数据
+func (s *AccountService) Delete(ctx context.Context, userID int64) error {
+	var fans []int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Follow{}).Where("follow_user_id = ?", userID).Pluck("user_id", &fans).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? OR follow_user_id = ?", userID, userID).Delete(&model.Follow{}).Error; err != nil {
+			return err
+		}
+		for _, m := range []interface{}{&model.UserInfo{}, &model.NotificationSetting{}, &model.UserOAuth{}, &model.LoginLog{}, &model.UserTwoFactor{}, &model.UserPrivacy{}, &model.BlogFavorite{}, &model.BlogCollection{}, &model.UserPinnedBlog{}, &model.ShopFavorite{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+		// 清空手机号、邮箱与密码，释放账号标识以便重新注册
+		res := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"phone":    "",
+			"email":    "",
+			"password": "",
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("用户不存在")
+		}
+		return tx.Delete(&model.User{}, userID).Error
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.purgeRedis(ctx, userID, fans); err != nil {
+		// 数据库已完成注销，Redis 清理失败时记录日志以便人工补偿
+		s.log.Error("purge account redis data failed", zap.Int64("userId", userID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Export 导出用户的个人资料、笔记、订单与关注关系
+func (s *AccountService) Export(ctx context.Context, userID int64) (*AccountArchive, error) {
+	db := s.db.WithContext(ctx)
+	var user model.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	archive := &AccountArchive{ExportTime: time.Now(), User: &user}
+	var info model.UserInfo
+	err := db.Where("user_id = ?", userID).Take(&info).Error
+	if err == nil {
+		archive.Info = &info
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("id ASC").Find(&archive.Blogs).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("user_id = ?", userID).Order("id ASC").Find(&archive.Orders).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&model.Follow{}).Where("user_id = ?", userID).Pluck("follow_user_id", &archive.Following).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&model.Follow{}).Where("follow_user_id = ?", userID).Pluck("user_id", &archive.Followers).Error; err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// purgeRedis 删除登录 token、关注集合、收件箱、签到位图等用户相关的 key
+func (s *AccountService) purgeRedis(ctx context.Context, userID int64, fans []int64) error {
+	uid := strconv.FormatInt(userID, 10)
+	tokensKey := utils.LOGIN_TOKENS_KEY + uid
+	tokens, err := s.rdb.SMembers(ctx, tokensKey).Result()
+	if err != nil {
+		return err
+	}
+	keys := []string{
+		tokensKey,
+		followKey(userID),
+		utils.FEED_KEY + uid,
+		utils.SHOP_HISTORY_KEY + uid,
+		utils.NOTIFY_INBOX_KEY + uid,
+		utils.NOTIFY_SETTING_KEY + uid,
+		utils.USER_PRIVACY_KEY + uid,
+		utils.CACHE_USER_KEY + uid,
+		utils.BLOG_FAVORITE_KEY + uid,
+		utils.SHOP_FAVORITE_KEY + uid,
+	}
+	for _, t := range tokens {
+		keys = append(keys, utils.LOGIN_USER_KEY+t)
+	}
+	for _, scope := range []string{SearchScopeShop, SearchScopeBlog} {
+		key, _ := searchHistoryKey(scope, userID)
+		keys = append(keys, key)
+	}
+	// 签到位图按月分 key，需扫描匹配
+	iter := s.rdb.Scan(ctx, 0, fmt.Sprintf("user:sign:%d:*", userID), 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	_, err = s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		// 从粉丝的关注集合中移除该用户
+		for _, fan := range fans {
+			pipe.SRem(ctx, followKey(fan), userID)
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// TestHotScore 验证热度随时间按半衰期衰减，且新笔记能超过点赞更多的旧笔记
+func TestHotScore(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	fresh := hotScore(10, 0, now, now)
+	if fresh != 11 {
+		t.Fatalf("fresh score: want 11, got %v", fresh)
+	}
+	halved := hotScore(10, 0, now.Add(-blogHotHalfLife), now)
+	if halved != fresh/2 {
+		t.Fatalf("score after one half-life: want %v, got %v", fresh/2, halved)
+	}
+	old := hotScore(100, 10, now.Add(-7*24*time.Hour), now)
+	recent := hotScore(5, 1, now.Add(-time.Hour), now)
+	if recent <= old {
+		t.Fatalf("recent blog should outrank week-old blog: recent=%v old=%v", recent, old)
+	}
+	if future := hotScore(1, 0, now.Add(time.Minute), now); future != 2 {
+		t.Fatalf("future create time should not boost score, got %v", future)
+	}
+}
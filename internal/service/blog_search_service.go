@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/data"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// blogDocument 写入 Elasticsearch 的笔记文档
+type blogDocument struct {
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	UserID     int64  `json:"userId"`
+	ShopID     int64  `json:"shopId"`
+	CreateTime int64  `json:"createTime"`
+}
+
+// BlogSearchService 笔记全文搜索：启用 Elasticsearch 时走倒排索引并返回高亮，否则退化为 MySQL LIKE
+type BlogSearchService struct {
+	db  *gorm.DB
+	es  *data.Elasticsearch
+	log *zap.Logger
+}
+
+// NewBlogSearchService 创建 BlogSearchService 实例，es 为 nil 表示未启用
+func NewBlogSearchService(db *gorm.DB, es *data.Elasticsearch, log *zap.Logger) *BlogSearchService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &BlogSearchService{db: db, es: es, log: log}
+}
+
+// Index 写入或覆盖笔记文档，未启用 Elasticsearch 时忽略
+func (s *BlogSearchService) Index(ctx context.Context, blog *model.Blog) {
+	if s.es == nil {
+		return
+	}
+	doc := blogDocument{
+		Title:      blog.Title,
+		Content:    blog.Content,
+		UserID:     blog.UserID,
+		ShopID:     blog.ShopID,
+		CreateTime: blog.CreateTime.UnixMilli(),
+	}
+	path := "/" + s.es.Index() + "/_doc/" + strconv.FormatInt(blog.ID, 10)
+	if err := s.es.Do(ctx, http.MethodPut, path, doc, nil); err != nil {
+		// 索引失败不影响笔记发布，搜索结果最终以数据库为准
+		s.log.Warn("index blog failed", zap.Int64("blogId", blog.ID), zap.Error(err))
+	}
+}
+
+// Remove 删除笔记文档，未启用 Elasticsearch 时忽略
+func (s *BlogSearchService) Remove(ctx context.Context, blogID int64) {
+	if s.es == nil {
+		return
+	}
+	path := "/" + s.es.Index() + "/_doc/" + strconv.FormatInt(blogID, 10)
+	if err := s.es.Do(ctx, http.MethodDelete, path, nil, nil); err != nil {
+		s.log.Warn("remove blog document failed", zap.Int64("blogId", blogID), zap.Error(err))
+	}
+}
+
+// Search 按关键字分页搜索笔记，返回当前页与命中总数；Elasticsearch 不可用时退化为 MySQL 查询
+func (s *BlogSearchService) Search(ctx context.Context, keyword string, page, size int) ([]model.Blog, int64, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return []model.Blog{}, 0, nil
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.MAX_PAGE_SIZE
+	}
+	if s.es != nil {
+		blogs, total, err := s.searchES(ctx, keyword, page, size)
+		if err == nil {
+			return blogs, total, nil
+		}
+		s.log.Warn("elasticsearch search failed, fallback to mysql", zap.Error(err))
+	}
+	return s.searchDB(ctx, keyword, page, size)
+}
+
+func (s *BlogSearchService) searchES(ctx context.Context, keyword string, page, size int) ([]model.Blog, int64, error) {
+	query := map[string]interface{}{
+		"from": (page - 1) * size,
+		"size": size,
+		"query": map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  keyword,
+				"fields": []string{"title^2", "content"},
+			},
+		},
+		// 高亮片段直接返回给前端渲染，html 编码器先转义原文再插入 <em> 标签，避免绕过内容清洗
+		"highlight": map[string]interface{}{
+			"encoder":   "html",
+			"pre_tags":  []string{"<em>"},
+			"post_tags": []string{"</em>"},
+			"fields": map[string]interface{}{
+				"title":   map[string]interface{}{},
+				"content": map[string]interface{}{"fragment_size": 100, "number_of_fragments": 1},
+			},
+		},
+		"_source": false,
+	}
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID        string              `json:"_id"`
+				Highlight map[string][]string `json:"highlight"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := s.es.Do(ctx, http.MethodPost, "/"+s.es.Index()+"/_search", query, &resp); err != nil {
+		return nil, 0, err
+	}
+	ids := make([]int64, 0, len(resp.Hits.Hits))
+	highlights := make(map[int64]model.Highlight, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		id, err := strconv.ParseInt(hit.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		highlights[id] = hit.Highlight
+	}
+	if len(ids) == 0 {
+		return []model.Blog{}, resp.Hits.Total.Value, nil
+	}
+	var blogs []model.Blog
+	if err := s.db.WithContext(ctx).Where("id IN ? AND status = ?", ids, model.BlogStatusPublished).Find(&blogs).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[int64]model.Blog, len(blogs))
+	for _, blog := range blogs {
+		byID[blog.ID] = blog
+	}
+	// 按相关度顺序输出，索引中已删除但数据库不存在的笔记直接跳过
+	res := make([]model.Blog, 0, len(ids))
+	for _, id := range ids {
+		if blog, ok := byID[id]; ok {
+			blog.Highlight = highlights[id]
+			res = append(res, blog)
+		}
+	}
+	return res, resp.Hits.Total.Value, nil
+}
+
+func (s *BlogSearchService) searchDB(ctx context.Context, keyword string, page, size int) ([]model.Blog, int64, error) {
+	pattern := "%" + escapeLike(keyword) + "%"
+	query := s.db.WithContext(ctx).Model(&model.Blog{}).Where("status = ? AND (title LIKE ? OR content LIKE ?)", model.BlogStatusPublished, pattern, pattern)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var blogs []model.Blog
+	err := query.Order("liked DESC, id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&blogs).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, err
+	}
+	return blogs, total, nil
+}
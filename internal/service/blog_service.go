@@ -2,50 +2,615 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/dto"
 	"hmdp-backend/internal/model"
 	"hmdp-backend/internal/utils"
 )
 
+// blogPublishedMessage 笔记发布事件，score 为写入收件箱的时间戳
+type blogPublishedMessage struct {
+	BlogID    int64   `json:"blogId"`
+	AuthorID  int64   `json:"authorId"`
+	Score     float64 `json:"score"`
+	CreatedAt int64   `json:"createdAt"`
+	// Routed 发布时已完成隐私与推拉模式判断，消费者直接推送；发件箱写入的事件为 false
+	Routed bool `json:"routed,omitempty"`
+}
+
+var (
+	errBlogPublished = errors.New("笔记已发布")
+	errShareNotFound = errors.New("分享链接不存在或已失效")
+)
+
+// blogShareTokenLength 分享 token 长度，36 进制 8 位约 2.8 万亿种组合
+const blogShareTokenLength = 8
+
 // BlogService 处理博客相关业务逻辑
 type BlogService struct {
-	db        *gorm.DB
-	rdb       *redis.Client
-	followSvc *FollowService
+	db         *gorm.DB
+	rdb        *redis.Client
+	feedWriter *kafka.Writer
+	feedReader *kafka.Reader
+	feedCfg    config.FeedConfig
+	followSvc  *FollowService
+	privacy    *PrivacyService
+	tags       *TagService
+	search     *BlogSearchService
+	sensitive  *SensitiveWordService
+	outbox     *OutboxService
+	log        *zap.Logger
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
 }
 
-// NewBlogService 创建 BlogService 实例
-func NewBlogService(db *gorm.DB, rdb *redis.Client, followSvc *FollowService) *BlogService {
-	return &BlogService{db: db, rdb: rdb, followSvc: followSvc}
+const (
+	// blogViewsFlushInterval 浏览量增量刷入数据库的周期
+	blogViewsFlushInterval = time.Minute
+	// 热门榜刷新周期、热度半衰期与参与排名的笔记发布时间窗口
+	blogHotRefreshInterval = 5 * time.Minute
+	blogHotHalfLife        = 24 * time.Hour
+	blogHotWindow          = 30 * 24 * time.Hour
+	blogHotCommentWeight   = 2
+	// 收件箱默认保留条数与过期时间
+	defaultFeedInboxMax = 1000
+	defaultFeedInboxTTL = 7 * 24 * time.Hour
+	// 异步推送时每批写入的收件箱数量与失败重试次数
+	feedFanOutBatchSize   = 500
+	feedFanOutMaxAttempts = 3
+)
+
+// NewBlogService 创建 BlogService 实例，并启动浏览量刷库、热门榜刷新、收件箱清理与推送消费任务；
+// 退出时调用 Shutdown 停止推送消费者
+func NewBlogService(
+	db *gorm.DB,
+	rdb *redis.Client,
+	feedWriter *kafka.Writer,
+	feedReader *kafka.Reader,
+	feedCfg config.FeedConfig,
+	followSvc *FollowService,
+	privacy *PrivacyService,
+	tags *TagService,
+	search *BlogSearchService,
+	sensitive *SensitiveWordService,
+	outbox *OutboxService,
+	log *zap.Logger,
+) *BlogService {
+	if feedCfg.InboxMax <= 0 {
+		feedCfg.InboxMax = defaultFeedInboxMax
+	}
+	if feedCfg.InboxTTL <= 0 {
+		feedCfg.InboxTTL = defaultFeedInboxTTL
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	svc := &BlogService{db: db, rdb: rdb, feedWriter: feedWriter, feedReader: feedReader, feedCfg: feedCfg, followSvc: followSvc, privacy: privacy, tags: tags, search: search, sensitive: sensitive, outbox: outbox, log: log}
+	go svc.flushViewsLoop(context.Background())
+	go svc.refreshHotLoop(context.Background())
+	if feedCfg.CleanupInterval > 0 {
+		go svc.cleanupInboxesLoop(context.Background())
+	}
+	// 启动笔记发布事件消费者，异步推送到粉丝收件箱
+	if feedReader != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		svc.cancel = cancel
+		svc.wg.Add(1)
+		go func() {
+			defer svc.wg.Done()
+			svc.consumeFeedEvents(ctx)
+		}()
+	}
+	return svc
+}
+
+// Shutdown 停止推送消费者，等待当前消息处理完成后关闭 Kafka reader，ctx 到期时不再等待
+func (s *BlogService) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return s.feedReader.Close()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (s *BlogService) Create(ctx context.Context, blog *model.Blog) error {
-	if err := s.db.WithContext(ctx).Create(blog).Error; err != nil {
+	// 清洗富文本，防止存储型 XSS
+	blog.Title = utils.SanitizePlainText(blog.Title)
+	blog.Content = utils.SanitizeRichText(blog.Content)
+	if err := s.checkSensitive(blog); err != nil {
+		return err
+	}
+	tags, err := NormalizeTags(blog.Tags)
+	if err != nil {
+		return err
+	}
+	blog.Tags = tags
+	if blog.Status != model.BlogStatusDraft {
+		blog.Status = model.BlogStatusPublished
+	}
+	if err := s.fillBlogLocation(ctx, blog); err != nil {
+		return err
+	}
+	enqueued := false
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(blog).Error; err != nil {
+			return err
+		}
+		if s.tags != nil {
+			if err := s.tags.Attach(tx, blog.ID, tags); err != nil {
+				return err
+			}
+		}
+		if blog.Status == model.BlogStatusDraft {
+			return nil
+		}
+		var err error
+		enqueued, err = s.enqueueFeedEvent(tx, blog)
+		return err
+	}); err != nil {
+		return err
+	}
+	// 草稿只落库，发布时再推送
+	if blog.Status == model.BlogStatusDraft {
+		return nil
+	}
+	return s.distribute(ctx, blog, tags, enqueued)
+}
+
+// enqueueFeedEvent 配置了事务发件箱时，在笔记落库的同一事务内写入发布事件，
+// 保证已发布的笔记一定会推送给粉丝；返回是否已写入发件箱
+func (s *BlogService) enqueueFeedEvent(tx *gorm.DB, blog *model.Blog) (bool, error) {
+	if s.outbox == nil || s.feedWriter == nil {
+		return false, nil
+	}
+	event := blogPublishedMessage{BlogID: blog.ID, AuthorID: blog.UserID, Score: float64(time.Now().UnixMilli()), CreatedAt: time.Now().Unix()}
+	if err := s.outbox.Enqueue(tx, s.feedWriter.Topic, strconv.FormatInt(blog.UserID, 10), event); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// distribute 笔记发布后的分发：话题热度、搜索索引、GEO 索引与粉丝推送；
+// enqueued 为 true 时发布事件已写入发件箱，粉丝推送交给事件消费者
+func (s *BlogService) distribute(ctx context.Context, blog *model.Blog, tags []string, enqueued bool) error {
+	if s.tags != nil {
+		_ = s.tags.RecordUsage(ctx, tags, time.Now())
+	}
+	if s.search != nil {
+		s.search.Index(ctx, blog)
+	}
+	// 带坐标的笔记写入 GEO 索引，供附近笔记查询
+	if hasLocation(blog.X, blog.Y) {
+		_ = s.rdb.GeoAdd(ctx, utils.BLOG_GEO_KEY, &redis.GeoLocation{
+			Name:      strconv.FormatInt(blog.ID, 10),
+			Longitude: blog.X,
+			Latitude:  blog.Y,
+		}).Err()
+	}
+	if enqueued {
+		return nil
+	}
+	event := blogPublishedMessage{BlogID: blog.ID, AuthorID: blog.UserID, Score: float64(time.Now().UnixMilli()), CreatedAt: time.Now().Unix()}
+	push, err := s.routeFeed(ctx, event)
+	if err != nil || !push {
 		return err
 	}
 	// 推模式：将新笔记推送到粉丝的收件箱（ZSet，score 为时间戳，越新越靠前）
-	if s.followSvc != nil {
-		fans, err := s.followSvc.FollowerIDs(ctx, blog.UserID)
+	event.Routed = true
+	if s.feedWriter != nil {
+		// 发布耗时不随粉丝数增长：只投递事件，由消费者分批推送
+		err := s.publishFeedEvent(ctx, event)
+		if err == nil {
+			return nil
+		}
+		s.log.Warn("publish blog event failed, fan out synchronously", zap.Int64("blogId", blog.ID), zap.Error(err))
+	}
+	if err := s.fanOut(ctx, event); err != nil {
+		// 笔记已落库，推送失败只记录日志
+		s.log.Warn("push blog to inboxes failed", zap.Int64("blogId", blog.ID), zap.Error(err))
+	}
+	return nil
+}
+
+// routeFeed 决定笔记的推送方式：作者隐藏动态时不推送；拉模式作者只写自己的时间线；
+// 返回 true 表示需要推送到粉丝收件箱
+func (s *BlogService) routeFeed(ctx context.Context, event blogPublishedMessage) (bool, error) {
+	// 作者设置了不推送到粉丝收件箱时跳过推送
+	if s.privacy != nil {
+		privacy, err := s.privacy.Get(ctx, event.AuthorID)
 		if err != nil {
+			return false, err
+		}
+		if privacy.HideBlogsFromFeed {
+			return false, nil
+		}
+	}
+	if s.followSvc == nil {
+		return false, nil
+	}
+	pull, err := s.isPullAuthor(ctx, event.AuthorID)
+	if err != nil {
+		return false, err
+	}
+	if !pull {
+		return true, nil
+	}
+	// 拉模式：粉丝数超过阈值的作者只写自己的时间线，粉丝读取关注流时再合并
+	timelineKey := fmt.Sprintf("%s%d", utils.FEED_TIMELINE_KEY, event.AuthorID)
+	_, err = s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, utils.FEED_PULL_AUTHORS, event.AuthorID)
+		pipe.ZAdd(ctx, timelineKey, redis.Z{Score: event.Score, Member: event.BlogID})
+		pipe.ZRemRangeByRank(ctx, timelineKey, 0, -utils.FEED_TIMELINE_MAX-1)
+		return nil
+	})
+	return false, err
+}
+
+// deliverFeedEvent 消费发布事件：发件箱投递的事件尚未决定推送方式，先执行 routeFeed
+func (s *BlogService) deliverFeedEvent(ctx context.Context, event blogPublishedMessage) error {
+	if !event.Routed {
+		push, err := s.routeFeed(ctx, event)
+		if err != nil || !push {
 			return err
 		}
-		score := float64(time.Now().UnixMilli())
-		for _, fan := range fans {
-			key := fmt.Sprintf("%s%d", utils.FEED_KEY, fan)
-			_ = s.rdb.ZAdd(ctx, key, redis.Z{Score: score, Member: blog.ID}).Err()
+	}
+	return s.fanOut(ctx, event)
+}
+
+// publishFeedEvent 投递笔记发布事件，以作者ID作为分区 key
+func (s *BlogService) publishFeedEvent(ctx context.Context, event blogPublishedMessage) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.feedWriter.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(strconv.FormatInt(event.AuthorID, 10)),
+		Value: data,
+	})
+}
+
+// fanOut 将笔记分批推送到作者全部粉丝的收件箱，每批一次 Pipeline
+func (s *BlogService) fanOut(ctx context.Context, event blogPublishedMessage) error {
+	fans, err := s.followSvc.FollowerIDs(ctx, event.AuthorID)
+	if err != nil {
+		return err
+	}
+	z := redis.Z{Score: event.Score, Member: event.BlogID}
+	for start := 0; start < len(fans); start += feedFanOutBatchSize {
+		end := start + feedFanOutBatchSize
+		if end > len(fans) {
+			end = len(fans)
+		}
+		if _, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, fan := range fans[start:end] {
+				s.pushInbox(ctx, pipe, fan, z)
+			}
+			return nil
+		}); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// consumeFeedEvents 消费笔记发布事件并推送到粉丝收件箱；推送失败时有限次重试，重复推送是幂等的。
+// 已拉取的消息在退出时仍会处理完成，重试等待期间退出则不提交 offset，由重启后重新消费
+func (s *BlogService) consumeFeedEvents(ctx context.Context) {
+	s.log.Info("blog feed consumer started")
+	bg := context.WithoutCancel(ctx)
+	for {
+		msg, err := s.feedReader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				s.log.Info("blog feed consumer stopped")
+				return
+			}
+			s.log.Error("blog feed fetch error", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		var event blogPublishedMessage
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			s.log.Error("blog feed parse error", zap.Error(err))
+			_ = s.feedReader.CommitMessages(bg, msg)
+			continue
+		}
+		for attempt := 1; attempt <= feedFanOutMaxAttempts; attempt++ {
+			if err = s.deliverFeedEvent(bg, event); err == nil {
+				break
+			}
+			s.log.Warn("blog feed fan-out failed",
+				zap.Int64("blogId", event.BlogID),
+				zap.Int("attempt", attempt),
+				zap.Error(err),
+			)
+			select {
+			case <-ctx.Done():
+				s.log.Info("blog feed consumer stopped")
+				return
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+		if err := s.feedReader.CommitMessages(bg, msg); err != nil {
+			s.log.Error("blog feed commit error", zap.Error(err))
+		}
+	}
+}
+
+// pushInbox 写入粉丝收件箱并裁剪到 InboxMax 条；仅在收件箱没有过期时间时设置，
+// 过期时间只在用户读取关注流时续期，长期不活跃用户的收件箱会自动过期
+func (s *BlogService) pushInbox(ctx context.Context, pipe redis.Pipeliner, fan int64, z redis.Z) {
+	key := fmt.Sprintf("%s%d", utils.FEED_KEY, fan)
+	pipe.ZAdd(ctx, key, z)
+	pipe.ZRemRangeByRank(ctx, key, 0, int64(-s.feedCfg.InboxMax-1))
+	pipe.ExpireNX(ctx, key, s.feedCfg.InboxTTL)
+}
+
+// cleanupInboxesLoop 定期清理收件箱
+func (s *BlogService) cleanupInboxesLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.feedCfg.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.cleanupInboxes(ctx); err != nil {
+				s.log.Warn("cleanup feed inboxes failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// cleanupInboxes 扫描没有过期时间的收件箱（引入过期策略前写入的历史数据），裁剪并补设过期时间
+func (s *BlogService) cleanupInboxes(ctx context.Context) error {
+	// feed:[0-9]* 只匹配用户收件箱，不包含 feed:timeline: 与 feed:pull:authors
+	iter := s.rdb.Scan(ctx, 0, utils.FEED_KEY+"[0-9]*", 200).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		ttl, err := s.rdb.TTL(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		// -1 表示未设置过期时间
+		if ttl != -1 {
+			continue
+		}
+		if _, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZRemRangeByRank(ctx, key, 0, int64(-s.feedCfg.InboxMax-1))
+			pipe.Expire(ctx, key, s.feedCfg.InboxTTL)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// checkSensitive 对标题与正文做敏感词处理
+func (s *BlogService) checkSensitive(blog *model.Blog) error {
+	if s.sensitive == nil {
+		return nil
+	}
+	var err error
+	if blog.Title, err = s.sensitive.Check(blog.Title); err != nil {
+		return err
+	}
+	blog.Content, err = s.sensitive.Check(blog.Content)
+	return err
+}
+
+// isPullAuthor 判断作者是否使用拉模式：粉丝数达到阈值后加入拉模式作者集合，此后不再回退，避免时间线中的旧笔记丢失
+func (s *BlogService) isPullAuthor(ctx context.Context, authorID int64) (bool, error) {
+	pull, err := s.rdb.SIsMember(ctx, utils.FEED_PULL_AUTHORS, authorID).Result()
+	if err != nil || pull {
+		return pull, err
+	}
+	count, err := s.followSvc.FollowerCount(ctx, authorID)
+	if err != nil {
+		return false, err
+	}
+	return count >= utils.FEED_PUSH_MAX_FANS, nil
+}
+
+// Update 作者编辑笔记的标题、正文、图片与话题，未传的字段保持原值；返回编辑前的笔记以便清理不再使用的图片
+func (s *BlogService) Update(ctx context.Context, userID, blogID int64, form dto.BlogUpdateForm) (*model.Blog, error) {
+	old, err := s.authorBlog(ctx, userID, blogID)
+	if err != nil {
+		return nil, err
+	}
+	blog := *old
+	if form.Title != nil {
+		blog.Title = utils.SanitizePlainText(*form.Title)
+	}
+	if form.Content != nil {
+		blog.Content = utils.SanitizeRichText(*form.Content)
+	}
+	if err := s.checkSensitive(&blog); err != nil {
+		return nil, err
+	}
+	updates := map[string]interface{}{}
+	if form.Title != nil {
+		updates["title"] = blog.Title
+	}
+	if form.Content != nil {
+		updates["content"] = blog.Content
+	}
+	if form.Images != nil {
+		updates["images"] = *form.Images
+	}
+	// 未传 tags 时保留原有话题
+	var tags []string
+	if form.Tags != nil {
+		if tags, err = NormalizeTags(form.Tags); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&model.Blog{}).Where("id = ?", blogID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if form.Tags == nil || s.tags == nil {
+			return nil
+		}
+		return s.tags.Attach(tx, blogID, tags)
+	}); err != nil {
+		return nil, err
+	}
+	if s.search != nil && old.Status == model.BlogStatusPublished {
+		s.search.Index(ctx, &blog)
+	}
+	return old, nil
+}
+
+// Delete 作者删除笔记，同时删除评论，并清理粉丝收件箱、点赞集合、热门榜与 GEO 索引；返回被删除的笔记以便清理图片
+func (s *BlogService) Delete(ctx context.Context, userID, blogID int64) (*model.Blog, error) {
+	blog, err := s.authorBlog(ctx, userID, blogID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("blog_id = ?", blogID).Delete(&model.BlogComment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blog_id = ?", blogID).Delete(&model.BlogTagRel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blog_id = ?", blogID).Delete(&model.BlogFavorite{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blog_id = ?", blogID).Delete(&model.UserPinnedBlog{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Blog{}, blogID).Error
+	}); err != nil {
+		return nil, err
+	}
+	var fans []int64
+	if s.followSvc != nil {
+		if fans, err = s.followSvc.FollowerIDs(ctx, blog.UserID); err != nil {
+			return nil, err
+		}
+	}
+	member := strconv.FormatInt(blogID, 10)
+	_, err = s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, fan := range fans {
+			pipe.ZRem(ctx, fmt.Sprintf("%s%d", utils.FEED_KEY, fan), member)
+		}
+		pipe.ZRem(ctx, fmt.Sprintf("%s%d", utils.FEED_TIMELINE_KEY, blog.UserID), member)
+		pipe.Del(ctx, fmt.Sprintf("%s%d", utils.BLOG_LIKED_KEY, blogID))
+		pipe.ZRem(ctx, utils.BLOG_GEO_KEY, member)
+		pipe.ZRem(ctx, utils.BLOG_HOT_KEY, member)
+		pipe.HDel(ctx, utils.BLOG_SHARE_COUNT_KEY, member)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if s.search != nil {
+		s.search.Remove(ctx, blogID)
+	}
+	return blog, nil
+}
+
+// Publish 发布草稿，发布时间重置为当前时间并执行推送
+func (s *BlogService) Publish(ctx context.Context, userID, blogID int64) error {
+	blog, err := s.authorBlog(ctx, userID, blogID)
+	if err != nil {
+		return err
+	}
+	if blog.Status != model.BlogStatusDraft {
+		return errBlogPublished
+	}
+	now := time.Now()
+	enqueued := false
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 以 status 作为条件，避免重复发布导致重复推送
+		res := tx.Model(&model.Blog{}).
+			Where("id = ? AND status = ?", blogID, model.BlogStatusDraft).
+			Updates(map[string]interface{}{
+				"status":      model.BlogStatusPublished,
+				"create_time": now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errBlogPublished
+		}
+		var err error
+		enqueued, err = s.enqueueFeedEvent(tx, blog)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	blog.Status = model.BlogStatusPublished
+	blog.CreateTime = now
+	var tags []string
+	if s.tags != nil {
+		if tags, err = s.tags.Tags(ctx, blogID); err != nil {
+			return err
+		}
+	}
+	return s.distribute(ctx, blog, tags, enqueued)
+}
+
+// QueryDrafts 分页查询作者自己的草稿，最近编辑的在前
+func (s *BlogService) QueryDrafts(ctx context.Context, userID int64, page, size int) ([]model.Blog, error) {
+	var blogs []model.Blog
+	offset := (page - 1) * size
+	if offset < 0 {
+		offset = 0
+	}
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND status = ?", userID, model.BlogStatusDraft).
+		Order("update_time DESC").
+		Offset(offset).
+		Limit(size).
+		Find(&blogs).Error
+	return blogs, err
+}
+
+// authorBlog 查询笔记并校验作者，非作者按不存在处理
+func (s *BlogService) authorBlog(ctx context.Context, userID, blogID int64) (*model.Blog, error) {
+	blog, err := s.GetByID(ctx, blogID)
+	if err != nil {
+		return nil, err
+	}
+	if blog == nil || blog.UserID != userID {
+		return nil, errBlogNotFound
+	}
+	return blog, nil
+}
+
 func (s *BlogService) GetByID(ctx context.Context, id int64) (*model.Blog, error) {
 	var blog model.Blog
 	err := s.db.WithContext(ctx).First(&blog, id).Error
@@ -66,33 +631,230 @@ func (s *BlogService) IncrementLike(ctx context.Context, id int64) error {
 		Error
 }
 
+// QueryByUser 分页查询用户已发布的笔记，置顶笔记固定出现在第一页首位，其余页不再重复返回
 func (s *BlogService) QueryByUser(ctx context.Context, userID int64, page, size int) ([]model.Blog, error) {
 	var blogs []model.Blog
 	offset := (page - 1) * size
 	if offset < 0 {
 		offset = 0
 	}
-	err := s.db.WithContext(ctx).
-		Where("user_id = ?", userID).
+	pinned, err := s.PinnedBlog(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	query := s.db.WithContext(ctx).Where("user_id = ? AND status = ?", userID, model.BlogStatusPublished)
+	if pinned != nil {
+		query = query.Where("id <> ?", pinned.ID)
+	}
+	if err := query.
 		Order("id ASC").
 		Offset(offset).
 		Limit(size).
-		Find(&blogs).Error
-	return blogs, err
+		Find(&blogs).Error; err != nil {
+		return nil, err
+	}
+	if pinned != nil && offset == 0 {
+		blogs = append([]model.Blog{*pinned}, blogs...)
+	}
+	return blogs, nil
+}
+
+// Pin 置顶笔记，每个作者只能置顶一篇，重复置顶会替换原有置顶
+func (s *BlogService) Pin(ctx context.Context, userID, blogID int64) error {
+	blog, err := s.authorBlog(ctx, userID, blogID)
+	if err != nil {
+		return err
+	}
+	if blog.Status != model.BlogStatusPublished {
+		return errBlogNotFound
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"blog_id": blogID, "create_time": time.Now()}),
+	}).Create(&model.UserPinnedBlog{UserID: userID, BlogID: blogID}).Error
+}
+
+// Unpin 取消置顶
+func (s *BlogService) Unpin(ctx context.Context, userID, blogID int64) error {
+	return s.db.WithContext(ctx).
+		Where("user_id = ? AND blog_id = ?", userID, blogID).
+		Delete(&model.UserPinnedBlog{}).Error
+}
+
+// PinnedBlog 查询用户的置顶笔记，未置顶或笔记已不可见时返回 nil
+func (s *BlogService) PinnedBlog(ctx context.Context, userID int64) (*model.Blog, error) {
+	var blog model.Blog
+	err := s.db.WithContext(ctx).
+		Joins("JOIN "+model.UserPinnedBlog{}.TableName()+" p ON p.blog_id = tb_blog.id AND p.user_id = tb_blog.user_id").
+		Where("p.user_id = ? AND tb_blog.status = ?", userID, model.BlogStatusPublished).
+		Take(&blog).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	blog.Pinned = true
+	return &blog, nil
 }
 
+// QueryHot 按热门榜分页查询笔记；榜单尚未生成时回退为按点赞数排序
 func (s *BlogService) QueryHot(ctx context.Context, page, size int) ([]model.Blog, error) {
-	var blogs []model.Blog
 	offset := (page - 1) * size
 	if offset < 0 {
 		offset = 0
 	}
-	err := s.db.WithContext(ctx).
-		Order("liked DESC").
-		Offset(offset).
-		Limit(size).
-		Find(&blogs).Error
-	return blogs, err
+	members, err := s.rdb.ZRevRange(ctx, utils.BLOG_HOT_KEY, int64(offset), int64(offset+size-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		count, err := s.rdb.ZCard(ctx, utils.BLOG_HOT_KEY).Result()
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return []model.Blog{}, nil
+		}
+		var blogs []model.Blog
+		err = s.db.WithContext(ctx).
+			Where("status = ?", model.BlogStatusPublished).
+			Order("liked DESC").
+			Offset(offset).
+			Limit(size).
+			Find(&blogs).Error
+		return blogs, err
+	}
+	ids := make([]int64, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.ParseInt(m, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	var blogs []model.Blog
+	if err := s.db.WithContext(ctx).
+		Where("id IN ? AND status = ?", ids, model.BlogStatusPublished).
+		Find(&blogs).Error; err != nil {
+		return nil, err
+	}
+	idIndex := make(map[int64]int, len(ids))
+	for i, id := range ids {
+		idIndex[id] = i
+	}
+	sort.Slice(blogs, func(i, j int) bool {
+		return idIndex[blogs[i].ID] < idIndex[blogs[j].ID]
+	})
+	return blogs, nil
+}
+
+// refreshHotLoop 启动时立即生成一次热门榜，此后定期刷新
+func (s *BlogService) refreshHotLoop(ctx context.Context) {
+	ticker := time.NewTicker(blogHotRefreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.refreshHot(ctx); err != nil {
+			s.log.Warn("refresh hot blogs failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshHot 重新计算时间窗口内已发布笔记的热度，取前 BLOG_HOT_MAX 篇写入临时 ZSet 后原子替换榜单
+func (s *BlogService) refreshHot(ctx context.Context) error {
+	now := time.Now()
+	var blogs []model.Blog
+	if err := s.db.WithContext(ctx).
+		Select("id", "liked", "comments", "create_time").
+		Where("status = ? AND create_time >= ?", model.BlogStatusPublished, now.Add(-blogHotWindow)).
+		Find(&blogs).Error; err != nil {
+		return err
+	}
+	if len(blogs) == 0 {
+		return s.rdb.Del(ctx, utils.BLOG_HOT_KEY).Err()
+	}
+	members := make([]redis.Z, 0, len(blogs))
+	for _, blog := range blogs {
+		members = append(members, redis.Z{Score: hotScore(blog.Liked, blog.Comments, blog.CreateTime, now), Member: blog.ID})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Score > members[j].Score })
+	if len(members) > utils.BLOG_HOT_MAX {
+		members = members[:utils.BLOG_HOT_MAX]
+	}
+	tmpKey := utils.BLOG_HOT_KEY + ":tmp"
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, tmpKey)
+		pipe.ZAdd(ctx, tmpKey, members...)
+		pipe.Rename(ctx, tmpKey, utils.BLOG_HOT_KEY)
+		return nil
+	})
+	return err
+}
+
+// hotScore 热度 = (点赞数 + 评论数×权重 + 1) × 0.5^(发布时长/半衰期)，越新的笔记衰减越少
+func hotScore(liked, comments int, createTime, now time.Time) float64 {
+	age := now.Sub(createTime)
+	if age < 0 {
+		age = 0
+	}
+	base := float64(liked + comments*blogHotCommentWeight + 1)
+	return base * math.Pow(0.5, age.Hours()/blogHotHalfLife.Hours())
+}
+
+// Share 为已发布的笔记生成分享 token（有效期 BLOG_SHARE_TTL 分钟），并返回累加后的分享次数
+func (s *BlogService) Share(ctx context.Context, blogID int64) (string, int64, error) {
+	blog, err := s.GetByID(ctx, blogID)
+	if err != nil {
+		return "", 0, err
+	}
+	if blog == nil || blog.Status != model.BlogStatusPublished {
+		return "", 0, errBlogNotFound
+	}
+	ttl := time.Duration(utils.BLOG_SHARE_TTL) * time.Minute
+	var token string
+	// token 冲突概率极低，SETNX 失败时重新生成
+	for i := 0; i < 3 && token == ""; i++ {
+		candidate := utils.RandomString(blogShareTokenLength)
+		ok, err := s.rdb.SetNX(ctx, utils.BLOG_SHARE_KEY+candidate, blogID, ttl).Result()
+		if err != nil {
+			return "", 0, err
+		}
+		if ok {
+			token = candidate
+		}
+	}
+	if token == "" {
+		return "", 0, errors.New("生成分享链接失败，请重试")
+	}
+	shares, err := s.rdb.HIncrBy(ctx, utils.BLOG_SHARE_COUNT_KEY, strconv.FormatInt(blogID, 10), 1).Result()
+	if err != nil {
+		return "", 0, err
+	}
+	return token, shares, nil
+}
+
+// ResolveShare 将分享 token 解析为笔记ID
+func (s *BlogService) ResolveShare(ctx context.Context, token string) (int64, error) {
+	if token == "" {
+		return 0, errShareNotFound
+	}
+	id, err := s.rdb.Get(ctx, utils.BLOG_SHARE_KEY+token).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, errShareNotFound
+	}
+	return id, err
+}
+
+// ShareCount 查询笔记的累计分享次数
+func (s *BlogService) ShareCount(ctx context.Context, blogID int64) (int64, error) {
+	n, err := s.rdb.HGet(ctx, utils.BLOG_SHARE_COUNT_KEY, strconv.FormatInt(blogID, 10)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return n, err
 }
 
 // ToggleLike 点赞/取消点赞；返回 true 表示点赞后状态
@@ -146,6 +908,108 @@ func (s *BlogService) IsLiked(ctx context.Context, blogID, userID int64) (bool,
 	return true, nil
 }
 
+// RecordView 记录一次浏览：增量累计在 Redis Hash 中，并把尚未刷库的增量计入 blog.Views
+func (s *BlogService) RecordView(ctx context.Context, blog *model.Blog) error {
+	field := strconv.FormatInt(blog.ID, 10)
+	var pending *redis.IntCmd
+	var flushing *redis.StringCmd
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pending = pipe.HIncrBy(ctx, utils.BLOG_VIEWS_KEY, field, 1)
+		flushing = pipe.HGet(ctx, utils.BLOG_VIEWS_FLUSH_KEY, field)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	blog.Views += pending.Val()
+	if n, convErr := flushing.Int64(); convErr == nil {
+		blog.Views += n
+	}
+	return nil
+}
+
+// flushViewsLoop 定期将 Redis 中累计的浏览量刷入 tb_blog.views
+func (s *BlogService) flushViewsLoop(ctx context.Context) {
+	ticker := time.NewTicker(blogViewsFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.flushViews(ctx); err != nil {
+				s.log.Warn("flush blog views failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// flushViews 先把累计 Hash 改名为待刷 Hash（RENAMENX 保证多实例下只有一个实例在刷），
+// 每刷入一篇笔记即删除对应字段，失败后下次重试不会重复累加
+func (s *BlogService) flushViews(ctx context.Context) error {
+	exists, err := s.rdb.Exists(ctx, utils.BLOG_VIEWS_FLUSH_KEY).Result()
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		pending, err := s.rdb.Exists(ctx, utils.BLOG_VIEWS_KEY).Result()
+		if err != nil || pending == 0 {
+			return err
+		}
+		ok, err := s.rdb.RenameNX(ctx, utils.BLOG_VIEWS_KEY, utils.BLOG_VIEWS_FLUSH_KEY).Result()
+		if err != nil || !ok {
+			return err
+		}
+	}
+	views, err := s.rdb.HGetAll(ctx, utils.BLOG_VIEWS_FLUSH_KEY).Result()
+	if err != nil {
+		return err
+	}
+	for field, raw := range views {
+		id, idErr := strconv.ParseInt(field, 10, 64)
+		n, nErr := strconv.ParseInt(raw, 10, 64)
+		if idErr == nil && nErr == nil && n > 0 {
+			if err := s.db.WithContext(ctx).Model(&model.Blog{}).
+				Where("id = ?", id).
+				UpdateColumn("views", gorm.Expr("views + ?", n)).Error; err != nil {
+				return err
+			}
+		}
+		if err := s.rdb.HDel(ctx, utils.BLOG_VIEWS_FLUSH_KEY, field).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsLikedBatch 批量判断用户是否点赞过多篇笔记，使用 Pipeline 一次往返完成所有 ZSCORE
+func (s *BlogService) IsLikedBatch(ctx context.Context, blogIDs []int64, userID int64) (map[int64]bool, error) {
+	res := make(map[int64]bool, len(blogIDs))
+	if len(blogIDs) == 0 {
+		return res, nil
+	}
+	member := fmt.Sprint(userID)
+	cmds := make([]*redis.FloatCmd, len(blogIDs))
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range blogIDs {
+			cmds[i] = pipe.ZScore(ctx, fmt.Sprintf("%s%d", utils.BLOG_LIKED_KEY, id), member)
+		}
+		return nil
+	})
+	// 未点赞的 ZSCORE 返回 redis.Nil，不视为错误
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	for i, id := range blogIDs {
+		cmdErr := cmds[i].Err()
+		if cmdErr != nil && !errors.Is(cmdErr, redis.Nil) {
+			return nil, cmdErr
+		}
+		res[id] = cmdErr == nil
+	}
+	return res, nil
+}
+
 // TopLikerIDs 返回最早点赞的前 N 个用户ID
 func (s *BlogService) TopLikerIDs(ctx context.Context, blogID int64, limit int64) ([]int64, error) {
 	key := fmt.Sprintf("%s%d", utils.BLOG_LIKED_KEY, blogID)
@@ -166,65 +1030,191 @@ func (s *BlogService) TopLikerIDs(ctx context.Context, blogID int64, limit int64
 	return ids, nil
 }
 
-// QueryFeed 滚动分页查询关注的笔记流
-// lastID 为上次查询的最小时间戳（初次可传 0），offset 处理同分数偏移
-func (s *BlogService) QueryFeed(ctx context.Context, userID int64, lastID int64, offset int64, limit int64) ([]model.Blog, int64, int64, error) {
-	key := fmt.Sprintf("%s%d", utils.FEED_KEY, userID)
+// QueryFeed 滚动分页查询关注的笔记流，返回本页笔记与下一页的游标
+// minTime 为上次查询的最小时间戳（初次可传 0），offset 处理同分数偏移
+func (s *BlogService) QueryFeed(ctx context.Context, userID int64, minTime int64, offset int64, limit int64) ([]model.Blog, dto.ScrollResult, error) {
 	// +inf 是Redis有序集合按分数查询时的正无穷
 	max := "+inf"
-	if lastID > 0 {
-		max = fmt.Sprintf("%d", lastID)
-	}
-	// 按分数降序取区间并且返回分数
-	zs, err := s.rdb.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
-		Min:    "-inf",
-		Max:    max,
-		Offset: offset,
-		Count:  limit,
-	}).Result()
+	if minTime > 0 {
+		max = fmt.Sprintf("%d", minTime)
+	}
+	// 读取关注流视为活跃，续期收件箱
+	_ = s.rdb.Expire(ctx, fmt.Sprintf("%s%d", utils.FEED_KEY, userID), s.feedCfg.InboxTTL).Err()
+	zs, err := s.feedCandidates(ctx, userID, max, offset, limit)
 	if err != nil {
-		return nil, 0, 0, err
+		return nil, dto.ScrollResult{}, err
 	}
 	if len(zs) == 0 {
-		return nil, 0, 0, nil
+		return nil, dto.ScrollResult{MinTime: minTime, Offset: offset}, nil
 	}
-	var (
-		ids        []int64
-		nextLast   int64
-		nextOffset int64
-	)
+	var ids []int64
 	for _, z := range zs {
 		if id, err := strconv.ParseInt(fmt.Sprint(z.Member), 10, 64); err == nil {
 			ids = append(ids, id)
 		}
 	}
-	// 计算下一次的 lastID 与 offset（处理同分数情况）
-	lastScore := int64(zs[len(zs)-1].Score)
-	nextLast = lastScore
-	nextOffset = 0
+	// 计算下一次的 minTime 与 offset（处理同分数情况）
+	next := dto.ScrollResult{
+		MinTime: int64(zs[len(zs)-1].Score),
+		HasMore: int64(len(zs)) == limit,
+	}
 	for i := len(zs) - 1; i >= 0; i-- {
-		if int64(zs[i].Score) == lastScore {
-			nextOffset++
+		if int64(zs[i].Score) == next.MinTime {
+			next.Offset++
 		}
 	}
+	// 整页都与上次的最小时间戳同分数时，需要累加上次的偏移量
+	if minTime > 0 && next.MinTime == minTime {
+		next.Offset += offset
+	}
 
 	// 按查询顺序返回博客列表
-	// SELECT ... WHERE id IN (...)  不保证返回顺序，可能乱序
+	// SELECT ... WHERE id IN (...)  不保证返回顺序，需按 ids 顺序排序
 	var blogs []model.Blog
 	if err := s.db.WithContext(ctx).
-		Where("id IN ?", ids).
+		Where("id IN ? AND status = ?", ids, model.BlogStatusPublished).
+		Order(orderByIDs(ids)).
 		Find(&blogs).Error; err != nil {
-		return nil, 0, 0, err
+		return nil, dto.ScrollResult{}, err
 	}
-	// 按 ids 顺序排序
-	idIndex := make(map[int64]int)
-	for i, id := range ids {
-		idIndex[id] = i
+
+	return blogs, next, nil
+}
+
+// feedCandidates 按分数降序取关注流的一页：收件箱（推模式）与已关注的拉模式作者时间线合并
+func (s *BlogService) feedCandidates(ctx context.Context, userID int64, max string, offset, limit int64) ([]redis.Z, error) {
+	inboxKey := fmt.Sprintf("%s%d", utils.FEED_KEY, userID)
+	authors, err := s.rdb.SInter(ctx, followKey(userID), utils.FEED_PULL_AUTHORS).Result()
+	if err != nil {
+		return nil, err
 	}
-	// 按idIndex映射的顺序，把blogs排好
-	sort.Slice(blogs, func(i, j int) bool {
-		return idIndex[blogs[i].ID] < idIndex[blogs[j].ID]
+	if len(authors) == 0 {
+		// 只有收件箱时直接在 Redis 中分页
+		return s.rdb.ZRevRangeByScoreWithScores(ctx, inboxKey, &redis.ZRangeBy{
+			Min:    "-inf",
+			Max:    max,
+			Offset: offset,
+			Count:  limit,
+		}).Result()
+	}
+	keys := make([]string, 0, len(authors)+1)
+	keys = append(keys, inboxKey)
+	for _, author := range authors {
+		keys = append(keys, utils.FEED_TIMELINE_KEY+author)
+	}
+	// 每个来源各取 offset+limit 条，合并排序后再截取当前页
+	cmds := make([]*redis.ZSliceCmd, 0, len(keys))
+	if _, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			cmds = append(cmds, pipe.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+				Min:   "-inf",
+				Max:   max,
+				Count: offset + limit,
+			}))
+		}
+		return nil
+	}); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	var merged []redis.Z
+	for _, cmd := range cmds {
+		merged = append(merged, cmd.Val()...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Score > merged[j].Score
 	})
+	if int64(len(merged)) <= offset {
+		return nil, nil
+	}
+	merged = merged[offset:]
+	if int64(len(merged)) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// QueryNearby 查询指定坐标附近的笔记，按距离升序分页
+// x、y 为用户经纬度，radius 为搜索半径（米），与店铺 GEO 查询保持一致的分页方式
+func (s *BlogService) QueryNearby(ctx context.Context, x, y, radius float64, page, size int) ([]model.Blog, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.DEFAULT_PAGE_SIZE
+	}
+	start := (page - 1) * size
+	end := page * size
+
+	locs, err := s.rdb.GeoSearchLocation(ctx, utils.BLOG_GEO_KEY, &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{
+			Longitude:  x,
+			Latitude:   y,
+			Radius:     radius,
+			RadiusUnit: "m",
+			Sort:       "ASC",
+			Count:      end,
+		},
+		WithDist: true,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(locs) <= start {
+		return []model.Blog{}, nil
+	}
+	if len(locs) > end {
+		locs = locs[:end]
+	}
+	locs = locs[start:]
+
+	ids := make([]int64, 0, len(locs))
+	for _, loc := range locs {
+		id, parseErr := strconv.ParseInt(loc.Name, 10, 64)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		ids = append(ids, id)
+	}
+	var blogs []model.Blog
+	if err := s.db.WithContext(ctx).Where("id IN ? AND status = ?", ids, model.BlogStatusPublished).Find(&blogs).Error; err != nil {
+		return nil, err
+	}
+	blogMap := make(map[int64]model.Blog, len(blogs))
+	for _, blog := range blogs {
+		blogMap[blog.ID] = blog
+	}
+
+	// 按 GEO 结果的顺序输出，并附上距离
+	res := make([]model.Blog, 0, len(ids))
+	for _, loc := range locs {
+		id, _ := strconv.ParseInt(loc.Name, 10, 64)
+		if blog, ok := blogMap[id]; ok {
+			dist := loc.Dist
+			blog.Distance = &dist
+			res = append(res, blog)
+		}
+	}
+	return res, nil
+}
+
+// fillBlogLocation 未显式指定坐标时，使用关联店铺的坐标
+func (s *BlogService) fillBlogLocation(ctx context.Context, blog *model.Blog) error {
+	if hasLocation(blog.X, blog.Y) || blog.ShopID <= 0 {
+		return nil
+	}
+	var shop model.Shop
+	err := s.db.WithContext(ctx).Select("x", "y").Where("id = ?", blog.ShopID).Take(&shop).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	blog.X, blog.Y = shop.X, shop.Y
+	return nil
+}
 
-	return blogs, nextLast, nextOffset, nil
+// hasLocation 经纬度均为 0 视为未设置坐标
+func hasLocation(x, y float64) bool {
+	return x != 0 || y != 0
 }
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// 活动状态：1上架 2下架
+const (
+	CampaignStatusOnline  = 1
+	CampaignStatusOffline = 2
+)
+
+var errCampaignNotFound = errors.New("活动不存在")
+
+// CampaignForm 创建活动的请求参数
+type CampaignForm struct {
+	Name       string    `json:"name"`
+	Banner     string    `json:"banner"`
+	BeginTime  time.Time `json:"beginTime"`
+	EndTime    time.Time `json:"endTime"`
+	VoucherIDs []int64   `json:"voucherIds"`
+}
+
+// CampaignView 活动及其包含的秒杀券，库存为 Redis 中的实时库存
+type CampaignView struct {
+	model.Campaign
+	Vouchers       []VoucherWithSeckill `json:"vouchers"`
+	RemainingStock int64                `json:"remainingStock"`
+}
+
+// CampaignVoucherStats 单张秒杀券的库存与销量
+type CampaignVoucherStats struct {
+	VoucherID      int64 `json:"voucherId"`
+	RemainingStock int64 `json:"remainingStock"`
+	SoldCount      int64 `json:"soldCount"`
+}
+
+// CampaignStats 活动维度的库存与销量汇总
+type CampaignStats struct {
+	CampaignID     int64                  `json:"campaignId"`
+	RemainingStock int64                  `json:"remainingStock"`
+	SoldCount      int64                  `json:"soldCount"`
+	Vouchers       []CampaignVoucherStats `json:"vouchers"`
+}
+
+// CampaignService 管理聚合多张秒杀券的营销活动
+type CampaignService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewCampaignService 创建 CampaignService 实例
+func NewCampaignService(db *gorm.DB, rdb *redis.Client) *CampaignService {
+	return &CampaignService{db: db, rdb: rdb}
+}
+
+// Create 创建活动并关联秒杀券，关联的券必须都是秒杀券
+func (s *CampaignService) Create(ctx context.Context, form CampaignForm) (*model.Campaign, error) {
+	form.Name = strings.TrimSpace(form.Name)
+	if form.Name == "" {
+		return nil, errors.New("活动名称不能为空")
+	}
+	if form.BeginTime.IsZero() || !form.EndTime.After(form.BeginTime) {
+		return nil, errors.New("活动时间不合法")
+	}
+	if len(form.VoucherIDs) == 0 {
+		return nil, errors.New("活动至少包含一张秒杀券")
+	}
+	campaign := &model.Campaign{
+		Name:      form.Name,
+		Banner:    form.Banner,
+		BeginTime: form.BeginTime,
+		EndTime:   form.EndTime,
+		Status:    CampaignStatusOnline,
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.SeckillVoucher{}).Where("voucher_id IN ?", form.VoucherIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != int64(len(form.VoucherIDs)) {
+			return errors.New("存在非秒杀券或重复的优惠券")
+		}
+		if err := tx.Create(campaign).Error; err != nil {
+			return err
+		}
+		links := make([]model.CampaignVoucher, 0, len(form.VoucherIDs))
+		for i, vid := range form.VoucherIDs {
+			links = append(links, model.CampaignVoucher{CampaignID: campaign.ID, VoucherID: vid, Sort: i})
+		}
+		return tx.Create(&links).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.rdb.Del(ctx, utils.CACHE_CAMPAIGN_KEY)
+	return campaign, nil
+}
+
+// ListActive 查询进行中的活动：活动与券信息走缓存，库存实时读取 Redis
+func (s *CampaignService) ListActive(ctx context.Context) ([]CampaignView, error) {
+	views, err := s.loadActiveCampaigns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range views {
+		ids := make([]int64, 0, len(views[i].Vouchers))
+		for _, v := range views[i].Vouchers {
+			ids = append(ids, v.ID)
+		}
+		stocks, err := s.liveStocks(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		views[i].RemainingStock = 0
+		for j := range views[i].Vouchers {
+			if stock, ok := stocks[views[i].Vouchers[j].ID]; ok {
+				v := int(stock)
+				views[i].Vouchers[j].Stock = &v
+			}
+			if views[i].Vouchers[j].Stock != nil {
+				views[i].RemainingStock += int64(*views[i].Vouchers[j].Stock)
+			}
+		}
+	}
+	return views, nil
+}
+
+// Stats 汇总活动内各秒杀券的剩余库存与有效订单数
+func (s *CampaignService) Stats(ctx context.Context, campaignID int64) (*CampaignStats, error) {
+	var campaign model.Campaign
+	err := s.db.WithContext(ctx).Select("id").Take(&campaign, campaignID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errCampaignNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	if err := s.db.WithContext(ctx).Model(&model.CampaignVoucher{}).
+		Where("campaign_id = ?", campaignID).
+		Order("sort ASC").
+		Pluck("voucher_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	stats := &CampaignStats{CampaignID: campaignID, Vouchers: make([]CampaignVoucherStats, 0, len(ids))}
+	if len(ids) == 0 {
+		return stats, nil
+	}
+	stocks, err := s.liveStocks(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	// 已取消、已退款的订单不计入销量
+	var rows []struct {
+		VoucherID int64
+		Cnt       int64
+	}
+	if err := s.db.WithContext(ctx).Model(&model.VoucherOrder{}).
+		Select("voucher_id, COUNT(*) AS cnt").
+		Where("voucher_id IN ? AND status NOT IN ?", ids, []int{model.OrderStatusCancelled, model.OrderStatusRefunded}).
+		Group("voucher_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	sold := make(map[int64]int64, len(rows))
+	for _, r := range rows {
+		sold[r.VoucherID] = r.Cnt
+	}
+	for _, id := range ids {
+		item := CampaignVoucherStats{VoucherID: id, RemainingStock: stocks[id], SoldCount: sold[id]}
+		stats.RemainingStock += item.RemainingStock
+		stats.SoldCount += item.SoldCount
+		stats.Vouchers = append(stats.Vouchers, item)
+	}
+	return stats, nil
+}
+
+// loadActiveCampaigns 读取进行中的活动列表，缓存未命中时回源数据库
+func (s *CampaignService) loadActiveCampaigns(ctx context.Context) ([]CampaignView, error) {
+	cached, err := s.rdb.Get(ctx, utils.CACHE_CAMPAIGN_KEY).Result()
+	if err == nil {
+		var views []CampaignView
+		if unmarshalErr := json.Unmarshal([]byte(cached), &views); unmarshalErr == nil {
+			return views, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	now := time.Now()
+	var campaigns []model.Campaign
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND begin_time <= ? AND end_time > ?", CampaignStatusOnline, now, now).
+		Order("begin_time ASC").
+		Find(&campaigns).Error; err != nil {
+		return nil, err
+	}
+	views := make([]CampaignView, 0, len(campaigns))
+	for _, c := range campaigns {
+		var vouchers []VoucherWithSeckill
+		query := `
+        SELECT v.id, v.shop_id, v.title, v.sub_title, v.rules, v.pay_value,
+               v.actual_value, v.type, v.status, v.min_spend, v.weekdays, v.applicable_shop_ids,
+               v.create_time, v.update_time,
+               sv.stock, sv.begin_time, sv.end_time
+        FROM tb_campaign_voucher cv
+        JOIN tb_voucher v ON v.id = cv.voucher_id
+        JOIN tb_seckill_voucher sv ON v.id = sv.voucher_id
+        WHERE cv.campaign_id = ? AND v.status = 1
+        ORDER BY cv.sort ASC`
+		if err := s.db.WithContext(ctx).Raw(query, c.ID).Scan(&vouchers).Error; err != nil {
+			return nil, err
+		}
+		views = append(views, CampaignView{Campaign: c, Vouchers: vouchers})
+	}
+
+	data, err := json.Marshal(views)
+	if err != nil {
+		return nil, err
+	}
+	_ = s.rdb.Set(ctx, utils.CACHE_CAMPAIGN_KEY, data, s.activeCacheTTL(ctx, now, campaigns)).Err()
+	return views, nil
+}
+
+// activeCacheTTL 缓存时间不超过最近一个活动的开始或结束时刻，避免活动上下线延迟
+func (s *CampaignService) activeCacheTTL(ctx context.Context, now time.Time, active []model.Campaign) time.Duration {
+	ttl := time.Duration(utils.CACHE_CAMPAIGN_TTL) * time.Minute
+	for _, c := range active {
+		if d := c.EndTime.Sub(now); d < ttl {
+			ttl = d
+		}
+	}
+	var next model.Campaign
+	err := s.db.WithContext(ctx).Select("begin_time").
+		Where("status = ? AND begin_time > ?", CampaignStatusOnline, now).
+		Order("begin_time ASC").
+		Take(&next).Error
+	if err == nil {
+		if d := next.BeginTime.Sub(now); d < ttl {
+			ttl = d
+		}
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	return ttl
+}
+
+// liveStocks 批量读取秒杀券在 Redis 中的实时库存
+func (s *CampaignService) liveStocks(ctx context.Context, voucherIDs []int64) (map[int64]int64, error) {
+	res := make(map[int64]int64, len(voucherIDs))
+	if len(voucherIDs) == 0 {
+		return res, nil
+	}
+	keys := make([]string, 0, len(voucherIDs))
+	for _, id := range voucherIDs {
+		keys = append(keys, fmt.Sprintf(stockKeyFmt, id))
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if stock, err := strconv.ParseInt(str, 10, 64); err == nil {
+			res[voucherIDs[i]] = stock
+		}
+	}
+	return res, nil
+}
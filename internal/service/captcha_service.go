@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/mojocn/base64Captcha"
+	"github.com/redis/go-redis/v9"
+
+	"hmdp-backend/internal/utils"
+)
+
+// CaptchaResult 图形验证码，Image 为 base64 编码的 data URI
+type CaptchaResult struct {
+	CaptchaID string `json:"captchaId"`
+	Image     string `json:"image"`
+}
+
+// CaptchaService 生成与校验图形验证码，答案保存在 Redis 中，多实例共享
+type CaptchaService struct {
+	captcha *base64Captcha.Captcha
+}
+
+// NewCaptchaService 创建 CaptchaService 实例
+func NewCaptchaService(rdb *redis.Client) *CaptchaService {
+	driver := base64Captcha.NewDriverDigit(80, 240, 5, 0.7, 80)
+	store := &redisCaptchaStore{rdb: rdb, ttl: time.Duration(utils.CAPTCHA_TTL) * time.Minute}
+	return &CaptchaService{captcha: base64Captcha.NewCaptcha(driver, store)}
+}
+
+// Generate 生成新的图形验证码
+func (s *CaptchaService) Generate() (*CaptchaResult, error) {
+	id, image, _, err := s.captcha.Generate()
+	if err != nil {
+		return nil, err
+	}
+	return &CaptchaResult{CaptchaID: id, Image: image}, nil
+}
+
+// Verify 校验图形验证码，无论成功与否都会失效，防止暴力尝试
+func (s *CaptchaService) Verify(id, answer string) bool {
+	if id == "" || answer == "" {
+		return false
+	}
+	return s.captcha.Verify(id, strings.TrimSpace(answer), true)
+}
+
+// redisCaptchaStore 基于 Redis 的 base64Captcha.Store 实现
+type redisCaptchaStore struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+func (s *redisCaptchaStore) Set(id string, value string) error {
+	return s.rdb.Set(context.Background(), utils.CAPTCHA_KEY+id, value, s.ttl).Err()
+}
+
+func (s *redisCaptchaStore) Get(id string, clear bool) string {
+	ctx := context.Background()
+	key := utils.CAPTCHA_KEY + id
+	if clear {
+		val, _ := s.rdb.GetDel(ctx, key).Result()
+		return val
+	}
+	val, _ := s.rdb.Get(ctx, key).Result()
+	return val
+}
+
+func (s *redisCaptchaStore) Verify(id, answer string, clear bool) bool {
+	val := s.Get(id, clear)
+	return val != "" && val == answer
+}
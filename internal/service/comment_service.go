@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const commentMaxLength = 500
+
+var (
+	errCommentEmpty    = errors.New("评论内容不能为空")
+	errCommentTooLong  = errors.New("评论内容过长")
+	errCommentNotFound = errors.New("评论不存在")
+	errBlogNotFound    = errors.New("笔记不存在")
+)
+
+// CommentService 处理笔记评论，评论数同步维护在 tb_blog.comments
+type CommentService struct {
+	db        *gorm.DB
+	sensitive *SensitiveWordService
+}
+
+// NewCommentService 创建 CommentService 实例
+func NewCommentService(db *gorm.DB, sensitive *SensitiveWordService) *CommentService {
+	return &CommentService{db: db, sensitive: sensitive}
+}
+
+// Create 发表评论或回复，回复只挂在一级评论下
+func (s *CommentService) Create(ctx context.Context, comment *model.BlogComment) error {
+	comment.Content = utils.SanitizePlainText(comment.Content)
+	if comment.Content == "" {
+		return errCommentEmpty
+	}
+	if utf8.RuneCountInString(comment.Content) > commentMaxLength {
+		return errCommentTooLong
+	}
+	if s.sensitive != nil {
+		var err error
+		if comment.Content, err = s.sensitive.Check(comment.Content); err != nil {
+			return err
+		}
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Blog{}).Where("id = ? AND status = ?", comment.BlogID, model.BlogStatusPublished).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errBlogNotFound
+		}
+		if comment.ParentID > 0 {
+			var parent model.BlogComment
+			err := tx.Where("id = ? AND blog_id = ?", comment.ParentID, comment.BlogID).Take(&parent).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errCommentNotFound
+			}
+			if err != nil {
+				return err
+			}
+			// 回复的回复统一归到一级评论下
+			if parent.ParentID > 0 {
+				comment.ParentID = parent.ParentID
+			}
+			if comment.AnswerID == 0 {
+				comment.AnswerID = parent.UserID
+			}
+		}
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Blog{}).
+			Where("id = ?", comment.BlogID).
+			UpdateColumn("comments", gorm.Expr("comments + 1")).Error
+	})
+}
+
+// List 分页查询笔记的评论（含回复），按发表时间正序，附带评论者信息
+func (s *CommentService) List(ctx context.Context, blogID int64, page, size int) ([]model.BlogComment, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.MAX_PAGE_SIZE
+	}
+	var comments []model.BlogComment
+	if err := s.db.WithContext(ctx).
+		Where("blog_id = ? AND status = ?", blogID, model.CommentStatusNormal).
+		Order("id ASC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return comments, nil
+	}
+	userIDs := make([]int64, 0, len(comments))
+	for _, c := range comments {
+		userIDs = append(userIDs, c.UserID)
+	}
+	var users []model.User
+	if err := s.db.WithContext(ctx).Select("id", "nick_name", "icon").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*model.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	for i := range comments {
+		if u, ok := byID[comments[i].UserID]; ok {
+			comments[i].Name = u.NickName
+			comments[i].Icon = u.Icon
+		}
+	}
+	return comments, nil
+}
+
+// Delete 删除评论，评论者与笔记作者均可删除；删除一级评论时一并删除其回复
+func (s *CommentService) Delete(ctx context.Context, userID, commentID int64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var comment model.BlogComment
+		err := tx.First(&comment, commentID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errCommentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if comment.UserID != userID {
+			var blog model.Blog
+			if err := tx.Select("id", "user_id").First(&blog, comment.BlogID).Error; err != nil {
+				return err
+			}
+			if blog.UserID != userID {
+				return errCommentNotFound
+			}
+		}
+		// 被屏蔽的评论已从评论数中扣除，只扣减仍可见的部分
+		var visible int64
+		if err := tx.Model(&model.BlogComment{}).
+			Where("(id = ? OR parent_id = ?) AND status = ?", commentID, commentID, model.CommentStatusNormal).
+			Count(&visible).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? OR parent_id = ?", commentID, commentID).Delete(&model.BlogComment{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Blog{}).
+			Where("id = ?", comment.BlogID).
+			UpdateColumn("comments", gorm.Expr("GREATEST(comments - ?, 0)", visible)).Error
+	})
+}
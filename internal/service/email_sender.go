@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	emailWorkers   = 2
+	emailQueueSize = 256
+)
+
+// orderPaidEmail 订单支付成功邮件，Data 字段见 PaymentService.notifyPaid
+var orderPaidEmail = utils.MustEmailTemplate("order_paid",
+	`【黑马点评】订单 {{index .Data "orderId"}} 支付成功`,
+	`{{.NickName}}，您好：
+
+您的订单已支付成功。
+订单号：{{index .Data "orderId"}}
+优惠券：{{index .Data "voucherTitle"}}
+实付金额：{{index .Data "payAmount"}} 元
+到店核销码：{{index .Data "redeemCode"}}
+
+请在到店消费时出示核销码。
+`,
+	`<!DOCTYPE html>
+<html><body style="font-family:sans-serif;color:#333">
+<p>{{.NickName}}，您好：</p>
+<p>您的订单已支付成功。</p>
+<table style="border-collapse:collapse">
+<tr><td style="padding:4px 12px 4px 0;color:#999">订单号</td><td>{{index .Data "orderId"}}</td></tr>
+<tr><td style="padding:4px 12px 4px 0;color:#999">优惠券</td><td>{{index .Data "voucherTitle"}}</td></tr>
+<tr><td style="padding:4px 12px 4px 0;color:#999">实付金额</td><td>{{index .Data "payAmount"}} 元</td></tr>
+<tr><td style="padding:4px 12px 4px 0;color:#999">到店核销码</td><td style="font-size:20px;font-weight:bold;letter-spacing:2px">{{index .Data "redeemCode"}}</td></tr>
+</table>
+<p style="color:#999">请在到店消费时出示核销码。</p>
+</body></html>`,
+)
+
+// defaultEmail 未配置专用模板的通知类型使用的通用邮件
+var defaultEmail = utils.MustEmailTemplate("default",
+	`【黑马点评】{{.Title}}`,
+	`{{.NickName}}，您好：
+
+{{.Content}}
+`,
+	`<!DOCTYPE html>
+<html><body style="font-family:sans-serif;color:#333">
+<p>{{.NickName}}，您好：</p>
+<p>{{.Content}}</p>
+</body></html>`,
+)
+
+// emailData 渲染邮件模板的数据
+type emailData struct {
+	Notification
+	NickName string
+}
+
+// EmailSender 邮件渠道的通知投递：按通知类型选择模板渲染后交给异步发送器，不等待 SMTP 结果
+type EmailSender struct {
+	db         *gorm.DB
+	dispatcher *utils.EmailDispatcher
+	templates  map[string]*utils.EmailTemplate
+	log        *zap.Logger
+}
+
+// NewEmailSender 创建 EmailSender 实例并启动后台发送协程
+func NewEmailSender(db *gorm.DB, smtpCfg utils.SMTPConfig, log *zap.Logger) *EmailSender {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	s := &EmailSender{
+		db: db,
+		templates: map[string]*utils.EmailTemplate{
+			NotificationTypeOrderPaid: orderPaidEmail,
+		},
+		log: log,
+	}
+	s.dispatcher = utils.NewEmailDispatcher(smtpCfg, emailWorkers, emailQueueSize, func(mail utils.EmailMessage, err error) {
+		log.Warn("send email failed", zap.String("to", mail.To), zap.String("subject", mail.Subject), zap.Error(err))
+	})
+	return s
+}
+
+// Deliver 渲染通知邮件并入队；用户未绑定邮箱时跳过
+func (s *EmailSender) Deliver(ctx context.Context, userID int64, n Notification) error {
+	var user model.User
+	if err := s.db.WithContext(ctx).Select("id", "email", "nick_name").Take(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return nil
+	}
+	tpl, ok := s.templates[n.Type]
+	if !ok {
+		tpl = defaultEmail
+	}
+	mail, err := tpl.Render(user.Email, emailData{Notification: n, NickName: user.NickName})
+	if err != nil {
+		return err
+	}
+	return s.dispatcher.Send(mail)
+}
+
+// Shutdown 停止接收新邮件并等待队列中的邮件发送完毕，ctx 到期时不再等待
+func (s *EmailSender) Shutdown(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		s.dispatcher.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	collectionNameMaxLength = 20
+	collectionMaxCount      = 50
+)
+
+var (
+	errCollectionNotFound = errors.New("收藏夹不存在")
+	errCollectionName     = errors.New("收藏夹名称不能为空且不超过20个字")
+	errCollectionExists   = errors.New("收藏夹名称已存在")
+	errCollectionLimit    = errors.New("收藏夹数量已达上限")
+)
+
+// FavoriteService 笔记收藏：收藏记录落库，每个用户的已收藏笔记ID缓存在 Redis Set 中用于快速判断
+type FavoriteService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewFavoriteService 创建 FavoriteService 实例
+func NewFavoriteService(db *gorm.DB, rdb *redis.Client) *FavoriteService {
+	return &FavoriteService{db: db, rdb: rdb}
+}
+
+// CreateCollection 新建收藏夹，同一用户下名称不可重复
+func (s *FavoriteService) CreateCollection(ctx context.Context, userID int64, name string) (*model.BlogCollection, error) {
+	name = strings.TrimSpace(utils.SanitizePlainText(name))
+	if name == "" || utf8.RuneCountInString(name) > collectionNameMaxLength {
+		return nil, errCollectionName
+	}
+	var collection *model.BlogCollection
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var names []string
+		if err := tx.Model(&model.BlogCollection{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			Pluck("name", &names).Error; err != nil {
+			return err
+		}
+		if len(names) >= collectionMaxCount {
+			return errCollectionLimit
+		}
+		for _, n := range names {
+			if n == name {
+				return errCollectionExists
+			}
+		}
+		collection = &model.BlogCollection{UserID: userID, Name: name}
+		return tx.Create(collection).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// ListCollections 查询用户的全部收藏夹
+func (s *FavoriteService) ListCollections(ctx context.Context, userID int64) ([]model.BlogCollection, error) {
+	var collections []model.BlogCollection
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("id ASC").Find(&collections).Error
+	return collections, err
+}
+
+// DeleteCollection 删除收藏夹及其中的收藏记录
+func (s *FavoriteService) DeleteCollection(ctx context.Context, userID, collectionID int64) error {
+	var blogIDs []int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND user_id = ?", collectionID, userID).Delete(&model.BlogCollection{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errCollectionNotFound
+		}
+		if err := tx.Model(&model.BlogFavorite{}).
+			Where("user_id = ? AND collection_id = ?", userID, collectionID).
+			Pluck("blog_id", &blogIDs).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND collection_id = ?", userID, collectionID).Delete(&model.BlogFavorite{}).Error
+	})
+	if err != nil || len(blogIDs) == 0 {
+		return err
+	}
+	members := make([]interface{}, 0, len(blogIDs))
+	for _, id := range blogIDs {
+		members = append(members, id)
+	}
+	return s.rdb.SRem(ctx, favoriteKey(userID), members...).Err()
+}
+
+// Add 收藏笔记到指定收藏夹（collectionID 为 0 时放入默认收藏夹）；已收藏时移动到新收藏夹
+func (s *FavoriteService) Add(ctx context.Context, userID, blogID, collectionID int64) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Blog{}).
+			Where("id = ? AND status = ?", blogID, model.BlogStatusPublished).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errBlogNotFound
+		}
+		if collectionID > 0 {
+			if err := tx.Model(&model.BlogCollection{}).
+				Where("id = ? AND user_id = ?", collectionID, userID).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return errCollectionNotFound
+			}
+		}
+		favorite := &model.BlogFavorite{UserID: userID, BlogID: blogID, CollectionID: collectionID}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "blog_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"collection_id"}),
+		}).Create(favorite).Error
+	})
+	if err != nil {
+		return err
+	}
+	// 删除集合而非 SADD：集合未加载时直接 SADD 会得到不完整的集合，下次判断时再从数据库重建
+	return s.rdb.Del(ctx, favoriteKey(userID)).Err()
+}
+
+// Remove 取消收藏
+func (s *FavoriteService) Remove(ctx context.Context, userID, blogID int64) error {
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND blog_id = ?", userID, blogID).
+		Delete(&model.BlogFavorite{}).Error; err != nil {
+		return err
+	}
+	return s.rdb.SRem(ctx, favoriteKey(userID), blogID).Err()
+}
+
+// IsFavorited 判断用户是否收藏了笔记；Redis 集合不存在时从数据库重建
+func (s *FavoriteService) IsFavorited(ctx context.Context, userID, blogID int64) (bool, error) {
+	key := favoriteKey(userID)
+	exists, err := s.rdb.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if exists > 0 {
+		return s.rdb.SIsMember(ctx, key, blogID).Result()
+	}
+	var blogIDs []int64
+	if err := s.db.WithContext(ctx).Model(&model.BlogFavorite{}).
+		Where("user_id = ?", userID).
+		Pluck("blog_id", &blogIDs).Error; err != nil {
+		return false, err
+	}
+	if len(blogIDs) == 0 {
+		return false, nil
+	}
+	members := make([]interface{}, 0, len(blogIDs))
+	found := false
+	for _, id := range blogIDs {
+		members = append(members, id)
+		found = found || id == blogID
+	}
+	if err := s.rdb.SAdd(ctx, key, members...).Err(); err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// List 分页查询用户收藏的笔记，最近收藏的在前；collectionID 小于 0 时查询全部收藏夹
+func (s *FavoriteService) List(ctx context.Context, userID, collectionID int64, page, size int) ([]model.Blog, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.MAX_PAGE_SIZE
+	}
+	query := s.db.WithContext(ctx).
+		Joins("JOIN "+model.BlogFavorite{}.TableName()+" f ON f.blog_id = tb_blog.id").
+		Where("f.user_id = ? AND tb_blog.status = ?", userID, model.BlogStatusPublished)
+	if collectionID >= 0 {
+		query = query.Where("f.collection_id = ?", collectionID)
+	}
+	var blogs []model.Blog
+	err := query.Order("f.id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&blogs).Error
+	return blogs, err
+}
+
+func favoriteKey(userID int64) string {
+	return utils.BLOG_FAVORITE_KEY + strconv.FormatInt(userID, 10)
+}
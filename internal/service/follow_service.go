@@ -13,12 +13,13 @@ import (
 
 // FollowService 关注相关业务
 type FollowService struct {
-	db  *gorm.DB
-	rdb *redis.Client
+	db      *gorm.DB
+	rdb     *redis.Client
+	privacy *PrivacyService
 }
 
-func NewFollowService(db *gorm.DB, rdb *redis.Client) *FollowService {
-	return &FollowService{db: db, rdb: rdb}
+func NewFollowService(db *gorm.DB, rdb *redis.Client, privacy *PrivacyService) *FollowService {
+	return &FollowService{db: db, rdb: rdb, privacy: privacy}
 }
 
 // Follow 关注或取关 targetID
@@ -69,11 +70,30 @@ func (s *FollowService) FollowerIDs(ctx context.Context, targetID int64) ([]int6
 	return ids, nil
 }
 
-// CommonFollowIDs 求 userID 与 targetID 的共同关注用户ID列表（Redis SINTER）
+// FollowerCount 统计 targetID 的粉丝数
+func (s *FollowService) FollowerCount(ctx context.Context, targetID int64) (int64, error) {
+	var count int64
+	err := s.db.WithContext(ctx).
+		Model(&model.Follow{}).
+		Where("follow_user_id = ?", targetID).
+		Count(&count).Error
+	return count, err
+}
+
+// CommonFollowIDs 求 userID 与 targetID 的共同关注用户ID列表（Redis SINTER），目标用户隐藏关注列表时返回空
 func (s *FollowService) CommonFollowIDs(ctx context.Context, userID, targetID int64) ([]int64, error) {
 	if userID == targetID {
 		return nil, nil
 	}
+	if s.privacy != nil {
+		privacy, err := s.privacy.Get(ctx, targetID)
+		if err != nil {
+			return nil, err
+		}
+		if privacy.HideFollows {
+			return nil, nil
+		}
+	}
 	res, err := s.rdb.SInter(ctx, followKey(userID), followKey(targetID)).Result()
 	if err != nil {
 		return nil, err
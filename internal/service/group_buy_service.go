@@ -0,0 +1,573 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	// groupBuyMaxSize 拼团人数上限
+	groupBuyMaxSize       = 20
+	defaultGroupBuyWindow = 24 * time.Hour
+	// groupBuyExpirePoll 扫描超时拼团的周期
+	groupBuyExpirePoll = 5 * time.Second
+	// groupBuyExpireBatch 单次扫描最多处理的超时拼团数
+	groupBuyExpireBatch = 100
+	// groupBuyRetryDelay 退款失败时重新入队的延迟
+	groupBuyRetryDelay = time.Minute
+	// groupBuyListMax 可参团列表的最大条数
+	groupBuyListMax = 20
+)
+
+var (
+	errGroupBuyNotFound    = errors.New("拼团不存在")
+	errGroupBuyUnsupported = errors.New("该优惠券不支持拼团")
+	errGroupBuyClosed      = errors.New("拼团已结束")
+	errGroupBuyFull        = errors.New("拼团人数已满")
+	errGroupBuyJoined      = errors.New("您已参加该拼团")
+	errGroupBuyForming     = errors.New("拼团尚未成团")
+)
+
+// groupJoinScript 预占拼团名额：校验拼团状态与截止时间、重复参团与剩余名额，通过后记录参团用户。
+// 返回 0 成功，1 人数已满，2 已参团，3 拼团已结束，5 状态未缓存（需回源数据库）
+var groupJoinScript = redis.NewScript(`
+local state = redis.call('HMGET', KEYS[1], 'status', 'size', 'joined', 'expire')
+if not state[1] then
+  return 5
+end
+-- 1 为拼团中
+if tonumber(state[1]) ~= 1 or tonumber(state[4]) <= tonumber(ARGV[2]) then
+  return 3
+end
+if redis.call('SISMEMBER', KEYS[2], ARGV[1]) == 1 then
+  return 2
+end
+if tonumber(state[3]) >= tonumber(state[2]) then
+  return 1
+end
+redis.call('SADD', KEYS[2], ARGV[1])
+redis.call('HINCRBY', KEYS[1], 'joined', 1)
+return 0
+`)
+
+// groupReleaseScript 释放用户预占的拼团名额
+var groupReleaseScript = redis.NewScript(`
+if redis.call('SREM', KEYS[2], ARGV[1]) == 1 then
+  redis.call('HINCRBY', KEYS[1], 'joined', -1)
+end
+return 0
+`)
+
+// GroupBuyDetail 拼团详情，Members 为已支付的参团用户
+type GroupBuyDetail struct {
+	model.GroupBuy
+	Members []int64 `json:"members"`
+}
+
+// GroupJoinResult 开团或参团结果
+type GroupJoinResult struct {
+	GroupID int64      `json:"groupId"`
+	OrderID int64      `json:"orderId"`
+	Status  int        `json:"status"` // 拼团状态，满员时为已成团
+	Pay     *PayResult `json:"pay"`
+}
+
+// GroupBuyService 拼团：团长开团后在有效期内邀请他人参团，每位成员参团即支付，满员后订单才可核销；
+// 名额通过 Redis 脚本预占，数据库以拼团行锁确认成团，超时未成团的拼团由后台任务关闭并自动退款
+type GroupBuyService struct {
+	db       *gorm.DB
+	rdb      *redis.Client
+	idWorker *utils.RedisIdWorker
+	payment  *PaymentService
+	state    *OrderStateService
+	window   time.Duration
+	log      *zap.Logger
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewGroupBuyService 创建 GroupBuyService 实例并启动超时拼团的退款任务，退出时调用 Shutdown 停止
+func NewGroupBuyService(db *gorm.DB, rdb *redis.Client, payment *PaymentService, state *OrderStateService, orderCfg config.OrderConfig, log *zap.Logger) *GroupBuyService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	if orderCfg.GroupBuyWindow <= 0 {
+		orderCfg.GroupBuyWindow = defaultGroupBuyWindow
+	}
+	svc := &GroupBuyService{
+		db:       db,
+		rdb:      rdb,
+		idWorker: utils.NewRedisIdWorker(rdb),
+		payment:  payment,
+		state:    state,
+		window:   orderCfg.GroupBuyWindow,
+		log:      log,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.cancel = cancel
+	svc.wg.Add(1)
+	go func() {
+		defer svc.wg.Done()
+		svc.expireLoop(ctx)
+	}()
+	return svc
+}
+
+// Shutdown 停止超时拼团任务并等待当前批次处理完成，ctx 到期时不再等待
+func (s *GroupBuyService) Shutdown(ctx context.Context) error {
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// validateGroupSize 校验券的成团人数，0 表示不支持拼团
+func validateGroupSize(size int) error {
+	if size == 0 {
+		return nil
+	}
+	if size < 2 || size > groupBuyMaxSize {
+		return &VoucherRuleError{Reason: VoucherRuleInvalidConfig, Message: fmt.Sprintf("拼团人数需在 2~%d 之间", groupBuyMaxSize)}
+	}
+	return nil
+}
+
+// isGroupFormingTx 判断订单所在的拼团是否尚未成团，未成团的订单不能核销、转赠或主动退款
+func isGroupFormingTx(tx *gorm.DB, order *model.VoucherOrder) (bool, error) {
+	if order.GroupID == 0 {
+		return false, nil
+	}
+	var status int
+	err := tx.Model(&model.GroupBuy{}).Where("id = ?", order.GroupID).Pluck("status", &status).Error
+	return status == model.GroupBuyStatusForming, err
+}
+
+// Create 开团：创建拼团并由团长完成首单支付；团长支付失败时拼团直接关闭
+func (s *GroupBuyService) Create(ctx context.Context, userID, voucherID int64, req PayRequest) (*GroupJoinResult, error) {
+	var voucher model.Voucher
+	err := s.db.WithContext(ctx).First(&voucher, voucherID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("优惠券不存在")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if voucher.Status != model.VoucherStatusOnline {
+		return nil, errors.New("优惠券已下架或过期")
+	}
+	if voucher.GroupSize < 2 {
+		return nil, errGroupBuyUnsupported
+	}
+	// 秒杀券的库存由秒杀脚本扣减，不参与拼团
+	var seckill int64
+	if err := s.db.WithContext(ctx).Model(&model.SeckillVoucher{}).Where("voucher_id = ?", voucherID).Count(&seckill).Error; err != nil {
+		return nil, err
+	}
+	if seckill > 0 {
+		return nil, errGroupBuyUnsupported
+	}
+	if err := EvaluateVoucherRules(&voucher, VoucherRuleContext{Stage: VoucherRuleStageClaim, Now: time.Now()}); err != nil {
+		return nil, err
+	}
+	group := &model.GroupBuy{
+		VoucherID:  voucherID,
+		LeaderID:   userID,
+		Size:       voucher.GroupSize,
+		Status:     model.GroupBuyStatusForming,
+		ExpireTime: time.Now().Add(s.window),
+	}
+	if err := s.db.WithContext(ctx).Create(group).Error; err != nil {
+		return nil, err
+	}
+	if err := s.cacheGroup(ctx, group, nil); err != nil {
+		s.log.Warn("cache group buy failed", zap.Int64("groupId", group.ID), zap.Error(err))
+	}
+	if err := s.rdb.ZAdd(ctx, utils.GROUP_BUY_EXPIRE_KEY, redis.Z{
+		Score:  float64(group.ExpireTime.Unix()),
+		Member: strconv.FormatInt(group.ID, 10),
+	}).Err(); err != nil {
+		s.log.Warn("schedule group buy expiry failed", zap.Int64("groupId", group.ID), zap.Error(err))
+	}
+	res, err := s.join(ctx, group.ID, userID, req)
+	if err != nil {
+		// 团长未能完成支付，关闭拼团
+		if ferr := s.failGroup(context.WithoutCancel(ctx), group.ID); ferr != nil {
+			s.log.Warn("close group buy failed", zap.Int64("groupId", group.ID), zap.Error(ferr))
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// Join 参加拼团并支付，满员时拼团成功
+func (s *GroupBuyService) Join(ctx context.Context, userID, groupID int64, req PayRequest) (*GroupJoinResult, error) {
+	return s.join(ctx, groupID, userID, req)
+}
+
+// join 预占名额、创建参团订单并支付，支付成功后确认参团；支付失败时取消订单并释放名额，
+// 确认时拼团已结束则立即退款
+func (s *GroupBuyService) join(ctx context.Context, groupID, userID int64, req PayRequest) (*GroupJoinResult, error) {
+	if err := s.reserve(ctx, groupID, userID); err != nil {
+		return nil, err
+	}
+	orderID, err := s.createOrder(ctx, groupID, userID)
+	if err != nil {
+		s.release(context.WithoutCancel(ctx), groupID, userID)
+		return nil, err
+	}
+	pay, err := s.payment.Pay(ctx, userID, orderID, req)
+	if err != nil {
+		bg := context.WithoutCancel(ctx)
+		if _, cerr := s.state.Transit(bg, orderID, userID, model.OrderStatusCancelled, nil); cerr != nil && !errors.Is(cerr, errOrderStateInvalid) {
+			s.log.Warn("cancel group buy order failed", zap.Int64("orderId", orderID), zap.Error(cerr))
+		}
+		s.release(bg, groupID, userID)
+		return nil, err
+	}
+	status, err := s.confirm(ctx, groupID)
+	if err != nil {
+		if rerr := s.payment.Refund(context.WithoutCancel(ctx), userID, orderID, "拼团已结束，自动退款"); rerr != nil {
+			s.log.Error("refund group buy order failed", zap.Int64("orderId", orderID), zap.Error(rerr))
+		}
+		return nil, err
+	}
+	s.log.Info("group buy joined",
+		zap.Int64("groupId", groupID),
+		zap.Int64("userId", userID),
+		zap.Int64("orderId", orderID),
+		zap.Int("status", status),
+	)
+	return &GroupJoinResult{GroupID: groupID, OrderID: orderID, Status: status, Pay: pay}, nil
+}
+
+// reserve 通过 Redis 脚本预占名额，状态未缓存时回源数据库重建后重试
+func (s *GroupBuyService) reserve(ctx context.Context, groupID, userID int64) error {
+	keys := []string{groupBuyStateKey(groupID), groupBuyMemberKey(groupID)}
+	res, err := groupJoinScript.Run(ctx, s.rdb, keys, userID, time.Now().Unix()).Int()
+	if err == nil && res == 5 {
+		if err := s.loadGroup(ctx, groupID); err != nil {
+			return err
+		}
+		res, err = groupJoinScript.Run(ctx, s.rdb, keys, userID, time.Now().Unix()).Int()
+	}
+	if err != nil {
+		return err
+	}
+	switch res {
+	case 0:
+		return nil
+	case 1:
+		return errGroupBuyFull
+	case 2:
+		return errGroupBuyJoined
+	case 3:
+		return errGroupBuyClosed
+	default:
+		return errors.New("参团失败")
+	}
+}
+
+// release 释放预占的名额
+func (s *GroupBuyService) release(ctx context.Context, groupID, userID int64) {
+	keys := []string{groupBuyStateKey(groupID), groupBuyMemberKey(groupID)}
+	if err := groupReleaseScript.Run(ctx, s.rdb, keys, userID).Err(); err != nil {
+		s.log.Warn("release group buy slot failed", zap.Int64("groupId", groupID), zap.Int64("userId", userID), zap.Error(err))
+	}
+}
+
+// createOrder 在拼团行锁内创建未支付的参团订单
+func (s *GroupBuyService) createOrder(ctx context.Context, groupID, userID int64) (int64, error) {
+	orderID, err := s.idWorker.NextId(ctx, "order")
+	if err != nil {
+		return 0, err
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		group, err := lockGroupBuyTx(tx, groupID)
+		if err != nil {
+			return err
+		}
+		if group.Status != model.GroupBuyStatusForming || !time.Now().Before(group.ExpireTime) {
+			return errGroupBuyClosed
+		}
+		var joined int64
+		if err := tx.Model(&model.VoucherOrder{}).
+			Where("group_id = ? AND user_id = ? AND status IN ?", groupID, userID, groupBuyHeldStatuses()).
+			Count(&joined).Error; err != nil {
+			return err
+		}
+		if joined > 0 {
+			return errGroupBuyJoined
+		}
+		now := time.Now()
+		return tx.Create(&model.VoucherOrder{
+			ID:         orderID,
+			UserID:     userID,
+			VoucherID:  group.VoucherID,
+			PayType:    model.PayTypeBalance,
+			Status:     model.OrderStatusUnpaid,
+			GroupID:    groupID,
+			CreateTime: now,
+			UpdateTime: now,
+		}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return orderID, nil
+}
+
+// confirm 支付成功后确认参团，人数达到成团人数时拼团成功；返回拼团最新状态
+func (s *GroupBuyService) confirm(ctx context.Context, groupID int64) (int, error) {
+	var status int
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		group, err := lockGroupBuyTx(tx, groupID)
+		if err != nil {
+			return err
+		}
+		if group.Status != model.GroupBuyStatusForming || !time.Now().Before(group.ExpireTime) {
+			return errGroupBuyClosed
+		}
+		updates := map[string]interface{}{"joined": group.Joined + 1}
+		status = model.GroupBuyStatusForming
+		if group.Joined+1 >= group.Size {
+			status = model.GroupBuyStatusSuccess
+			updates["status"] = status
+			updates["finish_time"] = time.Now()
+		}
+		return tx.Model(&model.GroupBuy{}).Where("id = ?", groupID).Updates(updates).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	if status == model.GroupBuyStatusSuccess {
+		if err := s.rdb.HSet(ctx, groupBuyStateKey(groupID), "status", status).Err(); err != nil {
+			s.log.Warn("update group buy state failed", zap.Int64("groupId", groupID), zap.Error(err))
+		}
+		_ = s.rdb.ZRem(ctx, utils.GROUP_BUY_EXPIRE_KEY, strconv.FormatInt(groupID, 10)).Err()
+		s.log.Info("group buy succeeded", zap.Int64("groupId", groupID))
+	}
+	return status, nil
+}
+
+// Get 查询拼团详情
+func (s *GroupBuyService) Get(ctx context.Context, groupID int64) (*GroupBuyDetail, error) {
+	var group model.GroupBuy
+	err := s.db.WithContext(ctx).First(&group, groupID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errGroupBuyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	members := make([]int64, 0, group.Size)
+	if err := s.db.WithContext(ctx).Model(&model.VoucherOrder{}).
+		Where("group_id = ? AND status IN ?", groupID, []int{model.OrderStatusPaid, model.OrderStatusUsed}).
+		Order("pay_time").
+		Pluck("user_id", &members).Error; err != nil {
+		return nil, err
+	}
+	return &GroupBuyDetail{GroupBuy: group, Members: members}, nil
+}
+
+// ListForming 查询券下仍可参加的拼团，差额少的在前，便于用户凑团
+func (s *GroupBuyService) ListForming(ctx context.Context, voucherID int64) ([]model.GroupBuy, error) {
+	groups := make([]model.GroupBuy, 0)
+	err := s.db.WithContext(ctx).
+		Where("voucher_id = ? AND status = ? AND expire_time > ?", voucherID, model.GroupBuyStatusForming, time.Now()).
+		Order("size - joined, expire_time").
+		Limit(groupBuyListMax).
+		Find(&groups).Error
+	return groups, err
+}
+
+// loadGroup 从数据库重建拼团的 Redis 状态，未支付的订单同样占用名额
+func (s *GroupBuyService) loadGroup(ctx context.Context, groupID int64) error {
+	var group model.GroupBuy
+	err := s.db.WithContext(ctx).First(&group, groupID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errGroupBuyNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var members []int64
+	if err := s.db.WithContext(ctx).Model(&model.VoucherOrder{}).
+		Where("group_id = ? AND status IN ?", groupID, groupBuyHeldStatuses()).
+		Pluck("user_id", &members).Error; err != nil {
+		return err
+	}
+	return s.cacheGroup(ctx, &group, members)
+}
+
+// cacheGroup 缓存拼团状态与参团用户，拼团截止一小时后自动过期
+func (s *GroupBuyService) cacheGroup(ctx context.Context, group *model.GroupBuy, members []int64) error {
+	stateKey, memberKey := groupBuyStateKey(group.ID), groupBuyMemberKey(group.ID)
+	expireAt := group.ExpireTime.Add(time.Hour)
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, stateKey,
+			"status", group.Status,
+			"size", group.Size,
+			"joined", len(members),
+			"expire", group.ExpireTime.Unix(),
+		)
+		pipe.Del(ctx, memberKey)
+		if len(members) > 0 {
+			values := make([]interface{}, len(members))
+			for i, id := range members {
+				values[i] = id
+			}
+			pipe.SAdd(ctx, memberKey, values...)
+			pipe.ExpireAt(ctx, memberKey, expireAt)
+		}
+		pipe.ExpireAt(ctx, stateKey, expireAt)
+		return nil
+	})
+	return err
+}
+
+// expireLoop 轮询到期未成团的拼团并关闭退款；通过 ZREM 抢占，多实例下同一拼团只会被处理一次
+func (s *GroupBuyService) expireLoop(ctx context.Context) {
+	ticker := time.NewTicker(groupBuyExpirePoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		members, err := s.rdb.ZRangeByScore(ctx, utils.GROUP_BUY_EXPIRE_KEY, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(time.Now().Unix(), 10),
+			Count: groupBuyExpireBatch,
+		}).Result()
+		if err != nil {
+			s.log.Warn("scan group buy expiry queue failed", zap.Error(err))
+			continue
+		}
+		for _, member := range members {
+			// 收到退出信号后不再认领新的拼团，已认领的拼团处理完成后再退出
+			if ctx.Err() != nil {
+				return
+			}
+			removed, err := s.rdb.ZRem(ctx, utils.GROUP_BUY_EXPIRE_KEY, member).Result()
+			if err != nil || removed == 0 {
+				continue
+			}
+			groupID, err := strconv.ParseInt(member, 10, 64)
+			if err != nil {
+				continue
+			}
+			bg := context.WithoutCancel(ctx)
+			if err := s.failGroup(bg, groupID); err != nil {
+				s.log.Error("close expired group buy failed, requeued", zap.Int64("groupId", groupID), zap.Error(err))
+				_ = s.rdb.ZAdd(bg, utils.GROUP_BUY_EXPIRE_KEY, redis.Z{
+					Score:  float64(time.Now().Add(groupBuyRetryDelay).Unix()),
+					Member: member,
+				}).Err()
+			}
+		}
+	}
+}
+
+// failGroup 关闭未成团的拼团：已支付的参团订单自动退款，未支付的订单取消；已成团时不做处理。
+// 可重复执行，部分退款失败时返回错误由调用方重试
+func (s *GroupBuyService) failGroup(ctx context.Context, groupID int64) error {
+	succeeded := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		group, err := lockGroupBuyTx(tx, groupID)
+		if err != nil {
+			return err
+		}
+		switch group.Status {
+		case model.GroupBuyStatusSuccess:
+			succeeded = true
+			return nil
+		case model.GroupBuyStatusForming:
+			return tx.Model(&model.GroupBuy{}).Where("id = ?", groupID).Updates(map[string]interface{}{
+				"status":      model.GroupBuyStatusFailed,
+				"finish_time": time.Now(),
+			}).Error
+		}
+		return nil
+	})
+	if errors.Is(err, errGroupBuyNotFound) {
+		return nil
+	}
+	if err != nil || succeeded {
+		return err
+	}
+	if err := s.rdb.HSet(ctx, groupBuyStateKey(groupID), "status", model.GroupBuyStatusFailed).Err(); err != nil {
+		s.log.Warn("update group buy state failed", zap.Int64("groupId", groupID), zap.Error(err))
+	}
+	var orders []model.VoucherOrder
+	if err := s.db.WithContext(ctx).
+		Where("group_id = ? AND status IN ?", groupID, []int{model.OrderStatusUnpaid, model.OrderStatusPaid}).
+		Find(&orders).Error; err != nil {
+		return err
+	}
+	var firstErr error
+	for _, o := range orders {
+		var err error
+		if o.Status == model.OrderStatusPaid {
+			err = s.payment.Refund(ctx, o.UserID, o.ID, "拼团未成团，自动退款")
+		} else {
+			_, err = s.state.Transit(ctx, o.ID, 0, model.OrderStatusCancelled, nil)
+		}
+		// 状态已变化说明订单已被并发处理
+		if err != nil && !errors.Is(err, errOrderStateInvalid) {
+			s.log.Error("settle failed group buy order failed", zap.Int64("groupId", groupID), zap.Int64("orderId", o.ID), zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	s.log.Info("group buy failed", zap.Int64("groupId", groupID), zap.Int("orders", len(orders)))
+	return firstErr
+}
+
+// lockGroupBuyTx 在事务内对拼团加行锁
+func lockGroupBuyTx(tx *gorm.DB, groupID int64) (*model.GroupBuy, error) {
+	var group model.GroupBuy
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&group, groupID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errGroupBuyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// groupBuyHeldStatuses 占用拼团名额的订单状态
+func groupBuyHeldStatuses() []int {
+	return []int{model.OrderStatusUnpaid, model.OrderStatusPaid, model.OrderStatusUsed}
+}
+
+func groupBuyStateKey(groupID int64) string {
+	return utils.GROUP_BUY_KEY + strconv.FormatInt(groupID, 10)
+}
+
+func groupBuyMemberKey(groupID int64) string {
+	return utils.GROUP_BUY_MEMBER_KEY + strconv.FormatInt(groupID, 10)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/model"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// TestValidateGroupSize 校验成团人数的取值范围，0 表示不支持拼团
+func TestValidateGroupSize(t *testing.T) {
+	cases := []struct {
+		size int
+		ok   bool
+	}{
+		{0, true},
+		{1, false},
+		{2, true},
+		{groupBuyMaxSize, true},
+		{groupBuyMaxSize + 1, false},
+		{-3, false},
+	}
+	for _, c := range cases {
+		if err := validateGroupSize(c.size); (err == nil) != c.ok {
+			t.Fatalf("size %d: err = %v, want ok=%v", c.size, err, c.ok)
+		}
+	}
+}
+
+// newTestGroupBuy 连接测试库并创建一张两人成团的券，返回停止了后台任务的 GroupBuyService 与券ID
+func newTestGroupBuy(t *testing.T, ctx context.Context, window time.Duration) (*GroupBuyService, *gorm.DB, int64) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		dsn = "root:root@tcp(127.0.0.1:3306)/hmdp?parseTime=true&loc=Local&charset=utf8mb4"
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Skipf("skip: cannot connect mysql: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		t.Cleanup(func() { sqlDB.Close() })
+	}
+	if !db.Migrator().HasTable(&model.GroupBuy{}) {
+		t.Skip("skip: tb_group_buy not found")
+	}
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr: "127.0.0.1:6379",
+		DB:   0,
+	})
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		t.Skipf("skip: cannot connect redis: %v", err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+
+	voucher := &model.Voucher{
+		ShopID:    1,
+		Title:     "拼团测试券",
+		PayValue:  1000,
+		Status:    model.VoucherStatusOnline,
+		GroupSize: 2,
+	}
+	if err := db.Create(voucher).Error; err != nil {
+		t.Fatalf("create voucher failed: %v", err)
+	}
+	t.Cleanup(func() {
+		var orderIDs []int64
+		db.Model(&model.VoucherOrder{}).Where("voucher_id = ?", voucher.ID).Pluck("id", &orderIDs)
+		if len(orderIDs) > 0 {
+			db.Where("order_id IN ?", orderIDs).Delete(&model.VoucherOrderRefund{})
+		}
+		db.Where("voucher_id = ?", voucher.ID).Delete(&model.VoucherOrder{})
+		db.Where("voucher_id = ?", voucher.ID).Delete(&model.GroupBuy{})
+		db.Delete(voucher)
+	})
+
+	log := newTestLogger(t)
+	state := NewOrderStateService(db)
+	payment := NewPaymentService(db, rdb, config.PointsConfig{}, state, nil, log)
+	svc := NewGroupBuyService(db, rdb, payment, state, config.OrderConfig{GroupBuyWindow: window}, log)
+	// 测试直接调用 failGroup，停止后台任务避免与之竞争
+	if err := svc.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown group buy service failed: %v", err)
+	}
+	return svc, db, voucher.ID
+}
+
+// assertGroupOrders 校验拼团内所有订单均处于期望状态
+func assertGroupOrders(t *testing.T, db *gorm.DB, groupID int64, want int, count int) {
+	t.Helper()
+	var orders []model.VoucherOrder
+	if err := db.Where("group_id = ?", groupID).Find(&orders).Error; err != nil {
+		t.Fatalf("query group orders failed: %v", err)
+	}
+	if len(orders) != count {
+		t.Fatalf("group %d has %d orders, want %d", groupID, len(orders), count)
+	}
+	for _, o := range orders {
+		if o.Status != want {
+			t.Fatalf("order %d status = %d, want %d", o.ID, o.Status, want)
+		}
+	}
+}
+
+// TestGroupBuyFullGroup 团长开团、第二人参团后满员成团，两笔订单均为已支付
+func TestGroupBuyFullGroup(t *testing.T) {
+	ctx := context.Background()
+	svc, db, voucherID := newTestGroupBuy(t, ctx, time.Hour)
+
+	const leaderID, memberID = int64(9101), int64(9102)
+	created, err := svc.Create(ctx, leaderID, voucherID, PayRequest{})
+	if err != nil {
+		t.Fatalf("create group failed: %v", err)
+	}
+	if created.Status != model.GroupBuyStatusForming {
+		t.Fatalf("status after create = %d, want forming", created.Status)
+	}
+	joined, err := svc.Join(ctx, memberID, created.GroupID, PayRequest{})
+	if err != nil {
+		t.Fatalf("join group failed: %v", err)
+	}
+	if joined.Status != model.GroupBuyStatusSuccess {
+		t.Fatalf("status after join = %d, want success", joined.Status)
+	}
+	if _, err := svc.Join(ctx, 9103, created.GroupID, PayRequest{}); err == nil {
+		t.Fatalf("join full group should fail")
+	}
+
+	var group model.GroupBuy
+	if err := db.First(&group, created.GroupID).Error; err != nil {
+		t.Fatalf("query group failed: %v", err)
+	}
+	if group.Status != model.GroupBuyStatusSuccess || group.Joined != 2 {
+		t.Fatalf("group = {status:%d joined:%d}, want {status:%d joined:2}", group.Status, group.Joined, model.GroupBuyStatusSuccess)
+	}
+	assertGroupOrders(t, db, created.GroupID, model.OrderStatusPaid, 2)
+
+	// 已成团的拼团不会被超时任务关闭
+	if err := svc.failGroup(ctx, created.GroupID); err != nil {
+		t.Fatalf("fail succeeded group: %v", err)
+	}
+	assertGroupOrders(t, db, created.GroupID, model.OrderStatusPaid, 2)
+}
+
+// TestGroupBuyExpireRefund 拼团到期未满员时关闭并为已支付的团长订单自动退款
+func TestGroupBuyExpireRefund(t *testing.T) {
+	ctx := context.Background()
+	svc, db, voucherID := newTestGroupBuy(t, ctx, time.Second)
+
+	created, err := svc.Create(ctx, 9201, voucherID, PayRequest{})
+	if err != nil {
+		t.Fatalf("create group failed: %v", err)
+	}
+	assertGroupOrders(t, db, created.GroupID, model.OrderStatusPaid, 1)
+
+	time.Sleep(1500 * time.Millisecond)
+	if err := svc.failGroup(ctx, created.GroupID); err != nil {
+		t.Fatalf("fail expired group: %v", err)
+	}
+	var group model.GroupBuy
+	if err := db.First(&group, created.GroupID).Error; err != nil {
+		t.Fatalf("query group failed: %v", err)
+	}
+	if group.Status != model.GroupBuyStatusFailed {
+		t.Fatalf("group status = %d, want failed", group.Status)
+	}
+	assertGroupOrders(t, db, created.GroupID, model.OrderStatusRefunded, 1)
+
+	// 重复关闭不会再次退款
+	if err := svc.failGroup(ctx, created.GroupID); err != nil {
+		t.Fatalf("fail group again: %v", err)
+	}
+	var refunds int64
+	db.Model(&model.VoucherOrderRefund{}).Where("order_id = ?", created.OrderID).Count(&refunds)
+	if refunds != 1 {
+		t.Fatalf("refund records = %d, want 1", refunds)
+	}
+
+	// 拼团结束后不能再参团
+	if _, err := svc.Join(ctx, 9202, created.GroupID, PayRequest{}); err == nil {
+		t.Fatalf("join failed group should fail")
+	}
+}
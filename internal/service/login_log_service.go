@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+)
+
+const (
+	loginLogWriteTimeout = 3 * time.Second
+	loginLogDeviceMaxLen = 255
+	loginHistoryMaxSize  = 50
+)
+
+// LoginClient 登录请求的客户端信息
+type LoginClient struct {
+	IP     string
+	Device string
+}
+
+type loginClientKey struct{}
+
+// WithLoginClient 将客户端信息写入 context，签发令牌时据此记录登录日志
+func WithLoginClient(ctx context.Context, client LoginClient) context.Context {
+	return context.WithValue(ctx, loginClientKey{}, client)
+}
+
+func loginClientFrom(ctx context.Context) LoginClient {
+	client, _ := ctx.Value(loginClientKey{}).(LoginClient)
+	return client
+}
+
+// LoginLogService 记录并查询用户的登录历史
+type LoginLogService struct {
+	db  *gorm.DB
+	log *zap.Logger
+}
+
+// NewLoginLogService 创建 LoginLogService 实例
+func NewLoginLogService(db *gorm.DB, log *zap.Logger) *LoginLogService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &LoginLogService{db: db, log: log}
+}
+
+// RecordAsync 异步写入登录日志，不阻塞登录流程，写入失败仅记录日志
+func (s *LoginLogService) RecordAsync(userID int64, client LoginClient, loginTime time.Time) {
+	device := client.Device
+	if len(device) > loginLogDeviceMaxLen {
+		device = device[:loginLogDeviceMaxLen]
+	}
+	entry := &model.LoginLog{UserID: userID, IP: client.IP, Device: device, LoginTime: loginTime}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), loginLogWriteTimeout)
+		defer cancel()
+		if err := s.db.WithContext(ctx).Create(entry).Error; err != nil {
+			s.log.Warn("record login log failed", zap.Int64("userId", userID), zap.Error(err))
+		}
+	}()
+}
+
+// List 查询用户最近的登录记录，最新的在前
+func (s *LoginLogService) List(ctx context.Context, userID int64, size int) ([]model.LoginLog, error) {
+	if size <= 0 || size > loginHistoryMaxSize {
+		size = loginHistoryMaxSize
+	}
+	var logs []model.LoginLog
+	err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("login_time DESC").
+		Limit(size).
+		Find(&logs).Error
+	return logs, err
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/utils"
+)
+
+// 通知类型
+const (
+	NotificationTypeOrderGift       = "order_gift"
+	NotificationTypeSeckillReminder = "seckill_reminder"
+	NotificationTypeOrderPaid       = "order_paid"
+)
+
+// Notification 站内通知
+type Notification struct {
+	Type       string            `json:"type"`
+	Title      string            `json:"title"`
+	Content    string            `json:"content"`
+	Data       map[string]string `json:"data,omitempty"`
+	CreateTime int64             `json:"createTime"`
+}
+
+// ChannelSender 站外通知渠道（推送、邮件等）的投递实现
+type ChannelSender interface {
+	Deliver(ctx context.Context, userID int64, n Notification) error
+}
+
+// NotificationService 站内通知：使用 Redis List 作为每个用户的收件箱
+type NotificationService struct {
+	rdb      *redis.Client
+	settings *NotificationSettingService
+	senders  map[string]ChannelSender
+	log      *zap.Logger
+}
+
+// NewNotificationService 创建 NotificationService 实例
+func NewNotificationService(rdb *redis.Client, settings *NotificationSettingService, log *zap.Logger) *NotificationService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &NotificationService{rdb: rdb, settings: settings, senders: make(map[string]ChannelSender), log: log}
+}
+
+// RegisterSender 注册站外渠道的投递实现，需在服务启动阶段调用
+func (s *NotificationService) RegisterSender(channel string, sender ChannelSender) {
+	s.senders[channel] = sender
+}
+
+// Dispatch 按用户的通知偏好分发到各渠道：站内信写入收件箱，其余渠道交给已注册的 sender
+func (s *NotificationService) Dispatch(ctx context.Context, userID int64, n Notification) error {
+	pref := defaultChannelPreference(n.Type)
+	if s.settings != nil {
+		p, err := s.settings.Preference(ctx, userID, n.Type)
+		if err != nil {
+			// 偏好读取失败时按默认值投递，避免通知丢失
+			s.log.Warn("load notification preference failed", zap.Int64("userId", userID), zap.Error(err))
+		} else {
+			pref = p
+		}
+	}
+	var firstErr error
+	if pref.InApp {
+		firstErr = s.Send(ctx, userID, n)
+	}
+	for _, channel := range []string{NotificationChannelPush, NotificationChannelEmail} {
+		if !pref.Enabled(channel) {
+			continue
+		}
+		sender, ok := s.senders[channel]
+		if !ok {
+			continue
+		}
+		if err := sender.Deliver(ctx, userID, n); err != nil {
+			s.log.Warn("deliver notification failed", zap.Int64("userId", userID), zap.String("channel", channel), zap.Error(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Send 向用户收件箱写入一条通知，收件箱只保留最近 NOTIFY_INBOX_MAX 条
+func (s *NotificationService) Send(ctx context.Context, userID int64, n Notification) error {
+	if n.CreateTime == 0 {
+		n.CreateTime = time.Now().UnixMilli()
+	}
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	key := utils.NOTIFY_INBOX_KEY + strconv.FormatInt(userID, 10)
+	_, err = s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, key, data)
+		pipe.LTrim(ctx, key, 0, utils.NOTIFY_INBOX_MAX-1)
+		return nil
+	})
+	if err != nil {
+		s.log.Warn("send notification failed", zap.Int64("userId", userID), zap.String("type", n.Type), zap.Error(err))
+	}
+	return err
+}
+
+// List 分页查询用户的通知，最新的在前
+func (s *NotificationService) List(ctx context.Context, userID int64, page, size int) ([]Notification, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.MAX_PAGE_SIZE
+	}
+	key := utils.NOTIFY_INBOX_KEY + strconv.FormatInt(userID, 10)
+	start := int64((page - 1) * size)
+	raw, err := s.rdb.LRange(ctx, key, start, start+int64(size)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Notification, 0, len(raw))
+	for _, item := range raw {
+		var n Notification
+		if err := json.Unmarshal([]byte(item), &n); err != nil {
+			continue
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
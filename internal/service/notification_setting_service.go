@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// 通知渠道
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelPush  = "push"
+	NotificationChannelEmail = "email"
+)
+
+// NotificationTypes 支持用户配置的通知类型
+var NotificationTypes = []string{
+	NotificationTypeOrderGift,
+	NotificationTypeSeckillReminder,
+	NotificationTypeOrderPaid,
+}
+
+var errNotificationTypeInvalid = errors.New("不支持的通知类型")
+
+// ChannelPreference 某一通知类型在各渠道的开关
+type ChannelPreference struct {
+	InApp bool `json:"inApp"`
+	Push  bool `json:"push"`
+	Email bool `json:"email"`
+}
+
+// Enabled 判断指定渠道是否开启
+func (p ChannelPreference) Enabled(channel string) bool {
+	switch channel {
+	case NotificationChannelInApp:
+		return p.InApp
+	case NotificationChannelPush:
+		return p.Push
+	case NotificationChannelEmail:
+		return p.Email
+	}
+	return false
+}
+
+// defaultChannelPreference 未配置时默认开启站内信与推送，关闭邮件；支付成功通知携带核销码，默认同时发送邮件
+func defaultChannelPreference(notifyType string) ChannelPreference {
+	return ChannelPreference{InApp: true, Push: true, Email: notifyType == NotificationTypeOrderPaid}
+}
+
+// NotificationSettingService 维护用户的通知偏好，数据库持久化并缓存到 Redis
+type NotificationSettingService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewNotificationSettingService 创建 NotificationSettingService 实例
+func NewNotificationSettingService(db *gorm.DB, rdb *redis.Client) *NotificationSettingService {
+	return &NotificationSettingService{db: db, rdb: rdb}
+}
+
+// Get 查询用户全部通知类型的偏好，未配置的类型使用默认值
+func (s *NotificationSettingService) Get(ctx context.Context, userID int64) (map[string]ChannelPreference, error) {
+	key := utils.NOTIFY_SETTING_KEY + strconv.FormatInt(userID, 10)
+	cached, err := s.rdb.Get(ctx, key).Result()
+	if err == nil {
+		var prefs map[string]ChannelPreference
+		if unmarshalErr := json.Unmarshal([]byte(cached), &prefs); unmarshalErr == nil {
+			return prefs, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	var rows []model.NotificationSetting
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	prefs := make(map[string]ChannelPreference, len(NotificationTypes))
+	for _, t := range NotificationTypes {
+		prefs[t] = defaultChannelPreference(t)
+	}
+	for _, row := range rows {
+		if _, ok := prefs[row.Type]; ok {
+			prefs[row.Type] = ChannelPreference{InApp: row.InApp, Push: row.Push, Email: row.Email}
+		}
+	}
+	if data, err := json.Marshal(prefs); err == nil {
+		_ = s.rdb.Set(ctx, key, data, time.Duration(utils.NOTIFY_SETTING_TTL)*time.Minute).Err()
+	}
+	return prefs, nil
+}
+
+// Preference 查询用户对某一通知类型的偏好
+func (s *NotificationSettingService) Preference(ctx context.Context, userID int64, notifyType string) (ChannelPreference, error) {
+	prefs, err := s.Get(ctx, userID)
+	if err != nil {
+		return ChannelPreference{}, err
+	}
+	if p, ok := prefs[notifyType]; ok {
+		return p, nil
+	}
+	return defaultChannelPreference(notifyType), nil
+}
+
+// Update 更新用户的通知偏好，只覆盖请求中出现的类型，写库后删除缓存
+func (s *NotificationSettingService) Update(ctx context.Context, userID int64, prefs map[string]ChannelPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	rows := make([]model.NotificationSetting, 0, len(prefs))
+	for t, p := range prefs {
+		if !isNotificationType(t) {
+			return errNotificationTypeInvalid
+		}
+		rows = append(rows, model.NotificationSetting{UserID: userID, Type: t, InApp: p.InApp, Push: p.Push, Email: p.Email})
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "notify_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "push", "email", "update_time"}),
+	}).Create(&rows).Error; err != nil {
+		return err
+	}
+	return s.rdb.Del(ctx, utils.NOTIFY_SETTING_KEY+strconv.FormatInt(userID, 10)).Err()
+}
+
+// isNotificationType 判断是否为支持配置的通知类型
+func isNotificationType(t string) bool {
+	for _, known := range NotificationTypes {
+		if known == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+var errOAuthProviderUnsupported = errors.New("不支持的登录方式")
+
+// OAuthIdentity 第三方平台返回的用户身份
+type OAuthIdentity struct {
+	OpenID  string
+	UnionID string
+}
+
+// OAuthProvider 第三方登录平台：用授权 code 换取用户身份
+type OAuthProvider interface {
+	Name() string
+	Exchange(ctx context.Context, code string) (*OAuthIdentity, error)
+}
+
+// OAuthService 处理第三方登录：首次登录自动注册，签发与手机号登录相同的令牌
+type OAuthService struct {
+	db        *gorm.DB
+	userSvc   *UserService
+	providers map[string]OAuthProvider
+}
+
+// NewOAuthService 创建 OAuthService 实例
+func NewOAuthService(db *gorm.DB, userSvc *UserService, providers ...OAuthProvider) *OAuthService {
+	m := make(map[string]OAuthProvider, len(providers))
+	for _, p := range providers {
+		m[p.Name()] = p
+	}
+	return &OAuthService{db: db, userSvc: userSvc, providers: m}
+}
+
+// Login 第三方登录，返回登录令牌
+func (s *OAuthService) Login(ctx context.Context, providerName, code string) (string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", errOAuthProviderUnsupported
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", errors.New("code is required")
+	}
+	identity, err := provider.Exchange(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	user, err := s.findOrCreateUser(ctx, providerName, identity)
+	if err != nil {
+		return "", err
+	}
+	return s.userSvc.issueToken(ctx, user)
+}
+
+// findOrCreateUser 按 provider + openid 查找绑定的用户，不存在则创建用户并绑定
+func (s *OAuthService) findOrCreateUser(ctx context.Context, providerName string, identity *OAuthIdentity) (*model.User, error) {
+	var user model.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var binding model.UserOAuth
+		err := tx.Where("provider = ? AND open_id = ?", providerName, identity.OpenID).Take(&binding).Error
+		if err == nil {
+			return tx.First(&user, binding.UserID).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		user = model.User{NickName: utils.USER_NICK_NAME_PREFIX + utils.RandomString(10)}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.UserOAuth{
+			UserID:   user.ID,
+			Provider: providerName,
+			OpenID:   identity.OpenID,
+			UnionID:  identity.UnionID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
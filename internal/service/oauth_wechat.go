@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"hmdp-backend/internal/config"
+)
+
+// OAuthProviderWeChat 微信小程序登录
+const OAuthProviderWeChat = "wechat"
+
+const wechatCode2SessionURL = "https://api.weixin.qq.com/sns/jscode2session"
+
+// WeChatProvider 通过 jscode2session 接口换取小程序用户的 openid
+type WeChatProvider struct {
+	cfg    config.WeChatConfig
+	client *http.Client
+}
+
+// NewWeChatProvider 创建微信小程序登录 provider，未配置 appId 时返回 nil
+func NewWeChatProvider(cfg config.WeChatConfig) *WeChatProvider {
+	if cfg.AppID == "" || cfg.AppSecret == "" {
+		return nil
+	}
+	return &WeChatProvider{cfg: cfg, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (p *WeChatProvider) Name() string { return OAuthProviderWeChat }
+
+// Exchange 用 wx.login 获取的 code 换取 openid/unionid
+func (p *WeChatProvider) Exchange(ctx context.Context, code string) (*OAuthIdentity, error) {
+	query := url.Values{}
+	query.Set("appid", p.cfg.AppID)
+	query.Set("secret", p.cfg.AppSecret)
+	query.Set("js_code", code)
+	query.Set("grant_type", "authorization_code")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wechatCode2SessionURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("wechat code2session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		OpenID  string `json:"openid"`
+		UnionID string `json:"unionid"`
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("wechat code2session decode: %w", err)
+	}
+	if body.ErrCode != 0 {
+		return nil, fmt.Errorf("wechat code2session: %d %s", body.ErrCode, body.ErrMsg)
+	}
+	if body.OpenID == "" {
+		return nil, errors.New("wechat code2session: empty openid")
+	}
+	return &OAuthIdentity{OpenID: body.OpenID, UnionID: body.UnionID}, nil
+}
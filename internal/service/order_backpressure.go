@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	// orderQueueSampleInterval 采样订单积压与刷新动态阈值的周期
+	orderQueueSampleInterval = time.Second
+	// orderQueueStaleAfter 超过该时间仍未落库或补偿的订单视为已丢失，不再计入积压
+	orderQueueStaleAfter = 10 * time.Minute
+)
+
+// ErrOrderQueueBusy 待落库订单积压超过阈值，秒杀请求被快速拒绝
+var ErrOrderQueueBusy = errors.New("系统繁忙，请稍后重试")
+
+// OrderQueueStatus 订单队列积压状况
+type OrderQueueStatus struct {
+	Depth         int64 `json:"depth"`         // 已抢购成功、尚未落库的订单数
+	MaxQueueDepth int64 `json:"maxQueueDepth"` // 当前生效的积压阈值，0 表示不限制
+	Overridden    bool  `json:"overridden"`    // 阈值是否为管理端动态设置
+}
+
+// trackQueued 记录已投递、待落库的订单；以订单ID为成员，消息重复投递不会重复计数
+func (s *VoucherOrderService) trackQueued(ctx context.Context, orderID int64) {
+	if err := s.rdb.ZAdd(ctx, utils.ORDER_QUEUE_KEY, redis.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: strconv.FormatInt(orderID, 10),
+	}).Err(); err != nil {
+		s.log.Warn("track queued order failed", zap.Int64("orderId", orderID), zap.Error(err))
+	}
+}
+
+// untrackQueued 订单落库或补偿后移出积压统计，重复调用无副作用
+func (s *VoucherOrderService) untrackQueued(ctx context.Context, orderID int64) {
+	if err := s.rdb.ZRem(ctx, utils.ORDER_QUEUE_KEY, strconv.FormatInt(orderID, 10)).Err(); err != nil {
+		s.log.Warn("untrack queued order failed", zap.Int64("orderId", orderID), zap.Error(err))
+	}
+}
+
+// overloaded 判断积压是否达到阈值；读取后台采样的结果，不访问 Redis
+func (s *VoucherOrderService) overloaded() bool {
+	limit := s.queueLimit.Load()
+	return limit > 0 && s.queueDepth.Load() >= limit
+}
+
+// sampleQueueLoop 周期性采样订单积压并刷新动态阈值
+func (s *VoucherOrderService) sampleQueueLoop(ctx context.Context) {
+	ticker := time.NewTicker(orderQueueSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := s.sampleQueue(ctx); err != nil {
+			s.log.Warn("sample order queue failed", zap.Error(err))
+		}
+	}
+}
+
+// sampleQueue 清理过期的积压记录后读取积压数量与 Redis 中的动态阈值，未设置动态阈值时使用配置值
+func (s *VoucherOrderService) sampleQueue(ctx context.Context) (*OrderQueueStatus, error) {
+	stale := time.Now().Add(-orderQueueStaleAfter).Unix()
+	var card *redis.IntCmd
+	var limit *redis.StringCmd
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, utils.ORDER_QUEUE_KEY, "-inf", "("+strconv.FormatInt(stale, 10))
+		card = pipe.ZCard(ctx, utils.ORDER_QUEUE_KEY)
+		limit = pipe.Get(ctx, utils.ORDER_QUEUE_LIMIT)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	status := &OrderQueueStatus{Depth: card.Val(), MaxQueueDepth: s.maxDepth.Load()}
+	if v, err := limit.Int64(); err == nil {
+		status.MaxQueueDepth = v
+		status.Overridden = true
+	}
+	s.queueDepth.Store(status.Depth)
+	s.queueLimit.Store(status.MaxQueueDepth)
+	s.metrics.SetOrderQueueDepth(status.Depth)
+	return status, nil
+}
+
+// QueueStatus 返回当前的订单积压与生效阈值
+func (s *VoucherOrderService) QueueStatus(ctx context.Context) (*OrderQueueStatus, error) {
+	return s.sampleQueue(ctx)
+}
+
+// SetMaxQueueDepth 动态设置积压阈值，0 表示不限制；写入 Redis 后所有实例在一个采样周期内生效
+func (s *VoucherOrderService) SetMaxQueueDepth(ctx context.Context, limit int64) (*OrderQueueStatus, error) {
+	if limit < 0 {
+		return nil, errors.New("积压阈值不能为负数")
+	}
+	if err := s.rdb.Set(ctx, utils.ORDER_QUEUE_LIMIT, limit, 0).Err(); err != nil {
+		return nil, err
+	}
+	return s.sampleQueue(ctx)
+}
+
+// ResetMaxQueueDepth 清除动态阈值，恢复使用配置值
+func (s *VoucherOrderService) ResetMaxQueueDepth(ctx context.Context) (*OrderQueueStatus, error) {
+	if err := s.rdb.Del(ctx, utils.ORDER_QUEUE_LIMIT).Err(); err != nil {
+		return nil, err
+	}
+	return s.sampleQueue(ctx)
+}
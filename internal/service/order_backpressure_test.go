@@ -0,0 +1,25 @@
+package service
+
+import "testing"
+
+// TestOrderQueueOverloaded 校验积压达到阈值时快速失败，阈值为 0 时不限制
+func TestOrderQueueOverloaded(t *testing.T) {
+	cases := []struct {
+		depth, limit int64
+		want         bool
+	}{
+		{0, 0, false},
+		{100000, 0, false},
+		{99, 100, false},
+		{100, 100, true},
+		{150, 100, true},
+	}
+	for _, c := range cases {
+		svc := &VoucherOrderService{}
+		svc.queueDepth.Store(c.depth)
+		svc.queueLimit.Store(c.limit)
+		if got := svc.overloaded(); got != c.want {
+			t.Fatalf("depth=%d limit=%d: overloaded = %v, want %v", c.depth, c.limit, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+	"hmdp-backend/pkg/lock"
+)
+
+const (
+	// redeemCodeDigits 核销码位数，纯数字便于手动输入，也作为二维码内容
+	redeemCodeDigits = 12
+	// redeemLockTTL 核销锁过期时间，覆盖一次核销事务的最长耗时
+	redeemLockTTL = 10 * time.Second
+)
+
+var (
+	errRedeemCodeInvalid = errors.New("核销码无效")
+	errRedeemUsed        = errors.New("该券码已核销")
+	errRedeemBusy        = errors.New("核销处理中，请勿重复提交")
+)
+
+// RedeemRequest 到店核销请求
+type RedeemRequest struct {
+	Code        string `json:"code"`        // 核销码或二维码内容
+	ShopID      int64  `json:"shopId"`      // 核销门店
+	SpendAmount int64  `json:"spendAmount"` // 本次消费金额（分），用于校验满减门槛
+}
+
+// RedemptionService 处理订单的到店核销
+type RedemptionService struct {
+	db    *gorm.DB
+	rdb   *redis.Client
+	state *OrderStateService
+	shops *ShopService
+	log   *zap.Logger
+}
+
+// NewRedemptionService 创建 RedemptionService 实例
+func NewRedemptionService(db *gorm.DB, rdb *redis.Client, state *OrderStateService, shops *ShopService, log *zap.Logger) *RedemptionService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &RedemptionService{db: db, rdb: rdb, state: state, shops: shops, log: log}
+}
+
+// newRedeemCode 生成随机数字核销码
+func newRedeemCode() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(redeemCodeDigits), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", redeemCodeDigits, n), nil
+}
+
+// RedeemQRCode 生成订单核销码的二维码 PNG，供用户到店出示；只有订单所属用户可以获取，且订单须处于待核销状态
+func (s *RedemptionService) RedeemQRCode(ctx context.Context, userID, orderID int64, size int) ([]byte, error) {
+	var order model.VoucherOrder
+	err := s.db.WithContext(ctx).Select("id", "user_id", "status", "redeem_code").Take(&order, orderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, errOrderNotFound
+	}
+	if order.Status == model.OrderStatusUsed {
+		return nil, errRedeemUsed
+	}
+	if order.Status != model.OrderStatusPaid || order.RedeemCode == "" {
+		return nil, errOrderStateInvalid
+	}
+	return utils.QRCodePNG(order.RedeemCode, size)
+}
+
+// Redeem 校验核销码并将订单流转为已核销；merchantID 大于 0 时只能核销自己名下门店的订单。
+// 同一核销码通过 Redis 锁串行处理，数据库以订单状态作为更新条件，保证只会核销一次
+func (s *RedemptionService) Redeem(ctx context.Context, merchantID int64, req RedeemRequest) (*model.VoucherOrder, error) {
+	code := strings.TrimSpace(req.Code)
+	if len(code) != redeemCodeDigits || req.ShopID <= 0 {
+		return nil, errRedeemCodeInvalid
+	}
+	if merchantID > 0 {
+		if err := s.shops.CheckOwner(ctx, req.ShopID, merchantID); err != nil {
+			return nil, err
+		}
+	}
+	mu := lock.New(s.rdb, utils.ORDER_REDEEM_LOCK+code, redeemLockTTL)
+	locked, err := mu.TryLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, errRedeemBusy
+	}
+	defer func() { _ = mu.Unlock(context.WithoutCancel(ctx)) }()
+
+	var order *model.VoucherOrder
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var id int64
+		if err := tx.Model(&model.VoucherOrder{}).Where("redeem_code = ?", code).Pluck("id", &id).Error; err != nil {
+			return err
+		}
+		if id == 0 {
+			return errRedeemCodeInvalid
+		}
+		var err error
+		if order, err = lockOrderTx(tx, id); err != nil {
+			return err
+		}
+		if order.Status == model.OrderStatusUsed {
+			return errRedeemUsed
+		}
+		if !CanTransitOrder(order.Status, model.OrderStatusUsed) {
+			return errOrderStateInvalid
+		}
+		// 转赠中的订单归属未定，不允许核销
+		pending, err := hasPendingTransferTx(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return errOrderStateInvalid
+		}
+		// 未成团的拼团订单不能核销
+		forming, err := isGroupFormingTx(tx, order)
+		if err != nil {
+			return err
+		}
+		if forming {
+			return errGroupBuyForming
+		}
+		var voucher model.Voucher
+		if err := tx.First(&voucher, order.VoucherID).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := EvaluateVoucherRules(&voucher, VoucherRuleContext{
+			Stage:       VoucherRuleStageWriteOff,
+			Now:         now,
+			ShopID:      req.ShopID,
+			SpendAmount: req.SpendAmount,
+		}); err != nil {
+			return err
+		}
+		if err := s.state.TransitTx(tx, order, model.OrderStatusUsed, map[string]interface{}{
+			"use_time":    now,
+			"use_shop_id": req.ShopID,
+		}); err != nil {
+			return err
+		}
+		order.UseTime = &now
+		order.UseShopID = req.ShopID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("order redeemed",
+		zap.Int64("orderId", order.ID),
+		zap.Int64("shopId", req.ShopID),
+		zap.Int64("operator", merchantID),
+	)
+	return order, nil
+}
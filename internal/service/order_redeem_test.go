@@ -0,0 +1,21 @@
+package service
+
+import "testing"
+
+// TestNewRedeemCode 校验核销码为固定位数的纯数字
+func TestNewRedeemCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := newRedeemCode()
+		if err != nil {
+			t.Fatalf("newRedeemCode: %v", err)
+		}
+		if len(code) != redeemCodeDigits {
+			t.Fatalf("code %q length = %d, want %d", code, len(code), redeemCodeDigits)
+		}
+		for _, c := range code {
+			if c < '0' || c > '9' {
+				t.Fatalf("code %q contains non-digit", code)
+			}
+		}
+	}
+}
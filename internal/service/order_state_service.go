@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+)
+
+// orderTransitions 订单状态机：key 为当前状态，value 为允许流转到的状态
+var orderTransitions = map[int][]int{
+	model.OrderStatusUnpaid:    {model.OrderStatusPaid, model.OrderStatusCancelled},
+	model.OrderStatusPaid:      {model.OrderStatusUsed, model.OrderStatusRefunding, model.OrderStatusRefunded},
+	model.OrderStatusRefunding: {model.OrderStatusRefunded, model.OrderStatusPaid},
+}
+
+// CanTransitOrder 判断订单能否从 from 流转到 to
+func CanTransitOrder(from, to int) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStateService 统一管理订单状态流转，所有修改订单状态的操作都应经过这里
+type OrderStateService struct {
+	db *gorm.DB
+}
+
+// NewOrderStateService 创建 OrderStateService 实例
+func NewOrderStateService(db *gorm.DB) *OrderStateService {
+	return &OrderStateService{db: db}
+}
+
+// Transit 加锁读取订单并流转状态，userID 为 0 时不校验订单归属
+func (s *OrderStateService) Transit(ctx context.Context, orderID, userID int64, to int, fields map[string]interface{}) (*model.VoucherOrder, error) {
+	var order *model.VoucherOrder
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOrderTx(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if userID > 0 && order.UserID != userID {
+			return errOrderNotFound
+		}
+		return s.TransitTx(tx, order, to, fields)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// TransitTx 在事务内将订单从当前状态流转到 to，fields 为需要同时更新的其他字段；
+// 以当前状态作为更新条件，并发修改时只有一方成功
+func (s *OrderStateService) TransitTx(tx *gorm.DB, order *model.VoucherOrder, to int, fields map[string]interface{}) error {
+	if !CanTransitOrder(order.Status, to) {
+		return errOrderStateInvalid
+	}
+	updates := make(map[string]interface{}, len(fields)+2)
+	for k, v := range fields {
+		updates[k] = v
+	}
+	updates["status"] = to
+	updates["update_time"] = time.Now()
+	res := tx.Model(&model.VoucherOrder{}).
+		Where("id = ? AND status = ?", order.ID, order.Status).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errOrderStateInvalid
+	}
+	order.Status = to
+	return nil
+}
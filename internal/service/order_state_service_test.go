@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+
+	"hmdp-backend/internal/model"
+)
+
+// TestCanTransitOrder 校验订单状态机只允许合法的状态流转
+func TestCanTransitOrder(t *testing.T) {
+	cases := []struct {
+		from, to int
+		want     bool
+	}{
+		{model.OrderStatusUnpaid, model.OrderStatusPaid, true},
+		{model.OrderStatusUnpaid, model.OrderStatusCancelled, true},
+		{model.OrderStatusPaid, model.OrderStatusUsed, true},
+		{model.OrderStatusPaid, model.OrderStatusRefunded, true},
+		{model.OrderStatusRefunding, model.OrderStatusRefunded, true},
+		{model.OrderStatusUnpaid, model.OrderStatusUsed, false},
+		{model.OrderStatusUnpaid, model.OrderStatusRefunded, false},
+		{model.OrderStatusUsed, model.OrderStatusRefunded, false},
+		{model.OrderStatusCancelled, model.OrderStatusPaid, false},
+		{model.OrderStatusRefunded, model.OrderStatusPaid, false},
+	}
+	for _, c := range cases {
+		if got := CanTransitOrder(c.from, c.to); got != c.want {
+			t.Fatalf("CanTransitOrder(%d, %d) = %v, want %v", c.from, c.to, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	// OrderQueueKafka 通过 Kafka 主题异步创建订单（默认）
+	OrderQueueKafka = "kafka"
+	// OrderQueueStream 通过 Redis Streams 消费者组异步创建订单
+	OrderQueueStream = "stream"
+	// OrderQueueOutbox 订单消息先持久化到发件箱表，由中继投递到 Kafka，Kafka 短暂不可用时不影响下单；
+	// 秒杀资格在 Redis 中扣减、订单由消费者落库，写入发件箱时没有可共享的业务事务，
+	// 因此这里只是数据库持久化队列：Redis 扣减成功而发件箱写入失败时依赖下单失败的回滚补偿
+	OrderQueueOutbox = "outbox"
+
+	orderStreamMaxLen = 100000
+	orderStreamBatch  = 10
+	orderStreamBlock  = 2 * time.Second
+	// orderStreamMinIdle 待确认消息空闲超过该时长视为消费者已崩溃，由其他消费者认领
+	orderStreamMinIdle    = time.Minute
+	orderStreamClaimEvery = 30 * time.Second
+)
+
+// publishStream 将订单消息写入 Redis Stream
+func (s *VoucherOrderService) publishStream(ctx context.Context, msg orderMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return s.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: utils.ORDER_STREAM_KEY,
+		MaxLen: orderStreamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"data": data},
+	}).Err()
+}
+
+// startStreamConsumers 创建消费者组并启动消费与认领协程
+func (s *VoucherOrderService) startStreamConsumers(ctx context.Context) {
+	err := s.rdb.XGroupCreateMkStream(ctx, utils.ORDER_STREAM_KEY, utils.ORDER_STREAM_GROUP, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		s.log.Error("create order stream group failed", zap.Error(err))
+	}
+	host, _ := os.Hostname()
+	consumer := fmt.Sprintf("%s-%d", host, os.Getpid())
+	s.goBackground(ctx, func(ctx context.Context) { s.consumeStream(ctx, consumer) })
+	s.goBackground(ctx, func(ctx context.Context) { s.claimStream(ctx, consumer) })
+}
+
+// consumeStream 读取分配给本消费者的新消息，处理成功后才 XACK
+func (s *VoucherOrderService) consumeStream(ctx context.Context, consumer string) {
+	s.log.Info("consumeStream started", zap.String("consumer", consumer))
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		streams, err := s.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    utils.ORDER_STREAM_GROUP,
+			Consumer: consumer,
+			Streams:  []string{utils.ORDER_STREAM_KEY, ">"},
+			Count:    orderStreamBatch,
+			Block:    orderStreamBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			s.log.Error("consumeStream read error", zap.Error(err))
+			time.Sleep(time.Second)
+			continue
+		}
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				s.handleStreamMessage(context.WithoutCancel(ctx), msg, 1)
+			}
+		}
+	}
+}
+
+// claimStream 定期认领空闲过久的待确认消息（XAUTOCLAIM），接管崩溃消费者未处理完的订单
+func (s *VoucherOrderService) claimStream(ctx context.Context, consumer string) {
+	ticker := time.NewTicker(orderStreamClaimEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		start := "0-0"
+		for {
+			msgs, next, err := s.rdb.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+				Stream:   utils.ORDER_STREAM_KEY,
+				Group:    utils.ORDER_STREAM_GROUP,
+				Consumer: consumer,
+				MinIdle:  orderStreamMinIdle,
+				Start:    start,
+				Count:    orderStreamBatch,
+			}).Result()
+			if err != nil {
+				s.log.Error("claimStream error", zap.Error(err))
+				break
+			}
+			for _, msg := range msgs {
+				s.handleStreamMessage(context.WithoutCancel(ctx), msg, s.streamDeliveries(ctx, msg.ID))
+			}
+			if next == "0-0" || len(msgs) == 0 {
+				break
+			}
+			start = next
+		}
+	}
+}
+
+// streamDeliveries 查询消息的投递次数，查询失败时按首次投递处理
+func (s *VoucherOrderService) streamDeliveries(ctx context.Context, id string) int64 {
+	pending, err := s.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: utils.ORDER_STREAM_KEY,
+		Group:  utils.ORDER_STREAM_GROUP,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil || len(pending) == 0 {
+		return 1
+	}
+	return pending[0].RetryCount
+}
+
+// handleStreamMessage 创建订单：成功或业务失败（补偿 Redis）后确认消息；
+// 可重试的失败不确认，留在待确认列表等待认领，超过最大投递次数后补偿并丢弃
+func (s *VoucherOrderService) handleStreamMessage(ctx context.Context, msg redis.XMessage, deliveries int64) {
+	raw, _ := msg.Values["data"].(string)
+	var payload orderMessage
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		s.log.Error("stream message parse error", zap.String("id", msg.ID), zap.Error(err))
+		s.ackStream(ctx, msg.ID)
+		return
+	}
+	err := s.createOrderTx(ctx, payload)
+	if err == nil {
+		s.scheduleOrderTimeout(ctx, payload.OrderID, time.Unix(payload.CreatedAt, 0))
+		s.untrackQueued(ctx, payload.OrderID)
+		s.ackStream(ctx, msg.ID)
+		return
+	}
+	if !isRetryableErr(err) || deliveries > maxRetryCount {
+		s.compensateRedis(ctx, payload)
+		s.log.Error("stream order dropped",
+			zap.String("id", msg.ID),
+			zap.Int64("orderId", payload.OrderID),
+			zap.Int64("deliveries", deliveries),
+			zap.Error(err),
+		)
+		s.ackStream(ctx, msg.ID)
+		return
+	}
+	s.log.Warn("stream order failed, left pending",
+		zap.String("id", msg.ID),
+		zap.Int64("orderId", payload.OrderID),
+		zap.Int64("deliveries", deliveries),
+		zap.Error(err),
+	)
+}
+
+func (s *VoucherOrderService) ackStream(ctx context.Context, id string) {
+	if err := s.rdb.XAck(ctx, utils.ORDER_STREAM_KEY, utils.ORDER_STREAM_GROUP, id).Err(); err != nil {
+		s.log.Error("stream ack failed", zap.String("id", id), zap.Error(err))
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	defaultPayTimeout = 15 * time.Minute
+	// orderTimeoutPoll 扫描延迟队列的周期
+	orderTimeoutPoll = time.Second
+	// orderTimeoutBatch 单次扫描最多处理的到期订单数
+	orderTimeoutBatch = 100
+	// orderTimeoutRetryDelay 取消失败时重新入队的延迟
+	orderTimeoutRetryDelay = 30 * time.Second
+)
+
+// scheduleOrderTimeout 将订单加入延迟队列（ZSET，score 为到期时间戳），到期仍未支付则自动取消
+func (s *VoucherOrderService) scheduleOrderTimeout(ctx context.Context, orderID int64, createdAt time.Time) {
+	deadline := createdAt.Add(s.payTimeout)
+	if err := s.rdb.ZAdd(ctx, utils.ORDER_TIMEOUT_KEY, redis.Z{
+		Score:  float64(deadline.Unix()),
+		Member: strconv.FormatInt(orderID, 10),
+	}).Err(); err != nil {
+		s.log.Warn("schedule order timeout failed", zap.Int64("orderId", orderID), zap.Error(err))
+	}
+}
+
+// cancelTimeoutLoop 轮询延迟队列中到期的订单并取消；通过 ZREM 抢占，多实例下同一订单只会被处理一次
+func (s *VoucherOrderService) cancelTimeoutLoop(ctx context.Context) {
+	ticker := time.NewTicker(orderTimeoutPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		members, err := s.rdb.ZRangeByScore(ctx, utils.ORDER_TIMEOUT_KEY, &redis.ZRangeBy{
+			Min:   "-inf",
+			Max:   strconv.FormatInt(time.Now().Unix(), 10),
+			Count: orderTimeoutBatch,
+		}).Result()
+		if err != nil {
+			s.log.Warn("scan order timeout queue failed", zap.Error(err))
+			continue
+		}
+		for _, member := range members {
+			removed, err := s.rdb.ZRem(ctx, utils.ORDER_TIMEOUT_KEY, member).Result()
+			if err != nil || removed == 0 {
+				continue
+			}
+			orderID, err := strconv.ParseInt(member, 10, 64)
+			if err != nil {
+				continue
+			}
+			if err := s.cancelUnpaidOrder(context.WithoutCancel(ctx), orderID); err != nil {
+				s.log.Error("cancel timeout order failed, requeued", zap.Int64("orderId", orderID), zap.Error(err))
+				_ = s.rdb.ZAdd(ctx, utils.ORDER_TIMEOUT_KEY, redis.Z{
+					Score:  float64(time.Now().Add(orderTimeoutRetryDelay).Unix()),
+					Member: member,
+				}).Err()
+			}
+		}
+	}
+}
+
+// cancelUnpaidOrder 取消未支付订单：订单状态流转与数据库库存回补在同一事务内完成，
+// 提交后回补 Redis 库存并释放用户的下单资格；订单已支付或已取消时直接跳过
+func (s *VoucherOrderService) cancelUnpaidOrder(ctx context.Context, orderID int64) error {
+	var order *model.VoucherOrder
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOrderTx(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != model.OrderStatusUnpaid {
+			order = nil
+			return nil
+		}
+		if err := s.state.TransitTx(tx, order, model.OrderStatusCancelled, nil); err != nil {
+			return err
+		}
+		return tx.Model(&model.SeckillVoucher{}).
+			Where("voucher_id = ?", order.VoucherID).
+			Update("stock", gorm.Expr("stock + 1")).Error
+	})
+	if errors.Is(err, errOrderNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if order == nil {
+		return nil
+	}
+	s.compensateRedis(ctx, orderMessage{OrderID: order.ID, UserID: order.UserID, VoucherID: order.VoucherID})
+	s.log.Info("unpaid order cancelled",
+		zap.Int64("orderId", order.ID),
+		zap.Int64("voucherId", order.VoucherID),
+		zap.Int64("userId", order.UserID),
+	)
+	return nil
+}
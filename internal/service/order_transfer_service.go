@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+var (
+	errTransferNotFound    = errors.New("转赠记录不存在")
+	errTransferNotPending  = errors.New("转赠已处理")
+	errTransferSelf        = errors.New("不能转赠给自己")
+	errTransferPending     = errors.New("订单正在转赠中")
+	errTransferRecipient   = errors.New("接收用户不存在")
+	errTransferLimitExceed = errors.New("对方已持有该优惠券，每人限购一单")
+	errTransferExpired     = errors.New("转赠已过期")
+	errClaimCodeInvalid    = errors.New("领取码无效")
+)
+
+const (
+	// claimCodeLength 领取码长度
+	claimCodeLength = 8
+	// claimCodeTTL 领取码有效期
+	claimCodeTTL = 7 * 24 * time.Hour
+	// claimCodeAlphabet 领取码字符集，去掉易混淆的 0/O、1/I/L
+	claimCodeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	// 领取码尝试频率限制：每个用户每秒 1 次、每小时 10 次，防止暴力枚举领取码
+	claimAttemptInterval = time.Second
+	claimAttemptWindow   = time.Hour
+	claimAttemptMax      = 10
+)
+
+// moveVoucherSlotScript 原子地将限购资格从转出方移给接收方；接收方已持有资格时返回 1 且不做修改，
+// 并发接收同一张券的多个转赠时只有一个能成功
+var moveVoucherSlotScript = redis.NewScript(`
+if redis.call('SISMEMBER', KEYS[1], ARGV[2]) == 1 then
+  return 1
+end
+redis.call('SREM', KEYS[1], ARGV[1])
+redis.call('SADD', KEYS[1], ARGV[2])
+return 0
+`)
+
+// ClaimLimitError 领取码尝试过于频繁，RetryAfter 为可重试的剩余秒数
+type ClaimLimitError struct {
+	RetryAfter int64
+}
+
+func (e *ClaimLimitError) Error() string {
+	return fmt.Sprintf("领取尝试过于频繁，请%d秒后再试", e.RetryAfter)
+}
+
+// GiftTarget 转赠对象，手机号与用户ID二选一
+type GiftTarget struct {
+	Phone  string `json:"phone"`
+	UserID int64  `json:"userId"`
+}
+
+// OrderTransferService 处理未使用订单在用户之间的转赠
+type OrderTransferService struct {
+	db       *gorm.DB
+	rdb      *redis.Client
+	notifier *NotificationService
+	log      *zap.Logger
+}
+
+// NewOrderTransferService 创建 OrderTransferService 实例
+func NewOrderTransferService(db *gorm.DB, rdb *redis.Client, notifier *NotificationService, log *zap.Logger) *OrderTransferService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &OrderTransferService{db: db, rdb: rdb, notifier: notifier, log: log}
+}
+
+// Gift 发起转赠：创建待接收记录并通知接收方
+func (s *OrderTransferService) Gift(ctx context.Context, fromUserID, orderID int64, target GiftTarget) (*model.VoucherOrderTransfer, error) {
+	toUserID, err := s.resolveRecipient(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if toUserID == fromUserID {
+		return nil, errTransferSelf
+	}
+	transfer := &model.VoucherOrderTransfer{ToUserID: toUserID}
+	if err := s.createTransfer(ctx, fromUserID, orderID, transfer); err != nil {
+		return nil, err
+	}
+	if s.notifier != nil {
+		_ = s.notifier.Dispatch(ctx, toUserID, Notification{
+			Type:    NotificationTypeOrderGift,
+			Title:   "你收到一张优惠券转赠",
+			Content: "好友向你转赠了一张优惠券，请及时确认接收",
+			Data: map[string]string{
+				"transferId": strconv.FormatInt(transfer.ID, 10),
+				"orderId":    strconv.FormatInt(orderID, 10),
+			},
+		})
+	}
+	return transfer, nil
+}
+
+// GiftByCode 生成一次性领取码发起转赠，转出方将领取码分享给好友，任意用户凭码领取一次；
+// 领取码在 claimCodeTTL 后失效，失效前转出方可撤回
+func (s *OrderTransferService) GiftByCode(ctx context.Context, fromUserID, orderID int64) (*model.VoucherOrderTransfer, error) {
+	code, err := newClaimCode()
+	if err != nil {
+		return nil, err
+	}
+	expire := time.Now().Add(claimCodeTTL)
+	transfer := &model.VoucherOrderTransfer{ClaimCode: code, ExpireTime: &expire}
+	if err := s.createTransfer(ctx, fromUserID, orderID, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// createTransfer 校验订单可转赠后创建待接收的转赠记录；指定接收方时同时校验其限购资格
+func (s *OrderTransferService) createTransfer(ctx context.Context, fromUserID, orderID int64, transfer *model.VoucherOrderTransfer) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrderTx(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.UserID != fromUserID {
+			return errOrderNotFound
+		}
+		if !isOrderTransferable(order.Status) {
+			return errOrderStateInvalid
+		}
+		pending, err := hasPendingTransferTx(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return errTransferPending
+		}
+		forming, err := isGroupFormingTx(tx, order)
+		if err != nil {
+			return err
+		}
+		if forming {
+			return errGroupBuyForming
+		}
+		// 指定接收方时提前拒绝已持有该券的用户，接收时再原子地校验并转移资格
+		if transfer.ToUserID > 0 {
+			held, err := s.holdsVoucher(ctx, order.VoucherID, transfer.ToUserID)
+			if err != nil {
+				return err
+			}
+			if held {
+				return errTransferLimitExceed
+			}
+		}
+		transfer.OrderID = orderID
+		transfer.VoucherID = order.VoucherID
+		transfer.FromUserID = fromUserID
+		transfer.Status = model.TransferStatusPending
+		return tx.Create(transfer).Error
+	})
+}
+
+// Accept 接收转赠：订单归属变更，同时维护限购集合
+func (s *OrderTransferService) Accept(ctx context.Context, userID, transferID int64) error {
+	_, err := s.accept(ctx, userID, func(tx *gorm.DB, transfer *model.VoucherOrderTransfer) error {
+		if err := lockTransferTx(tx, transferID, transfer); err != nil {
+			return err
+		}
+		if transfer.ToUserID != userID {
+			return errTransferNotFound
+		}
+		return nil
+	})
+	return err
+}
+
+// Claim 凭领取码领取转赠；领取码以转赠记录的行锁串行处理，领取后记录转为已接收，同一领取码只能领取一次；
+// 每个用户的尝试次数受频率限制，超限返回 ClaimLimitError
+func (s *OrderTransferService) Claim(ctx context.Context, userID int64, code string) (*model.VoucherOrderTransfer, error) {
+	limitErr, err := acquireAttempts(ctx, s.rdb, utils.GIFT_CLAIM_LIMIT_KEY, [][2]string{{"user", strconv.FormatInt(userID, 10)}},
+		claimAttemptInterval, claimAttemptWindow, claimAttemptMax)
+	if err != nil {
+		return nil, err
+	}
+	if limitErr != nil {
+		return nil, &ClaimLimitError{RetryAfter: limitErr.RetryAfter}
+	}
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != claimCodeLength {
+		return nil, errClaimCodeInvalid
+	}
+	transfer, err := s.accept(ctx, userID, func(tx *gorm.DB, transfer *model.VoucherOrderTransfer) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("claim_code = ?", code).Take(transfer).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errClaimCodeInvalid
+		}
+		if err != nil {
+			return err
+		}
+		if transfer.FromUserID == userID {
+			return errTransferSelf
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("order gift claimed",
+		zap.Int64("transferId", transfer.ID),
+		zap.Int64("orderId", transfer.OrderID),
+		zap.Int64("fromUserId", transfer.FromUserID),
+		zap.Int64("toUserId", userID),
+	)
+	if s.notifier != nil {
+		_ = s.notifier.Dispatch(ctx, transfer.FromUserID, Notification{
+			Type:    NotificationTypeOrderGift,
+			Title:   "你转赠的优惠券已被领取",
+			Content: "好友已通过领取码领取了你转赠的优惠券",
+			Data: map[string]string{
+				"transferId": strconv.FormatInt(transfer.ID, 10),
+				"orderId":    strconv.FormatInt(transfer.OrderID, 10),
+			},
+		})
+	}
+	return transfer, nil
+}
+
+// accept 在事务内由 locate 加锁定位转赠记录，校验后将订单转给 userID 并记录接收方；
+// 限购资格在事务内通过 Lua 原子地校验并转移，事务失败时移回转出方
+func (s *OrderTransferService) accept(ctx context.Context, userID int64, locate func(*gorm.DB, *model.VoucherOrderTransfer) error) (*model.VoucherOrderTransfer, error) {
+	var transfer model.VoucherOrderTransfer
+	slotMoved := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := locate(tx, &transfer); err != nil {
+			return err
+		}
+		if transfer.Status != model.TransferStatusPending {
+			return errTransferNotPending
+		}
+		if transfer.ExpireTime != nil && time.Now().After(*transfer.ExpireTime) {
+			return errTransferExpired
+		}
+		order, err := lockOrderTx(tx, transfer.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.UserID != transfer.FromUserID || !isOrderTransferable(order.Status) {
+			return errOrderStateInvalid
+		}
+		held, err := moveVoucherSlotScript.Run(ctx, s.rdb, []string{fmt.Sprintf(orderSetFmt, transfer.VoucherID)},
+			transfer.FromUserID, userID).Int()
+		if err != nil {
+			return err
+		}
+		if held == 1 {
+			return errTransferLimitExceed
+		}
+		slotMoved = true
+		updates := map[string]interface{}{"user_id": userID}
+		// 已支付订单重新生成核销码，转出方保存的旧码随之失效
+		if order.RedeemCode != "" {
+			code, err := newRedeemCode()
+			if err != nil {
+				return err
+			}
+			updates["redeem_code"] = code
+		}
+		if err := tx.Model(&model.VoucherOrder{}).
+			Where("id = ? AND user_id = ?", order.ID, transfer.FromUserID).
+			Updates(updates).Error; err != nil {
+			return err
+		}
+		transfer.ToUserID = userID
+		transfer.Status = model.TransferStatusAccepted
+		return tx.Model(&model.VoucherOrderTransfer{}).
+			Where("id = ?", transfer.ID).
+			Updates(map[string]interface{}{
+				"to_user_id": userID,
+				"status":     model.TransferStatusAccepted,
+			}).Error
+	})
+	if err != nil {
+		if slotMoved {
+			s.restoreVoucherSlot(context.WithoutCancel(ctx), transfer.VoucherID, transfer.FromUserID, userID)
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// restoreVoucherSlot 接收事务失败时将限购资格从接收方移回转出方
+func (s *OrderTransferService) restoreVoucherSlot(ctx context.Context, voucherID, fromUserID, toUserID int64) {
+	orderSetKey := fmt.Sprintf(orderSetFmt, voucherID)
+	if _, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, orderSetKey, toUserID)
+		pipe.SAdd(ctx, orderSetKey, fromUserID)
+		return nil
+	}); err != nil {
+		s.log.Error("restore purchase limit set failed",
+			zap.Int64("voucherId", voucherID),
+			zap.Int64("fromUserId", fromUserID),
+			zap.Int64("toUserId", toUserID),
+			zap.Error(err),
+		)
+	}
+}
+
+// Reject 接收方拒绝转赠
+func (s *OrderTransferService) Reject(ctx context.Context, userID, transferID int64) error {
+	return s.finish(ctx, transferID, func(t *model.VoucherOrderTransfer) bool { return t.ToUserID == userID }, model.TransferStatusRejected)
+}
+
+// Withdraw 转出方撤回转赠
+func (s *OrderTransferService) Withdraw(ctx context.Context, userID, transferID int64) error {
+	return s.finish(ctx, transferID, func(t *model.VoucherOrderTransfer) bool { return t.FromUserID == userID }, model.TransferStatusWithdrawn)
+}
+
+// ListIncoming 查询待当前用户接收的转赠
+func (s *OrderTransferService) ListIncoming(ctx context.Context, userID int64) ([]model.VoucherOrderTransfer, error) {
+	var transfers []model.VoucherOrderTransfer
+	err := s.db.WithContext(ctx).
+		Where("to_user_id = ? AND status = ?", userID, model.TransferStatusPending).
+		Order("id DESC").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+// finish 将待接收的转赠流转为终态
+func (s *OrderTransferService) finish(ctx context.Context, transferID int64, allowed func(*model.VoucherOrderTransfer) bool, status int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var transfer model.VoucherOrderTransfer
+		if err := lockTransferTx(tx, transferID, &transfer); err != nil {
+			return err
+		}
+		if !allowed(&transfer) {
+			return errTransferNotFound
+		}
+		if transfer.Status != model.TransferStatusPending {
+			return errTransferNotPending
+		}
+		return tx.Model(&model.VoucherOrderTransfer{}).Where("id = ?", transferID).Update("status", status).Error
+	})
+}
+
+// resolveRecipient 根据手机号或用户ID查找接收方
+func (s *OrderTransferService) resolveRecipient(ctx context.Context, target GiftTarget) (int64, error) {
+	var user model.User
+	query := s.db.WithContext(ctx).Select("id")
+	switch {
+	case target.UserID > 0:
+		query = query.Where("id = ?", target.UserID)
+	case target.Phone != "":
+		if utils.IsPhoneInvalid(target.Phone) {
+			return 0, errors.New("phone is invalid")
+		}
+		query = query.Where("phone = ?", target.Phone)
+	default:
+		return 0, errors.New("请指定接收用户")
+	}
+	err := query.Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, errTransferRecipient
+	}
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// holdsVoucher 判断用户是否已占用该券的限购资格
+func (s *OrderTransferService) holdsVoucher(ctx context.Context, voucherID, userID int64) (bool, error) {
+	return s.rdb.SIsMember(ctx, fmt.Sprintf(orderSetFmt, voucherID), userID).Result()
+}
+
+// isOrderTransferable 仅已支付且未使用的订单允许转赠，未支付订单的支付责任与超时取消不随转赠转移
+func isOrderTransferable(status int) bool {
+	return status == model.OrderStatusPaid
+}
+
+// hasPendingTransferTx 判断订单是否存在待接收且未过期的转赠
+func hasPendingTransferTx(tx *gorm.DB, orderID int64) (bool, error) {
+	var count int64
+	err := tx.Model(&model.VoucherOrderTransfer{}).
+		Where("order_id = ? AND status = ?", orderID, model.TransferStatusPending).
+		Where("expire_time IS NULL OR expire_time > ?", time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// newClaimCode 生成随机领取码
+func newClaimCode() (string, error) {
+	limit := big.NewInt(int64(len(claimCodeAlphabet)))
+	code := make([]byte, claimCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		code[i] = claimCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// lockTransferTx 在事务内对转赠记录加行锁
+func lockTransferTx(tx *gorm.DB, transferID int64, transfer *model.VoucherOrderTransfer) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(transfer, transferID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errTransferNotFound
+	}
+	return err
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestNewClaimCode 校验领取码为固定长度且只包含不易混淆的字符
+func TestNewClaimCode(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		code, err := newClaimCode()
+		if err != nil {
+			t.Fatalf("newClaimCode: %v", err)
+		}
+		if len(code) != claimCodeLength {
+			t.Fatalf("code %q length = %d, want %d", code, len(code), claimCodeLength)
+		}
+		for _, c := range code {
+			if !strings.ContainsRune(claimCodeAlphabet, c) {
+				t.Fatalf("code %q contains unexpected %q", code, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// orderWorkerQueueSize 每个 worker 的待处理消息缓冲，缓冲满时拉取协程阻塞，形成背压
+const orderWorkerQueueSize = 64
+
+type orderWork struct {
+	msg     kafka.Message
+	payload orderMessage
+}
+
+// orderWorkerPool 并发消费订单消息：按券ID+用户ID哈希分配 worker，同一用户对同一张券的消息由同一 worker 按拉取顺序处理，
+// 热门券的订单也能分散到多个 worker（库存扣减为条件更新，并发执行安全）；
+// offset 按分区连续提交，前面的消息未处理完时不提交后面的 offset，进程崩溃后从最早未完成的消息重新消费；
+// 处理失败的消息转入死信后从待提交队列移除，不阻塞该分区后续 offset 的提交
+type orderWorkerPool struct {
+	svc     *VoucherOrderService
+	reader  *kafka.Reader
+	name    string
+	handler consumeHandler
+	queues  []chan orderWork
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	offsets map[int]*partitionOffsets
+}
+
+// partitionOffsets 单个分区已拉取、尚未提交的消息
+type partitionOffsets struct {
+	pending []int64                 // 按拉取顺序排列的 offset
+	done    map[int64]kafka.Message // 已处理完成、等待前序消息完成的消息
+}
+
+func (s *VoucherOrderService) newOrderWorkerPool(ctx context.Context, reader *kafka.Reader, name string, workers int, handler consumeHandler) *orderWorkerPool {
+	p := &orderWorkerPool{
+		svc:     s,
+		reader:  reader,
+		name:    name,
+		handler: handler,
+		queues:  make([]chan orderWork, workers),
+		offsets: make(map[int]*partitionOffsets),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan orderWork, orderWorkerQueueSize)
+		p.wg.Add(1)
+		go p.work(ctx, p.queues[i])
+	}
+	return p
+}
+
+// dispatch 登记消息的 offset 后按券ID+用户ID分配给 worker；须在拉取协程中按拉取顺序调用
+func (p *orderWorkerPool) dispatch(msg kafka.Message, payload orderMessage) {
+	p.track(msg)
+	idx := (uint64(payload.VoucherID)*31 + uint64(payload.UserID)) % uint64(len(p.queues))
+	p.queues[idx] <- orderWork{msg: msg, payload: payload}
+}
+
+// skip 跳过无法解析的消息，视为已处理
+func (p *orderWorkerPool) skip(ctx context.Context, msg kafka.Message) {
+	p.track(msg)
+	p.ack(ctx, msg)
+}
+
+// stop 停止分发并等待 worker 退出；退出信号到来后尚未开始处理的消息不再处理，也不会提交 offset
+func (p *orderWorkerPool) stop() {
+	for _, q := range p.queues {
+		close(q)
+	}
+	p.wg.Wait()
+}
+
+func (p *orderWorkerPool) work(ctx context.Context, queue <-chan orderWork) {
+	defer p.wg.Done()
+	for w := range queue {
+		if ctx.Err() != nil {
+			continue
+		}
+		if p.svc.consumeMessage(ctx, p.name, w.msg, w.payload, p.handler) {
+			p.ack(context.WithoutCancel(ctx), w.msg)
+			continue
+		}
+		if ctx.Err() != nil {
+			// 退出时处理中断的消息不提交，重启后重新消费
+			continue
+		}
+		p.fail(context.WithoutCancel(ctx), w)
+	}
+}
+
+// fail 处理失败（重试队列写入失败等）的消息直接转入死信，并从待提交队列移除，
+// 与串行消费一致：失败消息不会阻塞后续 offset 的提交
+func (p *orderWorkerPool) fail(ctx context.Context, w orderWork) {
+	payload := w.payload
+	if payload.LastError == "" {
+		payload.LastError = "consume failed"
+	}
+	if p.svc.dlqWriter != nil {
+		if err := p.svc.publishDLQ(ctx, payload); err != nil {
+			p.svc.log.Error(fmt.Sprintf("%s drop failed message", p.name), zap.Error(err), zap.Int64("orderId", payload.OrderID), zap.Int64("offset", w.msg.Offset))
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	po := p.offsets[w.msg.Partition]
+	if po == nil {
+		return
+	}
+	if last, ok := po.drop(w.msg.Offset); ok {
+		p.commit(ctx, last)
+	}
+}
+
+func (p *orderWorkerPool) track(msg kafka.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	po, ok := p.offsets[msg.Partition]
+	if !ok {
+		po = &partitionOffsets{done: make(map[int64]kafka.Message)}
+		p.offsets[msg.Partition] = po
+	}
+	po.pending = append(po.pending, msg.Offset)
+}
+
+// ack 标记消息处理完成，并提交该分区从头开始连续完成的最后一条消息；
+// 提交在锁内进行，保证同一分区的 offset 只会前进
+func (p *orderWorkerPool) ack(ctx context.Context, msg kafka.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	po := p.offsets[msg.Partition]
+	if po == nil {
+		return
+	}
+	if last, ok := po.complete(msg); ok {
+		p.commit(ctx, last)
+	}
+}
+
+// commit 提交 offset，调用方须持有 p.mu
+func (p *orderWorkerPool) commit(ctx context.Context, last kafka.Message) {
+	if err := p.reader.CommitMessages(ctx, last); err != nil {
+		p.svc.log.Error(fmt.Sprintf("%s commit error", p.name), zap.Error(err), zap.Int("partition", last.Partition), zap.Int64("offset", last.Offset))
+	}
+}
+
+// complete 记录消息已完成，返回队首连续完成的最后一条消息
+func (po *partitionOffsets) complete(msg kafka.Message) (kafka.Message, bool) {
+	po.done[msg.Offset] = msg
+	return po.advance()
+}
+
+// drop 将失败的消息从待提交队列移除，返回移除后队首连续完成的最后一条消息；
+// 失败消息本身不提交，由其后已完成消息的提交一并越过
+func (po *partitionOffsets) drop(offset int64) (kafka.Message, bool) {
+	for i, o := range po.pending {
+		if o == offset {
+			po.pending = append(po.pending[:i], po.pending[i+1:]...)
+			break
+		}
+	}
+	return po.advance()
+}
+
+// advance 弹出队首连续完成的消息，返回其中最后一条
+func (po *partitionOffsets) advance() (kafka.Message, bool) {
+	var last kafka.Message
+	ok := false
+	for len(po.pending) > 0 {
+		m, finished := po.done[po.pending[0]]
+		if !finished {
+			break
+		}
+		delete(po.done, po.pending[0])
+		po.pending = po.pending[1:]
+		last, ok = m, true
+	}
+	return last, ok
+}
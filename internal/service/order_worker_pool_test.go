@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// TestPartitionOffsetsComplete 校验乱序完成的消息只按拉取顺序连续提交，失败移除的消息不阻塞提交
+func TestPartitionOffsetsComplete(t *testing.T) {
+	po := &partitionOffsets{pending: []int64{10, 11, 12, 13}, done: make(map[int64]kafka.Message)}
+	steps := []struct {
+		offset int64
+		commit int64 // -1 表示不提交
+	}{
+		{12, -1},
+		{11, -1},
+		{10, 12},
+		{13, 13},
+	}
+	for _, step := range steps {
+		last, ok := po.complete(kafka.Message{Offset: step.offset})
+		if step.commit < 0 {
+			if ok {
+				t.Fatalf("complete(%d) committed %d, want none", step.offset, last.Offset)
+			}
+			continue
+		}
+		if !ok || last.Offset != step.commit {
+			t.Fatalf("complete(%d) = %d/%v, want %d", step.offset, last.Offset, ok, step.commit)
+		}
+	}
+	if len(po.pending) != 0 || len(po.done) != 0 {
+		t.Fatalf("pending=%v done=%d, want empty", po.pending, len(po.done))
+	}
+
+	// 失败的消息移除后不再阻塞后续 offset 的提交
+	po = &partitionOffsets{pending: []int64{10, 11, 12, 13}, done: make(map[int64]kafka.Message)}
+	if _, ok := po.complete(kafka.Message{Offset: 11}); ok {
+		t.Fatalf("complete(11) committed before 10 finished")
+	}
+	if last, ok := po.drop(10); !ok || last.Offset != 11 {
+		t.Fatalf("drop(10) = %d/%v, want 11", last.Offset, ok)
+	}
+	if _, ok := po.drop(13); ok {
+		t.Fatalf("drop(13) committed while 12 pending")
+	}
+	if last, ok := po.complete(kafka.Message{Offset: 12}); !ok || last.Offset != 12 {
+		t.Fatalf("complete(12) = %d/%v, want 12", last.Offset, ok)
+	}
+	if len(po.pending) != 0 || len(po.done) != 0 {
+		t.Fatalf("pending=%v done=%d, want empty", po.pending, len(po.done))
+	}
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+)
+
+const (
+	// outboxPollInterval 中继扫描待投递消息的周期
+	outboxPollInterval = 500 * time.Millisecond
+	// outboxBatch 单次扫描最多投递的消息数
+	outboxBatch = 100
+	// outboxRetention 已投递消息的保留时长，超过后定期清理
+	outboxRetention     = 7 * 24 * time.Hour
+	outboxPurgeInterval = time.Hour
+	outboxLastErrorMax  = 255
+	// outboxMaxAttempts 单条消息最多投递次数，超过后标记为失败，不再阻塞后续消息
+	outboxMaxAttempts = 10
+	// outboxMaxBackoff 投递失败后的最长退避时间
+	outboxMaxBackoff = 5 * time.Minute
+)
+
+// OutboxService 事务发件箱：业务数据与待发送消息在同一事务内写入 tb_outbox，
+// 中继协程再将待投递的消息发送到 Kafka，保证数据库提交的事件至少投递一次；
+// 投递失败的消息按指数退避推迟重试，超过最大次数后标记为失败
+type OutboxService struct {
+	db      *gorm.DB
+	writers map[string]*kafka.Writer
+	log     *zap.Logger
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewOutboxService 创建 OutboxService 实例，按主题注册 Kafka 写入端并启动中继协程
+func NewOutboxService(db *gorm.DB, log *zap.Logger, writers ...*kafka.Writer) *OutboxService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	svc := &OutboxService{db: db, writers: make(map[string]*kafka.Writer), log: log}
+	for _, w := range writers {
+		if w != nil && w.Topic != "" {
+			svc.writers[w.Topic] = w
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.cancel = cancel
+	svc.wg.Add(1)
+	go func() {
+		defer svc.wg.Done()
+		svc.relayLoop(ctx)
+	}()
+	return svc
+}
+
+// Enqueue 在调用方的事务内写入一条待投递消息；tx 未开启事务时等同于单独写入
+func (s *OutboxService) Enqueue(tx *gorm.DB, topic, key string, payload interface{}) error {
+	if _, ok := s.writers[topic]; !ok {
+		return fmt.Errorf("outbox topic %q not registered", topic)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&model.OutboxEvent{
+		Topic:         topic,
+		MsgKey:        key,
+		Payload:       string(data),
+		Status:        model.OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// Shutdown 停止中继协程，ctx 到期时不再等待
+func (s *OutboxService) Shutdown(ctx context.Context) error {
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// relayLoop 周期性投递待发送消息并清理过期的已投递消息；一批全部成功时立即扫描下一批
+func (s *OutboxService) relayLoop(ctx context.Context) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	lastPurge := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for ctx.Err() == nil {
+			sent, err := s.relayOnce(ctx)
+			if err != nil {
+				s.log.Warn("outbox relay failed", zap.Error(err))
+				break
+			}
+			if sent < outboxBatch {
+				break
+			}
+		}
+		if time.Since(lastPurge) >= outboxPurgeInterval {
+			lastPurge = time.Now()
+			s.purge(ctx)
+		}
+	}
+}
+
+// relayOnce 锁定一批已到投递时间的消息（SKIP LOCKED，多实例并行中继互不阻塞）并按主题批量发送；
+// 发送成功后标记为已投递。Kafka 写入成功但事务提交失败时消息会被再次投递，消费者需保证幂等
+func (s *OutboxService) relayOnce(ctx context.Context) (int, error) {
+	sent := 0
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var events []model.OutboxEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", model.OutboxStatusPending, time.Now()).
+			Order("id").
+			Limit(outboxBatch).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		byTopic := make(map[string][]model.OutboxEvent)
+		for _, e := range events {
+			byTopic[e.Topic] = append(byTopic[e.Topic], e)
+		}
+		now := time.Now()
+		for topic, batch := range byTopic {
+			ids := make([]int64, 0, len(batch))
+			for _, e := range batch {
+				ids = append(ids, e.ID)
+			}
+			if err := s.publish(ctx, topic, batch); err != nil {
+				if err := s.markFailedTx(tx, batch, err, now); err != nil {
+					return err
+				}
+				s.log.Warn("outbox publish failed", zap.String("topic", topic), zap.Int("count", len(batch)), zap.Error(err))
+				continue
+			}
+			if err := tx.Model(&model.OutboxEvent{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"status":    model.OutboxStatusSent,
+				"attempts":  gorm.Expr("attempts + 1"),
+				"sent_time": now,
+			}).Error; err != nil {
+				return err
+			}
+			sent += len(batch)
+		}
+		return nil
+	})
+	return sent, err
+}
+
+// markFailedTx 记录投递失败：未达最大次数的消息按退避时间推迟下次投递，达到上限的消息标记为失败
+func (s *OutboxService) markFailedTx(tx *gorm.DB, batch []model.OutboxEvent, cause error, now time.Time) error {
+	lastErr := cause.Error()
+	if len(lastErr) > outboxLastErrorMax {
+		lastErr = lastErr[:outboxLastErrorMax]
+	}
+	// 同一批消息的已投递次数可能不同，按投递后的次数分组更新
+	byAttempts := make(map[int][]int64)
+	for _, e := range batch {
+		byAttempts[e.Attempts+1] = append(byAttempts[e.Attempts+1], e.ID)
+	}
+	for attempts, ids := range byAttempts {
+		updates := map[string]interface{}{
+			"attempts":        attempts,
+			"last_error":      lastErr,
+			"next_attempt_at": now.Add(outboxBackoff(attempts)),
+		}
+		if attempts >= outboxMaxAttempts {
+			updates["status"] = model.OutboxStatusFailed
+			s.log.Error("outbox event dead", zap.Int64s("ids", ids), zap.Int("attempts", attempts), zap.String("lastError", lastErr))
+		}
+		if err := tx.Model(&model.OutboxEvent{}).Where("id IN ?", ids).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outboxBackoff 第 attempts 次投递失败后的退避时间：1s 起按 2 倍递增，最长 outboxMaxBackoff
+func outboxBackoff(attempts int) time.Duration {
+	backoff := time.Second
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
+
+// publish 按写入顺序发送同一主题的一批消息
+func (s *OutboxService) publish(ctx context.Context, topic string, batch []model.OutboxEvent) error {
+	writer, ok := s.writers[topic]
+	if !ok {
+		return fmt.Errorf("outbox topic %q not registered", topic)
+	}
+	msgs := make([]kafka.Message, 0, len(batch))
+	for _, e := range batch {
+		msgs = append(msgs, kafka.Message{Key: []byte(e.MsgKey), Value: []byte(e.Payload)})
+	}
+	return writer.WriteMessages(ctx, msgs...)
+}
+
+// purge 删除超过保留时长的已投递消息
+func (s *OutboxService) purge(ctx context.Context) {
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND sent_time < ?", model.OutboxStatusSent, time.Now().Add(-outboxRetention)).
+		Delete(&model.OutboxEvent{}).Error
+	if err != nil {
+		s.log.Warn("outbox purge failed", zap.Error(err))
+	}
+}
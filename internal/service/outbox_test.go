@@ -0,0 +1,23 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// TestOutboxBackoff 校验发件箱投递失败后的退避时间按 2 倍递增且不超过上限
+func TestOutboxBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		5:  16 * time.Second,
+		9:  256 * time.Second,
+		10: outboxMaxBackoff,
+		50: outboxMaxBackoff,
+	}
+	for attempts, want := range cases {
+		if got := outboxBackoff(attempts); got != want {
+			t.Fatalf("outboxBackoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hmdp-backend/internal/model"
+)
+
+// PaymentGateway 支付渠道：负责向第三方发起扣款与退款，返回渠道交易号
+type PaymentGateway interface {
+	PayType() int
+	Charge(ctx context.Context, orderID, amount int64) (string, error)
+	Refund(ctx context.Context, orderID, amount int64, tradeNo string) error
+}
+
+// mockPaymentGateway 模拟支付渠道，扣款与退款总是成功；未注册真实渠道的支付方式使用它
+type mockPaymentGateway struct {
+	payType int
+}
+
+func (g mockPaymentGateway) PayType() int { return g.payType }
+
+func (g mockPaymentGateway) Charge(_ context.Context, orderID, _ int64) (string, error) {
+	return fmt.Sprintf("MOCK%d%d%d", g.payType, orderID, time.Now().UnixMilli()), nil
+}
+
+func (g mockPaymentGateway) Refund(context.Context, int64, int64, string) error { return nil }
+
+// defaultPaymentGateways 为所有支付方式注册模拟渠道
+func defaultPaymentGateways() map[int]PaymentGateway {
+	m := make(map[int]PaymentGateway)
+	for _, t := range []int{model.PayTypeBalance, model.PayTypeAlipay, model.PayTypeWechat} {
+		m[t] = mockPaymentGateway{payType: t}
+	}
+	return m
+}
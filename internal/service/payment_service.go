@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/model"
+)
+
+const (
+	defaultPointsPerYuan    = 100
+	defaultMaxDeductPercent = 50
+)
+
+var (
+	errOrderNotFound      = errors.New("订单不存在")
+	errOrderStateInvalid  = errors.New("订单状态不允许该操作")
+	errPayTypeUnsupported = errors.New("不支持的支付方式")
+)
+
+// PayRequest 支付请求参数
+type PayRequest struct {
+	Points  int64 `json:"points"`  // 使用的积分数量
+	PayType int   `json:"payType"` // 剩余金额的支付方式
+}
+
+// PayResult 支付结果
+type PayResult struct {
+	OrderID      int64  `json:"orderId"`
+	PayAmount    int64  `json:"payAmount"`    // 实付金额（分）
+	PointsUsed   int64  `json:"pointsUsed"`   // 实际使用的积分
+	DeductAmount int64  `json:"deductAmount"` // 积分抵扣金额（分）
+	TradeNo      string `json:"tradeNo"`      // 支付渠道交易号
+	RedeemCode   string `json:"redeemCode"`   // 到店核销码
+}
+
+// incrIfExistsScript key 存在时才自增，避免为已下线的券重新创建库存 key
+var incrIfExistsScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+  return redis.call('INCR', KEYS[1])
+end
+return 0
+`)
+
+// PaymentService 处理订单支付与退款，支持积分部分抵扣；剩余金额通过支付渠道扣款
+type PaymentService struct {
+	db       *gorm.DB
+	rdb      *redis.Client
+	cfg      config.PointsConfig
+	state    *OrderStateService
+	notify   *NotificationService
+	gateways map[int]PaymentGateway
+	log      *zap.Logger
+}
+
+// NewPaymentService 创建 PaymentService 实例，未传入渠道的支付方式使用模拟渠道；notify 为 nil 时不发送支付成功通知
+func NewPaymentService(db *gorm.DB, rdb *redis.Client, cfg config.PointsConfig, state *OrderStateService, notify *NotificationService, log *zap.Logger, gateways ...PaymentGateway) *PaymentService {
+	if cfg.PointsPerYuan <= 0 {
+		cfg.PointsPerYuan = defaultPointsPerYuan
+	}
+	if cfg.MaxDeductPercent <= 0 || cfg.MaxDeductPercent > 100 {
+		cfg.MaxDeductPercent = defaultMaxDeductPercent
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	m := defaultPaymentGateways()
+	for _, g := range gateways {
+		m[g.PayType()] = g
+	}
+	return &PaymentService{db: db, rdb: rdb, cfg: cfg, state: state, notify: notify, gateways: m, log: log}
+}
+
+// Pay 支付订单：先校验订单并计算实付金额，在事务外调用支付渠道扣款，再在事务内扣减积分并流转订单状态；
+// 事务失败时撤销渠道扣款，避免用户已扣款而订单仍为未支付
+func (s *PaymentService) Pay(ctx context.Context, userID, orderID int64, req PayRequest) (*PayResult, error) {
+	if req.Points < 0 {
+		return nil, errors.New("积分数量不合法")
+	}
+	payType := req.PayType
+	if payType == 0 {
+		payType = model.PayTypeBalance
+	}
+	gateway, ok := s.gateways[payType]
+	if !ok {
+		return nil, errPayTypeUnsupported
+	}
+	voucher, err := s.loadPayableVoucher(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	pointsUsed, deduct := s.pointsDeduction(voucher.PayValue, req.Points)
+	if pointsUsed > 0 {
+		balance, err := pointsBalance(s.db.WithContext(ctx), userID)
+		if err != nil {
+			return nil, err
+		}
+		if balance < pointsUsed {
+			return nil, errPointsNotEnough
+		}
+	}
+	code, err := newRedeemCode()
+	if err != nil {
+		return nil, err
+	}
+	amount := voucher.PayValue - deduct
+	var tradeNo string
+	// 积分全额抵扣时无需经过支付渠道
+	if amount > 0 {
+		if tradeNo, err = gateway.Charge(ctx, orderID, amount); err != nil {
+			return nil, err
+		}
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrderTx(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.UserID != userID {
+			return errOrderNotFound
+		}
+		if err := deductPointsTx(tx, userID, pointsUsed, orderID, PointsReasonPay); err != nil {
+			return err
+		}
+		// 扣款期间订单可能已被取消或重复支付，以当前状态为条件流转
+		return s.state.TransitTx(tx, order, model.OrderStatusPaid, map[string]interface{}{
+			"payer_id":    userID,
+			"pay_type":    payType,
+			"pay_amount":  amount,
+			"points_used": pointsUsed,
+			"trade_no":    tradeNo,
+			"redeem_code": code,
+			"pay_time":    time.Now(),
+		})
+	})
+	if err != nil {
+		if tradeNo != "" {
+			s.reverseCharge(context.WithoutCancel(ctx), gateway, orderID, amount, tradeNo)
+		}
+		return nil, err
+	}
+	res := &PayResult{
+		OrderID:      orderID,
+		PayAmount:    amount,
+		PointsUsed:   pointsUsed,
+		DeductAmount: deduct,
+		TradeNo:      tradeNo,
+		RedeemCode:   code,
+	}
+	s.log.Info("order paid",
+		zap.Int64("orderId", orderID),
+		zap.Int64("userId", userID),
+		zap.Int64("payAmount", res.PayAmount),
+		zap.Int64("pointsUsed", res.PointsUsed),
+	)
+	s.notifyPaid(context.WithoutCancel(ctx), userID, voucher.Title, res)
+	return res, nil
+}
+
+// loadPayableVoucher 校验订单归属、状态与券的支付规则，返回订单对应的券
+func (s *PaymentService) loadPayableVoucher(ctx context.Context, userID, orderID int64) (*model.Voucher, error) {
+	var order model.VoucherOrder
+	err := s.db.WithContext(ctx).First(&order, orderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && order.UserID != userID) {
+		return nil, errOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CanTransitOrder(order.Status, model.OrderStatusPaid) {
+		return nil, errOrderStateInvalid
+	}
+	var voucher model.Voucher
+	if err := s.db.WithContext(ctx).First(&voucher, order.VoucherID).Error; err != nil {
+		return nil, err
+	}
+	if err := EvaluateVoucherRules(&voucher, VoucherRuleContext{Stage: VoucherRuleStagePay}); err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// reverseCharge 订单落库失败后撤销渠道扣款；撤销失败时记录错误日志，需人工退款
+func (s *PaymentService) reverseCharge(ctx context.Context, gateway PaymentGateway, orderID, amount int64, tradeNo string) {
+	if err := gateway.Refund(ctx, orderID, amount, tradeNo); err != nil {
+		s.log.Error("reverse charge failed",
+			zap.Int64("orderId", orderID),
+			zap.Int64("amount", amount),
+			zap.String("tradeNo", tradeNo),
+			zap.Error(err),
+		)
+	}
+}
+
+// notifyPaid 发送支付成功通知（站内信与邮件），通知失败不影响支付结果
+func (s *PaymentService) notifyPaid(ctx context.Context, userID int64, voucherTitle string, res *PayResult) {
+	if s.notify == nil {
+		return
+	}
+	orderID := strconv.FormatInt(res.OrderID, 10)
+	_ = s.notify.Dispatch(ctx, userID, Notification{
+		Type:    NotificationTypeOrderPaid,
+		Title:   "订单支付成功",
+		Content: fmt.Sprintf("您购买的「%s」已支付成功，到店核销码 %s", voucherTitle, res.RedeemCode),
+		Data: map[string]string{
+			"orderId":      orderID,
+			"voucherTitle": voucherTitle,
+			"payAmount":    formatYuan(res.PayAmount),
+			"redeemCode":   res.RedeemCode,
+		},
+	})
+}
+
+// Refund 退款：订单先流转为退款中，再在事务外调用支付渠道退款，渠道退款成功后流转为已退款、向支付用户退还支付时使用的积分、
+// 回补秒杀库存并记录退款审计；渠道退款失败时订单恢复为已支付。提交后释放用户在 Redis 中占用的库存与限购资格，用户可再次抢购
+func (s *PaymentService) Refund(ctx context.Context, userID, orderID int64, reason string) error {
+	order, err := s.beginRefund(ctx, userID, orderID)
+	if err != nil {
+		return err
+	}
+	if order.PayAmount > 0 {
+		if gateway, ok := s.gateways[order.PayType]; ok {
+			if err := gateway.Refund(ctx, orderID, order.PayAmount, order.TradeNo); err != nil {
+				if _, rerr := s.state.Transit(context.WithoutCancel(ctx), orderID, 0, model.OrderStatusPaid, nil); rerr != nil {
+					s.log.Error("restore refunding order failed", zap.Int64("orderId", orderID), zap.Error(rerr))
+				}
+				return err
+			}
+		}
+	}
+	var refund *model.VoucherOrderRefund
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := lockOrderTx(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if err := s.state.TransitTx(tx, order, model.OrderStatusRefunded, map[string]interface{}{
+			"refund_time": time.Now(),
+		}); err != nil {
+			return err
+		}
+		// 订单可能已转赠，积分退还给支付用户而不是当前持有人
+		payerID := order.PayerID
+		if payerID == 0 {
+			payerID = order.UserID
+		}
+		if err := addPointsTx(tx, payerID, order.PointsUsed, orderID, PointsReasonRefund); err != nil {
+			return err
+		}
+		// 普通券没有秒杀库存行，影响行数为 0
+		restock := tx.Model(&model.SeckillVoucher{}).
+			Where("voucher_id = ?", order.VoucherID).
+			Update("stock", gorm.Expr("stock + 1"))
+		if restock.Error != nil {
+			return restock.Error
+		}
+		refund = &model.VoucherOrderRefund{
+			OrderID:      orderID,
+			UserID:       userID,
+			VoucherID:    order.VoucherID,
+			Amount:       order.PayAmount,
+			Points:       order.PointsUsed,
+			StockRestore: restock.RowsAffected > 0,
+			Reason:       strings.TrimSpace(reason),
+		}
+		return tx.Create(refund).Error
+	})
+	if err != nil {
+		// 渠道已退款，订单停留在退款中，不可再核销或重复退款，需人工补完
+		s.log.Error("complete refund failed",
+			zap.Int64("orderId", orderID),
+			zap.Int64("amount", order.PayAmount),
+			zap.String("tradeNo", order.TradeNo),
+			zap.Error(err),
+		)
+		return err
+	}
+	if refund.StockRestore {
+		s.restoreSeckillRedis(ctx, refund.VoucherID, userID)
+	}
+	s.log.Info("order refunded",
+		zap.Int64("orderId", orderID),
+		zap.Int64("userId", userID),
+		zap.Int64("amount", refund.Amount),
+		zap.Int64("points", refund.Points),
+	)
+	return nil
+}
+
+// beginRefund 校验订单可退款并流转为退款中，退款中的订单不能核销、转赠或重复退款
+func (s *PaymentService) beginRefund(ctx context.Context, userID, orderID int64) (*model.VoucherOrder, error) {
+	var order *model.VoucherOrder
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOrderTx(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.UserID != userID {
+			return errOrderNotFound
+		}
+		if !CanTransitOrder(order.Status, model.OrderStatusRefunding) {
+			return errOrderStateInvalid
+		}
+		pending, err := hasPendingTransferTx(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return errTransferPending
+		}
+		forming, err := isGroupFormingTx(tx, order)
+		if err != nil {
+			return err
+		}
+		if forming {
+			return errGroupBuyForming
+		}
+		return s.state.TransitTx(tx, order, model.OrderStatusRefunding, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// restoreSeckillRedis 回补 Redis 秒杀库存并移出限购集合；库存 key 不存在（如已过期清理）时不回补
+func (s *PaymentService) restoreSeckillRedis(ctx context.Context, voucherID, userID int64) {
+	err := s.rdb.SRem(ctx, fmt.Sprintf(orderSetFmt, voucherID), userID).Err()
+	if err == nil {
+		err = incrIfExistsScript.Run(ctx, s.rdb, []string{fmt.Sprintf(stockKeyFmt, voucherID)}).Err()
+	}
+	if err != nil {
+		s.log.Error("restore seckill redis failed", zap.Int64("voucherId", voucherID), zap.Int64("userId", userID), zap.Error(err))
+	}
+}
+
+// pointsDeduction 按兑换比例与上限计算实际使用的积分及抵扣金额（分）
+func (s *PaymentService) pointsDeduction(payValue, points int64) (int64, int64) {
+	if points <= 0 || payValue <= 0 {
+		return 0, 0
+	}
+	if s.cfg.MaxPointsPerOrder > 0 && points > s.cfg.MaxPointsPerOrder {
+		points = s.cfg.MaxPointsPerOrder
+	}
+	// 1 元 = 100 分，pointsPerYuan 积分抵扣 1 元
+	deduct := points * 100 / s.cfg.PointsPerYuan
+	maxDeduct := payValue * s.cfg.MaxDeductPercent / 100
+	if deduct > maxDeduct {
+		deduct = maxDeduct
+	}
+	// 按实际抵扣金额反算积分并向上取整，比例不能整除时抵扣金额不会超过所扣积分的价值
+	used := (deduct*s.cfg.PointsPerYuan + 99) / 100
+	if used == 0 {
+		return 0, 0
+	}
+	return used, deduct
+}
+
+// lockOrderTx 在事务内对订单加行锁
+func lockOrderTx(tx *gorm.DB, orderID int64) (*model.VoucherOrder, error) {
+	var order model.VoucherOrder
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
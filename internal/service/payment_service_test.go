@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+
+	"hmdp-backend/internal/config"
+)
+
+// TestPointsDeductionRoundsUp 兑换比例不能整除时，扣除的积分向上取整，抵扣金额不超过积分价值
+func TestPointsDeductionRoundsUp(t *testing.T) {
+	s := &PaymentService{cfg: config.PointsConfig{PointsPerYuan: 150, MaxDeductPercent: 100}}
+	cases := []struct {
+		payValue, points   int64
+		wantUsed, wantCent int64
+	}{
+		{payValue: 1000, points: 1, wantUsed: 0, wantCent: 0},
+		{payValue: 1000, points: 2, wantUsed: 2, wantCent: 1},
+		{payValue: 1000, points: 5, wantUsed: 5, wantCent: 3},
+		{payValue: 1000, points: 300, wantUsed: 300, wantCent: 200},
+		{payValue: 1, points: 300, wantUsed: 2, wantCent: 1},
+	}
+	for _, c := range cases {
+		used, deduct := s.pointsDeduction(c.payValue, c.points)
+		if used != c.wantUsed || deduct != c.wantCent {
+			t.Fatalf("pointsDeduction(%d, %d) = %d/%d, want %d/%d", c.payValue, c.points, used, deduct, c.wantUsed, c.wantCent)
+		}
+		// 抵扣金额（分）折算的积分不得多于实际扣除的积分
+		if deduct*s.cfg.PointsPerYuan > used*100 {
+			t.Fatalf("pointsDeduction(%d, %d) deducts %d cents for only %d points", c.payValue, c.points, deduct, used)
+		}
+	}
+}
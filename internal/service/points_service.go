@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+)
+
+// 积分变动原因
+const (
+	PointsReasonPay    = "order_pay"
+	PointsReasonRefund = "order_refund"
+)
+
+var errPointsNotEnough = errors.New("积分不足")
+
+// PointsService 处理用户积分余额与流水
+type PointsService struct {
+	db *gorm.DB
+}
+
+// NewPointsService 创建 PointsService 实例
+func NewPointsService(db *gorm.DB) *PointsService {
+	return &PointsService{db: db}
+}
+
+// Balance 查询用户积分余额，无记录视为 0
+func (s *PointsService) Balance(ctx context.Context, userID int64) (int64, error) {
+	return pointsBalance(s.db.WithContext(ctx), userID)
+}
+
+// pointsBalance 查询用户积分余额，无记录视为 0
+func pointsBalance(db *gorm.DB, userID int64) (int64, error) {
+	var points model.UserPoints
+	err := db.Where("user_id = ?", userID).Take(&points).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return points.Balance, nil
+}
+
+// Add 为用户增加积分并记录流水
+func (s *PointsService) Add(ctx context.Context, userID, amount, orderID int64, reason string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return addPointsTx(tx, userID, amount, orderID, reason)
+	})
+}
+
+// addPointsTx 在事务内增加积分（不存在则创建账户）
+func addPointsTx(tx *gorm.DB, userID, amount, orderID int64, reason string) error {
+	if amount <= 0 {
+		return nil
+	}
+	account := &model.UserPoints{UserID: userID, Balance: amount}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"balance": gorm.Expr("balance + ?", amount)}),
+	}).Create(account).Error; err != nil {
+		return err
+	}
+	return tx.Create(&model.PointsLog{UserID: userID, OrderID: orderID, Change: amount, Reason: reason}).Error
+}
+
+// deductPointsTx 在事务内扣减积分，余额不足时返回 errPointsNotEnough
+func deductPointsTx(tx *gorm.DB, userID, amount, orderID int64, reason string) error {
+	if amount <= 0 {
+		return nil
+	}
+	// 条件更新保证余额不会扣成负数
+	res := tx.Model(&model.UserPoints{}).
+		Where("user_id = ? AND balance >= ?", userID, amount).
+		Update("balance", gorm.Expr("balance - ?", amount))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errPointsNotEnough
+	}
+	return tx.Create(&model.PointsLog{UserID: userID, OrderID: orderID, Change: -amount, Reason: reason}).Error
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// PrivacyService 维护用户的隐私设置，数据库持久化并缓存到 Redis
+type PrivacyService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewPrivacyService 创建 PrivacyService 实例
+func NewPrivacyService(db *gorm.DB, rdb *redis.Client) *PrivacyService {
+	return &PrivacyService{db: db, rdb: rdb}
+}
+
+// Get 查询用户的隐私设置，未配置时全部关闭
+func (s *PrivacyService) Get(ctx context.Context, userID int64) (*model.UserPrivacy, error) {
+	key := utils.USER_PRIVACY_KEY + strconv.FormatInt(userID, 10)
+	cached, err := s.rdb.Get(ctx, key).Result()
+	if err == nil {
+		var privacy model.UserPrivacy
+		if unmarshalErr := json.Unmarshal([]byte(cached), &privacy); unmarshalErr == nil {
+			privacy.UserID = userID
+			return &privacy, nil
+		}
+	} else if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	privacy := model.UserPrivacy{UserID: userID}
+	err = s.db.WithContext(ctx).Where("user_id = ?", userID).Take(&privacy).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if data, err := json.Marshal(&privacy); err == nil {
+		_ = s.rdb.Set(ctx, key, data, time.Duration(utils.USER_PRIVACY_TTL)*time.Minute).Err()
+	}
+	return &privacy, nil
+}
+
+// Update 覆盖用户的隐私设置，写库后删除缓存
+func (s *PrivacyService) Update(ctx context.Context, userID int64, privacy model.UserPrivacy) error {
+	privacy.UserID = userID
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hide_blogs_from_feed", "hide_follows", "update_time"}),
+	}).Create(&privacy).Error; err != nil {
+		return err
+	}
+	return s.rdb.Del(ctx, utils.USER_PRIVACY_KEY+strconv.FormatInt(userID, 10)).Err()
+}
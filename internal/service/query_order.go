@@ -0,0 +1,13 @@
+package service
+
+import "gorm.io/gorm/clause"
+
+// orderByIDs 生成 ORDER BY FIELD(id, ...)，让 IN 查询按 ids 的顺序返回，
+// 用于先在 Redis 中排好序（GEO、关注流等）再回表查询详情的场景
+func orderByIDs(ids []int64) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:                "FIELD(id, ?)",
+		Vars:               []interface{}{ids},
+		WithoutParentheses: true,
+	}}
+}
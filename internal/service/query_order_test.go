@@ -0,0 +1,27 @@
+package service
+
+import (
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+)
+
+// TestOrderByIDs 校验生成的 ORDER BY FIELD 子句按 ids 顺序展开参数
+func TestOrderByIDs(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	ids := []int64{3, 1, 2}
+	stmt := db.Where("id IN ?", ids).Order(orderByIDs(ids)).Find(&[]model.Shop{}).Statement
+	want := "SELECT * FROM `tb_shop` WHERE id IN (?,?,?) ORDER BY FIELD(id, ?,?,?)"
+	if got := stmt.SQL.String(); got != want {
+		t.Fatalf("sql = %q, want %q", got, want)
+	}
+	if len(stmt.Vars) != 6 || stmt.Vars[3] != int64(3) || stmt.Vars[5] != int64(2) {
+		t.Fatalf("vars = %v", stmt.Vars)
+	}
+}
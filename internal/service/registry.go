@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 
 	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/data"
 	"hmdp-backend/internal/observability"
 	"hmdp-backend/internal/utils"
 )
@@ -14,6 +15,10 @@ import (
 // Registry 聚合全部业务 Service，方便注入 handler
 type Registry struct {
 	Blog           *BlogService
+	BlogSearch     *BlogSearchService
+	ShopSearch     *ShopSearchService
+	ShopReview     *ShopReviewService
+	ShopFavorite   *ShopFavoriteService
 	Shop           *ShopService
 	ShopType       *ShopTypeService
 	Voucher        *VoucherService
@@ -21,6 +26,31 @@ type Registry struct {
 	User           *UserService
 	VoucherOrder   *VoucherOrderService
 	Follow         *FollowService
+	Points         *PointsService
+	Payment        *PaymentService
+	OrderState     *OrderStateService
+	Redemption     *RedemptionService
+	Notification   *NotificationService
+	NotifySetting  *NotificationSettingService
+	Email          *EmailSender // 未配置 SMTP 时为 nil
+	OAuth          *OAuthService
+	Account        *AccountService
+	LoginLog       *LoginLogService
+	TwoFactor      *TwoFactorService
+	Privacy        *PrivacyService
+	Comment        *CommentService
+	Report         *ReportService
+	Tag            *TagService
+	Favorite       *FavoriteService
+	Captcha        *CaptchaService
+	OrderTransfer  *OrderTransferService
+	Search         *SearchService
+	ShopHistory    *ShopHistoryService
+	Campaign       *CampaignService
+	ShopView       *ShopViewService
+	SeckillRemind  *SeckillReminderService
+	Outbox         *OutboxService
+	GroupBuy       *GroupBuyService
 }
 
 // NewRegistry 构造服务注册中心
@@ -37,8 +67,17 @@ func NewRegistry(
 	kafkaDLQReader *kafka.Reader,
 	cacheInvalidateReader *kafka.Reader,
 	cacheInvalidateDLQReader *kafka.Reader,
+	feedWriter *kafka.Writer,
+	feedReader *kafka.Reader,
 	smtpCfg utils.SMTPConfig,
 	shopCacheCfg config.ShopCacheConfig,
+	shopGeoCfg config.ShopGeoConfig,
+	pointsCfg config.PointsConfig,
+	orderCfg config.OrderConfig,
+	authCfg config.AuthConfig,
+	sensitiveCfg config.SensitiveConfig,
+	feedCfg config.FeedConfig,
+	es *data.Elasticsearch,
 	seckillMetrics *observability.SeckillMetrics,
 	log *zap.Logger,
 ) *Registry {
@@ -46,15 +85,70 @@ func NewRegistry(
 		log = zap.NewNop()
 	}
 	seckillSvc := NewSeckillVoucherService(db)
-	followSvc := NewFollowService(db, rdb)
+	privacySvc := NewPrivacyService(db, rdb)
+	tagSvc := NewTagService(db, rdb)
+	blogSearchSvc := NewBlogSearchService(db, es, log)
+	shopSearchSvc := NewShopSearchService(db, es, log)
+	sensitiveSvc := NewSensitiveWordService(db, sensitiveCfg, log)
+	followSvc := NewFollowService(db, rdb, privacySvc)
+	notifySettingSvc := NewNotificationSettingService(db, rdb)
+	loginLogSvc := NewLoginLogService(db, log)
+	twoFactorSvc := NewTwoFactorService(db, rdb, authCfg)
+	userSvc := NewUserService(db, rdb, authCfg, smtpCfg, loginLogSvc, twoFactorSvc)
+	// 仅注册已配置的第三方登录平台
+	var oauthProviders []OAuthProvider
+	if wechat := NewWeChatProvider(authCfg.WeChat); wechat != nil {
+		oauthProviders = append(oauthProviders, wechat)
+	}
+	notificationSvc := NewNotificationService(rdb, notifySettingSvc, log)
+	// 配置 SMTP 后开启邮件通知渠道
+	var emailSender *EmailSender
+	if smtpCfg.Host != "" {
+		emailSender = NewEmailSender(db, smtpCfg, log)
+		notificationSvc.RegisterSender(NotificationChannelEmail, emailSender)
+	}
+	orderStateSvc := NewOrderStateService(db)
+	paymentSvc := NewPaymentService(db, rdb, pointsCfg, orderStateSvc, notificationSvc, log)
+	// 事务发件箱中继：订单主题与笔记发布事件主题
+	outboxSvc := NewOutboxService(db, log, kafkaWriter, feedWriter)
+	shopSvc := NewShopService(db, rdb, cacheInvalidateWriter, cacheInvalidateDLQWriter, cacheInvalidateReader, cacheInvalidateDLQReader, smtpCfg, shopCacheCfg, shopGeoCfg, shopSearchSvc, log)
 	return &Registry{
-		Blog:           NewBlogService(db, rdb, followSvc),
-		Shop:           NewShopService(db, rdb, cacheInvalidateWriter, cacheInvalidateDLQWriter, cacheInvalidateReader, cacheInvalidateDLQReader, smtpCfg, shopCacheCfg, log),
+		Blog:           NewBlogService(db, rdb, feedWriter, feedReader, feedCfg, followSvc, privacySvc, tagSvc, blogSearchSvc, sensitiveSvc, outboxSvc, log),
+		BlogSearch:     blogSearchSvc,
+		ShopSearch:     shopSearchSvc,
+		Report:         NewReportService(db, blogSearchSvc, log),
+		Favorite:       NewFavoriteService(db, rdb),
+		Shop:           shopSvc,
+		ShopReview:     NewShopReviewService(db, shopSvc, sensitiveSvc),
+		ShopFavorite:   NewShopFavoriteService(db, rdb),
 		ShopType:       NewShopTypeService(db, rdb),
-		Voucher:        NewVoucherService(db, seckillSvc, rdb),
+		Voucher:        NewVoucherService(db, seckillSvc, rdb, log),
 		SeckillVoucher: seckillSvc,
-		User:           NewUserService(db, rdb),
-		VoucherOrder:   NewVoucherOrderService(db, rdb, kafkaWriter, kafkaRetryWriter, kafkaDLQWriter, kafkaReader, kafkaRetryReader, kafkaDLQReader, smtpCfg, seckillMetrics, log),
+		User:           userSvc,
+		VoucherOrder:   NewVoucherOrderService(db, rdb, kafkaWriter, kafkaRetryWriter, kafkaDLQWriter, kafkaReader, kafkaRetryReader, kafkaDLQReader, smtpCfg, orderCfg, orderStateSvc, outboxSvc, seckillMetrics, log),
 		Follow:         followSvc,
+		Points:         NewPointsService(db),
+		Payment:        paymentSvc,
+		OrderState:     orderStateSvc,
+		Redemption:     NewRedemptionService(db, rdb, orderStateSvc, shopSvc, log),
+		Notification:   notificationSvc,
+		NotifySetting:  notifySettingSvc,
+		Email:          emailSender,
+		OAuth:          NewOAuthService(db, userSvc, oauthProviders...),
+		Account:        NewAccountService(db, rdb, log),
+		LoginLog:       loginLogSvc,
+		TwoFactor:      twoFactorSvc,
+		Privacy:        privacySvc,
+		Comment:        NewCommentService(db, sensitiveSvc),
+		Tag:            tagSvc,
+		Captcha:        NewCaptchaService(rdb),
+		OrderTransfer:  NewOrderTransferService(db, rdb, notificationSvc, log),
+		Search:         NewSearchService(db, rdb),
+		ShopHistory:    NewShopHistoryService(db, rdb),
+		Campaign:       NewCampaignService(db, rdb),
+		ShopView:       NewShopViewService(db, rdb, log),
+		SeckillRemind:  NewSeckillReminderService(db, rdb, notificationSvc, log),
+		Outbox:         outboxSvc,
+		GroupBuy:       NewGroupBuyService(db, rdb, paymentSvc, orderStateSvc, orderCfg, log),
 	}
 }
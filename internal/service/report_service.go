@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// 举报审核动作
+const (
+	ReportActionHide    = "hide"
+	ReportActionDismiss = "dismiss"
+)
+
+const reportReasonMaxLength = 200
+
+var (
+	errReportTarget    = errors.New("举报对象不存在")
+	errReportDuplicate = errors.New("已举报过该内容，请等待处理")
+	errReportNotFound  = errors.New("举报记录不存在")
+	errReportHandled   = errors.New("举报已处理")
+	errReportAction    = errors.New("不支持的审核动作")
+)
+
+// ReportService 处理笔记与评论的举报，以及管理员审核：屏蔽后的内容从各查询入口过滤
+type ReportService struct {
+	db     *gorm.DB
+	search *BlogSearchService
+	log    *zap.Logger
+}
+
+// NewReportService 创建 ReportService 实例
+func NewReportService(db *gorm.DB, search *BlogSearchService, log *zap.Logger) *ReportService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &ReportService{db: db, search: search, log: log}
+}
+
+// Create 提交举报，同一用户对同一内容只保留一条待处理记录
+func (s *ReportService) Create(ctx context.Context, report *model.Report) error {
+	report.Reason = utils.SanitizePlainText(report.Reason)
+	if report.Reason == "" {
+		return errors.New("请填写举报原因")
+	}
+	if utf8.RuneCountInString(report.Reason) > reportReasonMaxLength {
+		return errors.New("举报原因过长")
+	}
+	exists, err := s.targetExists(ctx, report.TargetType, report.TargetID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errReportTarget
+	}
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&model.Report{}).
+		Where("target_type = ? AND target_id = ? AND user_id = ? AND status = ?",
+			report.TargetType, report.TargetID, report.UserID, model.ReportStatusPending).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errReportDuplicate
+	}
+	report.ID = 0
+	report.Status = model.ReportStatusPending
+	report.HandlerID = 0
+	return s.db.WithContext(ctx).Create(report).Error
+}
+
+// List 管理员分页查询举报队列，status 为 0 时查询全部，最早提交的在前
+func (s *ReportService) List(ctx context.Context, status, page, size int) ([]model.Report, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.MAX_PAGE_SIZE
+	}
+	query := s.db.WithContext(ctx).Model(&model.Report{})
+	if status > 0 {
+		query = query.Where("status = ?", status)
+	}
+	var reports []model.Report
+	err := query.Order("id ASC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&reports).Error
+	return reports, err
+}
+
+// Review 审核举报：hide 屏蔽违规内容并结案同一内容的全部待处理举报，dismiss 仅驳回当前举报
+func (s *ReportService) Review(ctx context.Context, adminID, reportID int64, action string) error {
+	if action != ReportActionHide && action != ReportActionDismiss {
+		return errReportAction
+	}
+	var report model.Report
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&report, reportID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errReportNotFound
+			}
+			return err
+		}
+		if report.Status != model.ReportStatusPending {
+			return errReportHandled
+		}
+		if action == ReportActionDismiss {
+			return tx.Model(&model.Report{}).
+				Where("id = ? AND status = ?", reportID, model.ReportStatusPending).
+				Updates(map[string]interface{}{"status": model.ReportStatusDismissed, "handler_id": adminID}).Error
+		}
+		if err := hideTargetTx(tx, report.TargetType, report.TargetID); err != nil {
+			return err
+		}
+		return tx.Model(&model.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, model.ReportStatusPending).
+			Updates(map[string]interface{}{"status": model.ReportStatusHidden, "handler_id": adminID}).Error
+	})
+	if err != nil {
+		return err
+	}
+	if action == ReportActionHide && report.TargetType == model.ReportTargetBlog && s.search != nil {
+		s.search.Remove(ctx, report.TargetID)
+	}
+	s.log.Info("report reviewed",
+		zap.Int64("reportId", reportID),
+		zap.Int64("adminId", adminID),
+		zap.String("action", action),
+	)
+	return nil
+}
+
+// targetExists 判断被举报的内容是否存在且处于可见状态
+func (s *ReportService) targetExists(ctx context.Context, targetType int, targetID int64) (bool, error) {
+	var count int64
+	var err error
+	switch targetType {
+	case model.ReportTargetBlog:
+		err = s.db.WithContext(ctx).Model(&model.Blog{}).
+			Where("id = ? AND status = ?", targetID, model.BlogStatusPublished).
+			Count(&count).Error
+	case model.ReportTargetComment:
+		err = s.db.WithContext(ctx).Model(&model.BlogComment{}).
+			Where("id = ? AND status = ?", targetID, model.CommentStatusNormal).
+			Count(&count).Error
+	default:
+		return false, errReportTarget
+	}
+	return count > 0, err
+}
+
+// hideTargetTx 在事务内屏蔽笔记或评论；屏蔽评论时同步扣减笔记评论数
+func hideTargetTx(tx *gorm.DB, targetType int, targetID int64) error {
+	switch targetType {
+	case model.ReportTargetBlog:
+		return tx.Model(&model.Blog{}).
+			Where("id = ?", targetID).
+			Update("status", model.BlogStatusHidden).Error
+	case model.ReportTargetComment:
+		var comment model.BlogComment
+		if err := tx.First(&comment, targetID).Error; err != nil {
+			return err
+		}
+		if comment.Status == model.CommentStatusHidden {
+			return nil
+		}
+		if err := tx.Model(&model.BlogComment{}).
+			Where("id = ?", targetID).
+			Update("status", model.CommentStatusHidden).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Blog{}).
+			Where("id = ?", comment.BlogID).
+			UpdateColumn("comments", gorm.Expr("GREATEST(comments - 1, 0)")).Error
+	default:
+		return errReportTarget
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// 搜索范围
+const (
+	SearchScopeShop = "shop"
+	SearchScopeBlog = "blog"
+)
+
+const (
+	searchKeywordMaxLen = 50
+	searchSuggestLimit  = 10
+)
+
+var errSearchScopeInvalid = errors.New("不支持的搜索类型")
+
+// SearchService 维护用户的搜索历史，并提供搜索联想
+type SearchService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewSearchService 创建 SearchService 实例
+func NewSearchService(db *gorm.DB, rdb *redis.Client) *SearchService {
+	return &SearchService{db: db, rdb: rdb}
+}
+
+// Record 记录一次搜索：同一关键词去重后置顶，列表只保留最近 SEARCH_HISTORY_MAX 条
+func (s *SearchService) Record(ctx context.Context, userID int64, scope, keyword string) error {
+	keyword = normalizeKeyword(keyword)
+	if keyword == "" {
+		return nil
+	}
+	key, err := searchHistoryKey(scope, userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, key, 0, keyword)
+		pipe.LPush(ctx, key, keyword)
+		pipe.LTrim(ctx, key, 0, utils.SEARCH_HISTORY_MAX-1)
+		return nil
+	})
+	return err
+}
+
+// History 查询用户最近的搜索词，最新的在前
+func (s *SearchService) History(ctx context.Context, userID int64, scope string) ([]string, error) {
+	key, err := searchHistoryKey(scope, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.rdb.LRange(ctx, key, 0, utils.SEARCH_HISTORY_MAX-1).Result()
+}
+
+// Clear 清空用户的搜索历史
+func (s *SearchService) Clear(ctx context.Context, userID int64, scope string) error {
+	key, err := searchHistoryKey(scope, userID)
+	if err != nil {
+		return err
+	}
+	return s.rdb.Del(ctx, key).Err()
+}
+
+// Suggest 搜索联想：优先返回匹配前缀的个人历史，再补充匹配的店铺名或笔记标题
+func (s *SearchService) Suggest(ctx context.Context, userID int64, scope, prefix string) ([]string, error) {
+	prefix = normalizeKeyword(prefix)
+	if prefix == "" {
+		return []string{}, nil
+	}
+	if scope != SearchScopeShop && scope != SearchScopeBlog {
+		return nil, errSearchScopeInvalid
+	}
+	res := make([]string, 0, searchSuggestLimit)
+	seen := make(map[string]struct{}, searchSuggestLimit)
+	appendUnique := func(items []string) {
+		for _, item := range items {
+			if len(res) >= searchSuggestLimit {
+				return
+			}
+			if _, ok := seen[item]; ok {
+				continue
+			}
+			seen[item] = struct{}{}
+			res = append(res, item)
+		}
+	}
+
+	if userID > 0 {
+		history, err := s.History(ctx, userID, scope)
+		if err != nil {
+			return nil, err
+		}
+		matched := make([]string, 0, len(history))
+		for _, h := range history {
+			if strings.HasPrefix(h, prefix) {
+				matched = append(matched, h)
+			}
+		}
+		appendUnique(matched)
+	}
+	if len(res) >= searchSuggestLimit {
+		return res, nil
+	}
+
+	var names []string
+	var err error
+	pattern := escapeLike(prefix) + "%"
+	switch scope {
+	case SearchScopeShop:
+		err = s.db.WithContext(ctx).Model(&model.Shop{}).
+			Where("name LIKE ? AND status = ?", pattern, model.ShopStatusApproved).
+			Limit(searchSuggestLimit).
+			Pluck("name", &names).Error
+	case SearchScopeBlog:
+		err = s.db.WithContext(ctx).Model(&model.Blog{}).
+			Where("title LIKE ? AND status = ?", pattern, model.BlogStatusPublished).
+			Order("liked DESC").
+			Limit(searchSuggestLimit).
+			Pluck("title", &names).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	appendUnique(names)
+	return res, nil
+}
+
+// searchHistoryKey 生成搜索历史 key：search:history:{scope}:{userId}
+func searchHistoryKey(scope string, userID int64) (string, error) {
+	if scope != SearchScopeShop && scope != SearchScopeBlog {
+		return "", errSearchScopeInvalid
+	}
+	return fmt.Sprintf("%s%s:%d", utils.SEARCH_HISTORY_KEY, scope, userID), nil
+}
+
+// normalizeKeyword 去除首尾空白并截断过长的关键词
+func normalizeKeyword(keyword string) string {
+	keyword = strings.TrimSpace(keyword)
+	if utf8.RuneCountInString(keyword) > searchKeywordMaxLen {
+		keyword = string([]rune(keyword)[:searchKeywordMaxLen])
+	}
+	return keyword
+}
+
+// escapeLike 转义 LIKE 通配符，避免用户输入被当作模式
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
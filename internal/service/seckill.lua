@@ -1,6 +1,25 @@
 local stockKey = KEYS[1]
 local orderSetKey = KEYS[2]
+local windowKey = KEYS[3]
 local userId = ARGV[1]
+local now = tonumber(ARGV[2])
+local weekday = ARGV[3]
+-- 读取秒杀时间窗口，未缓存时由调用方回源数据库后重试
+local window = redis.call("hmget", windowKey, "begin", "end", "weekdays")
+if not window[1] then
+  return 5
+end
+if now < tonumber(window[1]) then
+  return 3
+end
+if now > tonumber(window[2]) then
+  return 4
+end
+-- 星期限制：weekdays 为逗号分隔的 ISO 星期，为空表示不限制
+local weekdays = window[3]
+if weekdays and weekdays ~= "" and not string.find("," .. weekdays .. ",", "," .. weekday .. ",", 1, true) then
+  return 6
+end
 -- 获取voucher的库存值
 local stock = tonumber(redis.call("get", stockKey))
 -- 判断库存是否存在或已小于0
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	// seckillRemindLead 秒杀开始前多久发送提醒
+	seckillRemindLead = 5 * time.Minute
+	// seckillRemindPoll 扫描提醒队列的周期
+	seckillRemindPoll = 30 * time.Second
+	// seckillRemindBatch 单次扫描最多发送的提醒数
+	seckillRemindBatch = 500
+)
+
+var errSeckillStarted = errors.New("秒杀已开始，无需订阅提醒")
+
+// SeckillReminderService 秒杀开抢提醒：订阅记录存放在 ZSET 中（member 为 voucherId:userId，score 为开抢时间戳），
+// 定时任务在开抢前 seckillRemindLead 取出到期的订阅并通过通知服务发送
+type SeckillReminderService struct {
+	db     *gorm.DB
+	rdb    *redis.Client
+	notify *NotificationService
+	log    *zap.Logger
+}
+
+// NewSeckillReminderService 创建 SeckillReminderService 实例并启动提醒发送任务
+func NewSeckillReminderService(db *gorm.DB, rdb *redis.Client, notify *NotificationService, log *zap.Logger) *SeckillReminderService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	svc := &SeckillReminderService{db: db, rdb: rdb, notify: notify, log: log}
+	go svc.remindLoop(context.Background())
+	return svc
+}
+
+func seckillRemindMember(voucherID, userID int64) string {
+	return strconv.FormatInt(voucherID, 10) + ":" + strconv.FormatInt(userID, 10)
+}
+
+// Subscribe 订阅秒杀券的开抢提醒，重复订阅只保留一条
+func (s *SeckillReminderService) Subscribe(ctx context.Context, userID, voucherID int64) error {
+	var sec model.SeckillVoucher
+	err := s.db.WithContext(ctx).Select("voucher_id", "begin_time").Where("voucher_id = ?", voucherID).Take(&sec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errSeckillNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !sec.BeginTime.After(time.Now()) {
+		return errSeckillStarted
+	}
+	return s.rdb.ZAdd(ctx, utils.SECKILL_REMIND_KEY, redis.Z{
+		Score:  float64(sec.BeginTime.Unix()),
+		Member: seckillRemindMember(voucherID, userID),
+	}).Err()
+}
+
+// Unsubscribe 取消开抢提醒
+func (s *SeckillReminderService) Unsubscribe(ctx context.Context, userID, voucherID int64) error {
+	return s.rdb.ZRem(ctx, utils.SECKILL_REMIND_KEY, seckillRemindMember(voucherID, userID)).Err()
+}
+
+// Subscribed 查询用户是否已订阅开抢提醒
+func (s *SeckillReminderService) Subscribed(ctx context.Context, userID, voucherID int64) (bool, error) {
+	_, err := s.rdb.ZScore(ctx, utils.SECKILL_REMIND_KEY, seckillRemindMember(voucherID, userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// remindLoop 周期性发送即将开抢的提醒；通过 ZREM 抢占，多实例下同一订阅只会发送一次
+func (s *SeckillReminderService) remindLoop(ctx context.Context) {
+	ticker := time.NewTicker(seckillRemindPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.sendDue(ctx, time.Now()); err != nil {
+			s.log.Warn("send seckill reminders failed", zap.Error(err))
+		}
+	}
+}
+
+// sendDue 发送开抢时间在 now+seckillRemindLead 之前的提醒，已开抢的订阅同样发送，避免因任务延迟丢失提醒
+func (s *SeckillReminderService) sendDue(ctx context.Context, now time.Time) error {
+	members, err := s.rdb.ZRangeByScoreWithScores(ctx, utils.SECKILL_REMIND_KEY, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Add(seckillRemindLead).Unix(), 10),
+		Count: seckillRemindBatch,
+	}).Result()
+	if err != nil {
+		return err
+	}
+	titles := make(map[int64]string)
+	for _, z := range members {
+		member, _ := z.Member.(string)
+		removed, err := s.rdb.ZRem(ctx, utils.SECKILL_REMIND_KEY, member).Result()
+		if err != nil || removed == 0 {
+			continue
+		}
+		voucherPart, userPart, ok := strings.Cut(member, ":")
+		if !ok {
+			continue
+		}
+		voucherID, err1 := strconv.ParseInt(voucherPart, 10, 64)
+		userID, err2 := strconv.ParseInt(userPart, 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		title, ok := titles[voucherID]
+		if !ok {
+			var voucher model.Voucher
+			if err := s.db.WithContext(ctx).Select("id", "title").First(&voucher, voucherID).Error; err == nil {
+				title = voucher.Title
+			}
+			titles[voucherID] = title
+		}
+		begin := time.Unix(int64(z.Score), 0)
+		n := Notification{
+			Type:    NotificationTypeSeckillReminder,
+			Title:   "秒杀即将开始",
+			Content: fmt.Sprintf("您订阅的「%s」将于 %s 开抢", title, begin.Format("15:04")),
+			Data: map[string]string{
+				"voucherId": voucherPart,
+				"beginTime": strconv.FormatInt(begin.Unix(), 10),
+			},
+		}
+		if err := s.notify.Dispatch(ctx, userID, n); err != nil {
+			s.log.Warn("dispatch seckill reminder failed", zap.Int64("userId", userID), zap.Int64("voucherId", voucherID), zap.Error(err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+)
+
+// seckillTimingTTL 进程内秒杀时间窗口缓存的有效期
+const seckillTimingTTL = 30 * time.Second
+
+// 秒杀倒计时状态
+const (
+	SeckillStatusNotStarted = "not_started"
+	SeckillStatusOngoing    = "ongoing"
+	SeckillStatusEnded      = "ended"
+)
+
+var (
+	errSeckillNotStarted = errors.New("秒杀尚未开始")
+	errSeckillEnded      = errors.New("秒杀已结束")
+)
+
+// SeckillTiming 秒杀倒计时信息，时间均为毫秒时间戳；客户端以 ServerTime 校准本地时钟后渲染倒计时
+type SeckillTiming struct {
+	VoucherID  int64  `json:"voucherId"`
+	ServerTime int64  `json:"serverTime"`
+	BeginTime  int64  `json:"beginTime"`
+	EndTime    int64  `json:"endTime"`
+	Countdown  int64  `json:"countdown"` // 距开抢的毫秒数，已开抢为 0
+	Status     string `json:"status"`    // not_started | ongoing | ended
+}
+
+// seckillWindow 进程内缓存的秒杀时间窗口
+type seckillWindow struct {
+	begin    time.Time
+	end      time.Time
+	expireAt time.Time
+}
+
+// SeckillTiming 返回服务器当前时间与秒杀券的开始、结束时间
+func (s *VoucherService) SeckillTiming(ctx context.Context, voucherID int64) (*SeckillTiming, error) {
+	w, err := s.seckillWindow(ctx, voucherID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	t := &SeckillTiming{
+		VoucherID:  voucherID,
+		ServerTime: now.UnixMilli(),
+		BeginTime:  w.begin.UnixMilli(),
+		EndTime:    w.end.UnixMilli(),
+		Status:     SeckillStatusOngoing,
+	}
+	switch {
+	case now.Before(w.begin):
+		t.Status = SeckillStatusNotStarted
+		t.Countdown = w.begin.Sub(now).Milliseconds()
+	case now.After(w.end):
+		t.Status = SeckillStatusEnded
+	}
+	return t, nil
+}
+
+// CheckSeckillWindow 在进入 Redis 之前按进程内缓存的时间窗口拦截未开始或已结束的抢购请求；
+// 查询失败时放行，由秒杀脚本完成最终校验
+func (s *VoucherService) CheckSeckillWindow(ctx context.Context, voucherID int64) error {
+	w, err := s.seckillWindow(ctx, voucherID)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	if now.Before(w.begin) {
+		return errSeckillNotStarted
+	}
+	if now.After(w.end) {
+		return errSeckillEnded
+	}
+	return nil
+}
+
+// seckillWindow 读取秒杀时间窗口，进程内缓存未命中或过期时回源数据库
+func (s *VoucherService) seckillWindow(ctx context.Context, voucherID int64) (seckillWindow, error) {
+	if v, ok := s.windows.Load(voucherID); ok {
+		if w := v.(seckillWindow); time.Now().Before(w.expireAt) {
+			return w, nil
+		}
+	}
+	var sec model.SeckillVoucher
+	err := s.db.WithContext(ctx).Select("voucher_id", "begin_time", "end_time").Where("voucher_id = ?", voucherID).Take(&sec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return seckillWindow{}, errSeckillNotFound
+	}
+	if err != nil {
+		return seckillWindow{}, err
+	}
+	return s.storeSeckillWindow(voucherID, sec.BeginTime, sec.EndTime), nil
+}
+
+func (s *VoucherService) storeSeckillWindow(voucherID int64, begin, end time.Time) seckillWindow {
+	w := seckillWindow{begin: begin, end: end, expireAt: time.Now().Add(seckillTimingTTL)}
+	s.windows.Store(voucherID, w)
+	return w
+}
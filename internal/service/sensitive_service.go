@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// 敏感词处理方式
+const (
+	SensitiveModeReject = "reject"
+	SensitiveModeMask   = "mask"
+)
+
+var errSensitiveWord = errors.New("内容包含敏感词，请修改后再提交")
+
+// SensitiveWordService 用户内容的敏感词过滤：词表由配置与 tb_sensitive_word 合并而成，定期重新加载
+type SensitiveWordService struct {
+	db     *gorm.DB
+	cfg    config.SensitiveConfig
+	filter atomic.Pointer[utils.SensitiveFilter]
+	log    *zap.Logger
+}
+
+// NewSensitiveWordService 创建 SensitiveWordService 实例，启动时加载词表并按配置启动定期重载任务
+func NewSensitiveWordService(db *gorm.DB, cfg config.SensitiveConfig, log *zap.Logger) *SensitiveWordService {
+	if cfg.Mode != SensitiveModeMask {
+		cfg.Mode = SensitiveModeReject
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	svc := &SensitiveWordService{db: db, cfg: cfg, log: log}
+	// 先用配置词表兜底，数据库加载失败时过滤仍然生效
+	svc.filter.Store(utils.NewSensitiveFilter(cfg.Words))
+	if err := svc.Reload(context.Background()); err != nil {
+		log.Warn("load sensitive words failed", zap.Error(err))
+	}
+	if cfg.ReloadInterval > 0 {
+		go svc.reloadLoop(context.Background())
+	}
+	return svc
+}
+
+// Reload 重新从数据库加载词表
+func (s *SensitiveWordService) Reload(ctx context.Context) error {
+	var words []string
+	if err := s.db.WithContext(ctx).Model(&model.SensitiveWord{}).Pluck("word", &words).Error; err != nil {
+		return err
+	}
+	s.filter.Store(utils.NewSensitiveFilter(append(words, s.cfg.Words...)))
+	return nil
+}
+
+// Check 按配置处理文本：reject 模式命中时返回错误，mask 模式返回替换后的文本
+func (s *SensitiveWordService) Check(text string) (string, error) {
+	filter := s.filter.Load()
+	if s.cfg.Mode == SensitiveModeMask {
+		return filter.Mask(text, '*'), nil
+	}
+	if hits := filter.Find(text); len(hits) > 0 {
+		return text, errSensitiveWord
+	}
+	return text, nil
+}
+
+func (s *SensitiveWordService) reloadLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				s.log.Warn("reload sensitive words failed", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// ShopBatchMax 批量查询单次允许的最大商铺数
+const ShopBatchMax = 50
+
+// GetByIDs 批量查询商铺：一次 MGET 读取缓存，未命中的 ID 合并为一次 IN 查询并回填缓存；
+// 按 ids 的顺序返回，重复 ID 只返回一次，不存在的商铺直接跳过
+func (s *ShopService) GetByIDs(ctx context.Context, ids []int64) ([]model.Shop, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) == 0 {
+		return []model.Shop{}, nil
+	}
+	if len(ids) > ShopBatchMax {
+		return nil, fmt.Errorf("一次最多查询 %d 个商铺", ShopBatchMax)
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = utils.CACHE_SHOP_KEY + strconv.FormatInt(id, 10)
+	}
+	values, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[int64]model.Shop, len(ids))
+	var missing []int64
+	for i, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			missing = append(missing, ids[i])
+			continue
+		}
+		if raw == "" {
+			// 空值缓存：商铺不存在
+			continue
+		}
+		shop, err := decodeCachedShop(raw)
+		if err != nil {
+			missing = append(missing, ids[i])
+			continue
+		}
+		found[ids[i]] = *shop
+	}
+	if len(missing) > 0 {
+		var shops []model.Shop
+		if err := s.db.WithContext(ctx).Where("id IN ? AND status = ?", missing, model.ShopStatusApproved).Find(&shops).Error; err != nil {
+			return nil, err
+		}
+		for _, shop := range shops {
+			found[shop.ID] = shop
+		}
+		s.backfillShopCache(ctx, missing, found)
+	}
+	res := make([]model.Shop, 0, len(found))
+	for _, id := range ids {
+		if shop, ok := found[id]; ok {
+			res = append(res, shop)
+		}
+	}
+	return res, nil
+}
+
+// backfillShopCache 用一次 pipeline 回填未命中的商铺缓存，数据库中也不存在的写入空值
+func (s *ShopService) backfillShopCache(ctx context.Context, missing []int64, found map[int64]model.Shop) {
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range missing {
+			key := utils.CACHE_SHOP_KEY + strconv.FormatInt(id, 10)
+			shop, ok := found[id]
+			if !ok {
+				pipe.Set(ctx, key, "", utils.JitterTTL(time.Duration(utils.CACHE_NULL_TTL)*time.Minute))
+				continue
+			}
+			data, err := json.Marshal(&shop)
+			if err != nil {
+				continue
+			}
+			pipe.Set(ctx, key, data, utils.JitterTTL(s.cacheSettings().redisTTL))
+		}
+		return nil
+	})
+	if err != nil && s.log != nil {
+		s.log.Warn("backfill shop cache failed", zap.Int("count", len(missing)), zap.Error(err))
+	}
+}
+
+// decodeCachedShop 解析商铺缓存，兼容普通缓存与热点商铺的逻辑过期包装
+func decodeCachedShop(raw string) (*model.Shop, error) {
+	var wrapper struct {
+		ExpireTime *time.Time  `json:"expireTime"`
+		Data       *model.Shop `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(raw), &wrapper); err == nil && wrapper.ExpireTime != nil && wrapper.Data != nil {
+		return wrapper.Data, nil
+	}
+	var shop model.Shop
+	if err := json.Unmarshal([]byte(raw), &shop); err != nil {
+		return nil, err
+	}
+	return &shop, nil
+}
+
+// uniqueIDs 去除非正数与重复 ID，保持原有顺序
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	res := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		res = append(res, id)
+	}
+	return res
+}
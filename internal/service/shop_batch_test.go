@@ -0,0 +1,30 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDecodeCachedShop 校验普通缓存与逻辑过期包装均能解析出商铺
+func TestDecodeCachedShop(t *testing.T) {
+	plain, err := decodeCachedShop(`{"id":1,"name":"plain"}`)
+	if err != nil || plain.ID != 1 || plain.Name != "plain" {
+		t.Fatalf("plain cache decode = %+v, %v", plain, err)
+	}
+	wrapped, err := decodeCachedShop(`{"expireTime":"2026-01-01T00:00:00Z","data":{"id":2,"name":"hot"}}`)
+	if err != nil || wrapped.ID != 2 || wrapped.Name != "hot" {
+		t.Fatalf("logical expire cache decode = %+v, %v", wrapped, err)
+	}
+	if _, err := decodeCachedShop("not json"); err == nil {
+		t.Fatal("expected error for invalid cache value")
+	}
+}
+
+// TestUniqueIDs 校验去重时保持原有顺序并过滤非法 ID
+func TestUniqueIDs(t *testing.T) {
+	got := uniqueIDs([]int64{3, 1, 3, 0, -2, 2, 1})
+	want := []int64{3, 1, 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("uniqueIDs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/utils"
+)
+
+// shopCacheSettings 可在运行时热更新的商铺缓存参数；进程内缓存的容量与 TTL 在创建时确定，不在此列
+type shopCacheSettings struct {
+	strategy         string
+	redisTTL         time.Duration
+	deleteRetryCount int
+	deleteRetryDelay time.Duration
+}
+
+// newShopCacheSettings 按配置生成缓存参数，未配置的项使用默认值
+func newShopCacheSettings(cfg config.ShopCacheConfig) *shopCacheSettings {
+	st := &shopCacheSettings{
+		strategy:         cfg.Strategy,
+		redisTTL:         cfg.RedisTTL,
+		deleteRetryCount: cfg.DeleteRetryCount,
+		deleteRetryDelay: cfg.DeleteRetryDelay,
+	}
+	if st.strategy != ShopCacheStrategyLogical {
+		st.strategy = ShopCacheStrategyMutex
+	}
+	if st.redisTTL <= 0 {
+		st.redisTTL = time.Duration(utils.CACHE_SHOP_TTL) * time.Minute
+	}
+	if st.deleteRetryCount <= 0 {
+		st.deleteRetryCount = defaultShopCacheDeleteRetryCount
+	}
+	if st.deleteRetryDelay <= 0 {
+		st.deleteRetryDelay = defaultShopCacheDeleteRetryDelay
+	}
+	return st
+}
+
+// cacheSettings 返回当前生效的缓存参数
+func (s *ShopService) cacheSettings() *shopCacheSettings {
+	return s.cacheCfg.Load()
+}
+
+// ApplyCacheConfig 热更新缓存策略、Redis 缓存有效期与删除重试参数，对之后的请求生效
+func (s *ShopService) ApplyCacheConfig(cfg config.ShopCacheConfig) {
+	st := newShopCacheSettings(cfg)
+	s.cacheCfg.Store(st)
+	if s.log != nil {
+		s.log.Info("shop cache config applied",
+			zap.String("strategy", st.strategy),
+			zap.Duration("redisTTL", st.redisTTL),
+			zap.Int("deleteRetryCount", st.deleteRetryCount),
+			zap.Duration("deleteRetryDelay", st.deleteRetryDelay),
+		)
+	}
+}
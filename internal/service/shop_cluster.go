@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	// shopClusterCellPx 聚合网格的边长（像素），按 256px 瓦片换算成经纬度跨度
+	shopClusterCellPx = 64
+	// shopClusterScanMax 单个类型 key 在视野内最多取出的商铺数，防止超大视野拖垮 Redis
+	shopClusterScanMax = 5000
+	shopClusterMaxZoom = 22
+)
+
+// ShopBounds 地图视野范围（经纬度）
+type ShopBounds struct {
+	MinX float64
+	MinY float64
+	MaxX float64
+	MaxY float64
+}
+
+// ShopCluster 地图聚合点：Count 为 1 时 ShopID 为对应商铺，X/Y 为聚合内商铺坐标的均值
+type ShopCluster struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Count  int     `json:"count"`
+	ShopID int64   `json:"shopId,omitempty"`
+}
+
+// ClusterShops 在视野范围内按网格聚合商铺 GEO 数据，zoom 越大网格越小；typeID 为 0 时聚合全部类型
+func (s *ShopService) ClusterShops(ctx context.Context, typeID int64, bounds ShopBounds, zoom int) ([]ShopCluster, error) {
+	if err := validateShopBounds(bounds); err != nil {
+		return nil, err
+	}
+	if zoom < 0 || zoom > shopClusterMaxZoom {
+		return nil, errors.New("invalid zoom")
+	}
+	typeIDs := []int64{typeID}
+	if typeID <= 0 {
+		if err := s.db.WithContext(ctx).Model(&model.ShopType{}).Pluck("id", &typeIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+	centerX := (bounds.MinX + bounds.MaxX) / 2
+	centerY := (bounds.MinY + bounds.MaxY) / 2
+	// BYBOX 的宽度取视野内最靠近赤道处的东西跨度，保证矩形覆盖整个视野，结果再按边界精确过滤
+	edgeY := bounds.MaxY
+	if math.Abs(bounds.MinY) < math.Abs(bounds.MaxY) {
+		edgeY = bounds.MinY
+	}
+	if bounds.MinY < 0 && bounds.MaxY > 0 {
+		edgeY = 0
+	}
+	width := geoDistance(bounds.MinX, edgeY, bounds.MaxX, edgeY)
+	height := geoDistance(centerX, bounds.MinY, centerX, bounds.MaxY)
+	var points []redis.GeoLocation
+	for _, id := range typeIDs {
+		locs, err := s.rdb.GeoSearchLocation(ctx, utils.SHOP_GEO_KEY+strconv.FormatInt(id, 10), &redis.GeoSearchLocationQuery{
+			GeoSearchQuery: redis.GeoSearchQuery{
+				Longitude: centerX,
+				Latitude:  centerY,
+				BoxWidth:  width,
+				BoxHeight: height,
+				BoxUnit:   "m",
+				Count:     shopClusterScanMax,
+			},
+			WithCoord: true,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, locs...)
+	}
+	return clusterGeoPoints(points, bounds, zoom), nil
+}
+
+// clusterGeoPoints 将视野内的点按网格分组，网格跨度为 shopClusterCellPx 像素对应的经度
+func clusterGeoPoints(points []redis.GeoLocation, bounds ShopBounds, zoom int) []ShopCluster {
+	cell := 360 / math.Exp2(float64(zoom)) * shopClusterCellPx / 256
+	type cellKey struct{ col, row int64 }
+	type acc struct {
+		sumX, sumY float64
+		count      int
+		name       string
+	}
+	cells := make(map[cellKey]*acc)
+	for _, p := range points {
+		if p.Longitude < bounds.MinX || p.Longitude > bounds.MaxX || p.Latitude < bounds.MinY || p.Latitude > bounds.MaxY {
+			continue
+		}
+		k := cellKey{col: int64(math.Floor(p.Longitude / cell)), row: int64(math.Floor(p.Latitude / cell))}
+		a, ok := cells[k]
+		if !ok {
+			a = &acc{name: p.Name}
+			cells[k] = a
+		}
+		a.sumX += p.Longitude
+		a.sumY += p.Latitude
+		a.count++
+	}
+	clusters := make([]ShopCluster, 0, len(cells))
+	for _, a := range cells {
+		c := ShopCluster{X: a.sumX / float64(a.count), Y: a.sumY / float64(a.count), Count: a.count}
+		if a.count == 1 {
+			c.ShopID, _ = strconv.ParseInt(a.name, 10, 64)
+		}
+		clusters = append(clusters, c)
+	}
+	// 数量多的聚合点优先，便于前端按序渲染
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		if clusters[i].X != clusters[j].X {
+			return clusters[i].X < clusters[j].X
+		}
+		return clusters[i].Y < clusters[j].Y
+	})
+	return clusters
+}
+
+// validateShopBounds 校验视野范围合法且不跨越 180 度经线
+func validateShopBounds(b ShopBounds) error {
+	if b.MinX < -180 || b.MaxX > 180 || b.MinY < -85.05112878 || b.MaxY > 85.05112878 {
+		return errors.New("视野范围超出经纬度范围")
+	}
+	if b.MinX >= b.MaxX || b.MinY >= b.MaxY {
+		return errors.New("视野范围无效")
+	}
+	return nil
+}
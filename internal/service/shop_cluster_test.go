@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TestClusterGeoPoints 校验同一网格内的点被合并、单点聚合带商铺ID、视野外的点被过滤
+func TestClusterGeoPoints(t *testing.T) {
+	bounds := ShopBounds{MinX: 120, MinY: 30, MaxX: 121, MaxY: 31}
+	points := []redis.GeoLocation{
+		{Name: "1", Longitude: 120.1001, Latitude: 30.1001},
+		{Name: "2", Longitude: 120.1003, Latitude: 30.1003},
+		{Name: "3", Longitude: 120.9, Latitude: 30.9},
+		{Name: "4", Longitude: 122, Latitude: 30.5},
+	}
+	clusters := clusterGeoPoints(points, bounds, 10)
+	if len(clusters) != 2 {
+		t.Fatalf("want 2 clusters, got %+v", clusters)
+	}
+	if clusters[0].Count != 2 || clusters[0].ShopID != 0 {
+		t.Fatalf("first cluster should merge shops 1 and 2, got %+v", clusters[0])
+	}
+	if clusters[1].Count != 1 || clusters[1].ShopID != 3 {
+		t.Fatalf("second cluster should be shop 3, got %+v", clusters[1])
+	}
+	// 放大到最大级别后网格足够小，两点各自成簇
+	if clusters := clusterGeoPoints(points[:2], bounds, shopClusterMaxZoom); len(clusters) != 2 {
+		t.Fatalf("max zoom should not merge distinct shops, got %+v", clusters)
+	}
+}
+
+// TestValidateShopBounds 校验视野范围的边界检查
+func TestValidateShopBounds(t *testing.T) {
+	if err := validateShopBounds(ShopBounds{MinX: 120, MinY: 30, MaxX: 121, MaxY: 31}); err != nil {
+		t.Fatalf("valid bounds rejected: %v", err)
+	}
+	if err := validateShopBounds(ShopBounds{MinX: 121, MinY: 30, MaxX: 120, MaxY: 31}); err == nil {
+		t.Fatal("inverted bounds accepted")
+	}
+	if err := validateShopBounds(ShopBounds{MinX: -181, MinY: 30, MaxX: 120, MaxY: 31}); err == nil {
+		t.Fatal("out of range bounds accepted")
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// ShopFavoriteService 商铺收藏：收藏记录落库，每个用户的已收藏商铺ID缓存在 Redis Set 中用于快速判断
+type ShopFavoriteService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewShopFavoriteService 创建 ShopFavoriteService 实例
+func NewShopFavoriteService(db *gorm.DB, rdb *redis.Client) *ShopFavoriteService {
+	return &ShopFavoriteService{db: db, rdb: rdb}
+}
+
+// Toggle 切换收藏状态，返回切换后是否已收藏
+func (s *ShopFavoriteService) Toggle(ctx context.Context, userID, shopID int64) (bool, error) {
+	favorited := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("user_id = ? AND shop_id = ?", userID, shopID).Delete(&model.ShopFavorite{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 {
+			return nil
+		}
+		var count int64
+		if err := tx.Model(&model.Shop{}).Where("id = ? AND status = ?", shopID, model.ShopStatusApproved).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errShopNotFound
+		}
+		favorited = true
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.ShopFavorite{UserID: userID, ShopID: shopID}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	key := shopFavoriteKey(userID)
+	if favorited {
+		// 删除集合而非 SADD：集合未加载时直接 SADD 会得到不完整的集合，下次判断时再从数据库重建
+		return true, s.rdb.Del(ctx, key).Err()
+	}
+	return false, s.rdb.SRem(ctx, key, shopID).Err()
+}
+
+// IsFavorited 判断用户是否收藏了商铺；Redis 集合不存在时从数据库重建
+func (s *ShopFavoriteService) IsFavorited(ctx context.Context, userID, shopID int64) (bool, error) {
+	key := shopFavoriteKey(userID)
+	exists, err := s.rdb.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	if exists > 0 {
+		return s.rdb.SIsMember(ctx, key, shopID).Result()
+	}
+	var shopIDs []int64
+	if err := s.db.WithContext(ctx).Model(&model.ShopFavorite{}).
+		Where("user_id = ?", userID).
+		Pluck("shop_id", &shopIDs).Error; err != nil {
+		return false, err
+	}
+	if len(shopIDs) == 0 {
+		return false, nil
+	}
+	members := make([]interface{}, 0, len(shopIDs))
+	found := false
+	for _, id := range shopIDs {
+		members = append(members, id)
+		found = found || id == shopID
+	}
+	if err := s.rdb.SAdd(ctx, key, members...).Err(); err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+// List 分页查询用户收藏的商铺，最近收藏的在前；传入经纬度时附带距离（米）
+func (s *ShopFavoriteService) List(ctx context.Context, userID int64, page, size int, x, y float64) ([]model.Shop, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.MAX_PAGE_SIZE
+	}
+	var shops []model.Shop
+	if err := s.db.WithContext(ctx).
+		Joins("JOIN "+model.ShopFavorite{}.TableName()+" f ON f.shop_id = tb_shop.id").
+		Where("f.user_id = ? AND tb_shop.status = ?", userID, model.ShopStatusApproved).
+		Order("f.id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&shops).Error; err != nil {
+		return nil, err
+	}
+	if hasLocation(x, y) {
+		AttachShopDistance(shops, x, y, "m")
+	}
+	return shops, nil
+}
+
+func shopFavoriteKey(userID int64) string {
+	return utils.SHOP_FAVORITE_KEY + strconv.FormatInt(userID, 10)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// ShopFilter 组合筛选条件，零值字段表示不限；X/Y 均为 0 表示未提供位置
+type ShopFilter struct {
+	TypeID   int64
+	Keyword  string
+	MinPrice int64
+	MaxPrice int64
+	MinScore int
+	SortBy   string
+	X        float64
+	Y        float64
+	Radius   float64
+	Unit     string
+	Page     int
+	Size     int
+}
+
+// List 按组合条件分页查询店铺，返回当前页与符合条件的总数；
+// 提供位置时附带距离，Radius 大于 0 时只返回半径内的店铺，sortBy 为空时默认按距离排序
+func (s *ShopService) List(ctx context.Context, f ShopFilter) ([]model.Shop, int64, error) {
+	if err := ValidateShopSortBy(f.SortBy); err != nil {
+		return nil, 0, err
+	}
+	if err := ValidateGeoUnit(f.Unit); err != nil {
+		return nil, 0, err
+	}
+	if f.MinPrice > 0 && f.MaxPrice > 0 && f.MinPrice > f.MaxPrice {
+		return nil, 0, errors.New("minPrice 不能大于 maxPrice")
+	}
+	geo := hasLocation(f.X, f.Y)
+	if f.SortBy == ShopSortDistance && !geo {
+		return nil, 0, errors.New("按距离排序需要提供坐标")
+	}
+	if f.Page <= 0 {
+		f.Page = 1
+	}
+	if f.Size <= 0 {
+		f.Size = utils.DEFAULT_PAGE_SIZE
+	}
+	query := s.db.WithContext(ctx).Model(&model.Shop{}).Where("status = ?", model.ShopStatusApproved)
+	if f.TypeID > 0 {
+		query = query.Where("type_id = ?", f.TypeID)
+	}
+	if keyword := strings.TrimSpace(f.Keyword); keyword != "" {
+		pattern := "%" + escapeLike(keyword) + "%"
+		query = query.Where("(name LIKE ? OR area LIKE ? OR address LIKE ?)", pattern, pattern, pattern)
+	}
+	if f.MinPrice > 0 {
+		query = query.Where("avg_price >= ?", f.MinPrice)
+	}
+	if f.MaxPrice > 0 {
+		query = query.Where("avg_price <= ?", f.MaxPrice)
+	}
+	if f.MinScore > 0 {
+		query = query.Where("score >= ?", f.MinScore)
+	}
+	distanceExpr := clause.Expr{SQL: "ST_Distance_Sphere(POINT(x, y), POINT(?, ?))", Vars: []interface{}{f.X, f.Y}}
+	if geo && f.Radius > 0 {
+		radius, unit, err := s.geoRadius(f.Radius, f.Unit)
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where("(x <> 0 OR y <> 0)").
+			Where("ST_Distance_Sphere(POINT(x, y), POINT(?, ?)) <= ?", f.X, f.Y, radius*geoUnitMeters[unit])
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if geo && (f.SortBy == "" || f.SortBy == ShopSortDistance) {
+		// 无坐标的店铺排在最后
+		query = query.Order("(x = 0 AND y = 0) ASC").
+			Order(clause.OrderBy{Expression: distanceExpr}).
+			Order("id ASC")
+	} else {
+		query = query.Order(shopSortOrders[f.SortBy])
+	}
+	var shops []model.Shop
+	if err := query.Offset((f.Page - 1) * f.Size).Limit(f.Size).Find(&shops).Error; err != nil {
+		return nil, 0, err
+	}
+	if geo {
+		AttachShopDistance(shops, f.X, f.Y, f.Unit)
+	}
+	return shops, total, nil
+}
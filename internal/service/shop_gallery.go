@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+)
+
+// shopGalleryMax 单个商铺最多保存的图片数
+const shopGalleryMax = 20
+
+var (
+	errShopImageNotFound = errors.New("图片不存在")
+	errShopGalleryFull   = errors.New("商铺图片数量已达上限")
+	errShopImageReorder  = errors.New("排序后的图片须与现有图片一致")
+)
+
+// AddImage 将已上传的图片追加到商铺图库末尾，返回最新的图片列表
+func (s *ShopService) AddImage(ctx context.Context, shopID int64, image string) ([]string, error) {
+	return s.updateGallery(ctx, shopID, func(images []string) ([]string, error) {
+		if len(images) >= shopGalleryMax {
+			return nil, errShopGalleryFull
+		}
+		return append(images, image), nil
+	})
+}
+
+// ReorderImages 按给定顺序重排商铺图库，列表必须与现有图片完全一致
+func (s *ShopService) ReorderImages(ctx context.Context, shopID int64, ordered []string) ([]string, error) {
+	return s.updateGallery(ctx, shopID, func(images []string) ([]string, error) {
+		if len(ordered) != len(images) {
+			return nil, errShopImageReorder
+		}
+		remain := make(map[string]int, len(images))
+		for _, img := range images {
+			remain[img]++
+		}
+		for _, img := range ordered {
+			if remain[img] == 0 {
+				return nil, errShopImageReorder
+			}
+			remain[img]--
+		}
+		return ordered, nil
+	})
+}
+
+// RemoveImage 从商铺图库中删除图片，返回最新的图片列表；调用方负责删除文件
+func (s *ShopService) RemoveImage(ctx context.Context, shopID int64, image string) ([]string, error) {
+	return s.updateGallery(ctx, shopID, func(images []string) ([]string, error) {
+		for i, img := range images {
+			if img == image {
+				return append(images[:i], images[i+1:]...), nil
+			}
+		}
+		return nil, errShopImageNotFound
+	})
+}
+
+// updateGallery 在行锁内读取并改写商铺图片列表，成功后删除商铺缓存
+func (s *ShopService) updateGallery(ctx context.Context, shopID int64, change func([]string) ([]string, error)) ([]string, error) {
+	var images []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var shop model.Shop
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "images").First(&shop, shopID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errShopNotFound
+		}
+		if err != nil {
+			return err
+		}
+		images, err = change(splitShopImages(shop.Images))
+		if err != nil {
+			return err
+		}
+		return tx.Model(&model.Shop{}).Where("id = ?", shopID).Update("images", strings.Join(images, ",")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.InvalidateCache(ctx, shopID)
+	return images, nil
+}
+
+// splitShopImages 将逗号分隔的图片字段拆分为列表，忽略空项
+func splitShopImages(raw string) []string {
+	images := make([]string, 0)
+	for _, img := range strings.Split(raw, ",") {
+		if img = strings.TrimSpace(img); img != "" {
+			images = append(images, img)
+		}
+	}
+	return images
+}
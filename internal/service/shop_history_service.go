@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// ShopHistoryItem 浏览记录，附带最近一次浏览时间（毫秒）
+type ShopHistoryItem struct {
+	model.Shop
+	ViewTime int64 `json:"viewTime"`
+}
+
+// ShopHistoryService 维护用户“最近浏览”的店铺：ZSet 以浏览时间为分数，天然去重
+type ShopHistoryService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewShopHistoryService 创建 ShopHistoryService 实例
+func NewShopHistoryService(db *gorm.DB, rdb *redis.Client) *ShopHistoryService {
+	return &ShopHistoryService{db: db, rdb: rdb}
+}
+
+// Record 记录一次店铺浏览，只保留最近 SHOP_HISTORY_MAX 条
+func (s *ShopHistoryService) Record(ctx context.Context, userID, shopID int64) error {
+	key := utils.SHOP_HISTORY_KEY + strconv.FormatInt(userID, 10)
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(time.Now().UnixMilli()), Member: shopID})
+		// 按分数升序排列，删除排名在最近 N 条之前的旧记录
+		pipe.ZRemRangeByRank(ctx, key, 0, -utils.SHOP_HISTORY_MAX-1)
+		return nil
+	})
+	return err
+}
+
+// List 分页查询最近浏览的店铺，按浏览时间倒序
+func (s *ShopHistoryService) List(ctx context.Context, userID int64, page, size int) ([]ShopHistoryItem, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.MAX_PAGE_SIZE
+	}
+	key := utils.SHOP_HISTORY_KEY + strconv.FormatInt(userID, 10)
+	start := int64((page - 1) * size)
+	views, err := s.rdb.ZRevRangeWithScores(ctx, key, start, start+int64(size)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(views) == 0 {
+		return []ShopHistoryItem{}, nil
+	}
+	ids := make([]int64, 0, len(views))
+	for _, v := range views {
+		id, err := strconv.ParseInt(v.Member.(string), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	var shops []model.Shop
+	if err := s.db.WithContext(ctx).Where("id IN ? AND status = ?", ids, model.ShopStatusApproved).Find(&shops).Error; err != nil {
+		return nil, err
+	}
+	shopMap := make(map[int64]model.Shop, len(shops))
+	for _, shop := range shops {
+		shopMap[shop.ID] = shop
+	}
+	// 按浏览时间顺序输出，已下架的店铺直接跳过
+	res := make([]ShopHistoryItem, 0, len(views))
+	for _, v := range views {
+		id, _ := strconv.ParseInt(v.Member.(string), 10, 64)
+		if shop, ok := shopMap[id]; ok {
+			res = append(res, ShopHistoryItem{Shop: shop, ViewTime: int64(v.Score)})
+		}
+	}
+	return res, nil
+}
+
+// Clear 清空用户的浏览记录
+func (s *ShopHistoryService) Clear(ctx context.Context, userID int64) error {
+	return s.rdb.Del(ctx, utils.SHOP_HISTORY_KEY+strconv.FormatInt(userID, 10)).Err()
+}
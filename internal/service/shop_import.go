@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+)
+
+// shopImportRequired CSV 必须包含的列，其余可选列为 area、x、y、avgPrice、openHours、images
+var shopImportRequired = []string{"name", "typeId", "address"}
+
+// ShopImportRow 被拒绝的行，Line 为 CSV 中的行号（表头为第 1 行）
+type ShopImportRow struct {
+	Line   int    `json:"line"`
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ShopImportReport 导入结果；DryRun 时 Created/Updated 为预计写入的数量
+type ShopImportReport struct {
+	DryRun   bool            `json:"dryRun"`
+	Total    int             `json:"total"`
+	Created  int             `json:"created"`
+	Updated  int             `json:"updated"`
+	Rejected []ShopImportRow `json:"rejected"`
+}
+
+// Import 从 CSV 批量导入商铺：按 name+address 匹配已有商铺则更新，否则新建并同步 GEO、布隆过滤器与搜索索引；
+// 单行校验或写入失败只记录到报告中，不影响其他行；dryRun 时只校验不写入
+func (s *ShopService) Import(ctx context.Context, r io.Reader, dryRun bool) (*ShopImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range shopImportRequired {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("csv 缺少列 %s", name)
+		}
+	}
+	var typeIDs []int64
+	if err := s.db.WithContext(ctx).Model(&model.ShopType{}).Pluck("id", &typeIDs).Error; err != nil {
+		return nil, err
+	}
+	validTypes := make(map[int64]struct{}, len(typeIDs))
+	for _, id := range typeIDs {
+		validTypes[id] = struct{}{}
+	}
+
+	report := &ShopImportReport{DryRun: dryRun, Rejected: []ShopImportRow{}}
+	seen := make(map[string]int)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			// 列数不一致等格式错误只影响当前行
+			report.Total++
+			report.Rejected = append(report.Rejected, ShopImportRow{Line: line, Reason: err.Error()})
+			continue
+		}
+		report.Total++
+		shop, err := parseImportShop(record, cols, validTypes)
+		if err != nil {
+			report.Rejected = append(report.Rejected, ShopImportRow{Line: line, Name: shop.Name, Reason: err.Error()})
+			continue
+		}
+		dedupKey := shop.Name + "\x00" + shop.Address
+		if first, ok := seen[dedupKey]; ok {
+			report.Rejected = append(report.Rejected, ShopImportRow{Line: line, Name: shop.Name, Reason: fmt.Sprintf("与第 %d 行重复", first)})
+			continue
+		}
+		seen[dedupKey] = line
+
+		var existing model.Shop
+		err = s.db.WithContext(ctx).Select("id").
+			Where("name = ? AND address = ?", shop.Name, shop.Address).
+			Take(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		exists := err == nil
+		if !dryRun {
+			if exists {
+				shop.ID = existing.ID
+				err = s.Update(ctx, shop)
+			} else {
+				err = s.Create(ctx, shop)
+			}
+			if err != nil {
+				report.Rejected = append(report.Rejected, ShopImportRow{Line: line, Name: shop.Name, Reason: err.Error()})
+				continue
+			}
+		}
+		if exists {
+			report.Updated++
+		} else {
+			report.Created++
+		}
+	}
+	return report, nil
+}
+
+// parseImportShop 将一行 CSV 解析为商铺并校验；出错时返回的商铺至少带有名称便于定位
+func parseImportShop(record []string, cols map[string]int, validTypes map[int64]struct{}) (*model.Shop, error) {
+	field := func(name string) string {
+		if i, ok := cols[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	shop := &model.Shop{
+		Name:      field("name"),
+		Address:   field("address"),
+		Area:      field("area"),
+		OpenHours: field("openHours"),
+		Images:    field("images"),
+	}
+	if shop.Name == "" {
+		return shop, errors.New("name 不能为空")
+	}
+	if shop.Address == "" {
+		return shop, errors.New("address 不能为空")
+	}
+	typeID, err := strconv.ParseInt(field("typeId"), 10, 64)
+	if err != nil {
+		return shop, errors.New("typeId 无效")
+	}
+	if _, ok := validTypes[typeID]; !ok {
+		return shop, fmt.Errorf("商铺类型 %d 不存在", typeID)
+	}
+	shop.TypeID = typeID
+	if v := field("avgPrice"); v != "" {
+		if shop.AvgPrice, err = strconv.ParseInt(v, 10, 64); err != nil || shop.AvgPrice < 0 {
+			return shop, errors.New("avgPrice 无效")
+		}
+	}
+	xStr, yStr := field("x"), field("y")
+	if (xStr == "") != (yStr == "") {
+		return shop, errors.New("x、y 需同时提供")
+	}
+	if xStr != "" {
+		if shop.X, err = strconv.ParseFloat(xStr, 64); err != nil || shop.X < -180 || shop.X > 180 {
+			return shop, errors.New("x 无效")
+		}
+		if shop.Y, err = strconv.ParseFloat(yStr, 64); err != nil || shop.Y < -85.05112878 || shop.Y > 85.05112878 {
+			return shop, errors.New("y 无效")
+		}
+	}
+	if shop.OpenHours != "" {
+		if _, err := parseOpenHours(shop.OpenHours); err != nil {
+			return shop, err
+		}
+	}
+	return shop, nil
+}
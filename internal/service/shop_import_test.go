@@ -0,0 +1,32 @@
+package service
+
+import "testing"
+
+// TestParseImportShop 校验 CSV 行的字段解析与各类非法输入
+func TestParseImportShop(t *testing.T) {
+	cols := map[string]int{"name": 0, "typeId": 1, "address": 2, "x": 3, "y": 4, "avgPrice": 5, "openHours": 6}
+	types := map[int64]struct{}{1: {}}
+
+	shop, err := parseImportShop([]string{" 茶餐厅 ", "1", "西湖区 1 号", "120.1", "30.2", "80", "10:00-22:00"}, cols, types)
+	if err != nil {
+		t.Fatalf("valid row rejected: %v", err)
+	}
+	if shop.Name != "茶餐厅" || shop.TypeID != 1 || shop.X != 120.1 || shop.Y != 30.2 || shop.AvgPrice != 80 {
+		t.Fatalf("unexpected shop: %+v", shop)
+	}
+
+	cases := map[string][]string{
+		"missing name":    {"", "1", "addr", "", "", "", ""},
+		"unknown type":    {"a", "2", "addr", "", "", "", ""},
+		"half location":   {"a", "1", "addr", "120", "", "", ""},
+		"bad longitude":   {"a", "1", "addr", "200", "30", "", ""},
+		"negative price":  {"a", "1", "addr", "", "", "-1", ""},
+		"bad open hours":  {"a", "1", "addr", "", "", "", "10-22"},
+		"missing address": {"a", "1", "", "", "", "", ""},
+	}
+	for name, record := range cases {
+		if _, err := parseImportShop(record, cols, types); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
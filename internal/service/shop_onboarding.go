@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+)
+
+var (
+	errShopNotPending  = errors.New("商铺不在待审核状态")
+	errShopRejectNote  = errors.New("驳回时请填写原因")
+	errShopNotOwned    = errors.New("只能管理自己的商铺")
+	errShopNameMissing = errors.New("商铺名称不能为空")
+)
+
+// Submit 商家提交入驻申请：商铺以待审核状态保存，审核通过前对顾客不可见
+func (s *ShopService) Submit(ctx context.Context, ownerID int64, shop *model.Shop) error {
+	shop.Name = strings.TrimSpace(shop.Name)
+	if shop.Name == "" {
+		return errShopNameMissing
+	}
+	if err := validateSchedule(shop.Schedule); err != nil {
+		return err
+	}
+	shop.ID = 0
+	shop.OwnerID = ownerID
+	shop.Status = model.ShopStatusPending
+	shop.ReviewNote = ""
+	return s.db.WithContext(ctx).Create(shop).Error
+}
+
+// Resubmit 商家修改被驳回的申请后重新提交审核
+func (s *ShopService) Resubmit(ctx context.Context, ownerID int64, shop *model.Shop) error {
+	if err := validateSchedule(shop.Schedule); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		old, err := lockShopTx(tx, shop.ID)
+		if err != nil {
+			return err
+		}
+		if old.OwnerID != ownerID {
+			return errShopNotOwned
+		}
+		if old.Status != model.ShopStatusRejected {
+			return errors.New("只有被驳回的申请可以重新提交")
+		}
+		shop.OwnerID = ownerID
+		shop.Status = model.ShopStatusPending
+		if err := tx.Model(&model.Shop{ID: shop.ID}).Updates(shop).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Shop{}).Where("id = ?", shop.ID).Update("review_note", "").Error
+	})
+}
+
+// Review 管理员审核入驻申请：通过后同步布隆过滤器、GEO 与搜索索引，驳回需填写原因
+func (s *ShopService) Review(ctx context.Context, shopID int64, approve bool, note string) error {
+	note = strings.TrimSpace(note)
+	if !approve && note == "" {
+		return errShopRejectNote
+	}
+	var shop *model.Shop
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		shop, err = lockShopTx(tx, shopID)
+		if err != nil {
+			return err
+		}
+		if shop.Status != model.ShopStatusPending {
+			return errShopNotPending
+		}
+		shop.Status = model.ShopStatusRejected
+		if approve {
+			shop.Status = model.ShopStatusApproved
+		}
+		shop.ReviewNote = note
+		return tx.Model(&model.Shop{}).Where("id = ?", shopID).Updates(map[string]interface{}{
+			"status":      shop.Status,
+			"review_note": note,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	if approve {
+		// 清理审核期间可能写入的空值缓存，再对外发布
+		s.InvalidateCache(ctx, shopID)
+		s.publishShop(ctx, shop)
+	}
+	return nil
+}
+
+// ListPending 分页查询待审核的入驻申请，按提交时间先后排序
+func (s *ShopService) ListPending(ctx context.Context, page, size int) ([]model.Shop, int64, error) {
+	query := s.db.WithContext(ctx).Model(&model.Shop{}).Where("status = ?", model.ShopStatusPending)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var shops []model.Shop
+	err := query.Order("id ASC").Offset((page - 1) * size).Limit(size).Find(&shops).Error
+	return shops, total, err
+}
+
+// ListOwned 查询商家名下的全部商铺（含待审核与被驳回的申请）
+func (s *ShopService) ListOwned(ctx context.Context, ownerID int64) ([]model.Shop, error) {
+	var shops []model.Shop
+	err := s.db.WithContext(ctx).Where("owner_id = ?", ownerID).Order("id DESC").Find(&shops).Error
+	return shops, err
+}
+
+// CheckOwner 校验商铺归属于指定商家
+func (s *ShopService) CheckOwner(ctx context.Context, shopID, ownerID int64) error {
+	var shop model.Shop
+	err := s.db.WithContext(ctx).Select("id", "owner_id").First(&shop, shopID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errShopNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if shop.OwnerID != ownerID {
+		return errShopNotOwned
+	}
+	return nil
+}
+
+// lockShopTx 在事务内对商铺加行锁
+func lockShopTx(tx *gorm.DB, shopID int64) (*model.Shop, error) {
+	var shop model.Shop
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&shop, shopID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errShopNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &shop, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	reviewMaxLength = 1000
+	reviewMaxImages = 9
+	// shopScoreScale tb_shop.score 以 10 倍整数存储平均分，如 47 表示 4.7 分
+	shopScoreScale = 10
+)
+
+var (
+	errReviewScore    = errors.New("评分必须在 1~5 之间")
+	errReviewTooLong  = errors.New("评价内容过长")
+	errReviewImages   = errors.New("评价图片最多 9 张")
+	errReviewNotFound = errors.New("评价不存在")
+	errReviewExists   = errors.New("已评价过该商铺")
+	errShopNotFound   = errors.New("商铺不存在")
+)
+
+// ShopReviewService 处理商铺评价，评分均值与评价数增量维护在 tb_shop.score / comments
+type ShopReviewService struct {
+	db        *gorm.DB
+	shop      *ShopService
+	sensitive *SensitiveWordService
+}
+
+// NewShopReviewService 创建 ShopReviewService 实例
+func NewShopReviewService(db *gorm.DB, shop *ShopService, sensitive *SensitiveWordService) *ShopReviewService {
+	return &ShopReviewService{db: db, shop: shop, sensitive: sensitive}
+}
+
+// Create 发表评价，每个用户对同一商铺只能评价一次
+func (s *ShopReviewService) Create(ctx context.Context, review *model.ShopReview) error {
+	if err := s.normalize(review); err != nil {
+		return err
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Shop{}).Where("id = ? AND status = ?", review.ShopID, model.ShopStatusApproved).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errShopNotFound
+		}
+		if err := tx.Model(&model.ShopReview{}).
+			Where("shop_id = ? AND user_id = ?", review.ShopID, review.UserID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errReviewExists
+		}
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		// 先按旧的评价数折算新均值，再累加评价数
+		return adjustShopRatingTx(tx, review.ShopID,
+			gorm.Expr("ROUND((score * comments + ?) / (comments + 1))", review.Score*shopScoreScale), 1)
+	})
+	if err != nil {
+		return err
+	}
+	s.shop.InvalidateCache(ctx, review.ShopID)
+	return nil
+}
+
+// Update 修改自己的评价，评分变化时按差值修正商铺均分
+func (s *ShopReviewService) Update(ctx context.Context, userID int64, review *model.ShopReview) error {
+	if err := s.normalize(review); err != nil {
+		return err
+	}
+	var shopID int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var old model.ShopReview
+		err := tx.Where("id = ? AND user_id = ?", review.ID, userID).Take(&old).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errReviewNotFound
+		}
+		if err != nil {
+			return err
+		}
+		shopID = old.ShopID
+		if err := tx.Model(&old).Updates(map[string]interface{}{
+			"score":   review.Score,
+			"content": review.Content,
+			"images":  review.Images,
+		}).Error; err != nil {
+			return err
+		}
+		delta := (review.Score - old.Score) * shopScoreScale
+		if delta == 0 {
+			return nil
+		}
+		return adjustShopRatingTx(tx, old.ShopID,
+			gorm.Expr("ROUND(score + ? / GREATEST(comments, 1))", delta), 0)
+	})
+	if err != nil {
+		return err
+	}
+	s.shop.InvalidateCache(ctx, shopID)
+	return nil
+}
+
+// Delete 删除自己的评价，并从商铺均分中剔除
+func (s *ShopReviewService) Delete(ctx context.Context, userID, reviewID int64) error {
+	var review model.ShopReview
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ? AND user_id = ?", reviewID, userID).Take(&review).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errReviewNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.ShopReview{}, reviewID).Error; err != nil {
+			return err
+		}
+		return adjustShopRatingTx(tx, review.ShopID,
+			gorm.Expr("IF(comments > 1, ROUND((score * comments - ?) / (comments - 1)), 0)", review.Score*shopScoreScale), -1)
+	})
+	if err != nil {
+		return err
+	}
+	s.shop.InvalidateCache(ctx, review.ShopID)
+	return nil
+}
+
+// List 分页查询商铺的评价，最新的在前，附带评价者信息
+func (s *ShopReviewService) List(ctx context.Context, shopID int64, page, size int) ([]model.ShopReview, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.MAX_PAGE_SIZE
+	}
+	var reviews []model.ShopReview
+	if err := s.db.WithContext(ctx).
+		Where("shop_id = ?", shopID).
+		Order("id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	if len(reviews) == 0 {
+		return reviews, nil
+	}
+	userIDs := make([]int64, 0, len(reviews))
+	for _, r := range reviews {
+		userIDs = append(userIDs, r.UserID)
+	}
+	var users []model.User
+	if err := s.db.WithContext(ctx).Select("id", "nick_name", "icon").Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*model.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	for i := range reviews {
+		if u, ok := byID[reviews[i].UserID]; ok {
+			reviews[i].Name = u.NickName
+			reviews[i].Icon = u.Icon
+		}
+	}
+	return reviews, nil
+}
+
+// normalize 校验评分、内容与图片数量，并过滤敏感词
+func (s *ShopReviewService) normalize(review *model.ShopReview) error {
+	if review.Score < 1 || review.Score > 5 {
+		return errReviewScore
+	}
+	review.Content = utils.SanitizePlainText(review.Content)
+	if utf8.RuneCountInString(review.Content) > reviewMaxLength {
+		return errReviewTooLong
+	}
+	review.Images = strings.Trim(strings.TrimSpace(review.Images), ",")
+	if review.Images != "" && len(strings.Split(review.Images, ",")) > reviewMaxImages {
+		return errReviewImages
+	}
+	if s.sensitive != nil && review.Content != "" {
+		var err error
+		if review.Content, err = s.sensitive.Check(review.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adjustShopRatingTx 在事务内更新商铺均分与评价数；score 须先于 comments 更新，表达式中引用的是旧的评价数
+func adjustShopRatingTx(tx *gorm.DB, shopID int64, score interface{}, commentsDelta int) error {
+	if err := tx.Model(&model.Shop{}).Where("id = ?", shopID).UpdateColumn("score", score).Error; err != nil {
+		return err
+	}
+	if commentsDelta == 0 {
+		return nil
+	}
+	return tx.Model(&model.Shop{}).
+		Where("id = ?", shopID).
+		UpdateColumn("comments", gorm.Expr("GREATEST(comments + ?, 0)", commentsDelta)).Error
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hmdp-backend/internal/model"
+)
+
+// isShopOpen 判断商铺在 now 时刻是否营业；未配置结构化营业时间时退回解析 OpenHours，两者均无法解析时视为营业
+func isShopOpen(shop *model.Shop, now time.Time) bool {
+	schedule := shop.Schedule
+	if len(schedule) == 0 {
+		parsed, err := parseOpenHours(shop.OpenHours)
+		if err != nil || len(parsed) == 0 {
+			return true
+		}
+		schedule = parsed
+	}
+	weekday := isoWeekday(now)
+	yesterday := weekday - 1
+	if yesterday == 0 {
+		yesterday = 7
+	}
+	minute := now.Hour()*60 + now.Minute()
+	for _, p := range schedule {
+		open, err1 := parseClock(p.Open)
+		closeAt, err2 := parseClock(p.Close)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		if closeAt > open {
+			if periodOnDay(p, weekday) && minute >= open && minute < closeAt {
+				return true
+			}
+			continue
+		}
+		// 跨零点：当天开门之后，或前一天开门延续到今天打烊之前
+		if periodOnDay(p, weekday) && minute >= open {
+			return true
+		}
+		if periodOnDay(p, yesterday) && minute < closeAt {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSchedule 校验营业时间表的星期与时刻格式
+func validateSchedule(schedule model.Schedule) error {
+	for _, p := range schedule {
+		for _, d := range p.Days {
+			if d < 1 || d > 7 {
+				return fmt.Errorf("invalid weekday: %d", d)
+			}
+		}
+		if _, err := parseClock(p.Open); err != nil {
+			return err
+		}
+		if _, err := parseClock(p.Close); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseOpenHours 解析 "10:00-22:00" 形式的营业时间，多段以逗号分隔，每段均视为每天营业
+func parseOpenHours(raw string) (model.Schedule, error) {
+	var schedule model.Schedule
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		open, closeAt, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid open hours: %q", part)
+		}
+		p := model.OpenPeriod{Open: strings.TrimSpace(open), Close: strings.TrimSpace(closeAt)}
+		if _, err := parseClock(p.Open); err != nil {
+			return nil, err
+		}
+		if _, err := parseClock(p.Close); err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, p)
+	}
+	return schedule, nil
+}
+
+// parseClock 将 HH:MM 解析为当天的分钟数，24:00 表示当天结束
+func parseClock(raw string) (int, error) {
+	h, m, ok := strings.Cut(raw, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time: %q", raw)
+	}
+	hour, err := strconv.Atoi(h)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time: %q", raw)
+	}
+	minute, err := strconv.Atoi(m)
+	if err != nil || minute < 0 || minute > 59 || hour < 0 || hour > 24 || (hour == 24 && minute > 0) {
+		return 0, fmt.Errorf("invalid time: %q", raw)
+	}
+	return hour*60 + minute, nil
+}
+
+// periodOnDay 判断营业时段是否适用于指定星期
+func periodOnDay(p model.OpenPeriod, weekday int) bool {
+	if len(p.Days) == 0 {
+		return true
+	}
+	for _, d := range p.Days {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
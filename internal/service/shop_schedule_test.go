@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"hmdp-backend/internal/model"
+)
+
+// TestIsShopOpen 覆盖普通时段、跨零点时段、按星期营业与 OpenHours 兜底解析
+func TestIsShopOpen(t *testing.T) {
+	// 2026-10-12 为周一
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.Local)
+	}
+	daily := &model.Shop{OpenHours: "10:00-22:00"}
+	night := &model.Shop{Schedule: model.Schedule{{Days: []int{5, 6}, Open: "18:00", Close: "02:00"}}}
+	weekday := &model.Shop{Schedule: model.Schedule{{Days: []int{1, 2, 3, 4, 5}, Open: "09:00", Close: "18:00"}}}
+
+	cases := []struct {
+		name string
+		shop *model.Shop
+		now  time.Time
+		want bool
+	}{
+		{"open hours inside", daily, at(12, 10, 0), true},
+		{"open hours at close", daily, at(12, 22, 0), false},
+		{"overnight friday evening", night, at(16, 23, 30), true},
+		{"overnight saturday early morning", night, at(17, 1, 59), true},
+		{"overnight sunday early morning", night, at(18, 1, 0), true},
+		{"overnight friday early morning", night, at(16, 1, 0), false},
+		{"weekday on monday", weekday, at(12, 9, 0), true},
+		{"weekday on sunday", weekday, at(18, 12, 0), false},
+		{"unknown hours", &model.Shop{OpenHours: "全天"}, at(12, 3, 0), true},
+	}
+	for _, tc := range cases {
+		if got := isShopOpen(tc.shop, tc.now); got != tc.want {
+			t.Fatalf("%s: want %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+// TestValidateSchedule 校验非法的星期与时刻会被拒绝
+func TestValidateSchedule(t *testing.T) {
+	bad := []model.Schedule{
+		{{Days: []int{0}, Open: "09:00", Close: "18:00"}},
+		{{Open: "9", Close: "18:00"}},
+		{{Open: "09:00", Close: "24:30"}},
+	}
+	for _, s := range bad {
+		if err := validateSchedule(s); err == nil {
+			t.Fatalf("expected error for %+v", s)
+		}
+	}
+	if err := validateSchedule(model.Schedule{{Open: "00:00", Close: "24:00"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
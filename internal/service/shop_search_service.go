@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/data"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+// earthRadius 地球平均半径（米），用于数据库兜底查询时计算距离
+const earthRadius = 6371000.0
+
+// shopIndexMapping 商铺索引映射：location 必须显式声明为 geo_point 才能按距离排序
+var shopIndexMapping = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"name":     map[string]interface{}{"type": "text"},
+			"area":     map[string]interface{}{"type": "text"},
+			"address":  map[string]interface{}{"type": "text"},
+			"typeId":   map[string]interface{}{"type": "long"},
+			"sold":     map[string]interface{}{"type": "integer"},
+			"score":    map[string]interface{}{"type": "integer"},
+			"location": map[string]interface{}{"type": "geo_point"},
+		},
+	},
+}
+
+// geoPoint Elasticsearch geo_point 的对象表示
+type geoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// shopDocument 写入 Elasticsearch 的商铺文档
+type shopDocument struct {
+	Name     string    `json:"name"`
+	TypeID   int64     `json:"typeId"`
+	Area     string    `json:"area"`
+	Address  string    `json:"address"`
+	Sold     int       `json:"sold"`
+	Score    int       `json:"score"`
+	Location *geoPoint `json:"location,omitempty"`
+}
+
+// ShopSearchQuery 商铺搜索条件，X/Y 为用户经纬度，均为 0 表示不按距离排序
+type ShopSearchQuery struct {
+	Keyword string
+	TypeID  int64
+	X       float64
+	Y       float64
+	Page    int
+	Size    int
+}
+
+// ShopSearchService 商铺搜索：关键字 + 类型过滤 + 距离排序，未启用 Elasticsearch 时退化为 MySQL 查询
+type ShopSearchService struct {
+	db  *gorm.DB
+	es  *data.Elasticsearch
+	log *zap.Logger
+}
+
+// NewShopSearchService 创建 ShopSearchService 实例，es 为 nil 表示未启用
+func NewShopSearchService(db *gorm.DB, es *data.Elasticsearch, log *zap.Logger) *ShopSearchService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	svc := &ShopSearchService{db: db, es: es, log: log}
+	if es != nil {
+		go svc.ensureIndex(context.Background())
+	}
+	return svc
+}
+
+// Index 写入或覆盖商铺文档，未启用 Elasticsearch 时忽略
+func (s *ShopSearchService) Index(ctx context.Context, shop *model.Shop) {
+	if s.es == nil {
+		return
+	}
+	doc := shopDocument{
+		Name:    shop.Name,
+		TypeID:  shop.TypeID,
+		Area:    shop.Area,
+		Address: shop.Address,
+		Sold:    shop.Sold,
+		Score:   shop.Score,
+	}
+	if hasLocation(shop.X, shop.Y) {
+		doc.Location = &geoPoint{Lat: shop.Y, Lon: shop.X}
+	}
+	path := "/" + s.es.ShopIndex() + "/_doc/" + strconv.FormatInt(shop.ID, 10)
+	if err := s.es.Do(ctx, http.MethodPut, path, doc, nil); err != nil {
+		// 索引失败不影响商铺写入，搜索结果最终以数据库为准
+		s.log.Warn("index shop failed", zap.Int64("shopId", shop.ID), zap.Error(err))
+	}
+}
+
+// Remove 删除商铺文档，未启用 Elasticsearch 时忽略
+func (s *ShopSearchService) Remove(ctx context.Context, shopID int64) {
+	if s.es == nil {
+		return
+	}
+	path := "/" + s.es.ShopIndex() + "/_doc/" + strconv.FormatInt(shopID, 10)
+	if err := s.es.Do(ctx, http.MethodDelete, path, nil, nil); err != nil {
+		s.log.Warn("remove shop document failed", zap.Int64("shopId", shopID), zap.Error(err))
+	}
+}
+
+// Search 分页搜索商铺，返回当前页与命中总数；传入坐标时按距离升序并附带距离（米）
+func (s *ShopSearchService) Search(ctx context.Context, q ShopSearchQuery) ([]model.Shop, int64, error) {
+	q.Keyword = strings.TrimSpace(q.Keyword)
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.Size <= 0 {
+		q.Size = utils.MAX_PAGE_SIZE
+	}
+	if s.es != nil {
+		shops, total, err := s.searchES(ctx, q)
+		if err == nil {
+			return shops, total, nil
+		}
+		s.log.Warn("elasticsearch shop search failed, fallback to mysql", zap.Error(err))
+	}
+	return s.searchDB(ctx, q)
+}
+
+func (s *ShopSearchService) searchES(ctx context.Context, q ShopSearchQuery) ([]model.Shop, int64, error) {
+	boolQuery := map[string]interface{}{}
+	if q.Keyword != "" {
+		boolQuery["must"] = map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  q.Keyword,
+				"fields": []string{"name^3", "area", "address"},
+			},
+		}
+	}
+	var filters []interface{}
+	if q.TypeID > 0 {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{"typeId": q.TypeID},
+		})
+	}
+	geo := hasLocation(q.X, q.Y)
+	var sort []interface{}
+	if geo {
+		// 按距离排序时只返回有坐标的商铺
+		filters = append(filters, map[string]interface{}{
+			"exists": map[string]interface{}{"field": "location"},
+		})
+		sort = []interface{}{map[string]interface{}{
+			"_geo_distance": map[string]interface{}{
+				"location": geoPoint{Lat: q.Y, Lon: q.X},
+				"order":    "asc",
+				"unit":     "m",
+			},
+		}}
+	} else {
+		sort = []interface{}{"_score", map[string]interface{}{"sold": "desc"}}
+	}
+	if len(filters) > 0 {
+		boolQuery["filter"] = filters
+	}
+	query := map[string]interface{}{
+		"from":             (q.Page - 1) * q.Size,
+		"size":             q.Size,
+		"query":            map[string]interface{}{"bool": boolQuery},
+		"sort":             sort,
+		"_source":          false,
+		"track_total_hits": true,
+	}
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID   string        `json:"_id"`
+				Sort []interface{} `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := s.es.Do(ctx, http.MethodPost, "/"+s.es.ShopIndex()+"/_search", query, &resp); err != nil {
+		return nil, 0, err
+	}
+	ids := make([]int64, 0, len(resp.Hits.Hits))
+	distances := make(map[int64]float64, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		id, err := strconv.ParseInt(hit.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		if geo && len(hit.Sort) > 0 {
+			if dist, ok := hit.Sort[0].(float64); ok {
+				distances[id] = dist
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return []model.Shop{}, resp.Hits.Total.Value, nil
+	}
+	var shops []model.Shop
+	if err := s.db.WithContext(ctx).Where("id IN ? AND status = ?", ids, model.ShopStatusApproved).Find(&shops).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[int64]model.Shop, len(shops))
+	for _, shop := range shops {
+		byID[shop.ID] = shop
+	}
+	// 按 Elasticsearch 的排序输出，索引中残留但数据库已删除的商铺直接跳过
+	res := make([]model.Shop, 0, len(ids))
+	for _, id := range ids {
+		shop, ok := byID[id]
+		if !ok {
+			continue
+		}
+		if dist, ok := distances[id]; ok {
+			shop.Distance = &dist
+		}
+		res = append(res, shop)
+	}
+	return res, resp.Hits.Total.Value, nil
+}
+
+func (s *ShopSearchService) searchDB(ctx context.Context, q ShopSearchQuery) ([]model.Shop, int64, error) {
+	query := s.db.WithContext(ctx).Model(&model.Shop{}).Where("status = ?", model.ShopStatusApproved)
+	if q.Keyword != "" {
+		pattern := "%" + escapeLike(q.Keyword) + "%"
+		query = query.Where("(name LIKE ? OR area LIKE ? OR address LIKE ?)", pattern, pattern, pattern)
+	}
+	if q.TypeID > 0 {
+		query = query.Where("type_id = ?", q.TypeID)
+	}
+	geo := hasLocation(q.X, q.Y)
+	if geo {
+		query = query.Where("(x <> 0 OR y <> 0)")
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if geo {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "ST_Distance_Sphere(POINT(x, y), POINT(?, ?))",
+			Vars: []interface{}{q.X, q.Y},
+		}})
+	} else {
+		query = query.Order("sold DESC, id ASC")
+	}
+	var shops []model.Shop
+	if err := query.Offset((q.Page - 1) * q.Size).Limit(q.Size).Find(&shops).Error; err != nil {
+		return nil, 0, err
+	}
+	if geo {
+		AttachShopDistance(shops, q.X, q.Y, "m")
+	}
+	return shops, total, nil
+}
+
+// ensureIndex 创建商铺索引及映射，索引已存在时忽略
+func (s *ShopSearchService) ensureIndex(ctx context.Context) {
+	err := s.es.Do(ctx, http.MethodPut, "/"+s.es.ShopIndex(), shopIndexMapping, nil)
+	if err != nil && !strings.Contains(err.Error(), "resource_already_exists_exception") {
+		s.log.Warn("create shop index failed", zap.String("index", s.es.ShopIndex()), zap.Error(err))
+	}
+}
+
+// AttachShopDistance 为有坐标的店铺附上与 (x, y) 的球面距离，unit 为 m、km、mi、ft，空或未知时按米
+func AttachShopDistance(shops []model.Shop, x, y float64, unit string) {
+	meters, ok := geoUnitMeters[unit]
+	if !ok {
+		meters = 1
+	}
+	for i := range shops {
+		if hasLocation(shops[i].X, shops[i].Y) {
+			dist := geoDistance(x, y, shops[i].X, shops[i].Y) / meters
+			shops[i].Distance = &dist
+		}
+	}
+}
+
+// geoDistance 使用 Haversine 公式计算两点间的球面距离（米）
+func geoDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+// TestGeoDistance 校验 Haversine 距离计算与已知坐标间距离的误差
+func TestGeoDistance(t *testing.T) {
+	if d := geoDistance(120.149993, 30.334229, 120.149993, 30.334229); d != 0 {
+		t.Fatalf("same point: want 0, got %f", d)
+	}
+	// 纬度相差 1 度约 111.2 km
+	d := geoDistance(120, 30, 120, 31)
+	if math.Abs(d-111195) > 100 {
+		t.Fatalf("one degree latitude: want ~111195m, got %f", d)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"hash/fnv"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/allegro/bigcache/v3"
@@ -14,18 +16,26 @@ import (
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"hmdp-backend/internal/config"
 	"hmdp-backend/internal/model"
 	"hmdp-backend/internal/utils"
 )
 
-const lockRetryDelay = 50 * time.Millisecond // 拿不到互斥锁时的短暂休眠时间，避免热点击穿
-const shopBloomSize = 1 << 20                // 约 1M 位布隆过滤器
-var shopBloomSeeds = []uint32{17, 29, 37}    // 多哈希种子（相当于多哈希函数）- 每个ID会设置3个bit
+const shopBloomSize = 1 << 20             // 约 1M 位布隆过滤器
+var shopBloomSeeds = []uint32{17, 29, 37} // 多哈希种子（相当于多哈希函数）- 每个ID会设置3个bit
 const defaultLocalShopCacheTTL = 30 * time.Second
 const defaultShopCacheDeleteRetryCount = 3
 const defaultShopCacheDeleteRetryDelay = 20 * time.Millisecond
+const shopGeoReloadBatchSize = 500
+const shopOpenNowScanMax = 500 // 按营业中过滤时最多扫描的商铺数量
+const shopBloomRebuildInterval = 10 * time.Minute
+const defaultShopGeoRadius = 20000    // 附近商铺默认搜索半径（米）
+const defaultShopGeoMaxRadius = 50000 // 附近商铺最大搜索半径（米）
+
+// shopBloomReloadTimeout 布隆过滤器重建标记的最长存活时间，重建异常中断时标记自动过期
+const shopBloomReloadTimeout = 30 * time.Minute
 
 type cacheInvalidateMessage struct {
 	ShopID    int64  `json:"shopId"`
@@ -40,13 +50,30 @@ type ShopService struct {
 	rdb                *redis.Client
 	log                *zap.Logger
 	localCache         *bigcache.BigCache
+	cacheClient        *utils.CacheClient
 	cacheWriter        *kafka.Writer
 	cacheDLQWriter     *kafka.Writer
 	cacheReader        *kafka.Reader
 	cacheDLQReader     *kafka.Reader
 	smtpCfg            utils.SMTPConfig
-	deleteRetryCount   int
-	deleteRetryDelay   time.Duration
+	search             *ShopSearchService
+	cacheCfg           atomic.Pointer[shopCacheSettings] // 缓存策略、Redis TTL 与删除重试参数，支持热更新
+	geoDefaultRadius   float64
+	geoMaxRadius       float64
+}
+
+// 商铺详情缓存策略
+const (
+	ShopCacheStrategyMutex   = "mutex"   // 互斥锁重建，预热过的热点商铺仍走逻辑过期
+	ShopCacheStrategyLogical = "logical" // 全部商铺走逻辑过期，未预热的商铺首次访问时同步加载
+)
+
+// geoUnitMeters GEO 搜索支持的距离单位及其对应的米数
+var geoUnitMeters = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"mi": 1609.344,
+	"ft": 0.3048,
 }
 
 // NewShopService 创建 ShopService 实例
@@ -59,30 +86,41 @@ func NewShopService(
 	cacheDLQReader *kafka.Reader,
 	smtpCfg utils.SMTPConfig,
 	cfg config.ShopCacheConfig,
+	geoCfg config.ShopGeoConfig,
+	search *ShopSearchService,
 	log *zap.Logger,
 ) *ShopService {
-	cache := initShopLocalCache(cfg.LocalTTL, log)
-	retryCount := cfg.DeleteRetryCount
-	if retryCount <= 0 {
-		retryCount = defaultShopCacheDeleteRetryCount
+	var cache *bigcache.BigCache
+	if !cfg.LocalDisabled {
+		cache = initShopLocalCache(cfg.LocalTTL, cfg.LocalMaxSizeMB, log)
+	}
+	geoMaxRadius := geoCfg.MaxRadius
+	if geoMaxRadius <= 0 {
+		geoMaxRadius = defaultShopGeoMaxRadius
 	}
-	retryDelay := cfg.DeleteRetryDelay
-	if retryDelay <= 0 {
-		retryDelay = defaultShopCacheDeleteRetryDelay
+	geoDefaultRadius := geoCfg.DefaultRadius
+	if geoDefaultRadius <= 0 {
+		geoDefaultRadius = defaultShopGeoRadius
+	}
+	if geoDefaultRadius > geoMaxRadius {
+		geoDefaultRadius = geoMaxRadius
 	}
 	svc := &ShopService{
 		db:                 db,
 		rdb:                rdb,
 		log:                log,
 		localCache:         cache,
+		cacheClient:        utils.NewCacheClient(rdb),
 		cacheWriter:        cacheWriter,
 		cacheDLQWriter:     cacheDLQWriter,
 		cacheReader:        cacheReader,
 		cacheDLQReader:     cacheDLQReader,
 		smtpCfg:            smtpCfg,
-		deleteRetryCount:   retryCount,
-		deleteRetryDelay:   retryDelay,
+		search:             search,
+		geoDefaultRadius:   geoDefaultRadius,
+		geoMaxRadius:       geoMaxRadius,
 	}
+	svc.cacheCfg.Store(newShopCacheSettings(cfg))
 	// 启动缓存补偿消费者协程
 	if svc.cacheReader != nil {
 		go svc.consumeCacheInvalidations(context.Background())
@@ -91,6 +129,14 @@ func NewShopService(
 	if svc.cacheDLQReader != nil {
 		go svc.consumeCacheInvalidateDLQ(context.Background())
 	}
+	// 订阅其他实例的本地缓存失效广播
+	if svc.localCache != nil && svc.rdb != nil {
+		go svc.subscribeLocalEvictions(context.Background())
+	}
+	// 布隆过滤器无法删除元素，商铺删除后由后台定期重建
+	if svc.db != nil {
+		go svc.rebuildBloomLoop(context.Background())
+	}
 	return svc
 }
 
@@ -108,59 +154,18 @@ func (s *ShopService) GetByIDWithMutex(ctx context.Context, id int64) (*model.Sh
 		return shop, nil
 	}
 
-	for {
-		// 1.从 Redis 查询商铺缓存 - 缓存命中
-		cached, err := s.rdb.Get(ctx, key).Result()
-		// err为空 说明查询到了缓存
-		if err == nil {
-			var shop model.Shop
-			if unmarshalErr := json.Unmarshal([]byte(cached), &shop); unmarshalErr != nil {
-				return nil, unmarshalErr
-			}
-			// 设置本地缓存
-			s.setLocalShop(key, []byte(cached))
-			return &shop, nil
-		}
-		// 如果err不是redis.Nil，说明是其他错误，直接返回
-		if !errors.Is(err, redis.Nil) {
-			return nil, err
-		}
-
-		// 2.缓存未命中，尝试获取互斥锁；若失败则短暂休眠后重试，避免热点 Key 的缓存击穿
-		locked, lockErr := s.tryLock(ctx, lockKey)
-		if lockErr != nil {
-			return nil, lockErr
-		}
-		// 获取锁失败，继续循环等待
-		if !locked {
-			time.Sleep(lockRetryDelay)
-			continue
-		}
-		// DoubleCheck 拿到锁后再次查询缓存：先查本地，再查 Redis，避免重复查询数据库和写缓存
-		if shop, ok := s.getLocalShop(key); ok {
-			_ = s.unlock(ctx, lockKey)
-			return shop, nil
-		}
-		cached, err = s.rdb.Get(ctx, key).Result()
-		if err == nil {
-			var shop model.Shop
-			if unmarshalErr := json.Unmarshal([]byte(cached), &shop); unmarshalErr != nil {
-				return nil, unmarshalErr
-			}
-			s.setLocalShop(key, []byte(cached))
-			_ = s.unlock(ctx, lockKey)
-			return &shop, nil
-		}
-		if !errors.Is(err, redis.Nil) {
-			_ = s.unlock(ctx, lockKey)
-			return nil, err
-		}
-
-		// 3.成功获取锁且缓存仍未构建，查询数据库并回填缓存，最后释放互斥锁
-		shop, loadErr := s.loadShopAndCache(ctx, id, key)
-		_ = s.unlock(ctx, lockKey)
-		return shop, loadErr
+	// 未命中时只有拿到互斥锁的请求回源数据库，不存在的商铺缓存空值防止穿透
+	shop, err := utils.QueryWithMutex(ctx, s.cacheClient, key, lockKey, s.cacheSettings().redisTTL, func(ctx context.Context) (*model.Shop, error) {
+		return s.loadShop(ctx, id)
+	})
+	if err != nil || shop == nil {
+		return nil, err
+	}
+	// 设置本地缓存
+	if data, err := json.Marshal(shop); err == nil {
+		s.setLocalShop(key, data)
 	}
+	return shop, nil
 }
 
 // GetByIDWithLogicalExpire 根据id查询热点商铺信息
@@ -173,59 +178,9 @@ func (s *ShopService) GetByIDWithLogicalExpire(ctx context.Context, id int64) (*
 	key := utils.CACHE_SHOP_KEY + strconv.FormatInt(id, 10)
 	lockKey := utils.LOCK_SHOP_KEY + strconv.FormatInt(id, 10)
 
-	// 1.从 Redis 查询缓存，未命中则直接返回空
-	cached, err := s.rdb.Get(ctx, key).Result()
-	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return nil, nil
-		}
-		return nil, err
-	}
-	if cached == "" {
-		return nil, nil
-	}
-
-	// 2.反序列化逻辑过期包装数据
-	var redisData utils.RedisData
-	// 先将redis中数据反序列化为redisData
-	if unmarshalErr := json.Unmarshal([]byte(cached), &redisData); unmarshalErr != nil {
-		return nil, unmarshalErr
-	}
-	// redisData.Data 是 interface{}，先二次序列化为 JSON 再反序列化成 Shop
-	dataBytes, marshalErr := json.Marshal(redisData.Data)
-	if marshalErr != nil {
-		return nil, marshalErr
-	}
-	var shop model.Shop
-	// 将通用 data 还原为具体的 Shop 结构
-	if unmarshalErr := json.Unmarshal(dataBytes, &shop); unmarshalErr != nil {
-		return nil, unmarshalErr
-	}
-
-	// 3.未过期，直接返回商铺信息
-	if redisData.ExpireTime.After(time.Now()) {
-		return &shop, nil
-	}
-
-	// 4.已过期：尝试获取互斥锁，获取失败直接返回旧数据
-	locked, lockErr := s.tryLock(ctx, lockKey)
-	if lockErr != nil {
-		return nil, lockErr
-	}
-	// 失败 直接返回redis中的旧数据
-	if !locked {
-		return &shop, nil
-	}
-
-	// 5.获取锁成功：异步重建缓存，避免阻塞当前请求
-	go func() {
-		defer func() {
-			_ = s.unlock(context.Background(), lockKey)
-		}()
-		_ = s.rebuildShopCacheWithLogicalExpire(id, key)
-	}()
-	// 先返回旧数据
-	return &shop, nil
+	return utils.QueryWithLogicalExpire(ctx, s.cacheClient, key, lockKey, s.cacheSettings().redisTTL, func(ctx context.Context) (*model.Shop, error) {
+		return s.loadShop(ctx, id)
+	})
 }
 
 // GetByIDWithBloom 使用布隆过滤器先拦截不存在的 ID，降低缓存穿透风险
@@ -241,76 +196,277 @@ func (s *ShopService) GetByIDWithBloom(ctx context.Context, id int64) (*model.Sh
 		return nil, nil
 	}
 
-	shop, err := s.GetByIDWithMutex(ctx, id)
-	if err != nil {
+	shop, err := s.getByStrategy(ctx, id)
+	if err != nil || shop == nil {
 		return nil, err
 	}
+	shop.Gallery = splitShopImages(shop.Images)
 	return shop, nil
 }
 
-// loadShopAndCache 查询数据库并将结果写入 Redis，配合互斥锁使用
-func (s *ShopService) loadShopAndCache(ctx context.Context, id int64, key string) (*model.Shop, error) {
-	var shop model.Shop
-	err := s.db.WithContext(ctx).First(&shop, id).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.New("shop not found")
+// getByStrategy 按配置的缓存策略查询：logical 策略或热点商铺走逻辑过期，其余走互斥锁
+func (s *ShopService) getByStrategy(ctx context.Context, id int64) (*model.Shop, error) {
+	if s.cacheSettings().strategy != ShopCacheStrategyLogical {
+		hot, err := s.rdb.SIsMember(ctx, utils.SHOP_HOT_KEY, id).Result()
+		if err != nil {
+			return nil, err
+		}
+		if !hot {
+			return s.GetByIDWithMutex(ctx, id)
+		}
 	}
-	if err != nil {
-		return nil, err
+	shop, err := s.GetByIDWithLogicalExpire(ctx, id)
+	if err != nil || shop != nil {
+		return shop, err
 	}
-
-	data, err := json.Marshal(&shop)
-	if err != nil {
+	// 逻辑过期未命中（未预热或已被删除）时兜底：查询数据库并写入逻辑过期缓存
+	shop, err = s.loadShop(ctx, id)
+	if err != nil || shop == nil {
 		return nil, err
 	}
-	// 使用string类型缓存商铺信息 添加上过期时间
-	if err := s.rdb.Set(ctx, key, data, time.Duration(utils.CACHE_SHOP_TTL)*time.Minute).Err(); err != nil {
-		return nil, err
+	key := utils.CACHE_SHOP_KEY + strconv.FormatInt(id, 10)
+	if err := s.saveShopWithLogicalExpire(key, shop, s.cacheSettings().redisTTL); err != nil && s.log != nil {
+		s.log.Warn("save shop logical expire cache failed", zap.Int64("shopId", id), zap.Error(err))
 	}
-	// 设置本地缓存
-	s.setLocalShop(key, data)
-	return &shop, nil
+	return shop, nil
 }
 
-// rebuildShopCacheWithLogicalExpire 查询数据库并写入逻辑过期缓存
-func (s *ShopService) rebuildShopCacheWithLogicalExpire(id int64, key string) error {
+// loadShop 从数据库加载商铺，不存在时返回 nil
+func (s *ShopService) loadShop(ctx context.Context, id int64) (*model.Shop, error) {
 	var shop model.Shop
-	err := s.db.WithContext(context.Background()).First(&shop, id).Error
+	err := s.db.WithContext(ctx).Where("status = ?", model.ShopStatusApproved).First(&shop, id).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil
+		return nil, nil
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return s.saveShopWithLogicalExpire(key, &shop, time.Duration(utils.CACHE_SHOP_TTL)*time.Minute)
+	return &shop, nil
 }
 
 // saveShopWithLogicalExpire 将数据和逻辑过期时间一起写入 Redis
 func (s *ShopService) saveShopWithLogicalExpire(key string, shop *model.Shop, ttl time.Duration) error {
-	redisData := utils.RedisData{
-		ExpireTime: time.Now().Add(ttl),
-		Data:       shop,
+	return s.cacheClient.SetWithLogicalExpire(context.Background(), key, shop, ttl)
+}
+
+func (s *ShopService) Create(ctx context.Context, shop *model.Shop) error {
+	if err := validateSchedule(shop.Schedule); err != nil {
+		return err
 	}
-	data, err := json.Marshal(redisData)
-	if err != nil {
+	shop.Status = model.ShopStatusApproved
+	if err := s.db.WithContext(ctx).Create(shop).Error; err != nil {
 		return err
 	}
-	return s.rdb.Set(context.Background(), key, data, 0).Err()
+	s.publishShop(ctx, shop)
+	return nil
 }
 
-// tryLock 尝试获取锁
-func (s *ShopService) tryLock(ctx context.Context, key string) (bool, error) {
-	// 利用 Redis SETNX 实现简单互斥锁，并设置 TTL 防止死锁
-	return s.rdb.SetNX(ctx, key, "1", time.Duration(utils.LOCK_SHOP_TTL)*time.Second).Result()
+// publishShop 商铺对顾客可见后同步布隆过滤器、GEO 与搜索索引
+func (s *ShopService) publishShop(ctx context.Context, shop *model.Shop) {
+	// 新商铺写入布隆过滤器，否则 GetByIDWithBloom 会将其误判为不存在
+	if err := s.bloomAdd(ctx, utils.SHOP_BLOOM_KEY, shop.ID); err != nil && s.log != nil {
+		s.log.Warn("add shop bloom failed", zap.Int64("shopId", shop.ID), zap.Error(err))
+	}
+	// 布隆过滤器重建中时同时写入临时 key，否则替换后新商铺会再次被误判为不存在
+	if n, err := s.rdb.Exists(ctx, utils.SHOP_BLOOM_RELOADING).Result(); err == nil && n > 0 {
+		if err := s.bloomAdd(ctx, utils.SHOP_BLOOM_TMP_KEY, shop.ID); err != nil {
+			if s.log != nil {
+				s.log.Warn("add shop bloom during reload failed", zap.Int64("shopId", shop.ID), zap.Error(err))
+			}
+			// 写入失败时标记待重建，下一轮重建时补上
+			_ = s.rdb.Set(ctx, utils.SHOP_BLOOM_DIRTY_KEY, 1, 0).Err()
+		}
+	}
+	// 新商铺写入所属类型的 GEO 索引，写入失败可通过 ReloadGeo 全量修复
+	if hasLocation(shop.X, shop.Y) {
+		key := utils.SHOP_GEO_KEY + strconv.FormatInt(shop.TypeID, 10)
+		if err := s.rdb.GeoAdd(ctx, key, &redis.GeoLocation{
+			Name:      strconv.FormatInt(shop.ID, 10),
+			Longitude: shop.X,
+			Latitude:  shop.Y,
+		}).Err(); err != nil && s.log != nil {
+			s.log.Warn("add shop geo failed", zap.Int64("shopId", shop.ID), zap.Error(err))
+		}
+	}
+	if s.search != nil {
+		s.search.Index(ctx, shop)
+	}
 }
 
-// unlock 释放锁
-func (s *ShopService) unlock(ctx context.Context, key string) error {
-	return s.rdb.Del(ctx, key).Err()
+// ReloadGeo 全量重建商铺 GEO 索引：按 type_id 分组分批 GEOADD 到临时 key，完成后原子替换，
+// 并删除已不存在商铺的类型 key；返回写入的商铺数
+func (s *ShopService) ReloadGeo(ctx context.Context) (int, error) {
+	const tmpSuffix = ":reload"
+	written := make(map[string]struct{})
+	total := 0
+	var shops []model.Shop
+	err := s.db.WithContext(ctx).
+		Select("id", "type_id", "x", "y").
+		Where("status = ?", model.ShopStatusApproved).
+		FindInBatches(&shops, shopGeoReloadBatchSize, func(tx *gorm.DB, batch int) error {
+			groups := make(map[string][]*redis.GeoLocation)
+			for _, shop := range shops {
+				if !hasLocation(shop.X, shop.Y) {
+					continue
+				}
+				key := utils.SHOP_GEO_KEY + strconv.FormatInt(shop.TypeID, 10)
+				groups[key] = append(groups[key], &redis.GeoLocation{
+					Name:      strconv.FormatInt(shop.ID, 10),
+					Longitude: shop.X,
+					Latitude:  shop.Y,
+				})
+			}
+			_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for key, locations := range groups {
+					// 首次写入某类型时先清掉上次中断残留的临时 key
+					if _, ok := written[key]; !ok {
+						pipe.Del(ctx, key+tmpSuffix)
+						written[key] = struct{}{}
+					}
+					pipe.GeoAdd(ctx, key+tmpSuffix, locations...)
+					total += len(locations)
+				}
+				return nil
+			})
+			return err
+		}).Error
+	if err != nil {
+		return 0, err
+	}
+	var stale []string
+	iter := s.rdb.Scan(ctx, 0, utils.SHOP_GEO_KEY+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if strings.HasSuffix(key, tmpSuffix) {
+			continue
+		}
+		if _, ok := written[key]; !ok {
+			stale = append(stale, key)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return 0, err
+	}
+	_, err = s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key := range written {
+			pipe.Rename(ctx, key+tmpSuffix, key)
+		}
+		if len(stale) > 0 {
+			pipe.Del(ctx, stale...)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if s.log != nil {
+		s.log.Info("shop geo reloaded", zap.Int("shops", total), zap.Int("types", len(written)))
+	}
+	return total, nil
 }
 
-func (s *ShopService) Create(ctx context.Context, shop *model.Shop) error {
-	return s.db.WithContext(ctx).Create(shop).Error
+// WarmUpHotShops 按销量预热前 limit 个商铺的逻辑过期缓存并标记为热点，返回写入的商铺数；
+// 不再属于热点的商铺删除其逻辑过期缓存，回到互斥锁策略
+func (s *ShopService) WarmUpHotShops(ctx context.Context, limit int) (int, error) {
+	if limit <= 0 {
+		return 0, nil
+	}
+	var shops []model.Shop
+	if err := s.db.WithContext(ctx).
+		Where("status = ?", model.ShopStatusApproved).
+		Order("sold DESC, comments DESC, id ASC").
+		Limit(limit).
+		Find(&shops).Error; err != nil {
+		return 0, err
+	}
+	ttl := s.cacheSettings().redisTTL
+	for i := range shops {
+		key := utils.CACHE_SHOP_KEY + strconv.FormatInt(shops[i].ID, 10)
+		if err := s.saveShopWithLogicalExpire(key, &shops[i], ttl); err != nil {
+			return i, err
+		}
+	}
+	if err := s.replaceHotShops(ctx, shops); err != nil {
+		return len(shops), err
+	}
+	return len(shops), nil
+}
+
+// replaceHotShops 用本次预热的商铺原子替换热点集合，并删除落选商铺的缓存
+func (s *ShopService) replaceHotShops(ctx context.Context, shops []model.Shop) error {
+	tmpKey := utils.SHOP_HOT_KEY + ":reload"
+	members := make([]interface{}, 0, len(shops))
+	for _, shop := range shops {
+		members = append(members, shop.ID)
+	}
+	if err := s.rdb.Del(ctx, tmpKey).Err(); err != nil {
+		return err
+	}
+	if len(members) > 0 {
+		if err := s.rdb.SAdd(ctx, tmpKey, members...).Err(); err != nil {
+			return err
+		}
+	}
+	dropped, err := s.rdb.SDiff(ctx, utils.SHOP_HOT_KEY, tmpKey).Result()
+	if err != nil {
+		return err
+	}
+	if len(members) > 0 {
+		err = s.rdb.Rename(ctx, tmpKey, utils.SHOP_HOT_KEY).Err()
+	} else {
+		err = s.rdb.Del(ctx, utils.SHOP_HOT_KEY).Err()
+	}
+	if err != nil {
+		return err
+	}
+	for _, member := range dropped {
+		if id, err := strconv.ParseInt(member, 10, 64); err == nil {
+			s.InvalidateCache(ctx, id)
+		}
+	}
+	return nil
+}
+
+// ReloadBloom 从数据库全量重建布隆过滤器：写入临时 key 后原子替换，已删除商铺的位随之清除；返回写入的商铺数
+func (s *ShopService) ReloadBloom(ctx context.Context) (int, error) {
+	tmpKey := utils.SHOP_BLOOM_TMP_KEY
+	if err := s.rdb.Del(ctx, tmpKey).Err(); err != nil {
+		return 0, err
+	}
+	// 标记重建中：期间新发布的商铺同时写入临时 key，替换后不会丢失
+	if err := s.rdb.Set(ctx, utils.SHOP_BLOOM_RELOADING, 1, shopBloomReloadTimeout).Err(); err != nil {
+		return 0, err
+	}
+	defer s.rdb.Del(context.WithoutCancel(ctx), utils.SHOP_BLOOM_RELOADING)
+	total := 0
+	var shops []model.Shop
+	err := s.db.WithContext(ctx).
+		Select("id").
+		Where("status = ?", model.ShopStatusApproved).
+		FindInBatches(&shops, shopGeoReloadBatchSize, func(tx *gorm.DB, batch int) error {
+			_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				for _, shop := range shops {
+					for _, off := range bloomOffsets(shop.ID) {
+						pipe.SetBit(ctx, tmpKey, int64(off), 1)
+					}
+				}
+				return nil
+			})
+			total += len(shops)
+			return err
+		}).Error
+	if err != nil {
+		return 0, err
+	}
+	// 数据库中没有商铺且重建期间也没有新商铺写入时，临时 key 不存在
+	exists, err := s.rdb.Exists(ctx, tmpKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return total, s.rdb.Del(ctx, utils.SHOP_BLOOM_KEY).Err()
+	}
+	return total, s.rdb.Rename(ctx, tmpKey, utils.SHOP_BLOOM_KEY).Err()
 }
 
 // Update 更新商铺信息
@@ -318,10 +474,26 @@ func (s *ShopService) Update(ctx context.Context, shop *model.Shop) error {
 	if shop == nil || shop.ID == 0 {
 		return errors.New("invalid shop id")
 	}
+	if err := validateSchedule(shop.Schedule); err != nil {
+		return err
+	}
+	// 审核状态与归属只能通过审核流程变更
+	shop.Status, shop.OwnerID, shop.ReviewNote = 0, 0, ""
 	key := utils.CACHE_SHOP_KEY + strconv.FormatInt(shop.ID, 10)
 	// 通过事务保证先更新数据库再删除缓存，出现错误时整体回滚
 	// 更新操作 先更新数据库 删除redis缓存 保证redis和数据库数据一致性
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	var old model.Shop
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁定并记录更新前的类型与坐标，用于同步 GEO 索引
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "type_id", "x", "y").
+			First(&old, shop.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("shop not found")
+		}
+		if err != nil {
+			return err
+		}
 		// 使用 Updates 忽略零值字段，避免覆盖 create_time 等只读列
 		if err := tx.Model(&model.Shop{ID: shop.ID}).Updates(shop).Error; err != nil {
 			return err
@@ -334,22 +506,175 @@ func (s *ShopService) Update(ctx context.Context, shop *model.Shop) error {
 			// 发布缓存失效消息
 			_ = s.publishCacheInvalidate(ctx, shop.ID, key, err)
 		}
-		s.deleteLocalShop(key)
+		s.evictLocalShop(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// 请求体只包含变更字段，回表取完整数据后再同步 GEO 与搜索索引
+	var latest model.Shop
+	if err := s.db.WithContext(ctx).First(&latest, shop.ID).Error; err != nil || latest.Status != model.ShopStatusApproved {
+		return nil
+	}
+	s.syncShopGeo(ctx, &old, &latest)
+	if s.search != nil {
+		s.search.Index(ctx, &latest)
+	}
+	return nil
+}
+
+// syncShopGeo 商铺类型或坐标变化后，在同一个 MULTI 中从旧类型 key 移除并写入新类型 key；
+// 失败时仅记录日志，可通过 ReloadGeo 全量修复
+func (s *ShopService) syncShopGeo(ctx context.Context, old, latest *model.Shop) {
+	if old.TypeID == latest.TypeID && old.X == latest.X && old.Y == latest.Y {
+		return
+	}
+	member := strconv.FormatInt(latest.ID, 10)
+	oldKey := utils.SHOP_GEO_KEY + strconv.FormatInt(old.TypeID, 10)
+	newKey := utils.SHOP_GEO_KEY + strconv.FormatInt(latest.TypeID, 10)
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if oldKey != newKey || !hasLocation(latest.X, latest.Y) {
+			pipe.ZRem(ctx, oldKey, member)
+		}
+		if hasLocation(latest.X, latest.Y) {
+			pipe.GeoAdd(ctx, newKey, &redis.GeoLocation{
+				Name:      member,
+				Longitude: latest.X,
+				Latitude:  latest.Y,
+			})
+		}
 		return nil
 	})
+	if err != nil && s.log != nil {
+		s.log.Warn("sync shop geo failed", zap.Int64("shopId", latest.ID), zap.Error(err))
+	}
+}
+
+// InvalidateCache 删除商铺的 Redis 与本地缓存，Redis 删除失败时走补偿通道
+func (s *ShopService) InvalidateCache(ctx context.Context, id int64) {
+	key := utils.CACHE_SHOP_KEY + strconv.FormatInt(id, 10)
+	if err := s.deleteShopCacheWithRetry(ctx, key); err != nil {
+		if s.log != nil {
+			s.log.Warn("shop cache delete failed, enqueue compensate", zap.Int64("shopId", id), zap.Error(err))
+		}
+		_ = s.publishCacheInvalidate(ctx, id, key, err)
+	}
+	s.evictLocalShop(ctx, key)
 }
 
-func (s *ShopService) QueryByType(ctx context.Context, typeID int64, page, size int) ([]model.Shop, error) {
+// Delete 删除商铺，同时清理缓存、GEO 索引与搜索索引
+func (s *ShopService) Delete(ctx context.Context, id int64) error {
+	var shop model.Shop
+	err := s.db.WithContext(ctx).Select("id", "type_id").First(&shop, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("shop not found")
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Delete(&model.Shop{}, id).Error; err != nil {
+		return err
+	}
+	s.InvalidateCache(ctx, id)
+	_ = s.rdb.SRem(ctx, utils.SHOP_HOT_KEY, id).Err()
+	geoKey := utils.SHOP_GEO_KEY + strconv.FormatInt(shop.TypeID, 10)
+	if err := s.rdb.ZRem(ctx, geoKey, strconv.FormatInt(id, 10)).Err(); err != nil && s.log != nil {
+		s.log.Warn("remove shop geo failed", zap.Int64("shopId", id), zap.Error(err))
+	}
+	if s.search != nil {
+		s.search.Remove(ctx, id)
+	}
+	// 布隆过滤器中仍残留该 ID，标记待重建
+	if err := s.rdb.Set(ctx, utils.SHOP_BLOOM_DIRTY_KEY, 1, 0).Err(); err != nil && s.log != nil {
+		s.log.Warn("mark shop bloom dirty failed", zap.Int64("shopId", id), zap.Error(err))
+	}
+	return nil
+}
+
+// rebuildBloomLoop 定期检查重建标记，存在时从数据库全量重建布隆过滤器
+func (s *ShopService) rebuildBloomLoop(ctx context.Context) {
+	ticker := time.NewTicker(shopBloomRebuildInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		// 先取走标记再重建：重建期间的新删除会重新打标，留到下一轮处理
+		dirty, err := s.rdb.GetDel(ctx, utils.SHOP_BLOOM_DIRTY_KEY).Result()
+		if errors.Is(err, redis.Nil) || dirty == "" {
+			continue
+		}
+		if err != nil {
+			if s.log != nil {
+				s.log.Warn("check shop bloom dirty failed", zap.Error(err))
+			}
+			continue
+		}
+		if _, err := s.ReloadBloom(ctx); err != nil {
+			if s.log != nil {
+				s.log.Warn("rebuild shop bloom failed", zap.Error(err))
+			}
+			_ = s.rdb.Set(ctx, utils.SHOP_BLOOM_DIRTY_KEY, 1, 0).Err()
+		}
+	}
+}
+
+// 店铺列表排序方式
+const (
+	ShopSortScore    = "score"
+	ShopSortComments = "comments"
+	ShopSortAvgPrice = "avgPrice"
+	ShopSortDistance = "distance"
+)
+
+var errShopSortBy = errors.New("sortBy 仅支持 score、comments、avgPrice、distance")
+
+// shopSortOrders 各排序方式对应的 ORDER BY，均以 id 兜底保证分页稳定；
+// 对应 tb_shop 上的 (type_id, score)、(type_id, comments)、(type_id, avg_price) 联合索引
+var shopSortOrders = map[string]string{
+	"":               "id ASC",
+	ShopSortScore:    "score DESC, id ASC",
+	ShopSortComments: "comments DESC, id ASC",
+	ShopSortAvgPrice: "avg_price ASC, id ASC",
+}
+
+// ValidateShopSortBy 校验排序参数，空串表示默认按 ID 排序
+func ValidateShopSortBy(sortBy string) error {
+	if _, ok := shopSortOrders[sortBy]; ok || sortBy == ShopSortDistance {
+		return nil
+	}
+	return errShopSortBy
+}
+
+// QueryByType 根据类型分页查询店铺，sortBy 指定排序方式（按距离排序请使用 QueryByTypeWithLocation），
+// openNow 为 true 时只返回当前营业中的店铺
+func (s *ShopService) QueryByType(ctx context.Context, typeID int64, page, size int, sortBy string, openNow bool) ([]model.Shop, error) {
+	order, ok := shopSortOrders[sortBy]
+	if !ok {
+		if sortBy == ShopSortDistance {
+			return nil, errors.New("按距离排序需要提供坐标")
+		}
+		return nil, errShopSortBy
+	}
 	var shops []model.Shop
 	offset := (page - 1) * size
 	if offset < 0 {
 		offset = 0
 	}
-	err := s.db.WithContext(ctx).
-		Where("type_id = ?", typeID).
+	query := s.db.WithContext(ctx).Where("type_id = ? AND status = ?", typeID, model.ShopStatusApproved).Order(order)
+	if openNow {
+		// 营业时间需在内存中判断，按排序取出一批候选后再过滤分页
+		if err := query.Limit(shopOpenNowScanMax).Find(&shops).Error; err != nil {
+			return nil, err
+		}
+		return pageOpenShops(shops, time.Now(), offset, size), nil
+	}
+	err := query.
 		Offset(offset).
 		Limit(size).
-		Order("id ASC").
 		Find(&shops).Error
 	return shops, err
 }
@@ -360,7 +685,7 @@ func (s *ShopService) QueryByName(ctx context.Context, name string, page, size i
 	if offset < 0 {
 		offset = 0
 	}
-	query := s.db.WithContext(ctx)
+	query := s.db.WithContext(ctx).Where("status = ?", model.ShopStatusApproved)
 	if name != "" {
 		query = query.Where("name LIKE ?", "%%"+name+"%%")
 	}
@@ -415,7 +740,7 @@ func bloomOffsets(id int64) []uint32 {
 	return res
 }
 // initShopLocalCache 初始化本地缓存
-func initShopLocalCache(ttl time.Duration, log *zap.Logger) *bigcache.BigCache {
+func initShopLocalCache(ttl time.Duration, maxSizeMB int, log *zap.Logger) *bigcache.BigCache {
 	// 设置本地缓存的默认 TTL，并使用清理窗口控制过期扫描频率
 	if ttl <= 0 {
 		ttl = defaultLocalShopCacheTTL
@@ -425,6 +750,10 @@ func initShopLocalCache(ttl time.Duration, log *zap.Logger) *bigcache.BigCache {
 		// 清理窗口设为 TTL 的一半，降低过期键清理的抖动
 		config.CleanWindow = ttl / 2
 	}
+	// 达到容量上限后覆盖最旧的条目，防止热点过多时占满内存
+	if maxSizeMB > 0 {
+		config.HardMaxCacheSize = maxSizeMB
+	}
 	cache, err := bigcache.New(context.Background(), config)
 	if err != nil && log != nil {
 		log.Warn("init shop local cache failed", zap.Error(err))
@@ -469,14 +798,37 @@ func (s *ShopService) deleteLocalShop(key string) {
 	}
 }
 
+// evictLocalShop 删除本实例的本地缓存，并通过 Redis Pub/Sub 通知其他实例删除
+func (s *ShopService) evictLocalShop(ctx context.Context, key string) {
+	s.deleteLocalShop(key)
+	if s.localCache == nil {
+		return
+	}
+	if err := s.rdb.Publish(ctx, utils.SHOP_LOCAL_EVICT_CH, key).Err(); err != nil && s.log != nil {
+		// 广播失败时其他实例的本地缓存最多在 TTL 内读到旧值
+		s.log.Warn("publish shop local evict failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// subscribeLocalEvictions 订阅本地缓存失效广播，收到的 key 直接从本地缓存删除
+func (s *ShopService) subscribeLocalEvictions(ctx context.Context) {
+	sub := s.rdb.Subscribe(ctx, utils.SHOP_LOCAL_EVICT_CH)
+	defer sub.Close()
+	// Channel 在连接断开后会自动重连并重新订阅
+	for msg := range sub.Channel() {
+		s.deleteLocalShop(msg.Payload)
+	}
+}
+
 // deleteShopCacheWithRetry 删除 Redis 缓存，失败时短暂重试
 func (s *ShopService) deleteShopCacheWithRetry(ctx context.Context, key string) error {
 	var err error
-	for i := 0; i < s.deleteRetryCount; i++ {
+	st := s.cacheSettings()
+	for i := 0; i < st.deleteRetryCount; i++ {
 		if err = s.rdb.Del(ctx, key).Err(); err == nil {
 			return nil
 		}
-		time.Sleep(s.deleteRetryDelay * time.Duration(i+1))
+		time.Sleep(st.deleteRetryDelay * time.Duration(i+1))
 	}
 	return err
 }
@@ -486,7 +838,7 @@ func (s *ShopService) deleteShopCacheOnce(ctx context.Context, key string) error
 	if err := s.rdb.Del(ctx, key).Err(); err != nil {
 		return err
 	}
-	s.deleteLocalShop(key)
+	s.evictLocalShop(ctx, key)
 	return nil
 }
 
@@ -620,28 +972,40 @@ func (s *ShopService) consumeCacheInvalidateDLQ(ctx context.Context) {
 
 // QueryByTypeWithLocation 根据类型 + 坐标查询店铺，按距离排序
 // x、y 为用户经纬度，page/size 用于分页，优先使用 Redis GEO，缺少坐标时可退回 QueryByType。
-func (s *ShopService) QueryByTypeWithLocation(ctx context.Context, typeID int64, page, size int, x, y float64) ([]model.Shop, error) {
+// radius/unit 为搜索半径及单位（m、km、mi、ft），返回的距离使用同一单位；半径为 0 时使用配置的默认值，超出上限时按上限搜索。
+// openNow 为 true 时先取出附近最多 shopOpenNowScanMax 个店铺，过滤出营业中的再分页。
+func (s *ShopService) QueryByTypeWithLocation(ctx context.Context, typeID int64, page, size int, x, y, radius float64, unit string, openNow bool) ([]model.Shop, error) {
 	if page <= 0 {
 		page = 1
 	}
 	if size <= 0 {
 		size = utils.DEFAULT_PAGE_SIZE
 	}
+	radius, unit, err := s.geoRadius(radius, unit)
+	if err != nil {
+		return nil, err
+	}
 	// page=1时 start=0 end=5  0~4
 	// page=2时 start=5 end=10 5~9
 	start := (page - 1) * size
 	end := page * size
 	key := utils.SHOP_GEO_KEY + strconv.FormatInt(typeID, 10)
+	count := end
+	if openNow {
+		// 营业状态无法在 GEO 中过滤，多取一批候选，回表后再过滤分页
+		count = shopOpenNowScanMax
+		start, end = 0, shopOpenNowScanMax
+	}
 
 	// 直接使用 GEOSEARCH，COUNT 使用 end
 	query := &redis.GeoSearchLocationQuery{
 		GeoSearchQuery: redis.GeoSearchQuery{
 			Longitude:  x,
 			Latitude:   y,
-			Radius:     20000,
-			RadiusUnit: "m",
+			Radius:     radius,
+			RadiusUnit: unit,
 			Sort:       "ASC", // 距离升序
-			Count:      end,   // 取到当前页末尾
+			Count:      count, // 取到当前页末尾
 		},
 		WithDist:  true, // 需要距离信息
 		WithCoord: true, // 返回坐标
@@ -665,34 +1029,74 @@ func (s *ShopService) QueryByTypeWithLocation(ctx context.Context, typeID int64,
 	}
 	locs = locs[start:]
 
-	// 取出 shopIds，按顺序回表查询并带回距离
+	// 取出 shopIds 与距离，按 GEO 结果的顺序回表查询
 	ids := make([]int64, 0, len(locs))
+	dists := make(map[int64]float64, len(locs))
 	for _, loc := range locs {
 		id, parseErr := strconv.ParseInt(loc.Name, 10, 64)
 		if parseErr != nil {
 			return nil, parseErr
 		}
 		ids = append(ids, id)
+		dists[id] = loc.Dist
 	}
 
-	var shops []model.Shop
-	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&shops).Error; err != nil {
+	var res []model.Shop
+	if err := s.db.WithContext(ctx).Where("id IN ? AND status = ?", ids, model.ShopStatusApproved).Order(orderByIDs(ids)).Find(&res).Error; err != nil {
 		return nil, err
 	}
-	shopMap := make(map[int64]model.Shop, len(shops))
-	for _, shop := range shops {
-		shopMap[shop.ID] = shop
+	// 附上距离
+	for i := range res {
+		dist := dists[res[i].ID]
+		res[i].Distance = &dist
 	}
+	if openNow {
+		return pageOpenShops(res, time.Now(), (page-1)*size, size), nil
+	}
+	return res, nil
+}
 
-	// 按 GEO 结果的顺序输出，并附上距离
-	res := make([]model.Shop, 0, len(ids))
-	for _, loc := range locs {
-		id, _ := strconv.ParseInt(loc.Name, 10, 64)
-		if shop, ok := shopMap[id]; ok {
-			dist := loc.Dist
-			shop.Distance = &dist
-			res = append(res, shop)
+// ValidateGeoUnit 校验距离单位，空串表示米
+func ValidateGeoUnit(unit string) error {
+	if _, ok := geoUnitMeters[unit]; ok || unit == "" {
+		return nil
+	}
+	return errors.New("unit 仅支持 m、km、mi、ft")
+}
+
+// geoRadius 校验距离单位，并将半径约束在 (0, 最大半径] 内
+func (s *ShopService) geoRadius(radius float64, unit string) (float64, string, error) {
+	if unit == "" {
+		unit = "m"
+	}
+	if err := ValidateGeoUnit(unit); err != nil {
+		return 0, "", err
+	}
+	meters := geoUnitMeters[unit]
+	if radius <= 0 {
+		radius = s.geoDefaultRadius / meters
+	}
+	if maxRadius := s.geoMaxRadius / meters; radius > maxRadius {
+		radius = maxRadius
+	}
+	return radius, unit, nil
+}
+
+// pageOpenShops 按原有顺序过滤出营业中的店铺，并截取 [offset, offset+size) 一页
+func pageOpenShops(shops []model.Shop, now time.Time, offset, size int) []model.Shop {
+	res := make([]model.Shop, 0, size)
+	for i := range shops {
+		if !isShopOpen(&shops[i], now) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		res = append(res, shops[i])
+		if len(res) == size {
+			break
 		}
 	}
-	return res, nil
+	return res
 }
@@ -2,8 +2,8 @@ package service
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
+	"time"
+
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
@@ -11,43 +11,47 @@ import (
 	"hmdp-backend/internal/utils"
 )
 
+// shopTypeCacheTTL 商铺类型列表缓存时长，写入时叠加随机抖动
+const shopTypeCacheTTL = time.Duration(utils.CACHE_SHOP_TYPE_TTL) * time.Minute
+
 type ShopTypeService struct {
-	db  *gorm.DB
-	rdb *redis.Client
+	db    *gorm.DB
+	rdb   *redis.Client
+	cache *utils.CacheClient
 }
 
 func NewShopTypeService(db *gorm.DB, rdb *redis.Client) *ShopTypeService {
-	return &ShopTypeService{db: db, rdb: rdb}
+	return &ShopTypeService{db: db, rdb: rdb, cache: utils.NewCacheClient(rdb)}
 }
 
 func (s *ShopTypeService) List(ctx context.Context) ([]model.ShopType, error) {
-	key := utils.CACHE_SHOP_TYPE_KEY
-	cached, err := s.rdb.Get(ctx, key).Result()
-	if err == nil {
-		var types []model.ShopType
-		if unmarshalErr := json.Unmarshal([]byte(cached), &types); unmarshalErr != nil {
-			return nil, unmarshalErr
-		}
-		return types, nil
-	}
-	if !errors.Is(err, redis.Nil) {
+	types, err := utils.QueryWithPassThrough(ctx, s.cache, utils.CACHE_SHOP_TYPE_KEY, shopTypeCacheTTL, s.load)
+	if err != nil || types == nil {
 		return nil, err
 	}
+	return *types, nil
+}
 
-	var types []model.ShopType
-	err = s.db.WithContext(ctx).
-		Order("sort ASC").
-		Find(&types).Error
+// Refresh 从数据库加载商铺类型列表并覆盖缓存
+func (s *ShopTypeService) Refresh(ctx context.Context) ([]model.ShopType, error) {
+	types, err := s.load(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.cache.Set(ctx, utils.CACHE_SHOP_TYPE_KEY, types, shopTypeCacheTTL); err != nil {
+		return nil, err
+	}
+	return *types, nil
+}
 
-	data, err := json.Marshal(types)
+// load 从数据库按排序加载商铺类型列表
+func (s *ShopTypeService) load(ctx context.Context) (*[]model.ShopType, error) {
+	var types []model.ShopType
+	err := s.db.WithContext(ctx).
+		Order("sort ASC").
+		Find(&types).Error
 	if err != nil {
 		return nil, err
 	}
-	if cacheErr := s.rdb.Set(ctx, key, data, 0).Err(); cacheErr != nil {
-		return nil, cacheErr
-	}
-	return types, nil
+	return &types, nil
 }
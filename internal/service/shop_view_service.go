@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	// shopViewAggInterval 浏览数据从 Redis 汇总到 MySQL 的周期
+	shopViewAggInterval = 10 * time.Minute
+	// shopViewTrendMaxDays 趋势查询允许的最大天数
+	shopViewTrendMaxDays = 90
+	shopViewDayLayout    = "2006-01-02"
+)
+
+// ShopViewService 统计商铺详情页浏览：Redis 中按天累计 PV（Hash）与 UV（HyperLogLog），
+// 后台定期汇总到 tb_shop_daily_view
+type ShopViewService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+	log *zap.Logger
+}
+
+// NewShopViewService 创建 ShopViewService 实例并启动汇总任务
+func NewShopViewService(db *gorm.DB, rdb *redis.Client, log *zap.Logger) *ShopViewService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	svc := &ShopViewService{db: db, rdb: rdb, log: log}
+	go svc.aggregateLoop(context.Background())
+	return svc
+}
+
+// Record 记录一次详情页浏览，visitor 为访客标识（登录用户ID或客户端IP），用于计算 UV
+func (s *ShopViewService) Record(ctx context.Context, shopID int64, visitor string) error {
+	day := time.Now().Format(shopViewDayLayout)
+	pvKey := utils.SHOP_PV_KEY + day
+	uvKey := utils.SHOP_UV_KEY + day + ":" + strconv.FormatInt(shopID, 10)
+	ttl := time.Duration(utils.SHOP_VIEW_TTL) * time.Minute
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, pvKey, strconv.FormatInt(shopID, 10), 1)
+		pipe.Expire(ctx, pvKey, ttl)
+		pipe.PFAdd(ctx, uvKey, visitor)
+		pipe.Expire(ctx, uvKey, ttl)
+		return nil
+	})
+	return err
+}
+
+// Trend 查询商铺最近 days 天（含今天）的每日 PV/UV，没有浏览的日期补 0；今天的数据直接读取 Redis
+func (s *ShopViewService) Trend(ctx context.Context, shopID int64, days int) ([]model.ShopDailyView, error) {
+	if days <= 0 || days > shopViewTrendMaxDays {
+		return nil, errors.New("days 需在 1~90 之间")
+	}
+	now := time.Now()
+	start := now.AddDate(0, 0, -(days - 1)).Format(shopViewDayLayout)
+	var rows []model.ShopDailyView
+	if err := s.db.WithContext(ctx).
+		Where("shop_id = ? AND day >= ?", shopID, start).
+		Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]model.ShopDailyView, len(rows))
+	for _, row := range rows {
+		// DATE 列可能以带时间的格式返回，只保留日期部分
+		if len(row.Day) > len(shopViewDayLayout) {
+			row.Day = row.Day[:len(shopViewDayLayout)]
+		}
+		byDay[row.Day] = row
+	}
+	today := now.Format(shopViewDayLayout)
+	live, err := s.liveStats(ctx, shopID, today)
+	if err != nil {
+		return nil, err
+	}
+	byDay[today] = live
+	res := make([]model.ShopDailyView, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		day := now.AddDate(0, 0, -i).Format(shopViewDayLayout)
+		row, ok := byDay[day]
+		if !ok {
+			row = model.ShopDailyView{ShopID: shopID, Day: day}
+		}
+		res = append(res, row)
+	}
+	return res, nil
+}
+
+// liveStats 读取 Redis 中某天的实时 PV/UV
+func (s *ShopViewService) liveStats(ctx context.Context, shopID int64, day string) (model.ShopDailyView, error) {
+	row := model.ShopDailyView{ShopID: shopID, Day: day}
+	pv, err := s.rdb.HGet(ctx, utils.SHOP_PV_KEY+day, strconv.FormatInt(shopID, 10)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return row, err
+	}
+	uv, err := s.rdb.PFCount(ctx, utils.SHOP_UV_KEY+day+":"+strconv.FormatInt(shopID, 10)).Result()
+	if err != nil {
+		return row, err
+	}
+	row.PV, row.UV = pv, uv
+	return row, nil
+}
+
+// aggregateLoop 定期汇总昨天与今天的浏览数据；写入为覆盖式，重复执行结果一致
+func (s *ShopViewService) aggregateLoop(ctx context.Context) {
+	ticker := time.NewTicker(shopViewAggInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		// 多实例部署时只需一个实例执行
+		locked, err := s.rdb.SetNX(ctx, utils.SHOP_VIEW_AGG_LOCK, 1, shopViewAggInterval/2).Result()
+		if err != nil || !locked {
+			continue
+		}
+		now := time.Now()
+		for _, day := range []string{now.AddDate(0, 0, -1).Format(shopViewDayLayout), now.Format(shopViewDayLayout)} {
+			if err := s.Aggregate(ctx, day); err != nil {
+				s.log.Warn("aggregate shop views failed", zap.String("day", day), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Aggregate 将某天 Redis 中的 PV/UV 写入 tb_shop_daily_view
+func (s *ShopViewService) Aggregate(ctx context.Context, day string) error {
+	pvs, err := s.rdb.HGetAll(ctx, utils.SHOP_PV_KEY+day).Result()
+	if err != nil {
+		return err
+	}
+	if len(pvs) == 0 {
+		return nil
+	}
+	rows := make([]model.ShopDailyView, 0, len(pvs))
+	for field, raw := range pvs {
+		shopID, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		pv, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			continue
+		}
+		uv, err := s.rdb.PFCount(ctx, utils.SHOP_UV_KEY+day+":"+field).Result()
+		if err != nil {
+			return err
+		}
+		rows = append(rows, model.ShopDailyView{ShopID: shopID, Day: day, PV: pv, UV: uv})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "shop_id"}, {Name: "day"}},
+		DoUpdates: clause.AssignmentColumns([]string{"pv", "uv", "update_time"}),
+	}).CreateInBatches(rows, 500).Error
+}
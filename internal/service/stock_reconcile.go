@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+	"hmdp-backend/pkg/lock"
+)
+
+const defaultReconcileInterval = 5 * time.Minute
+
+// StockDrift 单张秒杀券的库存对账结果；Reserved 与 ActiveOrders 之差为已通过 Lua 扣减、尚未落库的订单
+type StockDrift struct {
+	VoucherID    int64     `json:"voucherId"`
+	RedisStock   int64     `json:"redisStock"`   // Redis 中的剩余库存
+	DBStock      int64     `json:"dbStock"`      // 数据库中的剩余库存（已扣除落库的订单）
+	Reserved     int64     `json:"reserved"`     // 限购集合中的用户数
+	ActiveOrders int64     `json:"activeOrders"` // 数据库中未取消、未退款的订单数
+	Expected     int64     `json:"expected"`     // 按数据库推算的 Redis 库存
+	Drift        int64     `json:"drift"`        // RedisStock - Expected
+	Fixed        bool      `json:"fixed"`        // 本次对账是否已自动修正
+	CheckTime    time.Time `json:"checkTime"`
+}
+
+// reconcileLoop 定期对账秒杀库存；多实例部署时通过分布式锁只由一个实例执行
+func (s *VoucherOrderService) reconcileLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.reconcile)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		mu := lock.New(s.rdb, utils.STOCK_RECONCILE_LOCK, s.reconcile, lock.WithWatchdog())
+		locked, err := mu.TryLock(ctx)
+		if err != nil || !locked {
+			continue
+		}
+		drifts, err := s.ReconcileStock(ctx)
+		if err != nil {
+			s.log.Warn("reconcile seckill stock failed", zap.Error(err))
+		}
+		for _, d := range drifts {
+			s.log.Warn("seckill stock drift",
+				zap.Int64("voucherId", d.VoucherID),
+				zap.Int64("redisStock", d.RedisStock),
+				zap.Int64("expected", d.Expected),
+				zap.Int64("drift", d.Drift),
+				zap.Bool("fixed", d.Fixed),
+			)
+		}
+		_ = mu.Unlock(ctx)
+	}
+}
+
+// ReconcileStock 比对未结束秒杀券的 Redis 库存与数据库推算值，返回存在偏差的券并保存为最新对账结果。
+// 未落库的订单会造成短暂偏差，开启 AutoFix 时只修正与上次对账结果一致的偏差，
+// 修正使用 INCRBY 而非 SET，不覆盖对账期间 Lua 脚本的扣减
+func (s *VoucherOrderService) ReconcileStock(ctx context.Context) ([]StockDrift, error) {
+	var secs []model.SeckillVoucher
+	if err := s.db.WithContext(ctx).Where("end_time > ?", time.Now()).Find(&secs).Error; err != nil {
+		return nil, err
+	}
+	previous, err := s.stockDriftMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	drifts := make([]StockDrift, 0)
+	for _, sec := range secs {
+		stockKey := fmt.Sprintf(stockKeyFmt, sec.VoucherID)
+		redisStock, err := s.rdb.Get(ctx, stockKey).Int64()
+		if errors.Is(err, redis.Nil) {
+			// 未预热的券不参与对账
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		reserved, err := s.rdb.SCard(ctx, fmt.Sprintf(orderSetFmt, sec.VoucherID)).Result()
+		if err != nil {
+			return nil, err
+		}
+		var active int64
+		if err := s.db.WithContext(ctx).Model(&model.VoucherOrder{}).
+			Where("voucher_id = ? AND status NOT IN ?", sec.VoucherID, []int{model.OrderStatusCancelled, model.OrderStatusRefunded}).
+			Count(&active).Error; err != nil {
+			return nil, err
+		}
+		pending := reserved - active
+		if pending < 0 {
+			pending = 0
+		}
+		d := StockDrift{
+			VoucherID:    sec.VoucherID,
+			RedisStock:   redisStock,
+			DBStock:      int64(sec.Stock),
+			Reserved:     reserved,
+			ActiveOrders: active,
+			Expected:     int64(sec.Stock) - pending,
+			CheckTime:    time.Now(),
+		}
+		d.Drift = d.RedisStock - d.Expected
+		if d.Drift == 0 {
+			continue
+		}
+		if prev, ok := previous[sec.VoucherID]; ok && s.autoFix.Load() && !prev.Fixed && prev.Drift == d.Drift {
+			if err := s.rdb.IncrBy(ctx, stockKey, -d.Drift).Err(); err != nil {
+				return nil, err
+			}
+			d.Fixed = true
+		}
+		drifts = append(drifts, d)
+	}
+	if err := s.saveStockDrifts(ctx, drifts); err != nil {
+		return drifts, err
+	}
+	return drifts, nil
+}
+
+// StockDrifts 返回最近一次对账发现的库存偏差，按券ID排序
+func (s *VoucherOrderService) StockDrifts(ctx context.Context) ([]StockDrift, error) {
+	m, err := s.stockDriftMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	drifts := make([]StockDrift, 0, len(m))
+	for _, d := range m {
+		drifts = append(drifts, d)
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].VoucherID < drifts[j].VoucherID })
+	return drifts, nil
+}
+
+func (s *VoucherOrderService) stockDriftMap(ctx context.Context) (map[int64]StockDrift, error) {
+	values, err := s.rdb.HGetAll(ctx, utils.STOCK_DRIFT_KEY).Result()
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[int64]StockDrift, len(values))
+	for _, raw := range values {
+		var d StockDrift
+		if err := json.Unmarshal([]byte(raw), &d); err != nil {
+			continue
+		}
+		m[d.VoucherID] = d
+	}
+	return m, nil
+}
+
+// saveStockDrifts 以本次对账结果整体替换上次结果
+func (s *VoucherOrderService) saveStockDrifts(ctx context.Context, drifts []StockDrift) error {
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, utils.STOCK_DRIFT_KEY)
+		for _, d := range drifts {
+			data, err := json.Marshal(d)
+			if err != nil {
+				return err
+			}
+			pipe.HSet(ctx, utils.STOCK_DRIFT_KEY, strconv.FormatInt(d.VoucherID, 10), data)
+		}
+		return nil
+	})
+	return err
+}
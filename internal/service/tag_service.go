@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	blogTagMaxCount  = 5
+	blogTagMaxLength = 20
+	trendingWindow   = 24 // 热门话题统计窗口（小时）
+	trendingCacheTTL = time.Minute
+)
+
+var errTooManyTags = errors.New("每篇笔记最多添加5个话题")
+
+// TopicScore 热门话题及其在统计窗口内的使用次数
+type TopicScore struct {
+	Name  string `json:"name"`
+	Score int64  `json:"score"`
+}
+
+// TagService 处理笔记话题：话题与关联关系存 MySQL，热度按小时分桶存 Redis ZSet
+type TagService struct {
+	db  *gorm.DB
+	rdb *redis.Client
+}
+
+// NewTagService 创建 TagService 实例
+func NewTagService(db *gorm.DB, rdb *redis.Client) *TagService {
+	return &TagService{db: db, rdb: rdb}
+}
+
+// NormalizeTags 去掉 # 前缀与首尾空白、去重，并校验数量与长度
+func NormalizeTags(tags []string) ([]string, error) {
+	res := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(tag), "#"))
+		if tag == "" {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > blogTagMaxLength {
+			return nil, errors.New("话题过长：" + tag)
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		res = append(res, tag)
+	}
+	if len(res) > blogTagMaxCount {
+		return nil, errTooManyTags
+	}
+	return res, nil
+}
+
+// Attach 在事务内为笔记设置话题（覆盖原有话题），不存在的话题自动创建
+func (s *TagService) Attach(tx *gorm.DB, blogID int64, tags []string) error {
+	if err := tx.Where("blog_id = ?", blogID).Delete(&model.BlogTagRel{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	rows := make([]model.BlogTag, 0, len(tags))
+	for _, name := range tags {
+		rows = append(rows, model.BlogTag{Name: name})
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+		return err
+	}
+	var ids []int64
+	if err := tx.Model(&model.BlogTag{}).Where("name IN ?", tags).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	rels := make([]model.BlogTagRel, 0, len(ids))
+	for _, id := range ids {
+		rels = append(rels, model.BlogTagRel{BlogID: blogID, TagID: id})
+	}
+	return tx.Create(&rels).Error
+}
+
+// RecordUsage 累加话题在当前小时桶中的热度，桶在统计窗口结束后过期
+func (s *TagService) RecordUsage(ctx context.Context, tags []string, now time.Time) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	key := trendingBucketKey(now)
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, tag := range tags {
+			pipe.ZIncrBy(ctx, key, 1, tag)
+		}
+		pipe.Expire(ctx, key, (trendingWindow+1)*time.Hour)
+		return nil
+	})
+	return err
+}
+
+// Tags 查询笔记的话题
+func (s *TagService) Tags(ctx context.Context, blogID int64) ([]string, error) {
+	var names []string
+	err := s.db.WithContext(ctx).
+		Table(model.BlogTag{}.TableName()+" t").
+		Joins("JOIN "+model.BlogTagRel{}.TableName()+" r ON r.tag_id = t.id").
+		Where("r.blog_id = ?", blogID).
+		Pluck("t.name", &names).Error
+	return names, err
+}
+
+// QueryBlogs 分页查询某话题下的笔记，最新的在前
+func (s *TagService) QueryBlogs(ctx context.Context, tag string, page, size int) ([]model.Blog, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = utils.MAX_PAGE_SIZE
+	}
+	var blogs []model.Blog
+	err := s.db.WithContext(ctx).
+		Joins("JOIN "+model.BlogTagRel{}.TableName()+" r ON r.blog_id = tb_blog.id").
+		Joins("JOIN "+model.BlogTag{}.TableName()+" t ON t.id = r.tag_id").
+		Where("t.name = ? AND tb_blog.status = ?", strings.TrimLeft(strings.TrimSpace(tag), "#"), model.BlogStatusPublished).
+		Order("tb_blog.id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&blogs).Error
+	return blogs, err
+}
+
+// Trending 统计最近 trendingWindow 小时内使用最多的话题，合并结果短暂缓存
+func (s *TagService) Trending(ctx context.Context, limit int64) ([]TopicScore, error) {
+	exists, err := s.rdb.Exists(ctx, utils.BLOG_TAG_HOT_KEY).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		now := time.Now()
+		keys := make([]string, 0, trendingWindow)
+		for i := 0; i < trendingWindow; i++ {
+			keys = append(keys, trendingBucketKey(now.Add(-time.Duration(i)*time.Hour)))
+		}
+		_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZUnionStore(ctx, utils.BLOG_TAG_HOT_KEY, &redis.ZStore{Keys: keys})
+			pipe.Expire(ctx, utils.BLOG_TAG_HOT_KEY, trendingCacheTTL)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	zs, err := s.rdb.ZRevRangeWithScores(ctx, utils.BLOG_TAG_HOT_KEY, 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]TopicScore, 0, len(zs))
+	for _, z := range zs {
+		res = append(res, TopicScore{Name: z.Member.(string), Score: int64(z.Score)})
+	}
+	return res, nil
+}
+
+// trendingBucketKey 话题热度的小时桶，形如 blog:tag:trend:2026101512
+func trendingBucketKey(t time.Time) string {
+	return utils.BLOG_TAG_TREND_KEY + t.Format("2006010215")
+}
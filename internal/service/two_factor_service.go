@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/config"
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+)
+
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	defaultTOTPIssuer  = "hmdp"
+	// totpStepTTL 已使用时间步的保留时长，覆盖动态码允许偏差的全部窗口
+	totpStepTTL = 2 * time.Minute
+)
+
+// acceptTOTPStepScript 仅当时间步大于该用户上次通过的时间步时记录并返回 1，同一动态码在有效期内不能重复使用
+var acceptTOTPStepScript = redis.NewScript(`
+local last = tonumber(redis.call('GET', KEYS[1]) or '-1')
+if tonumber(ARGV[1]) <= last then
+  return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'EX', ARGV[2])
+return 1
+`)
+
+var (
+	errTwoFactorNotConfigured = errors.New("服务端未配置二次验证")
+	errTwoFactorNotSetup      = errors.New("请先生成二次验证密钥")
+	errTwoFactorEnabled       = errors.New("二次验证已开启")
+	errTwoFactorNotEnabled    = errors.New("二次验证未开启")
+	errTwoFactorCodeInvalid   = errors.New("动态码或恢复码错误")
+	errTwoFactorTicketInvalid = errors.New("二次验证已过期，请重新登录")
+)
+
+// TwoFactorRequiredError 账号已开启二次验证，需携带 Ticket 完成第二步登录
+type TwoFactorRequiredError struct {
+	Ticket string
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "需要二次验证"
+}
+
+// TwoFactorSetup 生成的 TOTP 密钥，URI 可渲染为二维码
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TwoFactorService 处理 TOTP 二次验证：密钥加密存储，恢复码只保存摘要
+type TwoFactorService struct {
+	db     *gorm.DB
+	rdb    *redis.Client
+	key    string
+	issuer string
+}
+
+// NewTwoFactorService 创建 TwoFactorService 实例
+func NewTwoFactorService(db *gorm.DB, rdb *redis.Client, auth config.AuthConfig) *TwoFactorService {
+	issuer := auth.JWTIssuer
+	if issuer == "" {
+		issuer = defaultTOTPIssuer
+	}
+	return &TwoFactorService{db: db, rdb: rdb, key: auth.TOTPKey, issuer: issuer}
+}
+
+// Setup 生成新的 TOTP 密钥，需调用 Enable 校验动态码后才生效
+func (s *TwoFactorService) Setup(ctx context.Context, userID int64, account string) (*TwoFactorSetup, error) {
+	if s.key == "" {
+		return nil, errTwoFactorNotConfigured
+	}
+	record, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil && record.Enabled {
+		return nil, errTwoFactorEnabled
+	}
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := utils.EncryptString(s.key, secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Save(&model.UserTwoFactor{UserID: userID, Secret: encrypted}).Error; err != nil {
+		return nil, err
+	}
+	return &TwoFactorSetup{Secret: secret, URI: utils.TOTPURI(s.issuer, account, secret)}, nil
+}
+
+// Enable 校验动态码后开启二次验证，返回仅展示一次的恢复码
+func (s *TwoFactorService) Enable(ctx context.Context, userID int64, code string) ([]string, error) {
+	record, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, errTwoFactorNotSetup
+	}
+	if record.Enabled {
+		return nil, errTwoFactorEnabled
+	}
+	ok, err := s.verifyTOTP(ctx, record, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errTwoFactorCodeInvalid
+	}
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i] = utils.RandomString(recoveryCodeLength)
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	err = s.db.WithContext(ctx).Model(&model.UserTwoFactor{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"enabled":        true,
+			"recovery_codes": strings.Join(hashes, ","),
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable 校验动态码或恢复码后关闭二次验证；与登录一致，连续失败达到上限后暂时锁定，防止被盗会话暴力尝试动态码
+func (s *TwoFactorService) Disable(ctx context.Context, userID int64, code string) error {
+	failKey := utils.LOGIN_2FA_FAIL_KEY + strconv.FormatInt(userID, 10)
+	fails, err := s.rdb.Get(ctx, failKey).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if fails >= utils.LOGIN_FAIL_MAX {
+		ttl, _ := s.rdb.TTL(ctx, failKey).Result()
+		return fmt.Errorf("动态码错误次数过多，请%d分钟后再试", int(ttl.Minutes())+1)
+	}
+	if err := s.Verify(ctx, userID, code); err != nil {
+		if errors.Is(err, errTwoFactorCodeInvalid) {
+			if n, incrErr := s.rdb.Incr(ctx, failKey).Result(); incrErr == nil && n == 1 {
+				s.rdb.Expire(ctx, failKey, time.Duration(utils.LOGIN_FAIL_TTL)*time.Minute)
+			}
+		}
+		return err
+	}
+	s.rdb.Del(ctx, failKey)
+	return s.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.UserTwoFactor{}).Error
+}
+
+// Enabled 判断用户是否已开启二次验证
+func (s *TwoFactorService) Enabled(ctx context.Context, userID int64) (bool, error) {
+	record, err := s.find(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return record != nil && record.Enabled, nil
+}
+
+// Verify 校验动态码，失败时尝试作为恢复码校验，恢复码使用后即作废
+func (s *TwoFactorService) Verify(ctx context.Context, userID int64, code string) error {
+	record, err := s.find(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if record == nil || !record.Enabled {
+		return errTwoFactorNotEnabled
+	}
+	ok, err := s.verifyTOTP(ctx, record, code)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return nil
+	}
+	hash := hashRecoveryCode(code)
+	hashes := strings.Split(record.RecoveryCodes, ",")
+	for i, h := range hashes {
+		if h == "" || h != hash {
+			continue
+		}
+		remaining := append(hashes[:i:i], hashes[i+1:]...)
+		// 以原值作为条件，避免同一恢复码被并发重复使用
+		res := s.db.WithContext(ctx).Model(&model.UserTwoFactor{}).
+			Where("user_id = ? AND recovery_codes = ?", userID, record.RecoveryCodes).
+			Update("recovery_codes", strings.Join(remaining, ","))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errTwoFactorCodeInvalid
+		}
+		return nil
+	}
+	return errTwoFactorCodeInvalid
+}
+
+// Challenge 第一步登录成功后生成二次验证票据
+func (s *TwoFactorService) Challenge(ctx context.Context, userID int64) (string, error) {
+	ticket := uuid.NewString()
+	key := utils.LOGIN_2FA_KEY + ticket
+	_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, "userId", userID, "fails", 0)
+		pipe.Expire(ctx, key, time.Duration(utils.LOGIN_2FA_TTL)*time.Minute)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
+
+// ResolveChallenge 校验票据与动态码，成功后票据作废；连续失败达到上限时票据同样作废
+func (s *TwoFactorService) ResolveChallenge(ctx context.Context, ticket, code string) (int64, error) {
+	key := utils.LOGIN_2FA_KEY + ticket
+	raw, err := s.rdb.HGet(ctx, key, "userId").Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, errTwoFactorTicketInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+	userID, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, errTwoFactorTicketInvalid
+	}
+	if err := s.Verify(ctx, userID, code); err != nil {
+		if errors.Is(err, errTwoFactorCodeInvalid) {
+			if fails, incrErr := s.rdb.HIncrBy(ctx, key, "fails", 1).Result(); incrErr == nil && fails >= utils.LOGIN_FAIL_MAX {
+				s.rdb.Del(ctx, key)
+			}
+		}
+		return 0, err
+	}
+	// 删除成功者获得登录资格，避免票据被并发重复使用
+	n, err := s.rdb.Del(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, errTwoFactorTicketInvalid
+	}
+	return userID, nil
+}
+
+func (s *TwoFactorService) find(ctx context.Context, userID int64) (*model.UserTwoFactor, error) {
+	var record model.UserTwoFactor
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).Take(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// verifyTOTP 校验动态码，并记录该用户最后通过的时间步，已使用过的时间步及更早的动态码一律拒绝
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, record *model.UserTwoFactor, code string) (bool, error) {
+	if s.key == "" {
+		return false, errTwoFactorNotConfigured
+	}
+	secret, err := utils.DecryptString(s.key, record.Secret)
+	if err != nil {
+		return false, err
+	}
+	step, ok := utils.MatchTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	key := utils.LOGIN_2FA_STEP_KEY + strconv.FormatInt(record.UserID, 10)
+	accepted, err := acceptTOTPStepScript.Run(ctx, s.rdb, []string{key}, step, int64(totpStepTTL.Seconds())).Int()
+	if err != nil {
+		return false, err
+	}
+	return accepted == 1, nil
+}
+
+// hashRecoveryCode 恢复码为高熵随机串，使用 SHA-256 摘要即可
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,17 +2,21 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"hmdp-backend/internal/mapper"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"hmdp-backend/internal/config"
 	"hmdp-backend/internal/dto"
 	"hmdp-backend/internal/model"
 	"hmdp-backend/internal/utils"
@@ -20,19 +24,92 @@ import (
 
 // UserService 处理登录与验证码相关业务
 type UserService struct {
-	db  *gorm.DB
-	rdb *redis.Client
+	db        *gorm.DB
+	rdb       *redis.Client
+	auth      config.AuthConfig
+	smtp      utils.SMTPConfig
+	loginLog  *LoginLogService
+	twoFactor *TwoFactorService
 }
 
+var (
+	errPasswordInvalid = errors.New("密码需为4~32位字母、数字或下划线")
+	errUserBanned      = errors.New("账号已被封禁")
+	errPhoneTaken      = errors.New("该手机号已绑定其他账号")
+)
+
+// 登录模式
+const (
+	AuthModeRedis = "redis"
+	AuthModeJWT   = "jwt"
+)
+
 // NewUserService 创建 UserService 实例
-func NewUserService(db *gorm.DB, rdb *redis.Client) *UserService {
-	return &UserService{db: db, rdb: rdb}
+func NewUserService(db *gorm.DB, rdb *redis.Client, auth config.AuthConfig, smtpCfg utils.SMTPConfig, loginLog *LoginLogService, twoFactor *TwoFactorService) *UserService {
+	if auth.Mode == "" {
+		auth.Mode = AuthModeRedis
+	}
+	if auth.JWTTTL <= 0 {
+		auth.JWTTTL = time.Duration(utils.LOGIN_USER_TTL) * time.Second
+	}
+	return &UserService{db: db, rdb: rdb, auth: auth, smtp: smtpCfg, loginLog: loginLog, twoFactor: twoFactor}
 }
 
-func (s *UserService) SendCode(ctx context.Context, phone string) error {
-	// 1.校验手机号
-	if utils.IsPhoneInvalid(phone) {
-		return errors.New("phone is invalid")
+// 验证码发送频率限制
+const (
+	sendCodeMinuteWindow = time.Minute
+	sendCodeHourWindow   = time.Hour
+	sendCodeHourMax      = 5
+)
+
+// sendCodeLimitScript 原子地检查并记录各维度的发送次数，任一维度超限时整体拒绝且不计数，避免并发请求同时通过检查；
+// KEYS 依次为每个维度的分钟 key 与小时 key，ARGV 为分钟窗口、小时窗口（秒）与小时上限；
+// 超限时返回 {维度序号(从 1 开始), 窗口, 剩余秒数}，通过时返回 0
+var sendCodeLimitScript = redis.NewScript(`
+for i = 1, #KEYS, 2 do
+  local ttl = redis.call('TTL', KEYS[i])
+  if ttl > 0 then
+    return {(i + 1) / 2, 'minute', ttl}
+  end
+  local count = tonumber(redis.call('GET', KEYS[i + 1]) or '0')
+  if count >= tonumber(ARGV[3]) then
+    return {(i + 1) / 2, 'hour', redis.call('TTL', KEYS[i + 1])}
+  end
+end
+for i = 1, #KEYS, 2 do
+  redis.call('SET', KEYS[i], 1, 'EX', ARGV[1])
+  if redis.call('INCR', KEYS[i + 1]) == 1 then
+    redis.call('EXPIRE', KEYS[i + 1], ARGV[2])
+  end
+end
+return 0
+`)
+
+// SendCodeLimitError 验证码发送过于频繁，RetryAfter 为可重试的剩余秒数
+type SendCodeLimitError struct {
+	Scope      string // phone、email 或 ip
+	Window     string // minute 或 hour
+	RetryAfter int64
+}
+
+func (e *SendCodeLimitError) Error() string {
+	return fmt.Sprintf("验证码发送过于频繁，请%d秒后再试", e.RetryAfter)
+}
+
+// SendCode 发送登录验证码，channel 为 phone 或 email，target 为对应的手机号或邮箱
+// 同一手机号/邮箱与同一 IP 均限制为每分钟 1 次、每小时 5 次
+func (s *UserService) SendCode(ctx context.Context, channel, target, ip string) error {
+	// 1.校验手机号或邮箱
+	channel, err := validateLoginTarget(channel, target)
+	if err != nil {
+		return err
+	}
+	scopes := [][2]string{{channel, target}}
+	if ip != "" {
+		scopes = append(scopes, [2]string{"ip", ip})
+	}
+	if err := s.acquireSendCode(ctx, scopes); err != nil {
+		return err
 	}
 	// 2.生成验证码
 	code, err := utils.GenerateVerifyCode()
@@ -40,62 +117,356 @@ func (s *UserService) SendCode(ctx context.Context, phone string) error {
 		return err
 	}
 	// 3.将验证码存到redis中
-	key := utils.LOGIN_CODE_KEY + phone
+	key := utils.LOGIN_CODE_KEY + target
 	if err := s.rdb.Set(ctx, key, code, time.Duration(utils.LOGIN_CODE_TTL)*time.Minute).Err(); err != nil {
 		return err
 	}
 
 	// 4.发送验证码
+	if channel == dto.LoginChannelEmail {
+		cfg := s.smtp
+		cfg.To = target
+		body := fmt.Sprintf("您的登录验证码为 %s，%d 分钟内有效。", code, utils.LOGIN_CODE_TTL)
+		return utils.SendEmail(cfg, "登录验证码", body)
+	}
 	log.Println("验证码为:", code)
 	return nil
 }
 
+// validateLoginTarget 校验验证码接收方，返回规范化后的渠道
+func validateLoginTarget(channel, target string) (string, error) {
+	switch channel {
+	case "", dto.LoginChannelPhone:
+		if utils.IsPhoneInvalid(target) {
+			return "", errors.New("phone is invalid")
+		}
+		return dto.LoginChannelPhone, nil
+	case dto.LoginChannelEmail:
+		if utils.IsEmailInvalid(target) {
+			return "", errors.New("email is invalid")
+		}
+		return dto.LoginChannelEmail, nil
+	default:
+		return "", errors.New("不支持的登录渠道")
+	}
+}
+
+// acquireSendCode 原子地检查并占用分钟级与小时级的发送次数，scopes 为 {维度, 值} 列表；
+// 小时窗口从第一次发送开始计时
+func (s *UserService) acquireSendCode(ctx context.Context, scopes [][2]string) error {
+	limitErr, err := acquireAttempts(ctx, s.rdb, utils.LOGIN_CODE_LIMIT_KEY, scopes, sendCodeMinuteWindow, sendCodeHourWindow, sendCodeHourMax)
+	if err != nil {
+		return err
+	}
+	if limitErr != nil {
+		return limitErr
+	}
+	return nil
+}
+
+// acquireAttempts 通过 sendCodeLimitScript 原子地检查并记录各维度的尝试次数：每个维度 interval 内最多 1 次、
+// window 内最多 max 次；超限时返回被拒绝的维度与剩余秒数，供验证码发送、领取码校验等防刷场景复用
+func acquireAttempts(ctx context.Context, rdb *redis.Client, keyPrefix string, scopes [][2]string, interval, window time.Duration, max int) (*SendCodeLimitError, error) {
+	keys := make([]string, 0, len(scopes)*2)
+	for _, sc := range scopes {
+		prefix := keyPrefix + sc[0] + ":" + sc[1]
+		keys = append(keys, prefix+":m", prefix+":h")
+	}
+	res, err := sendCodeLimitScript.Run(ctx, rdb, keys,
+		int64(interval.Seconds()), int64(window.Seconds()), max).Result()
+	if err != nil {
+		return nil, err
+	}
+	denied, ok := res.([]interface{})
+	if !ok || len(denied) != 3 {
+		return nil, nil
+	}
+	idx, _ := denied[0].(int64)
+	windowName, _ := denied[1].(string)
+	retryAfter, _ := denied[2].(int64)
+	if idx < 1 || int(idx) > len(scopes) {
+		return nil, fmt.Errorf("unexpected attempt limit result: %v", res)
+	}
+	return &SendCodeLimitError{Scope: scopes[idx-1][0], Window: windowName, RetryAfter: retryAfter}, nil
+}
+
 func (s *UserService) Login(ctx context.Context, loginForm dto.LoginForm) (string, error) {
 	var user model.User
-	// 1.校验手机号
-	if utils.IsPhoneInvalid(loginForm.Phone) {
-		return "", errors.New("phone is invalid")
-	}
-	// 2.校验验证码
-	codeKey := utils.LOGIN_CODE_KEY + loginForm.Phone
-	cacheCode, err := s.rdb.Get(ctx, codeKey).Result()
-	if errors.Is(err, redis.Nil) {
-		return "", errors.New("验证码不存在或已过期")
+	// 1.校验手机号或邮箱
+	target := loginForm.Phone
+	if loginForm.Channel == dto.LoginChannelEmail {
+		target = loginForm.Email
 	}
+	channel, err := validateLoginTarget(loginForm.Channel, target)
 	if err != nil {
 		return "", err
 	}
-	if cacheCode != loginForm.Code {
-		return "", errors.New("验证码错误")
-	}
-	// 验证通过后清理验证码，避免重复使用
-	if err := s.rdb.Del(ctx, codeKey).Err(); err != nil && !errors.Is(err, redis.Nil) {
+	// 2.校验验证码
+	if err := s.verifyCode(ctx, target, loginForm.Code); err != nil {
 		return "", err
 	}
-	// 3.根据手机号查询用户
-	err = s.db.WithContext(ctx).Where("phone = ?", loginForm.Phone).First(&user).Error
+	// 3.根据手机号或邮箱查询用户
+	err = s.db.WithContext(ctx).Where(channel+" = ?", target).First(&user).Error
 	// 4.用户不存在则创建
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		user = model.User{
-			Phone:    loginForm.Phone,
 			NickName: utils.USER_NICK_NAME_PREFIX + utils.RandomString(10),
 		}
+		if channel == dto.LoginChannelEmail {
+			user.Email = target
+		} else {
+			user.Phone = target
+		}
 		if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
 			return "", err
 		}
 	} else if err != nil {
 		return "", err
 	}
-	// 5.生成登录令牌并写入Redis
+	// 5.生成登录令牌
+	return s.issueToken(ctx, &user)
+}
+
+// Register 手机号 + 验证码注册并设置密码，注册成功后直接登录
+func (s *UserService) Register(ctx context.Context, form dto.LoginForm) (string, error) {
+	if utils.IsPhoneInvalid(form.Phone) {
+		return "", errors.New("phone is invalid")
+	}
+	if utils.IsPasswordInvalid(form.Password) {
+		return "", errPasswordInvalid
+	}
+	if err := s.verifyCode(ctx, form.Phone, form.Code); err != nil {
+		return "", err
+	}
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&model.User{}).Where("phone = ?", form.Phone).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count > 0 {
+		return "", errors.New("手机号已注册")
+	}
+	hash, err := utils.HashPassword(form.Password)
+	if err != nil {
+		return "", err
+	}
+	user := model.User{
+		Phone:    form.Phone,
+		Password: hash,
+		NickName: utils.USER_NICK_NAME_PREFIX + utils.RandomString(10),
+	}
+	if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
+		return "", err
+	}
+	return s.issueToken(ctx, &user)
+}
+
+// SetPassword 设置或修改密码：已设置过密码时需校验旧密码
+func (s *UserService) SetPassword(ctx context.Context, userID int64, form dto.PasswordForm) error {
+	if utils.IsPasswordInvalid(form.NewPassword) {
+		return errPasswordInvalid
+	}
+	var user model.User
+	if err := s.db.WithContext(ctx).Select("id", "password").First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.Password != "" && !utils.CheckPassword(user.Password, form.OldPassword) {
+		return errors.New("原密码错误")
+	}
+	hash, err := utils.HashPassword(form.NewPassword)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("password", hash).Error
+}
+
+// LoginWithPassword 手机号 + 密码登录，连续失败达到上限后暂时锁定
+func (s *UserService) LoginWithPassword(ctx context.Context, form dto.LoginForm) (string, error) {
+	if utils.IsPhoneInvalid(form.Phone) {
+		return "", errors.New("phone is invalid")
+	}
+	failKey := utils.LOGIN_FAIL_KEY + form.Phone
+	fails, err := s.rdb.Get(ctx, failKey).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return "", err
+	}
+	if fails >= utils.LOGIN_FAIL_MAX {
+		ttl, _ := s.rdb.TTL(ctx, failKey).Result()
+		return "", fmt.Errorf("密码错误次数过多，请%d分钟后再试", int(ttl.Minutes())+1)
+	}
+	var user model.User
+	err = s.db.WithContext(ctx).Where("phone = ?", form.Phone).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	// 用户不存在与密码错误返回相同提示，并同样计入失败次数，避免探测手机号是否注册
+	if err != nil || !utils.CheckPassword(user.Password, form.Password) {
+		if n, incrErr := s.rdb.Incr(ctx, failKey).Result(); incrErr == nil && n == 1 {
+			s.rdb.Expire(ctx, failKey, time.Duration(utils.LOGIN_FAIL_TTL)*time.Minute)
+		}
+		return "", errors.New("手机号或密码错误")
+	}
+	s.rdb.Del(ctx, failKey)
+	return s.issueToken(ctx, &user)
+}
+
+// verifyCode 校验登录验证码，通过后删除避免重复使用
+func (s *UserService) verifyCode(ctx context.Context, target, code string) error {
+	if err := s.checkCode(ctx, target, code); err != nil {
+		return err
+	}
+	if err := s.rdb.Del(ctx, utils.LOGIN_CODE_KEY+target).Err(); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return nil
+}
+
+// checkCode 只校验验证码，不删除
+func (s *UserService) checkCode(ctx context.Context, target, code string) error {
+	cacheCode, err := s.rdb.Get(ctx, utils.LOGIN_CODE_KEY+target).Result()
+	if errors.Is(err, redis.Nil) {
+		return errors.New("验证码不存在或已过期")
+	}
+	if err != nil {
+		return err
+	}
+	if cacheCode != code {
+		return errors.New("验证码错误")
+	}
+	return nil
+}
+
+// consumeCode 通过 GETDEL 原子地取出并删除验证码后再比对，无论校验是否通过验证码都只能使用一次
+func (s *UserService) consumeCode(ctx context.Context, target, code string) error {
+	cacheCode, err := s.rdb.GetDel(ctx, utils.LOGIN_CODE_KEY+target).Result()
+	if errors.Is(err, redis.Nil) {
+		return errors.New("验证码不存在或已过期")
+	}
+	if err != nil {
+		return err
+	}
+	if cacheCode != code {
+		return errors.New("验证码错误")
+	}
+	return nil
+}
+
+// ChangePhone 更换绑定手机号：原手机号与新手机号的验证码需同时校验通过（未绑定手机号的账号只校验新手机号），
+// 验证码在校验时即被消费，更换失败需重新获取；更换成功后吊销全部会话，用户需重新登录
+func (s *UserService) ChangePhone(ctx context.Context, userID int64, form dto.ChangePhoneForm) error {
+	if utils.IsPhoneInvalid(form.NewPhone) {
+		return errors.New("phone is invalid")
+	}
+	var user model.User
+	if err := s.db.WithContext(ctx).Select("id", "phone").First(&user, userID).Error; err != nil {
+		return err
+	}
+	if user.Phone == form.NewPhone {
+		return errors.New("新手机号与原手机号相同")
+	}
+	if user.Phone != "" {
+		if err := s.consumeCode(ctx, user.Phone, form.OldCode); err != nil {
+			return fmt.Errorf("原手机号%w", err)
+		}
+	}
+	if err := s.consumeCode(ctx, form.NewPhone, form.NewCode); err != nil {
+		return fmt.Errorf("新手机号%w", err)
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 对新手机号做加锁读，并发绑定同一号码的请求在此串行化，避免先查后改的竞态
+		var owners []int64
+		if err := tx.Model(&model.User{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("phone = ?", form.NewPhone).Pluck("id", &owners).Error; err != nil {
+			return err
+		}
+		for _, id := range owners {
+			if id != userID {
+				return errPhoneTaken
+			}
+		}
+		err := tx.Model(&model.User{}).Where("id = ?", userID).Update("phone", form.NewPhone).Error
+		if isDuplicateKey(err) {
+			return errPhoneTaken
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.RevokeSessions(ctx, userID)
+	return err
+}
+
+// issueToken 校验账号状态后签发令牌；开启二次验证的账号返回 TwoFactorRequiredError
+func (s *UserService) issueToken(ctx context.Context, user *model.User) (string, error) {
+	if user.Status == model.UserStatusBanned {
+		return "", errUserBanned
+	}
+	if s.twoFactor != nil {
+		enabled, err := s.twoFactor.Enabled(ctx, user.ID)
+		if err != nil {
+			return "", err
+		}
+		if enabled {
+			ticket, err := s.twoFactor.Challenge(ctx, user.ID)
+			if err != nil {
+				return "", err
+			}
+			return "", &TwoFactorRequiredError{Ticket: ticket}
+		}
+	}
+	return s.completeLogin(ctx, user)
+}
+
+// LoginWithTwoFactor 使用第一步登录返回的票据与动态码（或恢复码）完成登录
+func (s *UserService) LoginWithTwoFactor(ctx context.Context, ticket, code string) (string, error) {
+	if s.twoFactor == nil {
+		return "", errTwoFactorNotEnabled
+	}
+	userID, err := s.twoFactor.ResolveChallenge(ctx, ticket, code)
+	if err != nil {
+		return "", err
+	}
+	var user model.User
+	if err := s.db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		return "", err
+	}
+	if user.Status == model.UserStatusBanned {
+		return "", errUserBanned
+	}
+	return s.completeLogin(ctx, &user)
+}
+
+// completeLogin 签发令牌，签发成功时异步记录登录日志
+func (s *UserService) completeLogin(ctx context.Context, user *model.User) (string, error) {
+	token, err := s.signToken(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	if s.loginLog != nil {
+		s.loginLog.RecordAsync(user.ID, loginClientFrom(ctx), time.Now())
+	}
+	return token, nil
+}
+
+// signToken 按配置的登录模式签发令牌：jwt 模式返回无状态令牌，否则写入 Redis 会话
+func (s *UserService) signToken(ctx context.Context, user *model.User) (string, error) {
+	userDTO := mapper.ToUserDTO(user)
+	if s.auth.Mode == AuthModeJWT {
+		return utils.SignJWT(s.auth.JWTSecret, s.auth.JWTIssuer, s.auth.JWTTTL, utils.JWTClaims{
+			UserID:   userDTO.ID,
+			NickName: userDTO.NickName,
+			Icon:     userDTO.Icon,
+			Role:     userDTO.Role,
+		})
+	}
 	token := uuid.NewString()
-	//userDTO := dto.UserDTO{ID: user.ID, NickName: user.NickName, Icon: user.Icon}
-	userDTO := mapper.ToUserDTO(&user)
 	tokenKey := utils.LOGIN_USER_KEY + token
 	// 将 UserDTO 中的字段完整序列化到 Redis Hash，便于后续统一读取
 	data := map[string]string{
 		"id":       strconv.FormatInt(userDTO.ID, 10),
 		"nickName": userDTO.NickName,
 		"icon":     userDTO.Icon,
+		"role":     userDTO.Role,
 	}
 	if err := s.rdb.HSet(ctx, tokenKey, data).Err(); err != nil {
 		return "", err
@@ -104,10 +475,134 @@ func (s *UserService) Login(ctx context.Context, loginForm dto.LoginForm) (strin
 	if err := s.rdb.Expire(ctx, tokenKey, time.Duration(utils.LOGIN_USER_TTL)*time.Second).Err(); err != nil {
 		return "", err
 	}
+	// 记录用户的 token 索引，用于退出全部设备
+	tokensKey := utils.LOGIN_TOKENS_KEY + strconv.FormatInt(userDTO.ID, 10)
+	if _, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, tokensKey, token)
+		pipe.Expire(ctx, tokensKey, time.Duration(utils.LOGIN_USER_TTL)*time.Second)
+		return nil
+	}); err != nil {
+		return "", err
+	}
 	// 返回 token
 	return token, nil
 }
 
+// Logout 退出登录：删除当前 token，all 为 true 时删除该用户在所有设备上的 token
+func (s *UserService) Logout(ctx context.Context, userID int64, token string, all bool) error {
+	// JWT 为无状态令牌，服务端无会话可删除，由客户端丢弃令牌
+	if utils.LooksLikeJWT(token) && !all {
+		return nil
+	}
+	tokensKey := utils.LOGIN_TOKENS_KEY + strconv.FormatInt(userID, 10)
+	if !all {
+		_, err := s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, utils.LOGIN_USER_KEY+token)
+			pipe.SRem(ctx, tokensKey, token)
+			return nil
+		})
+		return err
+	}
+	// 当前 token 同样记录在索引中，随全部会话一并删除
+	_, err := s.RevokeSessions(ctx, userID)
+	return err
+}
+
+// RevokeSessions 通过用户的 token 索引删除其全部 Redis 会话，并记录吊销时间使此前签发的 JWT 失效，返回删除的会话数
+func (s *UserService) RevokeSessions(ctx context.Context, userID int64) (int, error) {
+	uid := strconv.FormatInt(userID, 10)
+	tokensKey := utils.LOGIN_TOKENS_KEY + uid
+	tokens, err := s.rdb.SMembers(ctx, tokensKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, 0, len(tokens)+1)
+	keys = append(keys, tokensKey)
+	for _, t := range tokens {
+		keys = append(keys, utils.LOGIN_USER_KEY+t)
+	}
+	_, err = s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		// JWT 最长有效期内保留吊销时间（毫秒），过期后旧令牌自然失效
+		pipe.Set(ctx, utils.LOGIN_REVOKE_KEY+uid, time.Now().UnixMilli(), s.auth.JWTTTL)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
+
+// Ban 封禁用户：更新状态、加入 Redis 黑名单并踢下线所有会话
+func (s *UserService) Ban(ctx context.Context, userID int64) error {
+	res := s.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("status", model.UserStatusBanned)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("用户不存在")
+	}
+	if err := s.rdb.SAdd(ctx, utils.USER_BANNED_KEY, userID).Err(); err != nil {
+		return err
+	}
+	_, err := s.RevokeSessions(ctx, userID)
+	return err
+}
+
+// Unban 解除封禁
+func (s *UserService) Unban(ctx context.Context, userID int64) error {
+	if err := s.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Update("status", model.UserStatusNormal).Error; err != nil {
+		return err
+	}
+	return s.rdb.SRem(ctx, utils.USER_BANNED_KEY, userID).Err()
+}
+
+// ListBanned 分页查询被封禁的用户
+func (s *UserService) ListBanned(ctx context.Context, page, size int) ([]model.User, error) {
+	var users []model.User
+	offset := (page - 1) * size
+	if offset < 0 {
+		offset = 0
+	}
+	err := s.db.WithContext(ctx).
+		Where("status = ?", model.UserStatusBanned).
+		Order("update_time DESC").
+		Offset(offset).
+		Limit(size).
+		Find(&users).Error
+	return users, err
+}
+
+// SearchByNickName 按昵称前缀分页搜索用户（可命中 nick_name 索引），不返回已封禁的用户
+func (s *UserService) SearchByNickName(ctx context.Context, name string, page, size int) ([]dto.UserDTO, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return []dto.UserDTO{}, nil
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > utils.MAX_PAGE_SIZE {
+		size = utils.MAX_PAGE_SIZE
+	}
+	var users []model.User
+	err := s.db.WithContext(ctx).
+		Select("id", "nick_name", "icon").
+		Where("nick_name LIKE ? AND status <> ?", escapeLike(name)+"%", model.UserStatusBanned).
+		Order("id ASC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]dto.UserDTO, 0, len(users))
+	for i := range users {
+		res = append(res, dto.UserDTO{ID: users[i].ID, NickName: users[i].NickName, Icon: users[i].Icon})
+	}
+	return res, nil
+}
+
 func (s *UserService) FindByID(ctx context.Context, id int64) (*model.User, error) {
 	var user model.User
 	err := s.db.WithContext(ctx).First(&user, id).Error
@@ -121,6 +616,61 @@ func (s *UserService) FindByID(ctx context.Context, id int64) (*model.User, erro
 	return &user, nil
 }
 
+// FindByIDs 批量查询用户的公开信息：先批量读 Redis 缓存，未命中的用一次 IN 查询补齐并回写缓存
+func (s *UserService) FindByIDs(ctx context.Context, ids []int64) (map[int64]*dto.UserDTO, error) {
+	res := make(map[int64]*dto.UserDTO, len(ids))
+	if len(ids) == 0 {
+		return res, nil
+	}
+	unique := make([]int64, 0, len(ids))
+	seen := make(map[int64]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			unique = append(unique, id)
+		}
+	}
+	keys := make([]string, len(unique))
+	for i, id := range unique {
+		keys[i] = utils.CACHE_USER_KEY + strconv.FormatInt(id, 10)
+	}
+	cached, err := s.rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	var missing []int64
+	for i, v := range cached {
+		str, ok := v.(string)
+		if ok {
+			var u dto.UserDTO
+			if json.Unmarshal([]byte(str), &u) == nil {
+				res[unique[i]] = &u
+				continue
+			}
+		}
+		missing = append(missing, unique[i])
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+	var users []model.User
+	if err := s.db.WithContext(ctx).Select("id", "nick_name", "icon").Where("id IN ?", missing).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	ttl := time.Duration(utils.CACHE_USER_TTL) * time.Minute
+	_, _ = s.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i := range users {
+			u := &dto.UserDTO{ID: users[i].ID, NickName: users[i].NickName, Icon: users[i].Icon}
+			res[u.ID] = u
+			if data, err := json.Marshal(u); err == nil {
+				pipe.Set(ctx, utils.CACHE_USER_KEY+strconv.FormatInt(u.ID, 10), data, ttl)
+			}
+		}
+		return nil
+	})
+	return res, nil
+}
+
 // Sign 处理用户签到，使用 Redis Bitmap 记录每日签到（offset=当天-1）
 // key 形如 user:sign:{userId}:{year}:{month}
 func (s *UserService) Sign(ctx context.Context, userID int64, now time.Time) error {
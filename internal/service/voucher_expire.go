@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/model"
+	"hmdp-backend/internal/utils"
+	"hmdp-backend/pkg/lock"
+)
+
+// voucherExpireInterval 扫描已结束秒杀券的周期
+const voucherExpireInterval = time.Minute
+
+// expireLoop 定期将已结束的秒杀券标记为过期；多实例部署时通过分布式锁只由一个实例执行
+func (s *VoucherService) expireLoop(ctx context.Context) {
+	ticker := time.NewTicker(voucherExpireInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		mu := lock.New(s.rdb, utils.VOUCHER_EXPIRE_LOCK, voucherExpireInterval, lock.WithWatchdog())
+		locked, err := mu.TryLock(ctx)
+		if err != nil || !locked {
+			continue
+		}
+		if _, err := s.ExpireVouchers(ctx); err != nil {
+			s.log.Warn("expire vouchers failed", zap.Error(err))
+		}
+		_ = mu.Unlock(ctx)
+	}
+}
+
+// ExpireVouchers 将 end_time 已过的上架秒杀券置为过期，删除其 Redis 库存、限购集合与时间窗口，
+// 并清理活动缓存；返回本次下线的券数量
+func (s *VoucherService) ExpireVouchers(ctx context.Context) (int, error) {
+	var ids []int64
+	if err := s.db.WithContext(ctx).Table("tb_voucher AS v").
+		Joins("JOIN tb_seckill_voucher sv ON sv.voucher_id = v.id").
+		Where("v.status = ? AND sv.end_time <= ?", model.VoucherStatusOnline, time.Now()).
+		Pluck("v.id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := s.db.WithContext(ctx).Model(&model.Voucher{}).
+		Where("id IN ? AND status = ?", ids, model.VoucherStatusOnline).
+		Update("status", model.VoucherStatusExpired).Error; err != nil {
+		return 0, err
+	}
+	keys := make([]string, 0, len(ids)*3+1)
+	for _, id := range ids {
+		s.windows.Delete(id)
+		keys = append(keys, fmt.Sprintf(stockKeyFmt, id), fmt.Sprintf(orderSetFmt, id), fmt.Sprintf(seckillWindowFmt, id))
+	}
+	// 活动缓存中可能包含这些券
+	keys = append(keys, utils.CACHE_CAMPAIGN_KEY)
+	if err := s.rdb.Del(ctx, keys...).Err(); err != nil {
+		return len(ids), err
+	}
+	return len(ids), nil
+}
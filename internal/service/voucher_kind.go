@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"hmdp-backend/internal/model"
+)
+
+// voucherFreeItemMaxLen 兑换商品名称的最大长度（字符）
+const voucherFreeItemMaxLen = 64
+
+// voucherKind 返回券类型，未设置类型的历史券按代金券处理
+func voucherKind(v *model.Voucher) int {
+	if v.Kind == 0 {
+		return model.VoucherKindCash
+	}
+	return v.Kind
+}
+
+// validateVoucherKind 按券类型校验优惠字段，与类型无关的字段必须为零值，避免配置歧义
+func validateVoucherKind(v *model.Voucher) error {
+	invalid := func(msg string) error {
+		return &VoucherRuleError{Reason: VoucherRuleInvalidConfig, Message: msg}
+	}
+	switch voucherKind(v) {
+	case model.VoucherKindCash:
+		if v.ActualValue <= 0 {
+			return invalid("代金券抵扣金额必须大于 0")
+		}
+		if v.DiscountPct != 0 || v.MaxDiscount != 0 || v.FreeItem != "" {
+			return invalid("代金券不能设置折扣或兑换商品")
+		}
+	case model.VoucherKindDiscount:
+		if v.DiscountPct <= 0 || v.DiscountPct >= 100 {
+			return invalid("折扣需在 1~99 之间")
+		}
+		if v.MaxDiscount < 0 {
+			return invalid("最高优惠金额不能为负数")
+		}
+		if v.FreeItem != "" {
+			return invalid("折扣券不能设置兑换商品")
+		}
+	case model.VoucherKindFreeItem:
+		item := strings.TrimSpace(v.FreeItem)
+		if item == "" {
+			return invalid("兑换券必须设置兑换商品")
+		}
+		if len([]rune(item)) > voucherFreeItemMaxLen {
+			return invalid(fmt.Sprintf("兑换商品名称不能超过 %d 个字", voucherFreeItemMaxLen))
+		}
+		if v.DiscountPct != 0 || v.MaxDiscount != 0 {
+			return invalid("兑换券不能设置折扣")
+		}
+		v.FreeItem = item
+	default:
+		return invalid("不支持的券类型")
+	}
+	return nil
+}
+
+// VoucherDeduction 计算消费 spend（分）时券可抵扣的金额；未达到最低消费时为 0，
+// 兑换券抵扣的是商品而非金额，也返回 0
+func VoucherDeduction(v *model.Voucher, spend int64) int64 {
+	if spend <= 0 || spend < v.MinSpend {
+		return 0
+	}
+	var off int64
+	switch voucherKind(v) {
+	case model.VoucherKindCash:
+		off = v.ActualValue
+	case model.VoucherKindDiscount:
+		off = spend * int64(100-v.DiscountPct) / 100
+		if v.MaxDiscount > 0 && off > v.MaxDiscount {
+			off = v.MaxDiscount
+		}
+	}
+	if off > spend {
+		off = spend
+	}
+	return off
+}
+
+// describeVoucherBenefit 生成券优惠内容的展示文案，如“满100减20”“满100元享8.5折”“兑换 招牌奶茶”
+func describeVoucherBenefit(v *model.Voucher) string {
+	prefix := ""
+	if v.MinSpend > 0 {
+		prefix = "满" + formatYuan(v.MinSpend) + "元"
+	}
+	switch voucherKind(v) {
+	case model.VoucherKindDiscount:
+		rate := strings.TrimSuffix(strings.TrimSuffix(fmt.Sprintf("%.1f", float64(v.DiscountPct)/10), "0"), ".")
+		desc := rate + "折"
+		if prefix != "" {
+			desc = prefix + "享" + desc
+		}
+		if v.MaxDiscount > 0 {
+			desc += "，最高优惠" + formatYuan(v.MaxDiscount) + "元"
+		}
+		return desc
+	case model.VoucherKindFreeItem:
+		return prefix + "兑换 " + v.FreeItem
+	default:
+		if v.MinSpend > 0 {
+			return "满" + formatYuan(v.MinSpend) + "减" + formatYuan(v.ActualValue)
+		}
+		return "立减" + formatYuan(v.ActualValue) + "元"
+	}
+}
+
+// formatYuan 将金额（分）格式化为元，去掉多余的小数位
+func formatYuan(cents int64) string {
+	s := fmt.Sprintf("%.2f", float64(cents)/100)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
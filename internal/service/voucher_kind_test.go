@@ -0,0 +1,72 @@
+package service
+
+import (
+	"testing"
+
+	"hmdp-backend/internal/model"
+)
+
+// TestValidateVoucherKind 校验各券类型的优惠字段配置
+func TestValidateVoucherKind(t *testing.T) {
+	cases := []struct {
+		name    string
+		voucher model.Voucher
+		ok      bool
+	}{
+		{"legacy cash", model.Voucher{ActualValue: 1000}, true},
+		{"cash without value", model.Voucher{Kind: model.VoucherKindCash}, false},
+		{"cash with discount", model.Voucher{Kind: model.VoucherKindCash, ActualValue: 1000, DiscountPct: 80}, false},
+		{"discount ok", model.Voucher{Kind: model.VoucherKindDiscount, DiscountPct: 85, MaxDiscount: 2000}, true},
+		{"discount out of range", model.Voucher{Kind: model.VoucherKindDiscount, DiscountPct: 100}, false},
+		{"free item ok", model.Voucher{Kind: model.VoucherKindFreeItem, FreeItem: "招牌奶茶"}, true},
+		{"free item missing", model.Voucher{Kind: model.VoucherKindFreeItem, FreeItem: "  "}, false},
+		{"unknown kind", model.Voucher{Kind: 9}, false},
+	}
+	for _, c := range cases {
+		err := validateVoucherKind(&c.voucher)
+		if (err == nil) != c.ok {
+			t.Fatalf("%s: err = %v, want ok=%v", c.name, err, c.ok)
+		}
+	}
+}
+
+// TestVoucherDeduction 校验不同券类型在消费金额下的抵扣金额
+func TestVoucherDeduction(t *testing.T) {
+	cases := []struct {
+		name    string
+		voucher model.Voucher
+		spend   int64
+		want    int64
+	}{
+		{"cash", model.Voucher{ActualValue: 2000, MinSpend: 10000}, 12000, 2000},
+		{"cash below threshold", model.Voucher{ActualValue: 2000, MinSpend: 10000}, 9999, 0},
+		{"cash exceeds spend", model.Voucher{ActualValue: 2000}, 1500, 1500},
+		{"discount", model.Voucher{Kind: model.VoucherKindDiscount, DiscountPct: 80}, 10000, 2000},
+		{"discount capped", model.Voucher{Kind: model.VoucherKindDiscount, DiscountPct: 50, MaxDiscount: 3000}, 10000, 3000},
+		{"free item", model.Voucher{Kind: model.VoucherKindFreeItem, FreeItem: "奶茶"}, 10000, 0},
+	}
+	for _, c := range cases {
+		if got := VoucherDeduction(&c.voucher, c.spend); got != c.want {
+			t.Fatalf("%s: VoucherDeduction = %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+// TestDescribeVoucherBenefit 校验券优惠内容的展示文案
+func TestDescribeVoucherBenefit(t *testing.T) {
+	cases := []struct {
+		voucher model.Voucher
+		want    string
+	}{
+		{model.Voucher{ActualValue: 2000, MinSpend: 10000}, "满100减20"},
+		{model.Voucher{ActualValue: 550}, "立减5.5元"},
+		{model.Voucher{Kind: model.VoucherKindDiscount, DiscountPct: 85}, "8.5折"},
+		{model.Voucher{Kind: model.VoucherKindDiscount, DiscountPct: 80, MinSpend: 5000, MaxDiscount: 2000}, "满50元享8折，最高优惠20元"},
+		{model.Voucher{Kind: model.VoucherKindFreeItem, FreeItem: "招牌奶茶"}, "兑换 招牌奶茶"},
+	}
+	for _, c := range cases {
+		if got := describeVoucherBenefit(&c.voucher); got != c.want {
+			t.Fatalf("describeVoucherBenefit = %q, want %q", got, c.want)
+		}
+	}
+}
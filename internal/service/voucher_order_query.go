@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"hmdp-backend/internal/model"
+)
+
+// VoucherOrderView 订单及其关联的优惠券、商铺信息，用于用户订单列表与详情
+type VoucherOrderView struct {
+	model.VoucherOrder
+	ShopID       int64  `gorm:"column:shop_id" json:"shopId"`
+	ShopName     string `gorm:"column:shop_name" json:"shopName"`
+	VoucherTitle string `gorm:"column:voucher_title" json:"voucherTitle"`
+	SubTitle     string `gorm:"column:sub_title" json:"subTitle"`
+	PayValue     int64  `gorm:"column:pay_value" json:"payValue"`
+	ActualValue  int64  `gorm:"column:actual_value" json:"actualValue"`
+}
+
+// orderViewQuery 订单关联优惠券与商铺的基础查询；券或商铺被删除时对应字段为空
+func (s *VoucherOrderService) orderViewQuery(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx).Table("tb_voucher_order AS o").
+		Select("o.*, v.shop_id, sh.name AS shop_name, v.title AS voucher_title, v.sub_title, v.pay_value, v.actual_value").
+		Joins("LEFT JOIN tb_voucher v ON v.id = o.voucher_id").
+		Joins("LEFT JOIN tb_shop sh ON sh.id = v.shop_id")
+}
+
+// ListByUser 分页查询用户的订单，最新下单的在前；status 为 0 时不过滤状态
+func (s *VoucherOrderService) ListByUser(ctx context.Context, userID int64, status, page, size int) ([]VoucherOrderView, int64, error) {
+	query := s.db.WithContext(ctx).Model(&model.VoucherOrder{}).Where("user_id = ?", userID)
+	if status > 0 {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	orders := make([]VoucherOrderView, 0)
+	if total == 0 {
+		return orders, 0, nil
+	}
+	list := s.orderViewQuery(ctx).Where("o.user_id = ?", userID)
+	if status > 0 {
+		list = list.Where("o.status = ?", status)
+	}
+	err := list.Order("o.create_time DESC, o.id DESC").
+		Offset((page - 1) * size).
+		Limit(size).
+		Scan(&orders).Error
+	return orders, total, err
+}
+
+// GetByUser 查询订单详情，只能查看自己的订单
+func (s *VoucherOrderService) GetByUser(ctx context.Context, userID, orderID int64) (*VoucherOrderView, error) {
+	var order VoucherOrderView
+	err := s.orderViewQuery(ctx).Where("o.id = ?", orderID).Take(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// 不区分“不存在”与“不属于当前用户”，避免泄露他人订单是否存在
+	if order.UserID != userID {
+		return nil, errOrderNotFound
+	}
+	return &order, nil
+}
@@ -8,6 +8,9 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
@@ -18,7 +21,9 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"hmdp-backend/internal/config"
 	"hmdp-backend/internal/model"
 	"hmdp-backend/internal/observability"
 	"hmdp-backend/internal/utils"
@@ -27,6 +32,8 @@ import (
 const (
 	stockKeyFmt  = "seckill:stock:vid:%d"
 	orderSetFmt  = "order:vid:%d"
+	// seckillWindowFmt 秒杀时间窗口缓存（Hash：begin、end 为秒级时间戳，weekdays 为可用星期）
+	seckillWindowFmt = "seckill:window:vid:%d"
 )
 
 var errRetryEnqueued = errors.New("retry enqueued")
@@ -47,8 +54,21 @@ type VoucherOrderService struct {
 	retryReader *kafka.Reader
 	dlqReader   *kafka.Reader
 	smtpCfg     utils.SMTPConfig
+	queue       string
+	workers     int
+	payTimeout  time.Duration
+	reconcile   time.Duration // 秒杀库存对账周期
+	autoFix     atomic.Bool   // 是否自动修正持续存在的库存偏差，支持热更新
+	maxDepth    atomic.Int64  // 配置的订单积压阈值，Redis 中未设置动态阈值时使用，支持热更新
+	queueDepth  atomic.Int64  // 最近一次采样的待落库订单数
+	queueLimit  atomic.Int64  // 当前生效的订单积压阈值，0 表示不限制
+	state       *OrderStateService
+	outbox      *OutboxService
 	metrics     *observability.SeckillMetrics
 	log         *zap.Logger
+	// cancel 通知后台消费协程退出，wg 等待处理中的订单完成
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 func NewVoucherOrderService(
@@ -61,12 +81,27 @@ func NewVoucherOrderService(
 	retryReader *kafka.Reader,
 	dlqReader *kafka.Reader,
 	smtpCfg utils.SMTPConfig,
+	orderCfg config.OrderConfig,
+	state *OrderStateService,
+	outbox *OutboxService,
 	metrics *observability.SeckillMetrics,
 	log *zap.Logger,
 ) *VoucherOrderService {
 	if log == nil {
 		log = zap.NewNop()
 	}
+	if orderCfg.PayTimeout <= 0 {
+		orderCfg.PayTimeout = defaultPayTimeout
+	}
+	if orderCfg.Workers <= 0 {
+		orderCfg.Workers = 1
+	}
+	if orderCfg.ReconcileInterval <= 0 {
+		orderCfg.ReconcileInterval = defaultReconcileInterval
+	}
+	if state == nil {
+		state = NewOrderStateService(db)
+	}
 	svc := &VoucherOrderService{
 		db:          db,
 		rdb:         rdb,
@@ -79,23 +114,84 @@ func NewVoucherOrderService(
 		retryReader: retryReader,
 		dlqReader:   dlqReader,
 		smtpCfg:     smtpCfg,
+		queue:       orderCfg.Queue,
+		workers:     orderCfg.Workers,
+		payTimeout:  orderCfg.PayTimeout,
+		reconcile:   orderCfg.ReconcileInterval,
+		state:       state,
+		outbox:      outbox,
 		metrics:     metrics,
 		log:         log,
 	}
+	svc.autoFix.Store(orderCfg.ReconcileAutoFix)
+	svc.maxDepth.Store(orderCfg.MaxQueueDepth)
+	svc.queueLimit.Store(orderCfg.MaxQueueDepth)
 	svc.warmupScripts(context.Background())
 	log.Info("voucher order consumers starting")
-	// 异步消费 Kafka 订单消息
-	go svc.consumeOrders(context.Background())
-	// 重试队列消费
-	go svc.consumeRetryOrders(context.Background())
-	// 记录消费延迟（lag）用于监控
-	go svc.logKafkaLag(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	svc.cancel = cancel
+	if svc.queue == OrderQueueStream {
+		// Redis Streams 消费者组：新消息消费与崩溃消费者的待确认消息认领
+		svc.startStreamConsumers(ctx)
+	} else {
+		// 异步消费 Kafka 订单消息
+		svc.goBackground(ctx, svc.consumeOrders)
+		// 重试队列消费
+		svc.goBackground(ctx, svc.consumeRetryOrders)
+		// 记录消费延迟（lag）用于监控
+		svc.goBackground(ctx, svc.logKafkaLag)
+	}
 	// 死信队列消费 邮件告警
 	if svc.dlqReader != nil {
-		go svc.consumeDLQ(context.Background())
-	}
+		svc.goBackground(ctx, svc.consumeDLQ)
+	}
+	// 超时未支付订单自动取消
+	svc.goBackground(ctx, svc.cancelTimeoutLoop)
+	// Redis 与数据库秒杀库存对账
+	svc.goBackground(ctx, svc.reconcileLoop)
+	// 采样订单队列积压与动态阈值
+	svc.goBackground(ctx, svc.sampleQueueLoop)
 	return svc
 }
+
+// goBackground 启动受 Shutdown 管理的后台协程
+func (s *VoucherOrderService) goBackground(ctx context.Context, fn func(context.Context)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		fn(ctx)
+	}()
+}
+
+// Shutdown 停止拉取新消息并等待处理中的订单落库，ctx 到期时不再等待
+func (s *VoucherOrderService) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ApplyOrderConfig 热更新积压阈值与库存自动修正开关；积压阈值在下一个采样周期生效，管理端设置的动态阈值优先
+func (s *VoucherOrderService) ApplyOrderConfig(cfg config.OrderConfig) {
+	s.maxDepth.Store(cfg.MaxQueueDepth)
+	s.autoFix.Store(cfg.ReconcileAutoFix)
+	s.log.Info("order config applied",
+		zap.Int64("maxQueueDepth", cfg.MaxQueueDepth),
+		zap.Bool("reconcileAutoFix", cfg.ReconcileAutoFix),
+	)
+}
+
 // warmupScripts 预加载 Lua 脚本到 Redis
 func (s *VoucherOrderService) warmupScripts(ctx context.Context) {
 	if s.rdb == nil || s.seckillLua == nil {
@@ -106,50 +202,15 @@ func (s *VoucherOrderService) warmupScripts(ctx context.Context) {
 	}
 }
 
-// Seckill 秒杀下单
+// Seckill 秒杀下单：时间窗口、星期限制、库存与限购均在 Lua 脚本内校验；
+// 窗口未缓存时回源数据库校验并写入缓存，之后的请求不再查询数据库
 func (s *VoucherOrderService) Seckill(ctx context.Context, voucherID, userID int64) (int64, error) {
 	start := time.Now()
-	var info struct {
-		ID        int64
-		BeginTime time.Time
-		EndTime   time.Time
-		Stock     int
-		Status    int
-	}
-	// 查询秒杀券信息
-	err := s.db.WithContext(ctx).Table("tb_voucher AS v").
-		Select("v.id, v.status, sv.begin_time, sv.end_time, sv.stock").
-		Joins("LEFT JOIN tb_seckill_voucher sv ON v.id = sv.voucher_id").
-		Where("v.id = ?", voucherID).
-		Take(&info).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		s.metrics.ObserveSeckill("rejected", "not_found", time.Since(start))
-		return 0, errors.New("优惠券不存在")
-	}
-	if err != nil {
-		s.metrics.ObserveSeckill("rejected", "query_error", time.Since(start))
-		return 0, err
-	}
-	if info.Status != 1 {
-		s.metrics.ObserveSeckill("rejected", "inactive", time.Since(start))
-		return 0, errors.New("优惠券已下架或过期")
-	}
-
-	now := time.Now()
-	if now.Before(info.BeginTime) {
-		s.metrics.ObserveSeckill("rejected", "not_started", time.Since(start))
-		return 0, errors.New("秒杀尚未开始")
+	// 订单积压超过阈值时快速失败，避免无限制地堆积待落库订单
+	if s.overloaded() {
+		s.metrics.ObserveSeckill("rejected", "busy", time.Since(start))
+		return 0, ErrOrderQueueBusy
 	}
-	if now.After(info.EndTime) {
-		s.metrics.ObserveSeckill("rejected", "ended", time.Since(start))
-		return 0, errors.New("秒杀已结束")
-	}
-	// 库存不足直接返回
-	if info.Stock <= 0 {
-		s.metrics.ObserveSeckill("rejected", "no_stock", time.Since(start))
-		return 0, errors.New("库存不足")
-	}
-
 	// 生成订单ID
 	orderID, err := s.idWorker.NextId(ctx, "order")
 	if err != nil {
@@ -157,11 +218,13 @@ func (s *VoucherOrderService) Seckill(ctx context.Context, voucherID, userID int
 		return 0, err
 	}
 
-	stockKey := fmt.Sprintf(stockKeyFmt, voucherID)
-	orderSetKey := fmt.Sprintf(orderSetFmt, voucherID)
-
-	// 执行 Lua 脚本，完成库存校验与扣减、用户下单资格校验与标记
-	res, err := s.seckillLua.Run(ctx, s.rdb, []string{stockKey, orderSetKey}, userID).Int()
+	res, err := s.runSeckillLua(ctx, voucherID, userID)
+	if err == nil && res == 5 {
+		if err := s.loadSeckillWindow(ctx, voucherID, start); err != nil {
+			return 0, err
+		}
+		res, err = s.runSeckillLua(ctx, voucherID, userID)
+	}
 	if err != nil {
 		s.metrics.ObserveSeckill("rejected", "lua_error", time.Since(start))
 		return 0, err
@@ -176,10 +239,13 @@ func (s *VoucherOrderService) Seckill(ctx context.Context, voucherID, userID int
 			VoucherID: voucherID,
 			CreatedAt: time.Now().Unix(),
 		}
+		s.trackQueued(ctx, orderID)
 		if err := s.publishOrder(ctx, msg); err != nil {
-			s.log.Error("publish kafka failed, queued for retry", zap.Error(err), zap.Int64("orderId", orderID))
-			s.metrics.ObserveSeckill("accepted", "publish_failed", time.Since(start))
-			return orderID, nil
+			// 消息未投递成功则订单不会落库，回滚 Redis 中的库存与下单资格，由用户重新下单
+			s.compensateRedis(context.WithoutCancel(ctx), msg)
+			s.log.Error("publish kafka failed, redis compensated", zap.Error(err), zap.Int64("orderId", orderID))
+			s.metrics.ObserveSeckill("rejected", "publish_failed", time.Since(start))
+			return 0, errors.New("下单失败，请稍后重试")
 		}
 		s.metrics.ObserveSeckill("accepted", "ok", time.Since(start))
 		return orderID, nil
@@ -189,12 +255,109 @@ func (s *VoucherOrderService) Seckill(ctx context.Context, voucherID, userID int
 	case 2:
 		s.metrics.ObserveSeckill("rejected", "duplicate", time.Since(start))
 		return 0, errors.New("每人限购一单")
+	case 3:
+		s.metrics.ObserveSeckill("rejected", "not_started", time.Since(start))
+		return 0, errors.New("秒杀尚未开始")
+	case 4:
+		s.metrics.ObserveSeckill("rejected", "ended", time.Since(start))
+		return 0, errors.New("秒杀已结束")
+	case 6:
+		s.metrics.ObserveSeckill("rejected", "rule", time.Since(start))
+		return 0, &VoucherRuleError{Reason: VoucherRuleWeekdayForbidden, Message: "优惠券今日不可用"}
 	default:
 		s.metrics.ObserveSeckill("rejected", "lua_failed", time.Since(start))
 		return 0, errors.New("秒杀失败")
 	}
 }
 
+// runSeckillLua 执行秒杀脚本，完成时间窗口与星期校验、库存校验与扣减、用户下单资格校验与标记
+func (s *VoucherOrderService) runSeckillLua(ctx context.Context, voucherID, userID int64) (int, error) {
+	now := time.Now()
+	keys := []string{
+		fmt.Sprintf(stockKeyFmt, voucherID),
+		fmt.Sprintf(orderSetFmt, voucherID),
+		fmt.Sprintf(seckillWindowFmt, voucherID),
+	}
+	return s.seckillLua.Run(ctx, s.rdb, keys, userID, now.Unix(), isoWeekday(now)).Int()
+}
+
+// cacheSeckillWindow 缓存秒杀时间窗口与星期限制，秒杀结束一天后自动过期
+func cacheSeckillWindow(ctx context.Context, c redis.Cmdable, voucherID int64, begin, end time.Time, weekdays string) error {
+	days, err := parseWeekdays(weekdays)
+	if err != nil {
+		return err
+	}
+	list := make([]string, 0, len(days))
+	for d := 1; d <= 7; d++ {
+		if days[d] {
+			list = append(list, strconv.Itoa(d))
+		}
+	}
+	key := fmt.Sprintf(seckillWindowFmt, voucherID)
+	if err := c.HSet(ctx, key, "begin", begin.Unix(), "end", end.Unix(), "weekdays", strings.Join(list, ",")).Err(); err != nil {
+		return err
+	}
+	return c.ExpireAt(ctx, key, end.Add(24*time.Hour)).Err()
+}
+
+// loadSeckillWindow 窗口缓存未命中时回源数据库校验秒杀券，校验通过后缓存时间窗口与星期限制
+func (s *VoucherOrderService) loadSeckillWindow(ctx context.Context, voucherID int64, start time.Time) error {
+	var info struct {
+		ID        int64
+		ShopID    int64
+		BeginTime time.Time
+		EndTime   time.Time
+		Stock     int
+		Status    int
+		MinSpend  int64
+		Weekdays  string
+		ShopIDs   string `gorm:"column:applicable_shop_ids"`
+	}
+	// 查询秒杀券信息
+	err := s.db.WithContext(ctx).Table("tb_voucher AS v").
+		Select("v.id, v.shop_id, v.status, v.min_spend, v.weekdays, v.applicable_shop_ids, sv.begin_time, sv.end_time, sv.stock").
+		Joins("LEFT JOIN tb_seckill_voucher sv ON v.id = sv.voucher_id").
+		Where("v.id = ?", voucherID).
+		Take(&info).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.metrics.ObserveSeckill("rejected", "not_found", time.Since(start))
+		return errors.New("优惠券不存在")
+	}
+	if err != nil {
+		s.metrics.ObserveSeckill("rejected", "query_error", time.Since(start))
+		return err
+	}
+	if info.Status != model.VoucherStatusOnline {
+		s.metrics.ObserveSeckill("rejected", "inactive", time.Since(start))
+		return errors.New("优惠券已下架或过期")
+	}
+
+	now := time.Now()
+	if now.Before(info.BeginTime) {
+		s.metrics.ObserveSeckill("rejected", "not_started", time.Since(start))
+		return errors.New("秒杀尚未开始")
+	}
+	if now.After(info.EndTime) {
+		s.metrics.ObserveSeckill("rejected", "ended", time.Since(start))
+		return errors.New("秒杀已结束")
+	}
+	// 校验优惠券使用规则（领取阶段）
+	voucher := &model.Voucher{ID: info.ID, ShopID: info.ShopID, MinSpend: info.MinSpend, Weekdays: info.Weekdays, ShopIDs: info.ShopIDs}
+	if err := EvaluateVoucherRules(voucher, VoucherRuleContext{Stage: VoucherRuleStageClaim, Now: now}); err != nil {
+		s.metrics.ObserveSeckill("rejected", "rule", time.Since(start))
+		return err
+	}
+	// 库存不足直接返回
+	if info.Stock <= 0 {
+		s.metrics.ObserveSeckill("rejected", "no_stock", time.Since(start))
+		return errors.New("库存不足")
+	}
+	if err := cacheSeckillWindow(ctx, s.rdb, voucherID, info.BeginTime, info.EndTime, info.Weekdays); err != nil {
+		s.log.Warn("cache seckill window failed", zap.Int64("voucherId", voucherID), zap.Error(err))
+	}
+	return nil
+}
+
 type orderMessage struct {
 	OrderID     int64  `json:"orderId"`
 	UserID      int64  `json:"userId"`
@@ -205,8 +368,15 @@ type orderMessage struct {
 	LastError   string `json:"lastError,omitempty"` // 最后一次错误信息
 }
 
-// publishOrder 将订单消息发送到 Kafka
+// publishOrder 将订单消息发送到配置的队列（Kafka、事务发件箱或 Redis Stream）
 func (s *VoucherOrderService) publishOrder(ctx context.Context, msg orderMessage) error {
+	switch {
+	case s.queue == OrderQueueStream:
+		return s.publishStream(ctx, msg)
+	case s.queue == OrderQueueOutbox && s.outbox != nil:
+		// 写入发件箱即视为投递成功，由中继协程异步发送到订单主题；秒杀下单此时尚无数据库事务，单独写入发件箱
+		return s.outbox.Enqueue(s.db.WithContext(ctx), s.writer.Topic, strconv.FormatInt(msg.UserID, 10), msg)
+	}
 	return s.publishKafkaMessage(ctx, s.writer, msg, "")
 }
 
@@ -218,77 +388,99 @@ const (
 	consumeError
 )
 
-// consumeLoop 通用消费循环：负责拉取消息、反序列化、埋点与提交 offset 具体业务由 handler(hui diao) 处理
-func (s *VoucherOrderService) consumeLoop(
-	ctx context.Context,
-	reader *kafka.Reader,
-	name string,
-	handler func(context.Context, orderMessage, kafka.Message, string, time.Time, trace.Span) (consumeOutcome, error),
-) {
-	s.log.Info(fmt.Sprintf("%s started", name))
+// consumeHandler 处理单条订单消息的业务回调
+type consumeHandler func(context.Context, orderMessage, kafka.Message, string, time.Time, trace.Span) (consumeOutcome, error)
+
+// consumeLoop 通用消费循环：负责拉取消息、反序列化、埋点与提交 offset 具体业务由 handler(hui diao) 处理；
+// workers 大于 1 时交给 worker 池并发处理，否则逐条串行处理
+func (s *VoucherOrderService) consumeLoop(ctx context.Context, reader *kafka.Reader, name string, workers int, handler consumeHandler) {
+	s.log.Info(fmt.Sprintf("%s started", name), zap.Int("workers", workers))
+	var pool *orderWorkerPool
+	if workers > 1 {
+		pool = s.newOrderWorkerPool(ctx, reader, name, workers, handler)
+	}
 	for {
 		msg, err := reader.FetchMessage(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				if pool != nil {
+					// 等待已分发的消息处理完成并提交 offset
+					pool.stop()
+				}
+				s.log.Info(fmt.Sprintf("%s stopped", name))
+				return
+			}
 			s.log.Error(fmt.Sprintf("%s fetch message error", name), zap.Error(err))
 			time.Sleep(time.Second)
 			continue
 		}
 
+		// 已拉取的消息在退出信号到来后仍需提交 offset
+		commitCtx := context.WithoutCancel(ctx)
 		var payload orderMessage
 		if err := json.Unmarshal(msg.Value, &payload); err != nil {
 			s.log.Error(fmt.Sprintf("%s parse message error", name), zap.Error(err))
-			_ = reader.CommitMessages(ctx, msg)
-			continue
-		}
-
-		topic := msg.Topic
-		if topic == "" {
-			topic = "unknown"
-		}
-		consumeCtx := observability.ExtractKafkaContext(ctx, msg.Headers)
-		consumeCtx, span := s.startKafkaConsumeSpan(consumeCtx, topic)
-		start := time.Now()
-
-		outcome, err := handler(consumeCtx, payload, msg, topic, start, span)
-		if err != nil {
-			span.RecordError(err)
-		}
-
-		switch outcome {
-		case consumeRetryEnqueued:
-			s.metrics.ObserveKafkaConsume(topic, "retry", time.Since(start))
-			s.log.Info(fmt.Sprintf("%s retry enqueued, committing offset", name),
-				zap.Int64("orderId", payload.OrderID),
-				zap.Int64("voucherId", payload.VoucherID),
-			)
-			span.End()
-			if err := reader.CommitMessages(ctx, msg); err != nil {
-				s.log.Error(fmt.Sprintf("%s commit error", name), zap.Error(err), zap.Int64("orderId", payload.OrderID))
-			}
-			continue
-		case consumeError:
-			s.metrics.ObserveKafkaConsume(topic, "error", time.Since(start))
-			if err != nil {
-				s.log.Error(fmt.Sprintf("%s handle error", name), zap.Error(err), zap.Int64("orderId", payload.OrderID), zap.Int64("voucherId", payload.VoucherID))
+			if pool != nil {
+				pool.skip(commitCtx, msg)
 			} else {
-				s.log.Error(fmt.Sprintf("%s handle error", name), zap.Int64("orderId", payload.OrderID), zap.Int64("voucherId", payload.VoucherID))
+				_ = reader.CommitMessages(commitCtx, msg)
 			}
-			span.End()
-			time.Sleep(200 * time.Millisecond)
 			continue
-		default:
-			s.metrics.ObserveKafkaConsume(topic, "success", time.Since(start))
-			span.End()
-			if err := reader.CommitMessages(ctx, msg); err != nil {
+		}
+		if pool != nil {
+			pool.dispatch(msg, payload)
+			continue
+		}
+		if s.consumeMessage(ctx, name, msg, payload, handler) {
+			if err := reader.CommitMessages(commitCtx, msg); err != nil {
 				s.log.Error(fmt.Sprintf("%s commit error", name), zap.Error(err), zap.Int64("orderId", payload.OrderID))
 			}
 		}
 	}
 }
 
+// consumeMessage 处理一条已解析的消息并记录埋点，返回是否需要提交 offset
+func (s *VoucherOrderService) consumeMessage(ctx context.Context, name string, msg kafka.Message, payload orderMessage, handler consumeHandler) bool {
+	topic := msg.Topic
+	if topic == "" {
+		topic = "unknown"
+	}
+	consumeCtx := observability.ExtractKafkaContext(ctx, msg.Headers)
+	consumeCtx, span := s.startKafkaConsumeSpan(consumeCtx, topic)
+	defer span.End()
+	start := time.Now()
+
+	outcome, err := handler(consumeCtx, payload, msg, topic, start, span)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	switch outcome {
+	case consumeRetryEnqueued:
+		s.metrics.ObserveKafkaConsume(topic, "retry", time.Since(start))
+		s.log.Info(fmt.Sprintf("%s retry enqueued, committing offset", name),
+			zap.Int64("orderId", payload.OrderID),
+			zap.Int64("voucherId", payload.VoucherID),
+		)
+		return true
+	case consumeError:
+		s.metrics.ObserveKafkaConsume(topic, "error", time.Since(start))
+		if err != nil {
+			s.log.Error(fmt.Sprintf("%s handle error", name), zap.Error(err), zap.Int64("orderId", payload.OrderID), zap.Int64("voucherId", payload.VoucherID))
+		} else {
+			s.log.Error(fmt.Sprintf("%s handle error", name), zap.Int64("orderId", payload.OrderID), zap.Int64("voucherId", payload.VoucherID))
+		}
+		time.Sleep(200 * time.Millisecond)
+		return false
+	default:
+		s.metrics.ObserveKafkaConsume(topic, "success", time.Since(start))
+		return true
+	}
+}
+
 // consumeOrders 异步创建订单（Kafka 消费端）
 func (s *VoucherOrderService) consumeOrders(ctx context.Context) {
-	s.consumeLoop(ctx, s.reader, "consumeOrders", func(consumeCtx context.Context, payload orderMessage, _ kafka.Message, _ string, _ time.Time, _ trace.Span) (consumeOutcome, error) {
+	s.consumeLoop(ctx, s.reader, "consumeOrders", s.workers, func(consumeCtx context.Context, payload orderMessage, _ kafka.Message, _ string, _ time.Time, _ trace.Span) (consumeOutcome, error) {
 		if err := s.handleConsume(consumeCtx, payload); err != nil {
 			if errors.Is(err, errRetryEnqueued) {
 				return consumeRetryEnqueued, err
@@ -301,7 +493,8 @@ func (s *VoucherOrderService) consumeOrders(ctx context.Context) {
 
 // consumeRetryOrders 消费重试 Topic，按回退时间再次执行
 func (s *VoucherOrderService) consumeRetryOrders(ctx context.Context) {
-	s.consumeLoop(ctx, s.retryReader, "consumeRetryOrders", func(consumeCtx context.Context, payload orderMessage, _ kafka.Message, _ string, _ time.Time, _ trace.Span) (consumeOutcome, error) {
+	s.consumeLoop(ctx, s.retryReader, "consumeRetryOrders", 1, func(consumeCtx context.Context, payload orderMessage, msg kafka.Message, _ string, _ time.Time, _ trace.Span) (consumeOutcome, error) {
+		applyRetryHeaders(&payload, msg.Headers)
 		s.log.Info("consumeRetryOrders received",
 			zap.Int64("orderId", payload.OrderID),
 			zap.Int64("voucherId", payload.VoucherID),
@@ -320,7 +513,7 @@ func (s *VoucherOrderService) consumeRetryOrders(ctx context.Context) {
 
 // consumeDLQ 消费死信队列 发送邮件告警
 func (s *VoucherOrderService) consumeDLQ(ctx context.Context) {
-	s.consumeLoop(ctx, s.dlqReader, "consumeDLQ", func(_ context.Context, payload orderMessage, _ kafka.Message, _ string, _ time.Time, span trace.Span) (consumeOutcome, error) {
+	s.consumeLoop(ctx, s.dlqReader, "consumeDLQ", 1, func(_ context.Context, payload orderMessage, _ kafka.Message, _ string, _ time.Time, span trace.Span) (consumeOutcome, error) {
 		if s.smtpCfg.Host != "" {
 			subject := fmt.Sprintf("[DLQ] seckill order failed: %d", payload.OrderID)
 			body := fmt.Sprintf(
@@ -352,11 +545,17 @@ func (s *VoucherOrderService) handleConsume(ctx context.Context, payload orderMe
 	if payload.NextRetryAt > 0 {
 		// 计算距离NextRetryAt时间点还有多久
 		delay := time.Until(time.Unix(payload.NextRetryAt, 0))
-		// 大于0 代表还没有到重试时间 等delay时间后再继续处理
+		// 大于0 代表还没有到重试时间 等delay时间后再继续处理；等待期间收到退出信号则放弃，消息未提交会在重启后重新投递
 		if delay > 0 {
-			time.Sleep(delay)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 	}
+	// 开始落库后不再响应退出信号，保证事务完整执行
+	ctx = context.WithoutCancel(ctx)
 
 	// 创建订单事务
 	if err := s.createOrderTx(ctx, payload); err != nil {
@@ -369,6 +568,9 @@ func (s *VoucherOrderService) handleConsume(ctx context.Context, payload orderMe
 		// 失败则进入重试队列
 		return s.publishRetryOrDLQ(ctx, payload, err)
 	}
+	// 订单落库后加入超时取消队列，重复消费时 ZADD 覆盖同一成员
+	s.scheduleOrderTimeout(ctx, payload.OrderID, time.Unix(payload.CreatedAt, 0))
+	s.untrackQueued(ctx, payload.OrderID)
 	s.log.Info("handleConsume success",
 		zap.Int64("orderId", payload.OrderID),
 		zap.Int64("voucherId", payload.VoucherID),
@@ -378,6 +580,27 @@ func (s *VoucherOrderService) handleConsume(ctx context.Context, payload orderMe
 	)
 	return nil
 }
+const (
+	retryCountHeader  = "x-retry-count"
+	nextRetryAtHeader = "x-next-retry-at"
+)
+
+// applyRetryHeaders 消息体缺少重试信息时（如旧版本生产者）从消息头补齐
+func applyRetryHeaders(payload *orderMessage, headers []kafka.Header) {
+	for _, h := range headers {
+		switch h.Key {
+		case retryCountHeader:
+			if payload.RetryCount == 0 {
+				payload.RetryCount, _ = strconv.Atoi(string(h.Value))
+			}
+		case nextRetryAtHeader:
+			if payload.NextRetryAt == 0 {
+				payload.NextRetryAt, _ = strconv.ParseInt(string(h.Value), 10, 64)
+			}
+		}
+	}
+}
+
 // retryPhaseLabel 返回重试阶段标签
 func retryPhaseLabel(retryCount int) string {
 	switch retryCount {
@@ -452,8 +675,8 @@ func (s *VoucherOrderService) publishKafkaMessage(ctx context.Context, writer *k
 		return err
 	}
 	message := kafka.Message{
-		// 使用 voucherId 作为 key，保证同券消息落到同一分区
-		Key:   []byte(strconv.FormatInt(payload.VoucherID, 10)),
+		// 使用 userId 作为 key，保证同一用户的订单消息落到同一分区、按序消费
+		Key:   []byte(strconv.FormatInt(payload.UserID, 10)),
 		Value: data,
 	}
 	topic := writer.Topic
@@ -463,6 +686,13 @@ func (s *VoucherOrderService) publishKafkaMessage(ctx context.Context, writer *k
 	spanCtx, span := s.startKafkaProduceSpan(ctx, topic)
 	defer span.End()
 	observability.InjectKafkaHeaders(spanCtx, &message.Headers)
+	if payload.RetryCount > 0 {
+		// 重试次数与下次重试时间同时写入消息头，便于在不解析消息体的情况下排查退避进度
+		message.Headers = append(message.Headers,
+			kafka.Header{Key: retryCountHeader, Value: []byte(strconv.Itoa(payload.RetryCount))},
+			kafka.Header{Key: nextRetryAtHeader, Value: []byte(strconv.FormatInt(payload.NextRetryAt, 10))},
+		)
+	}
 	if err := writer.WriteMessages(spanCtx, message); err != nil {
 		span.RecordError(err)
 		if errorMsg != "" {
@@ -497,13 +727,18 @@ func (s *VoucherOrderService) createOrderTx(ctx context.Context, payload orderMe
 			CreateTime: nowTime,
 			UpdateTime: nowTime,
 		}
-		if err := tx.Create(order).Error; err != nil {
+		// INSERT ... ON DUPLICATE KEY：同一订单ID重复投递时不报错也不插入，据影响行数判断是否已处理
+		created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(order)
+		if err := created.Error; err != nil {
 			if isDuplicateKey(err) {
-				// 已处理过该订单，避免重复扣减库存
 				return nil
 			}
 			return err
 		}
+		if created.RowsAffected == 0 {
+			// 已处理过该订单，避免重复扣减库存
+			return nil
+		}
 		// 订单创建成功后再扣减库存，避免重复消费导致多次扣减
 		// SQL - UPDATE ... SET stock = stock - 1 WHERE stock > 0;
 		// 这是一条原子SQL UPDATE ... WHERE ... 执行时会对目标行加锁
@@ -517,7 +752,7 @@ func (s *VoucherOrderService) createOrderTx(ctx context.Context, payload orderMe
 			return errDBStockNotEnough
 		}
 		return nil
-	}); err != nil { 
+	}); err != nil {
 		return err
 	}
 	return nil
@@ -551,8 +786,18 @@ func isRetryableErr(err error) bool {
 	}
 	return true
 }
-// compensateRedis 补偿 Redis 库存和用户下单资格
+// compensateRedis 补偿 Redis 库存和用户下单资格并移出待落库订单统计；同一订单只补偿一次，避免消息重放时库存被重复回补
 func (s *VoucherOrderService) compensateRedis(ctx context.Context, payload orderMessage) {
+	s.untrackQueued(ctx, payload.OrderID)
+	marker := utils.ORDER_COMPENSATE_KEY + strconv.FormatInt(payload.OrderID, 10)
+	first, err := s.rdb.SetNX(ctx, marker, 1, time.Duration(utils.ORDER_COMPENSATE_TTL)*time.Minute).Result()
+	if err != nil {
+		s.log.Error("compensate redis marker failed", zap.Int64("orderId", payload.OrderID), zap.Error(err))
+		return
+	}
+	if !first {
+		return
+	}
 	stockKey := fmt.Sprintf(stockKeyFmt, payload.VoucherID)
 	orderSetKey := fmt.Sprintf(orderSetFmt, payload.VoucherID)
 	// 管道补偿操作
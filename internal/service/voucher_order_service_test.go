@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"hmdp-backend/internal/config"
 	"hmdp-backend/internal/model"
 	"hmdp-backend/internal/utils"
 
@@ -165,7 +166,7 @@ func TestSeckillNoOversell(t *testing.T) {
 	writer, retryWriter, dlqWriter, reader, retryReader, cleanup := newTestKafka(t, ctx)
 	defer cleanup()
 
-	svc := NewVoucherOrderService(db, rdb, writer, retryWriter, dlqWriter, reader, retryReader, nil, utils.SMTPConfig{}, nil, newTestLogger(t))
+	svc := NewVoucherOrderService(db, rdb, writer, retryWriter, dlqWriter, reader, retryReader, nil, utils.SMTPConfig{}, config.OrderConfig{}, nil, nil, nil, newTestLogger(t))
 
 	// 使用现有的券 ID
 	const voucherID = int64(12)
@@ -185,6 +186,8 @@ func TestSeckillNoOversell(t *testing.T) {
 		}).Error; err != nil {
 		t.Fatalf("prepare seckill voucher: %v", err)
 	}
+	// 清除时间窗口缓存，下单时按新的窗口回源重建
+	_ = rdb.Del(ctx, fmt.Sprintf(seckillWindowFmt, voucherID)).Err()
 
 	// 并发请求
 	const workers = 200
@@ -252,7 +255,7 @@ func TestSeckillOneOrderPerUser(t *testing.T) {
 	writer, retryWriter, dlqWriter, reader, retryReader, cleanup := newTestKafka(t, ctx)
 	defer cleanup()
 
-	svc := NewVoucherOrderService(db, rdb, writer, retryWriter, dlqWriter, reader, retryReader, nil, utils.SMTPConfig{}, nil, newTestLogger(t))
+	svc := NewVoucherOrderService(db, rdb, writer, retryWriter, dlqWriter, reader, retryReader, nil, utils.SMTPConfig{}, config.OrderConfig{}, nil, nil, nil, newTestLogger(t))
 
 	const voucherID = int64(12)
 
@@ -312,7 +315,7 @@ func TestSeckillSingleUserRedisFlow(t *testing.T) {
 	writer, retryWriter, dlqWriter, reader, retryReader, cleanup := newTestKafka(t, ctx)
 	defer cleanup()
 
-	svc := NewVoucherOrderService(db, rdb, writer, retryWriter, dlqWriter, reader, retryReader, nil, utils.SMTPConfig{}, nil, newTestLogger(t))
+	svc := NewVoucherOrderService(db, rdb, writer, retryWriter, dlqWriter, reader, retryReader, nil, utils.SMTPConfig{}, config.OrderConfig{}, nil, nil, nil, newTestLogger(t))
 
 	const voucherID = int64(12)
 	const userID = int64(2)
@@ -325,8 +328,8 @@ func TestSeckillSingleUserRedisFlow(t *testing.T) {
 	t.Logf("seckill accepted, orderID=%d", orderID)
 }
 
-// TestSeckillKafkaDownWritesRetry 模拟 Kafka 不可用时进入 Redis 补偿队列
-func TestSeckillKafkaDownWritesRetry(t *testing.T) {
+// TestSeckillKafkaDownCompensatesRedis 校验 Kafka 不可用时下单失败并回滚 Redis 库存与下单资格
+func TestSeckillKafkaDownCompensatesRedis(t *testing.T) {
 	ctx := context.Background()
 
 	dsn := os.Getenv("TEST_DSN")
@@ -370,9 +373,9 @@ func TestSeckillKafkaDownWritesRetry(t *testing.T) {
 		t.Fatalf("prepare seckill voucher: %v", err)
 	}
 
-	// 预热 Redis 库存与限购集合，并清理补偿队列
+	// 预热 Redis 库存与限购集合
 	_ = rdb.Set(ctx, fmt.Sprintf(stockKeyFmt, voucherID), 100, 0).Err()
-	_ = rdb.Del(ctx, fmt.Sprintf(orderSetFmt, voucherID)).Err()
+	_ = rdb.Del(ctx, fmt.Sprintf(orderSetFmt, voucherID), fmt.Sprintf(seckillWindowFmt, voucherID)).Err()
 
 	// 构造不可用的 Kafka 连接，模拟写入失败
 	writer := &kafka.Writer{
@@ -422,14 +425,25 @@ func TestSeckillKafkaDownWritesRetry(t *testing.T) {
 		_ = retryReader.Close()
 	}()
 
-	svc := NewVoucherOrderService(db, rdb, writer, retryWriter, dlqWriter, reader, retryReader, nil, utils.SMTPConfig{}, nil, newTestLogger(t))
+	svc := NewVoucherOrderService(db, rdb, writer, retryWriter, dlqWriter, reader, retryReader, nil, utils.SMTPConfig{}, config.OrderConfig{}, nil, nil, nil, newTestLogger(t))
 
-	orderID, err := svc.Seckill(ctx, voucherID, userID)
+	if _, err := svc.Seckill(ctx, voucherID, userID); err == nil {
+		t.Fatalf("expected seckill to fail when kafka is down")
+	}
+	stock, err := rdb.Get(ctx, fmt.Sprintf(stockKeyFmt, voucherID)).Int()
 	if err != nil {
-		t.Fatalf("seckill failed: %v", err)
+		t.Fatalf("get stock: %v", err)
+	}
+	if stock != 100 {
+		t.Fatalf("expected stock compensated to 100, got %d", stock)
+	}
+	member, err := rdb.SIsMember(ctx, fmt.Sprintf(orderSetFmt, voucherID), userID).Result()
+	if err != nil {
+		t.Fatalf("check order set: %v", err)
+	}
+	if member {
+		t.Fatalf("expected user removed from order set")
 	}
-	t.Logf("seckill accepted, orderID=%d", orderID)
-
 }
 
 // TestDuplicateOrderIDReturns1062 插入相同 orderId，验证 MySQL 返回 1062
@@ -538,3 +552,22 @@ func TestCreateOrderTxDuplicateId(t *testing.T) {
 		t.Fatalf("expected 1 order record, got %d", count)
 	}
 }
+
+// TestApplyRetryHeaders 校验重试信息可从消息头补齐，且不覆盖消息体中已有的值
+func TestApplyRetryHeaders(t *testing.T) {
+	headers := []kafka.Header{
+		{Key: retryCountHeader, Value: []byte("2")},
+		{Key: nextRetryAtHeader, Value: []byte("1700000000")},
+	}
+	var payload orderMessage
+	applyRetryHeaders(&payload, headers)
+	if payload.RetryCount != 2 || payload.NextRetryAt != 1700000000 {
+		t.Fatalf("unexpected payload from headers: %+v", payload)
+	}
+
+	payload = orderMessage{RetryCount: 3, NextRetryAt: 1800000000}
+	applyRetryHeaders(&payload, headers)
+	if payload.RetryCount != 3 || payload.NextRetryAt != 1800000000 {
+		t.Fatalf("headers should not override payload: %+v", payload)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hmdp-backend/internal/model"
+)
+
+// VoucherRuleStage 规则校验所处的业务阶段
+type VoucherRuleStage string
+
+const (
+	VoucherRuleStageClaim    VoucherRuleStage = "claim"    // 领取/抢购
+	VoucherRuleStagePay      VoucherRuleStage = "pay"      // 支付
+	VoucherRuleStageWriteOff VoucherRuleStage = "writeOff" // 到店核销
+)
+
+// VoucherRuleReason 规则拒绝原因，供前端区分提示
+type VoucherRuleReason string
+
+const (
+	VoucherRuleMinSpendNotMet   VoucherRuleReason = "min_spend_not_met"
+	VoucherRuleWeekdayForbidden VoucherRuleReason = "weekday_not_allowed"
+	VoucherRuleShopMismatch     VoucherRuleReason = "shop_not_applicable"
+	VoucherRuleInvalidConfig    VoucherRuleReason = "invalid_rule_config"
+)
+
+// VoucherRuleError 优惠券使用规则校验失败
+type VoucherRuleError struct {
+	Reason  VoucherRuleReason
+	Message string
+}
+
+func (e *VoucherRuleError) Error() string { return e.Message }
+
+// VoucherRuleContext 规则校验的上下文；为零值的字段表示当前阶段不校验该项
+type VoucherRuleContext struct {
+	Stage       VoucherRuleStage
+	Now         time.Time
+	ShopID      int64 // 核销门店
+	SpendAmount int64 // 本次消费金额（分）
+}
+
+// EvaluateVoucherRules 按阶段校验优惠券的结构化规则
+func EvaluateVoucherRules(v *model.Voucher, rc VoucherRuleContext) error {
+	if v == nil {
+		return nil
+	}
+	now := rc.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	// 星期限制：领取、支付、核销均需满足
+	if v.Weekdays != "" {
+		days, err := parseWeekdays(v.Weekdays)
+		if err != nil {
+			return &VoucherRuleError{Reason: VoucherRuleInvalidConfig, Message: "优惠券规则配置错误"}
+		}
+		if !days[isoWeekday(now)] {
+			return &VoucherRuleError{Reason: VoucherRuleWeekdayForbidden, Message: "优惠券今日不可用"}
+		}
+	}
+	if rc.Stage != VoucherRuleStageWriteOff {
+		return nil
+	}
+	// 门店限制：仅核销时校验
+	if rc.ShopID > 0 && !voucherApplicableToShop(v, rc.ShopID) {
+		return &VoucherRuleError{Reason: VoucherRuleShopMismatch, Message: "优惠券不适用于该门店"}
+	}
+	// 最低消费：仅核销时校验
+	if v.MinSpend > 0 && rc.SpendAmount < v.MinSpend {
+		return &VoucherRuleError{
+			Reason:  VoucherRuleMinSpendNotMet,
+			Message: fmt.Sprintf("消费金额未达到最低消费 %.2f 元", float64(v.MinSpend)/100),
+		}
+	}
+	return nil
+}
+
+// ValidateVoucherRuleConfig 创建优惠券时校验规则字段是否合法
+func ValidateVoucherRuleConfig(v *model.Voucher) error {
+	if v.MinSpend < 0 {
+		return &VoucherRuleError{Reason: VoucherRuleInvalidConfig, Message: "最低消费金额不能为负数"}
+	}
+	if v.Weekdays != "" {
+		if _, err := parseWeekdays(v.Weekdays); err != nil {
+			return &VoucherRuleError{Reason: VoucherRuleInvalidConfig, Message: err.Error()}
+		}
+	}
+	if _, err := parseShopIDs(v.ShopIDs); err != nil {
+		return &VoucherRuleError{Reason: VoucherRuleInvalidConfig, Message: err.Error()}
+	}
+	return nil
+}
+
+// voucherApplicableToShop 判断优惠券是否适用于指定门店
+func voucherApplicableToShop(v *model.Voucher, shopID int64) bool {
+	ids, err := parseShopIDs(v.ShopIDs)
+	if err != nil {
+		return false
+	}
+	if len(ids) == 0 {
+		return v.ShopID == shopID
+	}
+	for _, id := range ids {
+		if id == shopID {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWeekdays 解析 "1,2,3" 形式的星期列表（1=周一，7=周日）
+func parseWeekdays(raw string) (map[int]bool, error) {
+	days := make(map[int]bool, 7)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := strconv.Atoi(part)
+		if err != nil || d < 1 || d > 7 {
+			return nil, fmt.Errorf("invalid weekday: %q", part)
+		}
+		days[d] = true
+	}
+	return days, nil
+}
+
+// parseShopIDs 解析逗号分隔的门店ID列表
+func parseShopIDs(raw string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid shop id: %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// isoWeekday 返回 ISO 星期（1=周一，7=周日）
+func isoWeekday(t time.Time) int {
+	wd := int(t.Weekday())
+	if wd == 0 {
+		return 7
+	}
+	return wd
+}
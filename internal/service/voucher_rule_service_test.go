@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hmdp-backend/internal/model"
+)
+
+// TestEvaluateVoucherRules 覆盖星期、门店与最低消费三类规则的拒绝原因
+func TestEvaluateVoucherRules(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 12, 0, 0, 0, time.Local)
+	sunday := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	voucher := &model.Voucher{ID: 1, ShopID: 10, MinSpend: 5000, Weekdays: "1,2,3,4,5", ShopIDs: "10,11"}
+
+	cases := []struct {
+		name string
+		rc   VoucherRuleContext
+		want VoucherRuleReason
+	}{
+		{"claim on weekday", VoucherRuleContext{Stage: VoucherRuleStageClaim, Now: monday}, ""},
+		{"claim on weekend", VoucherRuleContext{Stage: VoucherRuleStageClaim, Now: sunday}, VoucherRuleWeekdayForbidden},
+		{"claim ignores spend", VoucherRuleContext{Stage: VoucherRuleStageClaim, Now: monday, SpendAmount: 1}, ""},
+		{"write-off other shop", VoucherRuleContext{Stage: VoucherRuleStageWriteOff, Now: monday, ShopID: 12, SpendAmount: 6000}, VoucherRuleShopMismatch},
+		{"write-off below min spend", VoucherRuleContext{Stage: VoucherRuleStageWriteOff, Now: monday, ShopID: 11, SpendAmount: 4999}, VoucherRuleMinSpendNotMet},
+		{"write-off ok", VoucherRuleContext{Stage: VoucherRuleStageWriteOff, Now: monday, ShopID: 11, SpendAmount: 5000}, ""},
+	}
+	for _, tc := range cases {
+		err := EvaluateVoucherRules(voucher, tc.rc)
+		if tc.want == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tc.name, err)
+			}
+			continue
+		}
+		var ruleErr *VoucherRuleError
+		if !errors.As(err, &ruleErr) || ruleErr.Reason != tc.want {
+			t.Fatalf("%s: want reason %s, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+// TestValidateVoucherRuleConfig 校验非法的规则配置会被拒绝
+func TestValidateVoucherRuleConfig(t *testing.T) {
+	bad := []*model.Voucher{
+		{MinSpend: -1},
+		{Weekdays: "0,8"},
+		{ShopIDs: "1,abc"},
+	}
+	for _, v := range bad {
+		if err := ValidateVoucherRuleConfig(v); err == nil {
+			t.Fatalf("expected error for %+v", v)
+		}
+	}
+	if err := ValidateVoucherRuleConfig(&model.Voucher{Weekdays: "6,7", ShopIDs: "3"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
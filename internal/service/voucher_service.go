@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"hmdp-backend/internal/model"
@@ -16,6 +19,8 @@ type VoucherService struct {
 	db         *gorm.DB
 	rdb        *redis.Client
 	seckillSvc *SeckillVoucherService
+	log        *zap.Logger
+	windows    sync.Map // voucherID -> seckillWindow，进程内秒杀时间窗口缓存
 }
 
 // VoucherWithSeckill 用于返回携带秒杀信息的券
@@ -29,6 +34,15 @@ type VoucherWithSeckill struct {
 	ActualValue int64      `gorm:"column:actual_value" json:"actualValue"`
 	Type        int        `gorm:"column:type" json:"type"`
 	Status      int        `gorm:"column:status" json:"status"`
+	MinSpend    int64      `gorm:"column:min_spend" json:"minSpend"`
+	Weekdays    string     `gorm:"column:weekdays" json:"weekdays"`
+	ShopIDs     string     `gorm:"column:applicable_shop_ids" json:"applicableShopIds"`
+	Kind        int        `gorm:"column:kind" json:"kind"`
+	DiscountPct int        `gorm:"column:discount_percent" json:"discountPercent"`
+	MaxDiscount int64      `gorm:"column:max_discount" json:"maxDiscount"`
+	FreeItem    string     `gorm:"column:free_item" json:"freeItem"`
+	GroupSize   int        `gorm:"column:group_size" json:"groupSize"`
+	Benefit     string     `gorm:"-" json:"benefit"` // 优惠内容展示文案
 	CreateTime  time.Time  `gorm:"column:create_time" json:"createTime"`
 	UpdateTime  time.Time  `gorm:"column:update_time" json:"updateTime"`
 	Stock       *int       `gorm:"column:stock" json:"stock,omitempty"`
@@ -36,12 +50,26 @@ type VoucherWithSeckill struct {
 	EndTime     *time.Time `gorm:"column:end_time" json:"endTime,omitempty"`
 }
 
-// NewVoucherService 创建 VoucherService 实例
-func NewVoucherService(db *gorm.DB, seckillSvc *SeckillVoucherService, rdb *redis.Client) *VoucherService {
-	return &VoucherService{db: db, seckillSvc: seckillSvc, rdb: rdb}
+// NewVoucherService 创建 VoucherService 实例并启动过期下线任务
+func NewVoucherService(db *gorm.DB, seckillSvc *SeckillVoucherService, rdb *redis.Client, log *zap.Logger) *VoucherService {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	svc := &VoucherService{db: db, seckillSvc: seckillSvc, rdb: rdb, log: log}
+	go svc.expireLoop(context.Background())
+	return svc
 }
 
 func (s *VoucherService) Create(ctx context.Context, voucher *model.Voucher) error {
+	if err := ValidateVoucherRuleConfig(voucher); err != nil {
+		return err
+	}
+	if err := validateVoucherKind(voucher); err != nil {
+		return err
+	}
+	if err := validateGroupSize(voucher.GroupSize); err != nil {
+		return err
+	}
 	return s.db.WithContext(ctx).Create(voucher).Error
 
 }
@@ -50,13 +78,32 @@ func (s *VoucherService) QueryVoucherOfShop(ctx context.Context, shopID int64) (
 	var vouchers []VoucherWithSeckill
 	query := `
         SELECT v.id, v.shop_id, v.title, v.sub_title, v.rules, v.pay_value,
-               v.actual_value, v.type, v.status, v.create_time, v.update_time,
+               v.actual_value, v.type, v.status, v.min_spend, v.weekdays, v.applicable_shop_ids,
+               v.kind, v.discount_percent, v.max_discount, v.free_item, v.group_size,
+               v.create_time, v.update_time,
                sv.stock, sv.begin_time, sv.end_time
         FROM tb_voucher v
         LEFT JOIN tb_seckill_voucher sv ON v.id = sv.voucher_id
-        WHERE v.shop_id = ? AND v.status = 1`
-	err := s.db.WithContext(ctx).Raw(query, shopID).Scan(&vouchers).Error
-	return vouchers, err
+        WHERE v.shop_id = ? AND v.status = ? AND (sv.end_time IS NULL OR sv.end_time > ?)`
+	err := s.db.WithContext(ctx).Raw(query, shopID, model.VoucherStatusOnline, time.Now()).Scan(&vouchers).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range vouchers {
+		v := &vouchers[i]
+		if v.Kind == 0 {
+			v.Kind = model.VoucherKindCash
+		}
+		v.Benefit = describeVoucherBenefit(&model.Voucher{
+			Kind:        v.Kind,
+			ActualValue: v.ActualValue,
+			MinSpend:    v.MinSpend,
+			DiscountPct: v.DiscountPct,
+			MaxDiscount: v.MaxDiscount,
+			FreeItem:    v.FreeItem,
+		})
+	}
+	return vouchers, nil
 }
 
 func (s *VoucherService) AddSeckillVoucher(ctx context.Context, voucher *model.Voucher) error {
@@ -84,7 +131,82 @@ func (s *VoucherService) AddSeckillVoucher(ctx context.Context, voucher *model.V
 	if err := s.seckillSvc.Create(ctx, sec); err != nil {
 		return err
 	}
-	// 将库存写入 Redis，供秒杀脚本扣减
-	stockKey := fmt.Sprintf("seckill:stock:vid:%d", voucher.ID)
-	return s.rdb.Set(ctx, stockKey, stock, 0).Err()
+	s.storeSeckillWindow(voucher.ID, begin, end)
+	// 将库存与时间窗口写入 Redis 供秒杀脚本校验与扣减，同时清理可能残留的限购集合
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fmt.Sprintf(stockKeyFmt, voucher.ID), stock, 0)
+		pipe.Del(ctx, fmt.Sprintf(orderSetFmt, voucher.ID))
+		return cacheSeckillWindow(ctx, pipe, voucher.ID, begin, end, voucher.Weekdays)
+	})
+	return err
+}
+
+// errSeckillNotFound 查询的券不存在或不是秒杀券
+var errSeckillNotFound = errors.New("秒杀券不存在")
+
+// SeckillStock 秒杀券的剩余库存
+type SeckillStock struct {
+	VoucherID int64 `json:"voucherId"`
+	Stock     int64 `json:"stock"`
+	SoldOut   bool  `json:"soldOut"`
+}
+
+// RemainingStock 查询秒杀券的实时剩余库存：优先读取秒杀脚本扣减的 Redis 库存，
+// 未预热或 Redis 不可用时回退到数据库中已落库的剩余库存
+func (s *VoucherService) RemainingStock(ctx context.Context, voucherID int64) (*SeckillStock, error) {
+	stock, err := s.rdb.Get(ctx, fmt.Sprintf(stockKeyFmt, voucherID)).Int64()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			s.log.Warn("read seckill stock from redis failed", zap.Int64("voucherId", voucherID), zap.Error(err))
+		}
+		var sec model.SeckillVoucher
+		err := s.db.WithContext(ctx).Select("voucher_id", "stock").Where("voucher_id = ?", voucherID).Take(&sec).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errSeckillNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		stock = int64(sec.Stock)
+	}
+	if stock < 0 {
+		stock = 0
+	}
+	return &SeckillStock{VoucherID: voucherID, Stock: stock, SoldOut: stock == 0}, nil
+}
+
+// SyncSeckillStock 按数据库修复未结束秒杀券的 Redis 数据：库存取 tb_seckill_voucher 的剩余库存，
+// 限购集合重建为持有有效订单的用户，时间窗口缓存清除后由下一次秒杀回源重建；
+// 应在没有秒杀流量时执行，避免与未落库的订单冲突
+func (s *VoucherService) SyncSeckillStock(ctx context.Context) (int, error) {
+	var secs []model.SeckillVoucher
+	if err := s.db.WithContext(ctx).Where("end_time > ?", time.Now()).Find(&secs).Error; err != nil {
+		return 0, err
+	}
+	for _, sec := range secs {
+		var userIDs []int64
+		if err := s.db.WithContext(ctx).Model(&model.VoucherOrder{}).
+			Where("voucher_id = ? AND status NOT IN ?", sec.VoucherID, []int{model.OrderStatusCancelled, model.OrderStatusRefunded}).
+			Distinct().
+			Pluck("user_id", &userIDs).Error; err != nil {
+			return 0, err
+		}
+		orderSetKey := fmt.Sprintf(orderSetFmt, sec.VoucherID)
+		_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, fmt.Sprintf(stockKeyFmt, sec.VoucherID), sec.Stock, 0)
+			pipe.Del(ctx, orderSetKey, fmt.Sprintf(seckillWindowFmt, sec.VoucherID))
+			if len(userIDs) > 0 {
+				members := make([]interface{}, len(userIDs))
+				for i, id := range userIDs {
+					members[i] = id
+				}
+				pipe.SAdd(ctx, orderSetKey, members...)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(secs), nil
 }
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"hmdp-backend/internal/config"
+)
+
+const (
+	defaultWarmupHotShops = 100
+	defaultWarmupTimeout  = 30 * time.Second
+)
+
+// WarmupService 在 HTTP 服务开始接收流量前预热缓存：
+// 热门商铺逻辑过期缓存、商铺类型列表、GEO 索引与商铺布隆过滤器
+type WarmupService struct {
+	shop     *ShopService
+	shopType *ShopTypeService
+	cfg      config.WarmupConfig
+	log      *zap.Logger
+}
+
+// NewWarmupService 创建 WarmupService 实例
+func NewWarmupService(shop *ShopService, shopType *ShopTypeService, cfg config.WarmupConfig, log *zap.Logger) *WarmupService {
+	if cfg.HotShops <= 0 {
+		cfg.HotShops = defaultWarmupHotShops
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultWarmupTimeout
+	}
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &WarmupService{shop: shop, shopType: shopType, cfg: cfg, log: log}
+}
+
+// Run 依次执行各预热步骤；未开启 failFast 时单步失败只记录告警，不阻塞启动
+func (s *WarmupService) Run(ctx context.Context) error {
+	if !s.cfg.Enabled {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	steps := []struct {
+		name string
+		run  func(context.Context) (int, error)
+	}{
+		{"shop_bloom", s.shop.ReloadBloom},
+		{"shop_geo", s.shop.ReloadGeo},
+		{"shop_type", func(ctx context.Context) (int, error) {
+			types, err := s.shopType.Refresh(ctx)
+			return len(types), err
+		}},
+		{"hot_shop", func(ctx context.Context) (int, error) {
+			return s.shop.WarmUpHotShops(ctx, s.cfg.HotShops)
+		}},
+	}
+	start := time.Now()
+	for _, step := range steps {
+		stepStart := time.Now()
+		count, err := step.run(ctx)
+		if err != nil {
+			if s.cfg.FailFast {
+				return fmt.Errorf("warmup %s: %w", step.name, err)
+			}
+			s.log.Warn("warmup step failed", zap.String("step", step.name), zap.Error(err))
+			continue
+		}
+		s.log.Info("warmup step done",
+			zap.String("step", step.name),
+			zap.Int("count", count),
+			zap.Duration("cost", time.Since(stepStart)),
+		)
+	}
+	s.log.Info("cache warmup finished", zap.Duration("cost", time.Since(start)))
+	return nil
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+
+	"hmdp-backend/pkg/lock"
+)
+
+const (
+	defaultCacheLockTTL    = time.Duration(LOCK_SHOP_TTL) * time.Second
+	defaultCacheRetryDelay = 50 * time.Millisecond
+)
+
+// CacheLoader 缓存未命中时从数据源加载数据，返回 nil 表示数据不存在
+type CacheLoader[T any] func(ctx context.Context) (*T, error)
+
+// CacheClient 基于 Redis 的通用缓存工具：
+// 空值缓存解决缓存穿透，互斥锁或逻辑过期解决热点 key 的缓存击穿
+type CacheClient struct {
+	rdb        *redis.Client
+	lockTTL    time.Duration
+	retryDelay time.Duration
+	group      singleflight.Group
+}
+
+// NewCacheClient 创建 CacheClient 实例
+func NewCacheClient(rdb *redis.Client) *CacheClient {
+	return &CacheClient{rdb: rdb, lockTTL: defaultCacheLockTTL, retryDelay: defaultCacheRetryDelay}
+}
+
+// Set 将 value 序列化为 JSON 写入 Redis，ttl 会叠加随机抖动，为 0 表示不过期
+func (c *CacheClient) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Set(ctx, key, data, JitterTTL(ttl)).Err()
+}
+
+// SetWithLogicalExpire 将 value 与逻辑过期时间（叠加随机抖动）一起写入 Redis，key 本身不设置 TTL
+func (c *CacheClient) SetWithLogicalExpire(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.Set(ctx, key, RedisData{ExpireTime: time.Now().Add(JitterTTL(ttl)), Data: value}, 0)
+}
+
+// Delete 删除缓存
+func (c *CacheClient) Delete(ctx context.Context, key string) error {
+	return c.rdb.Del(ctx, key).Err()
+}
+
+// QueryWithPassThrough 查询缓存，未命中时加载并回填；数据不存在时缓存空值 CACHE_NULL_TTL 分钟，防止缓存穿透
+func QueryWithPassThrough[T any](ctx context.Context, c *CacheClient, key string, ttl time.Duration, load CacheLoader[T]) (*T, error) {
+	value, hit, err := getCached[T](ctx, c, key)
+	if err != nil || hit {
+		return value, err
+	}
+	return loadAndSet(ctx, c, key, ttl, load)
+}
+
+// QueryWithMutex 在 QueryWithPassThrough 的基础上，未命中时只允许拿到互斥锁的请求重建缓存，其余请求休眠后重试；
+// 同一进程内对同一 key 的并发查询经 singleflight 合并，只有一个请求参与抢锁与回源
+func QueryWithMutex[T any](ctx context.Context, c *CacheClient, key, lockKey string, ttl time.Duration, load CacheLoader[T]) (*T, error) {
+	// 合并后的查询不随首个调用方取消而中断，避免连带其他等待者失败
+	v, err, shared := c.group.Do(key, func() (interface{}, error) {
+		return queryWithMutex(context.WithoutCancel(ctx), c, key, lockKey, ttl, load)
+	})
+	value, _ := v.(*T)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	if shared {
+		// 共享结果时返回浅拷贝，调用方修改顶层字段互不影响
+		cp := *value
+		return &cp, nil
+	}
+	return value, nil
+}
+
+func queryWithMutex[T any](ctx context.Context, c *CacheClient, key, lockKey string, ttl time.Duration, load CacheLoader[T]) (*T, error) {
+	for {
+		value, hit, err := getCached[T](ctx, c, key)
+		if err != nil || hit {
+			return value, err
+		}
+		mu := lock.New(c.rdb, lockKey, c.lockTTL)
+		locked, err := mu.TryLock(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !locked {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(c.retryDelay):
+			}
+			continue
+		}
+		// DoubleCheck：拿到锁后再查一次，避免重复加载
+		value, hit, err = getCached[T](ctx, c, key)
+		if err != nil || hit {
+			_ = mu.Unlock(ctx)
+			return value, err
+		}
+		value, err = loadAndSet(ctx, c, key, ttl, load)
+		_ = mu.Unlock(ctx)
+		return value, err
+	}
+}
+
+// QueryWithLogicalExpire 读取逻辑过期缓存：未命中直接返回 nil（需提前预热），
+// 已过期时由拿到互斥锁的请求异步重建，当前请求先返回旧值
+func QueryWithLogicalExpire[T any](ctx context.Context, c *CacheClient, key, lockKey string, ttl time.Duration, load CacheLoader[T]) (*T, error) {
+	cached, err := c.rdb.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) || (err == nil && cached == "") {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var wrapper struct {
+		ExpireTime time.Time `json:"expireTime"`
+		Data       *T        `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(cached), &wrapper); err != nil {
+		return nil, err
+	}
+	if wrapper.ExpireTime.After(time.Now()) {
+		return wrapper.Data, nil
+	}
+	// 重建耗时不可控，由看门狗续期直到重建结束
+	mu := lock.New(c.rdb, lockKey, c.lockTTL, lock.WithWatchdog())
+	locked, err := mu.TryLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return wrapper.Data, nil
+	}
+	// 重建与请求生命周期无关，使用独立的 context
+	go func() {
+		bg := context.Background()
+		defer func() { _ = mu.Unlock(bg) }()
+		value, err := load(bg)
+		if err != nil || value == nil {
+			return
+		}
+		_ = c.SetWithLogicalExpire(bg, key, value, ttl)
+	}()
+	return wrapper.Data, nil
+}
+
+// getCached 读取缓存，hit 为 true 时 value 可能为 nil（命中空值）
+func getCached[T any](ctx context.Context, c *CacheClient, key string) (*T, bool, error) {
+	cached, err := c.rdb.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if cached == "" {
+		return nil, true, nil
+	}
+	var value T
+	if err := json.Unmarshal([]byte(cached), &value); err != nil {
+		return nil, false, err
+	}
+	return &value, true, nil
+}
+
+// loadAndSet 加载数据并回填缓存，数据不存在时写入空值
+func loadAndSet[T any](ctx context.Context, c *CacheClient, key string, ttl time.Duration, load CacheLoader[T]) (*T, error) {
+	value, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, c.rdb.Set(ctx, key, "", JitterTTL(time.Duration(CACHE_NULL_TTL)*time.Minute)).Err()
+	}
+	return value, c.Set(ctx, key, value, ttl)
+}
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"bytes"
+	"crypto/rand"
 	"crypto/tls"
+	"encoding/hex"
 	"fmt"
+	"mime"
 	"net/smtp"
 )
 
@@ -15,14 +19,33 @@ type SMTPConfig struct {
 	To   string
 }
 
+// EmailMessage 一封待发送的邮件；HTML 为空时只发送纯文本，否则以 multipart/alternative 同时携带纯文本版本
+type EmailMessage struct {
+	To      string // 收件人，为空时使用 SMTPConfig.To
+	Subject string
+	Text    string
+	HTML    string
+}
+
 // SendEmail 使用 SMTP 发送纯文本电子邮件
 func SendEmail(cfg SMTPConfig, subject, body string) error {
+	return SendMail(cfg, EmailMessage{Subject: subject, Text: body})
+}
+
+// SendMail 使用 SMTP 发送邮件，支持 HTML 正文与纯文本回退
+func SendMail(cfg SMTPConfig, mail EmailMessage) error {
+	if mail.To != "" {
+		cfg.To = mail.To
+	}
 	if cfg.Host == "" || cfg.Port == 0 || cfg.User == "" || cfg.Pass == "" || cfg.To == "" {
 		return fmt.Errorf("smtp config is incomplete")
 	}
 	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-	// QQ SMTP 要求包含 From/To 头，使用纯文本 MIME。
-	msg := []byte(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s", cfg.User, cfg.To, subject, body))
+	// QQ SMTP 要求包含 From/To 头
+	msg, err := buildMIME(cfg.User, cfg.To, mail)
+	if err != nil {
+		return err
+	}
 
 	if cfg.Port == 465 {
 		// 465 端口使用 TLS 直连
@@ -74,3 +97,24 @@ func SendEmail(cfg SMTPConfig, subject, body string) error {
 	}
 	return nil
 }
+
+// buildMIME 组装邮件头与正文；主题按 RFC 2047 编码，避免中文乱码
+func buildMIME(from, to string, mail EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n", from, to, mime.BEncoding.Encode("UTF-8", mail.Subject))
+	if mail.HTML == "" {
+		fmt.Fprintf(&buf, "Content-Type: text/plain; charset=UTF-8\r\n\r\n%s", mail.Text)
+		return buf.Bytes(), nil
+	}
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("mime boundary: %w", err)
+	}
+	boundary := "hmdp-" + hex.EncodeToString(b)
+	// 客户端优先展示最后一个可识别的部分，HTML 放在纯文本之后
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&buf, "--%s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n", boundary, mail.Text)
+	fmt.Fprintf(&buf, "--%s\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n%s\r\n", boundary, mail.HTML)
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
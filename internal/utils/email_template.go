@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"sync"
+	texttemplate "text/template"
+)
+
+// ErrEmailQueueFull 异步发送队列已满或已关闭，邮件被丢弃
+var ErrEmailQueueFull = errors.New("email queue is full")
+
+// EmailTemplate 邮件模板：主题与纯文本正文使用 text/template，HTML 正文使用 html/template 自动转义
+type EmailTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// NewEmailTemplate 解析邮件模板，html 为空时只渲染纯文本正文
+func NewEmailTemplate(name, subject, text, html string) (*EmailTemplate, error) {
+	t := &EmailTemplate{}
+	var err error
+	if t.subject, err = texttemplate.New(name + ".subject").Parse(subject); err != nil {
+		return nil, fmt.Errorf("parse email subject %s: %w", name, err)
+	}
+	if t.text, err = texttemplate.New(name + ".text").Parse(text); err != nil {
+		return nil, fmt.Errorf("parse email text %s: %w", name, err)
+	}
+	if html != "" {
+		if t.html, err = htmltemplate.New(name + ".html").Parse(html); err != nil {
+			return nil, fmt.Errorf("parse email html %s: %w", name, err)
+		}
+	}
+	return t, nil
+}
+
+// MustEmailTemplate 同 NewEmailTemplate，解析失败时 panic，用于包级模板变量
+func MustEmailTemplate(name, subject, text, html string) *EmailTemplate {
+	t, err := NewEmailTemplate(name, subject, text, html)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Render 使用 data 渲染模板，生成发往 to 的邮件
+func (t *EmailTemplate) Render(to string, data any) (EmailMessage, error) {
+	mail := EmailMessage{To: to}
+	var buf bytes.Buffer
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return mail, fmt.Errorf("render email subject: %w", err)
+	}
+	mail.Subject = buf.String()
+	buf.Reset()
+	if err := t.text.Execute(&buf, data); err != nil {
+		return mail, fmt.Errorf("render email text: %w", err)
+	}
+	mail.Text = buf.String()
+	if t.html != nil {
+		buf.Reset()
+		if err := t.html.Execute(&buf, data); err != nil {
+			return mail, fmt.Errorf("render email html: %w", err)
+		}
+		mail.HTML = buf.String()
+	}
+	return mail, nil
+}
+
+// EmailDispatcher 异步发送邮件：请求线程只负责入队，后台 worker 串行连接 SMTP 发送，
+// 避免 SMTP 延迟拖慢下单、支付等接口
+type EmailDispatcher struct {
+	cfg     SMTPConfig
+	queue   chan EmailMessage
+	onError func(EmailMessage, error)
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+}
+
+// NewEmailDispatcher 创建并启动异步发送器；onError 在发送失败时回调，可为 nil
+func NewEmailDispatcher(cfg SMTPConfig, workers, queueSize int, onError func(EmailMessage, error)) *EmailDispatcher {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	d := &EmailDispatcher{cfg: cfg, queue: make(chan EmailMessage, queueSize), onError: onError}
+	for i := 0; i < workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+// Send 将邮件放入发送队列，队列已满或发送器已关闭时返回 ErrEmailQueueFull，不阻塞调用方
+func (d *EmailDispatcher) Send(mail EmailMessage) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrEmailQueueFull
+	}
+	select {
+	case d.queue <- mail:
+		return nil
+	default:
+		return ErrEmailQueueFull
+	}
+}
+
+// Close 停止接收新邮件，并等待队列中已有的邮件发送完毕
+func (d *EmailDispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.queue)
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+func (d *EmailDispatcher) work() {
+	defer d.wg.Done()
+	for mail := range d.queue {
+		if err := SendMail(d.cfg, mail); err != nil && d.onError != nil {
+			d.onError(mail, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestEmailTemplateRender 校验 HTML 正文自动转义、纯文本正文原样输出，且两者都写入 multipart 邮件
+func TestEmailTemplateRender(t *testing.T) {
+	tpl := MustEmailTemplate("test", "订单 {{.ID}}", "券：{{.Title}}", "<p>券：{{.Title}}</p>")
+	mail, err := tpl.Render("a@example.com", map[string]string{"ID": "1", "Title": "<双人餐>"})
+	if err != nil {
+		t.Fatalf("Render error: %v", err)
+	}
+	if mail.To != "a@example.com" || mail.Subject != "订单 1" {
+		t.Fatalf("to/subject = %q/%q", mail.To, mail.Subject)
+	}
+	if mail.Text != "券：<双人餐>" {
+		t.Fatalf("text = %q", mail.Text)
+	}
+	if mail.HTML != "<p>券：&lt;双人餐&gt;</p>" {
+		t.Fatalf("html = %q", mail.HTML)
+	}
+	raw, err := buildMIME("from@example.com", mail.To, mail)
+	if err != nil {
+		t.Fatalf("buildMIME error: %v", err)
+	}
+	msg := string(raw)
+	for _, want := range []string{"multipart/alternative", "text/plain", "text/html", mail.Text, mail.HTML} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// richTextPolicy 富文本白名单：仅保留常见排版标签与安全的链接/图片属性
+var richTextPolicy = newRichTextPolicy()
+
+// plainTextPolicy 去除全部标签，用于标题等纯文本字段
+var plainTextPolicy = bluemonday.StrictPolicy()
+
+func newRichTextPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "span", "b", "strong", "i", "em", "u", "s", "del",
+		"blockquote", "ul", "ol", "li", "h1", "h2", "h3", "h4", "pre", "code")
+	// 链接只允许 http/https，并强制 rel=nofollow noopener
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowURLSchemes("http", "https")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	p.AllowAttrs("src", "alt", "width", "height").OnElements("img")
+	return p
+}
+
+// SanitizeRichText 按白名单清洗用户提交的富文本，防止存储型 XSS
+func SanitizeRichText(input string) string {
+	if input == "" {
+		return input
+	}
+	return strings.TrimSpace(richTextPolicy.Sanitize(input))
+}
+
+// SanitizePlainText 移除全部 HTML 标签，仅保留文本
+func SanitizePlainText(input string) string {
+	if input == "" {
+		return input
+	}
+	return strings.TrimSpace(plainTextPolicy.Sanitize(input))
+}
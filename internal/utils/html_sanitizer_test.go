@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestSanitizeRichText 校验脚本、事件属性与危险协议会被移除，常见排版标签被保留
+func TestSanitizeRichText(t *testing.T) {
+	cases := []struct {
+		input    string
+		contains []string
+		absent   []string
+	}{
+		{
+			input:    `<p>好吃<strong>推荐</strong></p><script>alert(1)</script>`,
+			contains: []string{"<p>好吃<strong>推荐</strong></p>"},
+			absent:   []string{"<script", "alert(1)"},
+		},
+		{
+			input:  `<img src="https://a.com/1.png" onerror="alert(1)">`,
+			absent: []string{"onerror"},
+		},
+		{
+			input:  `<a href="javascript:alert(1)">x</a>`,
+			absent: []string{"javascript:"},
+		},
+		{
+			input:    `<a href="https://a.com">x</a>`,
+			contains: []string{`rel="nofollow noopener"`},
+		},
+	}
+	for _, tc := range cases {
+		out := SanitizeRichText(tc.input)
+		for _, want := range tc.contains {
+			if !strings.Contains(out, want) {
+				t.Fatalf("sanitize %q: expected %q in %q", tc.input, want, out)
+			}
+		}
+		for _, bad := range tc.absent {
+			if strings.Contains(out, bad) {
+				t.Fatalf("sanitize %q: unexpected %q in %q", tc.input, bad, out)
+			}
+		}
+	}
+}
+
+// TestSanitizePlainText 校验纯文本字段中的标签被全部移除
+func TestSanitizePlainText(t *testing.T) {
+	if got := SanitizePlainText(`<b>标题</b><script>x</script>`); got != "标题" {
+		t.Fatalf("unexpected plain text: %q", got)
+	}
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSignAndParseJWT 校验签发的令牌可以被正确解析，篡改密钥或过期后解析失败
+func TestSignAndParseJWT(t *testing.T) {
+	token, err := SignJWT("secret", "hmdp", time.Hour, JWTClaims{UserID: 42, NickName: "nick", Icon: "icon.png", Role: "admin"})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if !LooksLikeJWT(token) {
+		t.Fatalf("unexpected token format: %s", token)
+	}
+	claims, err := ParseJWT("secret", token)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if claims.UserID != 42 || claims.NickName != "nick" || claims.Icon != "icon.png" || claims.Role != "admin" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if _, err := ParseJWT("other", token); err == nil {
+		t.Fatalf("expected signature error")
+	}
+	expired, err := SignJWT("secret", "hmdp", -time.Minute, JWTClaims{UserID: 42, NickName: "nick"})
+	if err != nil {
+		t.Fatalf("sign expired: %v", err)
+	}
+	if _, err := ParseJWT("secret", expired); err == nil {
+		t.Fatalf("expected expiration error")
+	}
+}
+
+// TestJWTIssuedAtMillisecond 校验签发时间解析后保留毫秒精度，供强制下线判断使用
+func TestJWTIssuedAtMillisecond(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		before := time.Now().UnixMilli()
+		token, err := SignJWT("secret", "hmdp", time.Hour, JWTClaims{UserID: 42})
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		after := time.Now().UnixMilli()
+		claims, err := ParseJWT("secret", token)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		if iat := claims.IssuedAtMilli(); iat < before || iat > after {
+			t.Fatalf("issuedAt %d out of [%d, %d]", iat, before, after)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Encode hashes a password with a random salt.
@@ -32,3 +33,24 @@ func encodeWithSalt(password, salt string) string {
 	sum := md5.Sum([]byte(password + salt))
 	return salt + "@" + hex.EncodeToString(sum[:])
 }
+
+// HashPassword 使用 bcrypt 生成密码哈希
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword 校验密码，兼容 bcrypt 与旧的 salt@md5 格式
+func CheckPassword(encodedPassword, rawPassword string) bool {
+	if encodedPassword == "" || rawPassword == "" {
+		return false
+	}
+	if strings.HasPrefix(encodedPassword, "$2") {
+		return bcrypt.CompareHashAndPassword([]byte(encodedPassword), []byte(rawPassword)) == nil
+	}
+	ok, err := Matches(encodedPassword, rawPassword)
+	return err == nil && ok
+}
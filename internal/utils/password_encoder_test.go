@@ -0,0 +1,21 @@
+package utils
+
+import "testing"
+
+// TestCheckPassword 校验 bcrypt 哈希与旧的 salt@md5 格式均可验证
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("secret_123")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if !CheckPassword(hash, "secret_123") || CheckPassword(hash, "wrong") {
+		t.Fatalf("bcrypt verification mismatch")
+	}
+	legacy := Encode("secret_123")
+	if !CheckPassword(legacy, "secret_123") || CheckPassword(legacy, "wrong") {
+		t.Fatalf("legacy verification mismatch")
+	}
+	if CheckPassword("", "secret_123") {
+		t.Fatalf("empty hash must not match")
+	}
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"fmt"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	QRCodeDefaultSize = 256
+	QRCodeMinSize     = 128
+	QRCodeMaxSize     = 1024
+)
+
+// QRCodePNG 将内容编码为 PNG 格式的二维码，size 为图片边长（像素），超出范围时取边界值，0 使用默认尺寸；
+// 使用中等纠错级别，兼顾扫码容错与码点密度
+func QRCodePNG(content string, size int) ([]byte, error) {
+	switch {
+	case size == 0:
+		size = QRCodeDefaultSize
+	case size < QRCodeMinSize:
+		size = QRCodeMinSize
+	case size > QRCodeMaxSize:
+		size = QRCodeMaxSize
+	}
+	png, err := qrcode.Encode(content, qrcode.Medium, size)
+	if err != nil {
+		return nil, fmt.Errorf("qrcode encode: %w", err)
+	}
+	return png, nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+// TestQRCodePNG 校验生成的图片为合法 PNG，且尺寸被限制在允许范围内
+func TestQRCodePNG(t *testing.T) {
+	cases := []struct {
+		size int
+		want int
+	}{
+		{0, QRCodeDefaultSize},
+		{64, QRCodeMinSize},
+		{300, 300},
+		{4096, QRCodeMaxSize},
+	}
+	for _, c := range cases {
+		data, err := QRCodePNG("123456789012", c.size)
+		if err != nil {
+			t.Fatalf("QRCodePNG(size=%d) error: %v", c.size, err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("QRCodePNG(size=%d) not a png: %v", c.size, err)
+		}
+		if got := img.Bounds().Dx(); got != c.want {
+			t.Fatalf("QRCodePNG(size=%d) width = %d, want %d", c.size, got, c.want)
+		}
+	}
+}
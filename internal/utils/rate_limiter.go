@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript 滑动窗口限流：ZSET 记录窗口内每次请求的时间戳，先清理过期记录再计数，
+// 未超限时记录本次请求；返回 1 表示放行，0 表示拒绝
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+if redis.call('ZCARD', key) >= limit then
+  return 0
+end
+redis.call('ZADD', key, now, ARGV[4])
+redis.call('PEXPIRE', key, window)
+return 1
+`)
+
+// SlidingWindowLimiter 基于 Redis 的滑动窗口限流器，多实例共享同一计数
+type SlidingWindowLimiter struct {
+	rdb *redis.Client
+}
+
+// NewSlidingWindowLimiter 创建滑动窗口限流器
+func NewSlidingWindowLimiter(rdb *redis.Client) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{rdb: rdb}
+}
+
+// Allow 判断 key 在最近 window 内的请求数是否小于 limit，放行时计入本次请求
+func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	now := time.Now().UnixMilli()
+	// 同一毫秒内的多次请求需要不同的成员
+	member := strconv.FormatInt(now, 10) + "-" + RandomString(8)
+	res, err := slidingWindowScript.Run(ctx, l.rdb, []string{key}, now, window.Milliseconds(), limit, member).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
@@ -1,21 +1,91 @@
 package utils
 
 const (
-	LOGIN_CODE_KEY      = "login:code:"
-	LOGIN_CODE_TTL      = 2
-	LOGIN_USER_KEY      = "login:token:"
-	LOGIN_USER_TTL      = 36000
-	CACHE_NULL_TTL      = 2
-	CACHE_SHOP_TTL      = 30
-	CACHE_SHOP_KEY      = "cache:shop:"
-	CACHE_SHOP_TYPE_KEY = "cache:shoptype:list"
-	CACHE_SHOP_TYPE_TTL = 30
-	LOCK_SHOP_KEY       = "lock:shop:"
-	LOCK_SHOP_TTL       = 10
-	SECKILL_STOCK_KEY   = "seckill:stock:"
-	BLOG_LIKED_KEY      = "blog:liked:"
-	FEED_KEY            = "feed:"
-	SHOP_GEO_KEY        = "shop:geo:"
-	USER_SIGN_KEY       = "sign:"
-	SHOP_BLOOM_KEY      = "bloom:shop"
+	LOGIN_CODE_KEY       = "login:code:"
+	LOGIN_CODE_TTL       = 2
+	LOGIN_CODE_LIMIT_KEY = "limit:code:"
+	CAPTCHA_KEY          = "captcha:"
+	CAPTCHA_TTL          = 5
+	LOGIN_USER_KEY       = "login:token:"
+	LOGIN_USER_TTL       = 36000
+	LOGIN_TOKENS_KEY     = "login:tokens:"
+	LOGIN_REVOKE_KEY     = "login:revoke:"
+	LOGIN_FAIL_KEY       = "login:fail:"
+	LOGIN_FAIL_TTL       = 15
+	LOGIN_FAIL_MAX       = 5
+	LOGIN_2FA_KEY        = "login:2fa:"
+	LOGIN_2FA_TTL        = 5
+	LOGIN_2FA_FAIL_KEY   = "login:2fa:fail:"
+	LOGIN_2FA_STEP_KEY   = "login:2fa:step:"
+	USER_BANNED_KEY      = "user:banned"
+	CACHE_NULL_TTL       = 2
+	CACHE_SHOP_TTL       = 30
+	CACHE_SHOP_KEY       = "cache:shop:"
+	CACHE_SHOP_TYPE_KEY  = "cache:shoptype:list"
+	CACHE_SHOP_TYPE_TTL  = 30
+	CACHE_CAMPAIGN_KEY   = "cache:campaign:active"
+	CACHE_CAMPAIGN_TTL   = 5
+	CACHE_USER_KEY       = "cache:user:"
+	CACHE_USER_TTL       = 30
+	LOCK_SHOP_KEY        = "lock:shop:"
+	LOCK_SHOP_TTL        = 10
+	SECKILL_STOCK_KEY    = "seckill:stock:"
+	SECKILL_LIMIT_USER   = "limit:seckill:user:"
+	SECKILL_LIMIT_VOUCH  = "limit:seckill:voucher:"
+	VOUCHER_EXPIRE_LOCK  = "lock:voucher:expire"
+	ORDER_TIMEOUT_KEY    = "order:timeout"
+	ORDER_COMPENSATE_KEY = "order:compensated:"
+	ORDER_COMPENSATE_TTL = 24 * 60
+	ORDER_STREAM_KEY     = "stream:orders"
+	ORDER_STREAM_GROUP   = "order-consumers"
+	ORDER_QUEUE_KEY      = "order:queue:pending"
+	ORDER_QUEUE_LIMIT    = "order:queue:limit"
+	ORDER_REDEEM_LOCK    = "lock:order:redeem:"
+	GIFT_CLAIM_LIMIT_KEY = "limit:gift:claim:"
+	SECKILL_REMIND_KEY   = "seckill:remind"
+	STOCK_DRIFT_KEY      = "seckill:stock:drift"
+	STOCK_RECONCILE_LOCK = "lock:seckill:reconcile"
+	GROUP_BUY_KEY        = "groupbuy:"
+	GROUP_BUY_MEMBER_KEY = "groupbuy:member:"
+	GROUP_BUY_EXPIRE_KEY = "groupbuy:expire"
+	BLOG_LIKED_KEY       = "blog:liked:"
+	FEED_KEY             = "feed:"
+	FEED_PULL_AUTHORS    = "feed:pull:authors"
+	FEED_TIMELINE_KEY    = "feed:timeline:"
+	FEED_PUSH_MAX_FANS   = 5000
+	FEED_TIMELINE_MAX    = 1000
+	SHOP_GEO_KEY         = "shop:geo:"
+	BLOG_GEO_KEY         = "blog:geo"
+	BLOG_VIEWS_KEY       = "blog:views:pending"
+	BLOG_VIEWS_FLUSH_KEY = "blog:views:flushing"
+	BLOG_TAG_TREND_KEY   = "blog:tag:trend:"
+	BLOG_TAG_HOT_KEY     = "blog:tag:hot"
+	BLOG_HOT_KEY         = "blog:hot"
+	BLOG_HOT_MAX         = 1000
+	BLOG_FAVORITE_KEY    = "blog:favorite:"
+	BLOG_SHARE_KEY       = "blog:share:"
+	BLOG_SHARE_TTL       = 7 * 24 * 60
+	BLOG_SHARE_COUNT_KEY = "blog:share:count"
+	USER_SIGN_KEY        = "sign:"
+	SHOP_BLOOM_KEY       = "bloom:shop"
+	SHOP_BLOOM_DIRTY_KEY = "bloom:shop:dirty"
+	SHOP_BLOOM_TMP_KEY   = "bloom:shop:reload"
+	SHOP_BLOOM_RELOADING = "bloom:shop:reloading"
+	SHOP_LOCAL_EVICT_CH  = "shop:cache:evict"
+	SHOP_HOT_KEY         = "shop:hot"
+	SHOP_PV_KEY          = "shop:pv:"
+	SHOP_UV_KEY          = "shop:uv:"
+	SHOP_VIEW_TTL        = 3 * 24 * 60
+	SHOP_VIEW_AGG_LOCK   = "lock:shop:view:agg"
+	NOTIFY_INBOX_KEY     = "notify:inbox:"
+	NOTIFY_INBOX_MAX     = 200
+	NOTIFY_SETTING_KEY   = "notify:setting:"
+	NOTIFY_SETTING_TTL   = 30
+	USER_PRIVACY_KEY     = "user:privacy:"
+	USER_PRIVACY_TTL     = 30
+	SEARCH_HISTORY_KEY   = "search:history:"
+	SEARCH_HISTORY_MAX   = 20
+	SHOP_HISTORY_KEY     = "shop:history:"
+	SHOP_HISTORY_MAX     = 50
+	SHOP_FAVORITE_KEY    = "shop:favorite:"
 )
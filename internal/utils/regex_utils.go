@@ -12,6 +12,11 @@ func IsEmailInvalid(email string) bool {
 	return mismatch(email, EMAIL_REGEX)
 }
 
+// IsPasswordInvalid 验证密码格式是否合法（4~32 位字母、数字或下划线）
+func IsPasswordInvalid(password string) bool {
+	return mismatch(password, PASSWORD_REGEX)
+}
+
 // IsCodeInvalid replicates RegexUtils#isCodeInvalid.
 func IsCodeInvalid(code string) bool {
 	return mismatch(code, VERIFY_CODE_REGEX)
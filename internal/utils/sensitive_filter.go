@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SensitiveFilter 基于前缀树（Trie）的敏感词匹配：忽略大小写，同一位置优先匹配最长的词，
+// 词中间夹杂的空白与符号（如 "敏 感"、"敏*感"）也视为命中
+type SensitiveFilter struct {
+	root *trieNode
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	end      bool
+}
+
+// NewSensitiveFilter 根据词表构建过滤器，空白词会被忽略
+func NewSensitiveFilter(words []string) *SensitiveFilter {
+	root := &trieNode{children: make(map[rune]*trieNode)}
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+		node := root
+		for _, r := range word {
+			if isSensitiveNoise(r) {
+				continue
+			}
+			r = unicode.ToLower(r)
+			next, ok := node.children[r]
+			if !ok {
+				next = &trieNode{children: make(map[rune]*trieNode)}
+				node.children[r] = next
+			}
+			node = next
+		}
+		if node != root {
+			node.end = true
+		}
+	}
+	return &SensitiveFilter{root: root}
+}
+
+// Find 返回文本中命中的敏感词片段（按出现顺序，不去重）
+func (f *SensitiveFilter) Find(text string) []string {
+	var res []string
+	runes := []rune(text)
+	f.scan(runes, func(start, end int) {
+		res = append(res, string(runes[start:end]))
+	})
+	return res
+}
+
+// Mask 将命中的敏感词片段逐字替换为 mask
+func (f *SensitiveFilter) Mask(text string, mask rune) string {
+	runes := []rune(text)
+	changed := false
+	f.scan(runes, func(start, end int) {
+		for i := start; i < end; i++ {
+			runes[i] = mask
+		}
+		changed = true
+	})
+	if !changed {
+		return text
+	}
+	return string(runes)
+}
+
+// scan 从左到右匹配，每次命中回调片段区间 [start, end)，并从 end 继续匹配
+func (f *SensitiveFilter) scan(runes []rune, hit func(start, end int)) {
+	for i := 0; i < len(runes); {
+		if isSensitiveNoise(runes[i]) {
+			i++
+			continue
+		}
+		node := f.root
+		matched := -1
+		for j := i; j < len(runes); j++ {
+			if j > i && isSensitiveNoise(runes[j]) {
+				continue
+			}
+			node = node.children[unicode.ToLower(runes[j])]
+			if node == nil {
+				break
+			}
+			if node.end {
+				matched = j
+			}
+		}
+		if matched < 0 {
+			i++
+			continue
+		}
+		hit(i, matched+1)
+		i = matched + 1
+	}
+}
+
+// isSensitiveNoise 判断字符是否为可被跳过的干扰符号
+func isSensitiveNoise(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
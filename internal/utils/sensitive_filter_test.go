@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSensitiveFilterFind(t *testing.T) {
+	f := NewSensitiveFilter([]string{"赌博", "赌博网站", "Spam", " "})
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"正常的探店笔记", nil},
+		{"这里有赌博网站链接", []string{"赌博网站"}},
+		{"赌博和赌 博", []string{"赌博", "赌 博"}},
+		{"no SPAM please, spam", []string{"SPAM", "spam"}},
+		{"赌*博", []string{"赌*博"}},
+	}
+	for _, tc := range cases {
+		if got := f.Find(tc.text); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("Find(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestSensitiveFilterMask(t *testing.T) {
+	f := NewSensitiveFilter([]string{"赌博"})
+	if got := f.Mask("不要赌 博哦", '*'); got != "不要***哦" {
+		t.Fatalf("unexpected mask result: %s", got)
+	}
+	if got := f.Mask("正常内容", '*'); got != "正常内容" {
+		t.Fatalf("clean text should be unchanged: %s", got)
+	}
+}
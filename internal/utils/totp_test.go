@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// TestTOTPCode 使用 RFC 6238 附录 B 的 SHA1 测试向量（取后 6 位）
+func TestTOTPCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for ts, want := range cases {
+		got, err := TOTPCode(secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatalf("code at %d: %v", ts, err)
+		}
+		if got != want {
+			t.Fatalf("code at %d: want %s, got %s", ts, want, got)
+		}
+	}
+}
+
+// TestVerifyTOTP 校验相邻时间窗口的动态码可通过，超出偏差范围的被拒绝
+func TestVerifyTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	prev, _ := TOTPCode(secret, now.Add(-30*time.Second))
+	if !VerifyTOTP(secret, prev, now) {
+		t.Fatalf("expected previous window code to pass")
+	}
+	old, _ := TOTPCode(secret, now.Add(-90*time.Second))
+	if VerifyTOTP(secret, old, now) {
+		t.Fatalf("expected stale code to be rejected")
+	}
+	if VerifyTOTP(secret, "12345", now) {
+		t.Fatalf("expected malformed code to be rejected")
+	}
+}
+
+// TestMatchTOTP 校验返回的时间步为动态码实际所属的窗口
+func TestMatchTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	prev, _ := TOTPCode(secret, now.Add(-30*time.Second))
+	if step, ok := MatchTOTP(secret, prev, now); !ok || step != now.Unix()/30-1 {
+		t.Fatalf("MatchTOTP = %d/%v, want %d", step, ok, now.Unix()/30-1)
+	}
+	if _, ok := MatchTOTP(secret, "000000x", now); ok {
+		t.Fatalf("expected malformed code to be rejected")
+	}
+}
+
+// TestEncryptString 校验加解密往返，错误密钥无法解密
+func TestEncryptString(t *testing.T) {
+	enc, err := EncryptString("key", "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	plain, err := DecryptString("key", enc)
+	if err != nil || plain != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("decrypt: %q %v", plain, err)
+	}
+	if _, err := DecryptString("other", enc); err == nil {
+		t.Fatalf("expected decrypt with wrong key to fail")
+	}
+}
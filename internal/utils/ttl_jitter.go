@@ -0,0 +1,19 @@
+package utils
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// TTL_JITTER_RATIO 过期时间随机上浮的最大比例
+const TTL_JITTER_RATIO = 0.1
+
+// JitterTTL 在 ttl 基础上随机增加 [0, ttl*TTL_JITTER_RATIO) 的时长，
+// 避免同一批写入（如预热）的缓存同时过期引发缓存雪崩；ttl <= 0 时原样返回
+func JitterTTL(ttl time.Duration) time.Duration {
+	maxJitter := int64(float64(ttl) * TTL_JITTER_RATIO)
+	if ttl <= 0 || maxJitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int64N(maxJitter))
+}
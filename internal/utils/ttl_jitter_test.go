@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// TestJitterTTL 校验抖动后的 TTL 落在 [ttl, ttl*(1+比例)) 内，非正数与过小的 TTL 原样返回
+func TestJitterTTL(t *testing.T) {
+	ttl := 30 * time.Minute
+	maxTTL := ttl + time.Duration(float64(ttl)*TTL_JITTER_RATIO)
+	for i := 0; i < 1000; i++ {
+		got := JitterTTL(ttl)
+		if got < ttl || got >= maxTTL {
+			t.Fatalf("JitterTTL(%v) = %v, want in [%v, %v)", ttl, got, ttl, maxTTL)
+		}
+	}
+	if got := JitterTTL(0); got != 0 {
+		t.Fatalf("JitterTTL(0) = %v, want 0", got)
+	}
+	if got := JitterTTL(time.Nanosecond); got != time.Nanosecond {
+		t.Fatalf("JitterTTL(1ns) = %v, want 1ns", got)
+	}
+}
@@ -0,0 +1,133 @@
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotHeld 解锁或续期时锁已不属于当前持有者（已过期或被他人持有）
+var ErrNotHeld = errors.New("lock not held")
+
+// unlockScript 仅当 value 与持有者标识一致时才删除，避免误删他人的锁
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// renewScript 仅当 value 与持有者标识一致时才延长过期时间
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Option 配置锁的可选行为
+type Option func(*Lock)
+
+// WithWatchdog 加锁成功后每隔 ttl/3 自动续期，直到 Unlock，适用于执行时间无法预估的任务
+func WithWatchdog() Option {
+	return func(l *Lock) { l.watchdog = true }
+}
+
+// Lock 基于 Redis 的分布式互斥锁：value 为随机持有者标识，解锁与续期均通过 Lua 校验持有者；
+// 同一个 Lock 实例不可并发使用
+type Lock struct {
+	rdb      *redis.Client
+	key      string
+	value    string
+	ttl      time.Duration
+	watchdog bool
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New 创建锁，ttl 为锁的过期时间，持有者崩溃时锁最迟在 ttl 后自动释放
+func New(rdb *redis.Client, key string, ttl time.Duration, opts ...Option) *Lock {
+	l := &Lock{rdb: rdb, key: key, value: newToken(), ttl: ttl}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// TryLock 尝试加锁，不阻塞
+func (l *Lock) TryLock(ctx context.Context) (bool, error) {
+	ok, err := l.rdb.SetNX(ctx, l.key, l.value, l.ttl).Result()
+	if err != nil || !ok {
+		return false, err
+	}
+	if l.watchdog {
+		l.startWatchdog()
+	}
+	return true, nil
+}
+
+// Unlock 释放锁并停止续期；锁已过期或被他人持有时返回 ErrNotHeld
+func (l *Lock) Unlock(ctx context.Context) error {
+	if l.stop != nil {
+		close(l.stop)
+		l.wg.Wait()
+		l.stop = nil
+	}
+	n, err := unlockScript.Run(ctx, l.rdb, []string{l.key}, l.value).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Renew 手动续期为完整的 ttl
+func (l *Lock) Renew(ctx context.Context) error {
+	n, err := renewScript.Run(ctx, l.rdb, []string{l.key}, l.value, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// startWatchdog 后台定期续期，锁已丢失时停止
+func (l *Lock) startWatchdog() {
+	l.stop = make(chan struct{})
+	interval := l.ttl / 3
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	l.wg.Add(1)
+	go func(stop <-chan struct{}) {
+		defer l.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := l.Renew(context.Background()); errors.Is(err, ErrNotHeld) {
+					return
+				}
+			}
+		}
+	}(l.stop)
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,64 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:6379"})
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("skip: cannot connect redis: %v", err)
+	}
+	t.Cleanup(func() { _ = rdb.Close() })
+	return rdb
+}
+
+// TestUnlockOnlyByHolder 校验锁过期后被他人持有时，原持有者解锁不会删除他人的锁
+func TestUnlockOnlyByHolder(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+	key := "lock:test:holder"
+	_ = rdb.Del(ctx, key).Err()
+
+	first := New(rdb, key, 50*time.Millisecond)
+	if ok, err := first.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("first lock: ok=%v err=%v", ok, err)
+	}
+	time.Sleep(80 * time.Millisecond)
+	second := New(rdb, key, time.Second)
+	if ok, err := second.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("second lock: ok=%v err=%v", ok, err)
+	}
+	if err := first.Unlock(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("expected ErrNotHeld, got %v", err)
+	}
+	if err := second.Unlock(ctx); err != nil {
+		t.Fatalf("second unlock: %v", err)
+	}
+}
+
+// TestWatchdogRenews 校验看门狗在任务执行超过 ttl 时持续续期
+func TestWatchdogRenews(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+	key := "lock:test:watchdog"
+	_ = rdb.Del(ctx, key).Err()
+
+	l := New(rdb, key, 90*time.Millisecond, WithWatchdog())
+	if ok, err := l.TryLock(ctx); err != nil || !ok {
+		t.Fatalf("lock: ok=%v err=%v", ok, err)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if ok, err := New(rdb, key, time.Second).TryLock(ctx); err != nil || ok {
+		t.Fatalf("expected lock still held: ok=%v err=%v", ok, err)
+	}
+	if err := l.Unlock(ctx); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+}
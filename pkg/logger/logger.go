@@ -9,19 +9,26 @@ import (
 
 // New 根据日志级别与环境创建 zap.Logger（本地/开发环境使用彩色控制台输出）
 func New(level, environment string) (*zap.Logger, error) {
+	log, _, err := NewWithLevel(level, environment)
+	return log, err
+}
+
+// NewWithLevel 同 New，并返回可在运行时调整的日志级别
+func NewWithLevel(level, environment string) (*zap.Logger, zap.AtomicLevel, error) {
 	cfg := zap.NewProductionConfig()
 	if isDevEnv(environment) {
 		cfg = zap.NewDevelopmentConfig()
 		cfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	}
-	cfg.Level = zap.NewAtomicLevelAt(parseLevel(level))
+	cfg.Level = zap.NewAtomicLevelAt(ParseLevel(level))
 	cfg.EncoderConfig.TimeKey = "timestamp"
 	cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	return cfg.Build()
+	log, err := cfg.Build()
+	return log, cfg.Level, err
 }
 
-// parseLevel 解析日志级别字符串
-func parseLevel(level string) zapcore.Level {
+// ParseLevel 解析日志级别字符串，无法识别时返回 info
+func ParseLevel(level string) zapcore.Level {
 	switch strings.ToLower(level) {
 	case "debug":
 		return zapcore.DebugLevel